@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"promviz/internal/app"
+	"promviz/internal/backend"
+	"promviz/internal/backend/prom"
+	"promviz/internal/bench"
+	"promviz/internal/compare"
+	"promviz/internal/config"
+	"promviz/internal/cost"
+	"promviz/internal/keychain"
+	"promviz/internal/rules"
+	"promviz/internal/selfupdate"
+	"promviz/internal/suggest"
+	"promviz/internal/templates"
+	"promviz/internal/tmux"
+	"promviz/internal/urlimport"
+
+	"golang.org/x/term"
+)
+
+// exitWithError prints the error and terminates with a non-zero status
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// loadConfig loads the configuration for a subcommand, printing any warnings
+// to stderr, or failing on them in strict mode
+func loadConfig(path string, strict bool) *config.Config {
+	load := config.LoadConfig
+	if strict {
+		load = config.LoadConfigStrict
+	}
+
+	cfg, err := load(path)
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, w := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	return cfg
+}
+
+// runTmux implements `promviz tmux`, spawning one tmux pane per query
+func runTmux(args []string) {
+	fs := flag.NewFlagSet("tmux", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file")
+	session := fs.String("session", "promviz", "Name of the tmux session to create")
+	tag := fs.String("tag", "", "Only open panes for the queries with this tag")
+	fs.Parse(args)
+
+	cfg := loadConfig(*configPath, false)
+	if *tag != "" {
+		if err := cfg.SelectTag(*tag); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	// Panes may start in a different directory, so hand them an absolute path
+	absPath, err := filepath.Abs(*configPath)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if err := tmux.Launch(*session, absPath, cfg.Queries); err != nil {
+		exitWithError(err)
+	}
+}
+
+// runAddURL implements `promviz add-url`, appending the queries encoded in a
+// Grafana Explore or Prometheus graph URL to the config file
+func runAddURL(args []string) {
+	fs := flag.NewFlagSet("add-url", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file")
+	name := fs.String("name", "", "Panel name (defaults to the expression)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: promviz add-url [--config FILE] [--name NAME] URL\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	extracted, err := urlimport.Parse(fs.Arg(0))
+	if err != nil {
+		exitWithError(err)
+	}
+
+	queries := urlimport.ToQueries(extracted, *name)
+	if err := config.AppendQueries(*configPath, queries); err != nil {
+		exitWithError(err)
+	}
+
+	for _, q := range queries {
+		fmt.Printf("Added %q to %s\n", q.Name, *configPath)
+	}
+}
+
+// runCompare implements `promviz compare`, printing statistical deltas between
+// the recent window of an expression and the same window in the past
+func runCompare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file (selects the backend)")
+	expr := fs.String("expr", "", "Expression to compare")
+	rangeFlag := fs.String("range", "1h", "Length of the compared windows")
+	against := fs.String("against", "24h_ago", "How far back the baseline window lies, e.g. 24h_ago or 7d_ago")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Parse(args)
+
+	if *expr == "" {
+		exitWithError(fmt.Errorf("--expr is required"))
+	}
+	if *format != "table" && *format != "json" {
+		exitWithError(fmt.Errorf("unsupported format: %s (supported: table, json)", *format))
+	}
+
+	rangeDur, err := backend.ParseDuration(*rangeFlag)
+	if err != nil {
+		exitWithError(fmt.Errorf("invalid range: %w", err))
+	}
+	offset, err := compare.ParseOffset(*against)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	cfg := loadConfig(*configPath, false)
+
+	b, err := app.ConnectBackend(cfg)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(backend.WithRaw(context.Background(), cfg.Raw), 30*time.Second)
+	defer cancel()
+
+	report, err := compare.Run(ctx, b, *expr, rangeDur, offset)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if *format == "json" {
+		err = compare.WriteJSON(os.Stdout, report)
+	} else {
+		err = compare.WriteTable(os.Stdout, report, cfg.NumberFormat())
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+// runCost implements `promviz cost`, estimating the requests, samples and
+// bytes a dashboard fetches from its backends
+func runCost(args []string) {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file")
+	refresh := fs.Duration("refresh", 5*time.Second, "Refresh interval to estimate for")
+	series := fs.Int("series", 1, "Series assumed per query unless --probe is given")
+	probe := fs.Bool("probe", false, "Run every query once to count its series")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		exitWithError(fmt.Errorf("unsupported format: %s (supported: table, json)", *format))
+	}
+	if *refresh <= 0 {
+		exitWithError(fmt.Errorf("--refresh must be positive"))
+	}
+	if *series < 0 {
+		exitWithError(fmt.Errorf("--series must not be negative"))
+	}
+
+	cfg := loadConfig(*configPath, false)
+
+	var count cost.SeriesCounter
+	if *probe {
+		backends, statuses, err := app.ConnectBackends(cfg)
+		if err != nil {
+			exitWithError(err)
+		}
+		for _, s := range statuses {
+			if s.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", s)
+			}
+		}
+		defer func() {
+			for _, b := range backends {
+				b.Close()
+			}
+		}()
+
+		count = func(ctx context.Context, name, expr string, tr backend.TimeRange) (int, error) {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			result, err := backends[name].QueryRange(ctx, expr, tr)
+			if err != nil {
+				return 0, err
+			}
+			seen := make(map[string]bool)
+			for _, p := range result.Points {
+				seen[p.Series] = true
+			}
+			return len(seen), nil
+		}
+	}
+
+	report, err := cost.Estimate(context.Background(), cfg, *refresh, *series, count)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if *format == "json" {
+		err = cost.WriteJSON(os.Stdout, report)
+	} else {
+		err = cost.WriteTable(os.Stdout, report, cfg.NumberFormat())
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+// runBenchRender implements `promviz bench-render`, measuring how long the
+// dashboard takes to render and draw panels of mock data
+func runBenchRender(args []string) {
+	fs := flag.NewFlagSet("bench-render", flag.ExitOnError)
+	panels := fs.Int("panels", 20, "Graph panels to render")
+	points := fs.Int("points", 2000, "Points per panel")
+	duration := fs.Duration("duration", 10*time.Second, "How long to keep drawing frames")
+	width := fs.Int("width", 200, "Simulated terminal width")
+	height := fs.Int("height", 50, "Simulated terminal height")
+	format := fs.String("format", "table", "Output format: table or json")
+	fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		exitWithError(fmt.Errorf("unsupported format: %s (supported: table, json)", *format))
+	}
+	if *width < 1 || *height < 1 {
+		exitWithError(fmt.Errorf("--width and --height must be positive"))
+	}
+
+	report, err := bench.Run(context.Background(), bench.Options{
+		Panels:   *panels,
+		Points:   *points,
+		Duration: *duration,
+		Width:    *width,
+		Height:   *height,
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if *format == "json" {
+		err = bench.WriteJSON(os.Stdout, report)
+	} else {
+		err = bench.WriteText(os.Stdout, report)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+// runExportRules implements `promviz export-rules`, converting query thresholds
+// into a Prometheus alerting rules file
+func runExportRules(args []string) {
+	fs := flag.NewFlagSet("export-rules", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file")
+	output := fs.String("output", "", "Rules file to write (defaults to stdout)")
+	group := fs.String("group", "promviz", "Name of the rule group")
+	forDuration := fs.String("for", "", "How long a threshold must be breached before firing, e.g. 5m")
+	strict := fs.Bool("strict", false, "Fail on unknown config keys and duplicate query names")
+	tag := fs.String("tag", "", "Only export the queries with this tag")
+	fs.Parse(args)
+
+	cfg := loadConfig(*configPath, *strict)
+	if *tag != "" {
+		if err := cfg.SelectTag(*tag); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if *forDuration != "" {
+		if _, err := backend.ParseDuration(*forDuration); err != nil {
+			exitWithError(fmt.Errorf("invalid --for: %w", err))
+		}
+	}
+
+	// Only PromQL expressions can become Prometheus rules
+	var promQueries []backend.Query
+	for _, q := range cfg.Queries {
+		switch name := cfg.BackendFor(q); name {
+		case "prometheus", "mock":
+			promQueries = append(promQueries, q)
+		default:
+			if q.Thresholds != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %q: backend %s does not use PromQL\n", q.Name, name)
+			}
+		}
+	}
+
+	file := rules.Build(*group, promQueries, *forDuration)
+	if len(file.Groups[0].Rules) == 0 {
+		exitWithError(fmt.Errorf("no PromQL query in %s defines thresholds", *configPath))
+	}
+
+	if *output == "" {
+		if err := rules.Write(os.Stdout, file); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer f.Close()
+
+	if err := rules.Write(f, file); err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Wrote %d rules to %s\n", len(file.Groups[0].Rules), *output)
+}
+
+// runInit implements `promviz init`, writing a config generated from one of
+// the built-in dashboard templates
+func runInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	name := fs.String("template", "", "Built-in template to generate the config from")
+	url := fs.String("url", "http://localhost:9090", "Prometheus server URL")
+	job := fs.String("job", "", "Value of the job label selecting the exporter (defaults to the template's)")
+	output := fs.String("output", "queries.yaml", "Config file to write")
+	force := fs.Bool("force", false, "Overwrite the output file if it exists")
+	list := fs.Bool("list", false, "List the available templates")
+	fs.Parse(args)
+
+	if *list || *name == "" {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TEMPLATE\tJOB\tDESCRIPTION")
+		for _, t := range templates.Catalog {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, t.Job, t.Description)
+		}
+		w.Flush()
+		if !*list {
+			fmt.Fprintf(os.Stderr, "\nUsage: promviz init --template NAME [--url URL] [--job JOB] [--output FILE]\n")
+			os.Exit(2)
+		}
+		return
+	}
+
+	data, err := templates.Render(*name, templates.Params{URL: *url, Job: *job})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if !*force {
+		if _, err := os.Stat(*output); err == nil {
+			exitWithError(fmt.Errorf("%s already exists (use --force to overwrite)", *output))
+		}
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Wrote %s template to %s\n", *name, *output)
+}
+
+// runSuggest implements `promviz suggest`, proposing panels for the exporters
+// found among the metrics of a Prometheus server
+func runSuggest(args []string) {
+	fs := flag.NewFlagSet("suggest", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:9090", "Prometheus server URL")
+	output := fs.String("output", "queries.yaml", "Config file to write")
+	yes := fs.Bool("yes", false, "Accept all suggested panels without asking")
+	force := fs.Bool("force", false, "Overwrite the output file if it exists")
+	fs.Parse(args)
+
+	if !*force {
+		if _, err := os.Stat(*output); err == nil {
+			exitWithError(fmt.Errorf("%s already exists (use --force to overwrite)", *output))
+		}
+	}
+
+	client, err := prom.NewClient(&prom.Config{URL: *url})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	suggestions, err := suggest.Suggest(ctx, client, *url)
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(suggestions) == 0 {
+		exitWithError(fmt.Errorf("no known exporter found at %s", *url))
+	}
+
+	for _, s := range suggestions {
+		fmt.Printf("Found %s", s.Template.Name)
+		if len(s.Jobs) > 1 {
+			fmt.Printf(" (jobs: %s; using %s, edit the job label for the others)", strings.Join(s.Jobs, ", "), s.Jobs[0])
+		}
+		fmt.Println()
+	}
+
+	panels := suggest.All(suggestions)
+	if !*yes {
+		panels = suggest.Review(os.Stdin, os.Stdout, suggestions)
+	}
+	if len(panels) == 0 {
+		exitWithError(fmt.Errorf("no panels selected"))
+	}
+
+	data, err := suggest.Build(*url, panels)
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Wrote %d panels to %s\n", len(panels), *output)
+}
+
+// runSelfUpdate implements `promviz self-update`, replacing the running binary
+// with the latest verified GitHub release
+func runSelfUpdate(args []string) {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := fs.Bool("check", false, "Only report whether an update is available")
+	force := fs.Bool("force", false, "Install the latest release even if it is not newer than the running version")
+	insecure := fs.Bool("insecure", false, "Trust the release checksums without a signature, for builds without a release signing key")
+	fs.Parse(args)
+
+	updater, err := selfupdate.NewUpdater(releaseKey)
+	if err != nil {
+		exitWithError(err)
+	}
+	updater.Insecure = *insecure
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	release, err := updater.Latest(ctx)
+	if err != nil {
+		exitWithError(err)
+	}
+	if !release.IsNewer(version) && !*force {
+		if !selfupdate.IsRelease(version) {
+			fmt.Printf("promviz %s is not a release build; use --force to install %s\n", version, release.Tag)
+			return
+		}
+		fmt.Printf("promviz %s is up to date\n", version)
+		return
+	}
+	if *check {
+		fmt.Printf("Update available: %s -> %s\n", version, release.Tag)
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exitWithError(err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		exitWithError(err)
+	}
+
+	binary, err := updater.Download(ctx, release)
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := selfupdate.Replace(exe, binary); err != nil {
+		exitWithError(fmt.Errorf("failed to replace %s: %w", exe, err))
+	}
+	fmt.Printf("Updated %s from %s to %s\n", exe, version, release.Tag)
+}
+
+// runLogin implements `promviz login <backend>`, storing the token or
+// password of a backend in the OS keychain so the config only names its
+// entry
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	configPath := fs.String("config", "queries.yaml", "Path to configuration file")
+	profile := fs.String("profile", "", "Profile whose backend section names the keychain entry")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		exitWithError(fmt.Errorf("usage: promviz login [--config file] [--profile name] <backend>"))
+	}
+	name := fs.Arg(0)
+
+	cfg := loadConfig(*configPath, false)
+	if *profile != "" {
+		var err error
+		if cfg, err = cfg.WithProfile(*profile); err != nil {
+			exitWithError(err)
+		}
+	}
+	entry, credential, err := cfg.CredentialEntry(name)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	secret, err := readSecret(fmt.Sprintf("%s %s: ", name, credential))
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := keychain.Set(entry, secret); err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Stored the %s %s in keychain entry %q\n", name, credential, entry)
+	if _, ok := cfg.KeychainEntry(name); !ok {
+		fmt.Printf("Set %s.credential_store: keychain in %s and remove the %s to use it\n", name, *configPath, credential)
+	}
+}
+
+// readSecret reads a secret from the terminal without echoing it, or the
+// first line of stdin when it is piped
+func readSecret(prompt string) (string, error) {
+	var secret string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		secret = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the secret from stdin: %w", err)
+		}
+		secret = strings.TrimRight(line, "\r\n")
+	}
+	if secret == "" {
+		return "", fmt.Errorf("no secret entered")
+	}
+	return secret, nil
+}
@@ -12,7 +12,9 @@ require (
 	github.com/prometheus/client_golang v1.19.1
 	github.com/prometheus/common v0.53.0
 	github.com/rivo/tview v0.0.0-20231102183219-1b91b8131c43
-	gopkg.in/yaml.v2 v2.4.0
+	golang.org/x/term v0.30.0
+	google.golang.org/protobuf v1.34.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -31,7 +33,5 @@ require (
 	github.com/rivo/uniseg v0.4.3 // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/term v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
-	google.golang.org/protobuf v1.34.1 // indirect
 )
@@ -0,0 +1,161 @@
+package alert
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Level is the alert state of a query
+type Level int
+
+const (
+	LevelOK Level = iota
+	LevelWarning
+	LevelCritical
+)
+
+// String returns the level name used in logs and the UI
+func (l Level) String() string {
+	switch l {
+	case LevelWarning:
+		return "warning"
+	case LevelCritical:
+		return "critical"
+	default:
+		return "ok"
+	}
+}
+
+// Color returns the tview color name for the level
+func (l Level) Color() string {
+	switch l {
+	case LevelWarning:
+		return "yellow"
+	case LevelCritical:
+		return "red"
+	default:
+		return "green"
+	}
+}
+
+// Evaluate returns the level of a value against the thresholds
+func Evaluate(th *backend.Thresholds, value float64) Level {
+	if th == nil {
+		return LevelOK
+	}
+
+	breached := func(limit *float64) bool {
+		if limit == nil {
+			return false
+		}
+		if th.Below {
+			return value < *limit
+		}
+		return value > *limit
+	}
+
+	switch {
+	case breached(th.Crit):
+		return LevelCritical
+	case breached(th.Warn):
+		return LevelWarning
+	default:
+		return LevelOK
+	}
+}
+
+// threshold returns the limit that defines the given level
+func threshold(th *backend.Thresholds, level Level) float64 {
+	var limit *float64
+	switch level {
+	case LevelCritical:
+		limit = th.Crit
+	case LevelWarning:
+		limit = th.Warn
+	}
+	if limit == nil {
+		return 0
+	}
+	return *limit
+}
+
+// Latest returns the most recent non-NaN point of a series
+func Latest(ts *backend.TimeSeriesResult) (backend.DataPoint, bool) {
+	var latest backend.DataPoint
+	found := false
+	if ts == nil {
+		return latest, false
+	}
+	for _, p := range ts.Points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		if !found || p.Timestamp.After(latest.Timestamp) {
+			latest = p
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// Transition records a change of alert level for a query
+type Transition struct {
+	ID         string    `json:"id"`
+	Query      string    `json:"query"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Time       time.Time `json:"time"`        // timestamp of the sample that caused the change
+	DetectedAt time.Time `json:"detected_at"` // when promviz noticed it
+}
+
+// Tracker remembers the alert level of every query by ID and reports transitions
+type Tracker struct {
+	mu     sync.Mutex
+	levels map[string]Level
+}
+
+// NewTracker creates a tracker with every query in the OK state
+func NewTracker() *Tracker {
+	return &Tracker{levels: make(map[string]Level)}
+}
+
+// Observe evaluates the latest point of a query and returns a transition if
+// its level changed. Queries without thresholds never transition.
+func (t *Tracker) Observe(q backend.Query, latest backend.DataPoint) (*Transition, bool) {
+	if q.Thresholds == nil {
+		return nil, false
+	}
+
+	level := Evaluate(q.Thresholds, latest.Value)
+
+	t.mu.Lock()
+	previous := t.levels[q.ID]
+	t.levels[q.ID] = level
+	t.mu.Unlock()
+
+	if level == previous {
+		return nil, false
+	}
+
+	// Recoveries report the limit that was left behind
+	limitLevel := level
+	if level < previous {
+		limitLevel = previous
+	}
+
+	return &Transition{
+		ID:         q.ID,
+		Query:      q.Name,
+		From:       previous.String(),
+		To:         level.String(),
+		Value:      latest.Value,
+		Threshold:  threshold(q.Thresholds, limitLevel),
+		Time:       latest.Timestamp,
+		DetectedAt: time.Now(),
+	}, true
+}
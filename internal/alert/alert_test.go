@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestEvaluate(t *testing.T) {
+	above := &backend.Thresholds{Warn: float(80), Crit: float(90)}
+	below := &backend.Thresholds{Warn: float(20), Crit: float(10), Below: true}
+
+	tests := []struct {
+		name     string
+		th       *backend.Thresholds
+		value    float64
+		expected Level
+	}{
+		{"no thresholds", nil, 1000, LevelOK},
+		{"above ok", above, 50, LevelOK},
+		{"above at warn is ok", above, 80, LevelOK},
+		{"above warning", above, 85, LevelWarning},
+		{"above critical", above, 95, LevelCritical},
+		{"below ok", below, 50, LevelOK},
+		{"below warning", below, 15, LevelWarning},
+		{"below critical", below, 5, LevelCritical},
+		{"crit only", &backend.Thresholds{Crit: float(90)}, 85, LevelOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.th, tt.value); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	now := time.Now()
+	ts := &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now.Add(-time.Minute), Value: 1},
+		{Timestamp: now.Add(time.Minute), Value: math.NaN()},
+		{Timestamp: now, Value: 2},
+	}}
+
+	latest, ok := Latest(ts)
+	if !ok {
+		t.Fatal("Expected a latest point")
+	}
+	if latest.Value != 2 {
+		t.Errorf("Expected latest non-NaN value 2, got %v", latest.Value)
+	}
+
+	if _, ok := Latest(&backend.TimeSeriesResult{}); ok {
+		t.Error("Empty series should have no latest point")
+	}
+}
+
+func TestTrackerObserve(t *testing.T) {
+	tracker := NewTracker()
+	q := backend.Query{ID: "cpu", Name: "CPU", Thresholds: &backend.Thresholds{Warn: float(80), Crit: float(90)}}
+	now := time.Now()
+
+	if _, changed := tracker.Observe(q, backend.DataPoint{Timestamp: now, Value: 50}); changed {
+		t.Error("Staying OK should not be a transition")
+	}
+
+	tr, changed := tracker.Observe(q, backend.DataPoint{Timestamp: now, Value: 95})
+	if !changed {
+		t.Fatal("Crossing crit should be a transition")
+	}
+	if tr.From != "ok" || tr.To != "critical" || tr.Threshold != 90 || tr.Query != "CPU" {
+		t.Errorf("Unexpected transition: %+v", tr)
+	}
+
+	if _, changed := tracker.Observe(q, backend.DataPoint{Timestamp: now, Value: 96}); changed {
+		t.Error("Staying critical should not be a transition")
+	}
+
+	tr, changed = tracker.Observe(q, backend.DataPoint{Timestamp: now, Value: 10})
+	if !changed {
+		t.Fatal("Recovery should be a transition")
+	}
+	if tr.To != "ok" || tr.Threshold != 90 {
+		t.Errorf("Recovery should report the critical threshold, got %+v", tr)
+	}
+
+	// Queries are tracked independently by ID
+	other := q
+	other.ID = "cpu-2"
+	if _, changed := tracker.Observe(other, backend.DataPoint{Timestamp: now, Value: 85}); !changed {
+		t.Error("Second query should transition on its own")
+	}
+}
+
+func TestTrackerIgnoresQueriesWithoutThresholds(t *testing.T) {
+	tracker := NewTracker()
+	q := backend.Query{ID: "cpu", Name: "CPU"}
+
+	if _, changed := tracker.Observe(q, backend.DataPoint{Value: 1e9}); changed {
+		t.Error("Queries without thresholds should never transition")
+	}
+}
@@ -0,0 +1,80 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Log appends transitions to a JSON-lines file
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewLog creates a log writing to path, creating its directory if needed
+func NewLog(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create breach log directory: %w", err)
+	}
+	return &Log{path: path}, nil
+}
+
+// Path returns the file the log writes to
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes a transition as a single JSON line
+func (l *Log) Append(tr Transition) error {
+	data, err := json.Marshal(tr)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open breach log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Recent returns up to limit of the newest transitions, oldest first.
+// A missing file yields no transitions; malformed lines are skipped.
+func (l *Log) Recent(limit int) ([]Transition, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach log: %w", err)
+	}
+	defer f.Close()
+
+	var transitions []Transition
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var tr Transition
+		if err := json.Unmarshal(scanner.Bytes(), &tr); err != nil {
+			continue
+		}
+		transitions = append(transitions, tr)
+		if len(transitions) > limit {
+			transitions = transitions[1:]
+		}
+	}
+
+	return transitions, scanner.Err()
+}
@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogAppendAndRecent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "breaches.jsonl")
+
+	l, err := NewLog(path)
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		tr := Transition{Query: "CPU", From: "ok", To: "critical", Value: float64(i), Time: base.Add(time.Duration(i) * time.Minute)}
+		if err := l.Append(tr); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	recent, err := l.Recent(3)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 3 {
+		t.Fatalf("Expected 3 transitions, got %d", len(recent))
+	}
+	if recent[0].Value != 2 || recent[2].Value != 4 {
+		t.Errorf("Expected the newest transitions oldest first, got %+v", recent)
+	}
+	if !recent[2].Time.Equal(base.Add(4 * time.Minute)) {
+		t.Errorf("Timestamp should round-trip, got %v", recent[2].Time)
+	}
+}
+
+func TestLogRecentMissingFile(t *testing.T) {
+	l, err := NewLog(filepath.Join(t.TempDir(), "breaches.jsonl"))
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	recent, err := l.Recent(10)
+	if err != nil {
+		t.Errorf("Missing log should not be an error, got %v", err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("Expected no transitions, got %d", len(recent))
+	}
+}
+
+func TestLogRecentSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breaches.jsonl")
+	content := "not json\n{\"query\":\"CPU\",\"to\":\"warning\"}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	l, _ := NewLog(path)
+	recent, err := l.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].To != "warning" {
+		t.Errorf("Expected one valid transition, got %+v", recent)
+	}
+}
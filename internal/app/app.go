@@ -2,92 +2,351 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"promviz/internal/alert"
 	"promviz/internal/backend"
 	"promviz/internal/backend/influxdb"
 	"promviz/internal/backend/influxdb1"
 	"promviz/internal/backend/mock"
 	"promviz/internal/backend/prom"
 	"promviz/internal/config"
+	"promviz/internal/convert"
+	"promviz/internal/expand"
+	"promviz/internal/join"
+	"promviz/internal/resources"
+	"promviz/internal/sink"
+	"promviz/internal/state"
+	"promviz/internal/tracing"
 	"promviz/internal/ui"
 )
 
 // App represents the main application
 type App struct {
-	config       *config.Config
-	backend      backend.Backend
-	ui           *ui.TUI
-	updateTicker *time.Ticker
-	ctx          context.Context
-	cancel       context.CancelFunc
-	wg           sync.WaitGroup
+	config         *config.Config
+	mu             sync.RWMutex               // guards backends, profile, queryCtx, prompted, muted, idle and the query ranges, and orders tracked goroutines before Stop
+	backends       map[string]backend.Backend // keyed by backend name
+	profile        string                     // backend profile the backends were created from
+	prompted       map[string]bool            // backends asked for a new credential
+	muted          map[string]bool            // tags whose panels raise no alerts
+	idle           map[int]time.Time          // panels outside their active hours, until they open again
+	queryCtx       context.Context            // parent of queries against the current backends
+	cancelQueries  context.CancelFunc         // cancels queryCtx
+	ui             *ui.TUI
+	alerts         *alert.Tracker
+	throttle       *throttle
+	supervisor     *supervisor
+	breachLog      *alert.Log      // nil when no query has thresholds
+	tracer         *tracing.Tracer // nil when tracing is off
+	sinks          *sink.Writer    // nil without sinks
+	statePath      string          // per-user customizations, empty if unavailable
+	refresh        time.Duration   // panel refresh interval
+	updateTicker   *time.Ticker
+	playlistTicker *time.Ticker
+	headerTicker   *time.Ticker
+	memoryTicker   *time.Ticker
+	budget         resources.Budget // memory and CPU promviz may use, zero without limits
+	downsampling   atomic.Bool      // results are thinned out while memory runs short
+	ctx            context.Context
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+// updateInterval is how often panels are refreshed
+const updateInterval = 5 * time.Second
+
+// queryTimeout bounds the queries of one refresh
+const queryTimeout = 3 * time.Second
+
+// Option customizes how the application is built
+type Option func(*options)
+
+// options holds settings that come from the command line rather than the config file
+type options struct {
+	panel  string
+	tag    string
+	window string
+	strict bool
+}
+
+// WithPanel limits the application to the single query with the given name or ID
+func WithPanel(name string) Option {
+	return func(o *options) {
+		o.panel = name
+	}
+}
+
+// WithTag limits the application to the queries with the given tag
+func WithTag(tag string) Option {
+	return func(o *options) {
+		o.tag = tag
+	}
+}
+
+// WithWindow shows the fixed window, e.g. "2024-05-02 13:00 to 15:00" in
+// local time, instead of the configured ranges
+func WithWindow(window string) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithStrict makes configuration warnings fatal instead of listing them in
+// the diagnostics view
+func WithStrict(strict bool) Option {
+	return func(o *options) {
+		o.strict = strict
+	}
 }
 
 // New creates a new application instance
-func New(configPath string) (*App, error) {
+func New(configPath string, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig(configPath)
+	load := config.LoadConfig
+	if o.strict {
+		load = config.LoadConfigStrict
+	}
+	cfg, err := load(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Create backend (currently only Prometheus)
-	backend, err := createBackend(cfg)
+	if o.panel != "" {
+		if err := cfg.SelectQuery(o.panel); err != nil {
+			return nil, err
+		}
+	}
+	if o.tag != "" {
+		if err := cfg.SelectTag(o.tag); err != nil {
+			return nil, err
+		}
+	}
+	if o.window != "" {
+		window, err := backend.ParseWindow(o.window, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		for i := range cfg.Queries {
+			cfg.Queries[i].Window = &window
+		}
+	}
+
+	backends, statuses, err := ConnectBackends(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create backend: %w", err)
+		return nil, err
 	}
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Write every fetched series to the sinks as well
+	var sinks *sink.Writer
+	if len(cfg.Sinks) > 0 {
+		sinks, err = sink.New(cfg.Sinks)
+		if err != nil {
+			closeAll(backends)
+			return nil, err
+		}
+	}
 
-	if err := backend.Connect(ctx); err != nil {
-		return nil, err
+	// Trace queries so backend operators can tell which dashboard sent them
+	var tracer *tracing.Tracer
+	if cfg.Tracing != nil {
+		tracer = tracing.New(*cfg.Tracing, map[string]string{"promviz.dashboard": cfg.HeaderTitle(configPath)})
+		traceBackends(backends, tracer)
 	}
+	sinkBackends(backends, sinks)
+	limitBackends(backends, cfg.ResultLimits())
+
+	// Turn expand_by queries into one panel per label value
+	queries, warnings := expand.Queries(context.Background(), cfg.Queries, func(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+		name := cfg.BackendFor(q)
+		ctx, cancel := context.WithTimeout(backend.WithRaw(ctx, cfg.RawFor(q)), cfg.ConnectTimeout(name))
+		defer cancel()
+		return backends[name].QueryRange(ctx, q.Expr, q.TimeRange())
+	})
+	cfg.Queries = queries
+	cfg.Warnings = append(cfg.Warnings, warnings...)
 
 	// Create application context
 	appCtx, appCancel := context.WithCancel(context.Background())
 
+	queryCtx, cancelQueries := context.WithCancel(appCtx)
+
 	app := &App{
-		config:  cfg,
-		backend: backend,
-		ctx:     appCtx,
-		cancel:  appCancel,
+		config:        cfg,
+		backends:      backends,
+		profile:       config.DefaultProfile,
+		prompted:      make(map[string]bool),
+		idle:          make(map[int]time.Time),
+		queryCtx:      queryCtx,
+		cancelQueries: cancelQueries,
+		alerts:        alert.NewTracker(),
+		throttle:      newThrottle(updateInterval),
+		supervisor:    newSupervisor(),
+		tracer:        tracer,
+		sinks:         sinks,
+		refresh:       updateInterval,
+		ctx:           appCtx,
+		cancel:        appCancel,
 	}
 
 	// Create UI with quit handler
 	app.ui = ui.NewTUI(cfg.Queries, app.Stop)
+	app.ui.SetDiagnostics(cfg.Warnings)
+	app.ui.SetBackendStatus(statusLines(statuses), countFailed(statuses))
+	app.ui.SetSinks(sinks.Names())
+	app.ui.SetRetryHandler(app.retry)
+	app.watchCredentials(backends, cfg)
+	app.ui.SetCredentialHandler(app.setCredential)
+	app.ui.SetMuteHandler(app.setMuted)
+	app.ui.SetRangeHandler(app.setRanges)
+	app.ui.SetNumberFormat(cfg.NumberFormat())
+	app.ui.SetLanguage(cfg.Catalog())
+	app.limitResources()
+	if cfg.Header != nil {
+		app.ui.EnableHeader(cfg.HeaderTitle(configPath), time.Now())
+	}
+	app.loadState(configPath)
+	if names := cfg.ProfileNames(); len(names) > 0 {
+		app.ui.SetProfiles(names, app.profile)
+		app.ui.SetProfileHandler(app.switchProfile)
+	}
+
+	if cfg.HasThresholds() {
+		if err := app.openBreachLog(); err != nil {
+			appCancel()
+			app.release()
+			return nil, err
+		}
+	}
 
 	return app, nil
 }
 
-// createBackend creates the appropriate backend based on configuration
+// openBreachLog prepares the breach log and loads its history into the UI
+func (a *App) openBreachLog() error {
+	path, err := a.config.AlertLogPath()
+	if err != nil {
+		return err
+	}
+
+	breachLog, err := alert.NewLog(path)
+	if err != nil {
+		return err
+	}
+
+	recent, err := breachLog.Recent(100)
+	if err != nil {
+		return err
+	}
+
+	a.breachLog = breachLog
+	a.ui.SetBreaches(recent)
+	return nil
+}
+
+// loadState restores the customizations saved for this config and saves
+// them again whenever they change. Without a config directory nothing is
+// kept; an unreadable state file is listed in the diagnostics view.
+func (a *App) loadState(configPath string) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return
+	}
+	path, err := state.Path(data)
+	if err != nil {
+		return
+	}
+
+	s, err := state.Load(path)
+	if err != nil {
+		a.config.Warnings = append(a.config.Warnings, err.Error())
+		a.ui.SetDiagnostics(a.config.Warnings)
+	}
+	if s.Refresh > 0 {
+		a.refresh = s.Refresh
+		a.throttle.setInterval(s.Refresh)
+	}
+	s.Refresh = a.refresh
+	a.setMuted(s.Muted)
+
+	a.statePath = path
+	a.ui.SetState(s)
+	a.ui.SetStateHandler(a.saveState)
+}
+
+// saveState writes the customizations and applies a changed refresh
+// interval. It runs on the UI event loop.
+func (a *App) saveState(s state.State) {
+	if s.Refresh != a.refresh && a.updateTicker != nil {
+		a.refresh = s.Refresh
+		a.updateTicker.Reset(s.Refresh)
+		a.throttle.setInterval(s.Refresh)
+	}
+	if s.Refresh == updateInterval {
+		s.Refresh = 0 // follow the default if it changes
+	}
+
+	// A failed write only loses the customizations on restart
+	_ = state.Save(a.statePath, s)
+}
+
+// createBackend creates the default backend of the configuration
 func createBackend(cfg *config.Config) (backend.Backend, error) {
-	switch cfg.Backend {
+	return createNamedBackend(cfg, cfg.Backend)
+}
+
+// createNamedBackend creates the backend with the given name from its config section
+func createNamedBackend(cfg *config.Config, name string) (backend.Backend, error) {
+	switch name {
 	case "prometheus", "":
 		promConfig := cfg.GetPrometheusConfig()
 		return prom.NewClient(promConfig)
 	case "influxdb":
 		influxConfig := cfg.GetInfluxDBConfig()
+		if entry, ok := cfg.KeychainEntry(name); ok {
+			token, err := keychainCredential(name, entry)
+			if err != nil {
+				return nil, err
+			}
+			resolved := *influxConfig
+			resolved.Token = token
+			influxConfig = &resolved
+		}
 		return influxdb.NewClient(influxConfig)
 	case "influxdb1":
 		influxConfig := cfg.GetInfluxDB1Config()
+		if entry, ok := cfg.KeychainEntry(name); ok {
+			password, err := keychainCredential(name, entry)
+			if err != nil {
+				return nil, err
+			}
+			resolved := *influxConfig
+			resolved.Password = password
+			influxConfig = &resolved
+		}
 		return influxdb1.NewClient(influxConfig)
 	case "mock":
 		mockConfig := cfg.GetMockConfig()
 		return mock.NewClient(mockConfig), nil
 	default:
-		return nil, fmt.Errorf("unsupported backend: %s (supported: prometheus, influxdb, influxdb1, mock)", cfg.Backend)
+		return nil, fmt.Errorf("unsupported backend: %s (supported: prometheus, influxdb, influxdb1, mock)", name)
 	}
 }
 
 // Start begins the application
 func (a *App) Start() error {
 	// Start periodic updates
-	a.updateTicker = time.NewTicker(5 * time.Second)
+	a.updateTicker = time.NewTicker(a.refresh)
 
 	a.wg.Add(1)
 	go func() {
@@ -95,8 +354,61 @@ func (a *App) Start() error {
 		a.updateLoop()
 	}()
 
-	// Initial update
-	go a.updateMetrics()
+	// Rotate through panel pages if a playlist is configured
+	if a.config.Playlist != nil {
+		a.playlistTicker = time.NewTicker(a.config.Playlist.Interval)
+		a.ui.EnablePlaylist()
+
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.playlistLoop()
+		}()
+	}
+
+	// Keep the header clock ticking
+	if a.config.Header != nil {
+		a.headerTicker = time.NewTicker(time.Second)
+
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.headerLoop()
+		}()
+	}
+
+	// Thin out panel data before the memory budget runs out
+	if a.budget.Memory > 0 {
+		a.memoryTicker = time.NewTicker(memoryCheckInterval)
+
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.memoryLoop()
+		}()
+	}
+
+	// Export spans until the application stops
+	if a.tracer != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.tracer.Run(a.ctx, traceFlushInterval)
+		}()
+	}
+
+	// Write fetched series to the sinks until the application stops
+	if a.sinks != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.sinks.Run(a.ctx, sinkFlushInterval, a.ui.SetSinkError)
+		}()
+	}
+
+	// Initial update, and units and descriptions the config leaves out
+	a.goTracked(a.updateMetrics)
+	a.goTracked(a.describePanels)
 
 	// Start the TUI (this blocks until quit)
 	return a.ui.Run()
@@ -107,45 +419,499 @@ func (a *App) Stop() {
 	if a.updateTicker != nil {
 		a.updateTicker.Stop()
 	}
+	if a.playlistTicker != nil {
+		a.playlistTicker.Stop()
+	}
+	if a.headerTicker != nil {
+		a.headerTicker.Stop()
+	}
+	if a.memoryTicker != nil {
+		a.memoryTicker.Stop()
+	}
+
+	// Cancel in-flight queries; no goroutine is tracked after this
+	a.mu.Lock()
 	a.cancel()
+	a.mu.Unlock()
 	a.ui.Stop()
 
 	// Wait for background goroutines to finish
 	a.wg.Wait()
 
-	// Close backend connection
-	if a.backend != nil {
-		a.backend.Close()
+	a.release()
+}
+
+// release closes the sinks and the backend connections
+func (a *App) release() {
+	a.sinks.Close()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	closeAll(a.backends)
+}
+
+// switchProfile connects the backends of the named profile and repoints all
+// panels at them. If none of them can be reached the current backends stay.
+func (a *App) switchProfile(name string) {
+	if !a.track() {
+		return
+	}
+	defer a.wg.Done()
+
+	cfg, err := a.config.WithProfile(name)
+	if err != nil {
+		a.ui.ProfileSwitchFailed(name, err)
+		return
+	}
+	backends, statuses, err := connectBackends(a.ctx, cfg)
+	if a.ctx.Err() != nil {
+		// Stopped while connecting
+		if err == nil {
+			closeAll(backends)
+		}
+		return
+	}
+	if err != nil {
+		a.ui.ProfileSwitchFailed(name, err)
+		return
 	}
+	if a.tracer != nil {
+		traceBackends(backends, a.tracer)
+	}
+	sinkBackends(backends, a.sinks)
+	limitBackends(backends, cfg.ResultLimits())
+	a.watchCredentials(backends, cfg)
+
+	a.mu.Lock()
+	if a.ctx.Err() != nil {
+		// Stopped while connecting
+		a.mu.Unlock()
+		closeAll(backends)
+		return
+	}
+	old := a.backends
+	a.backends = backends
+	a.profile = name
+	a.prompted = make(map[string]bool)
+	a.cancelQueries()
+	a.queryCtx, a.cancelQueries = context.WithCancel(a.ctx)
+	a.mu.Unlock()
+
+	// Queries still running against the old backends are canceled and
+	// replaced by the refresh below
+	closeAll(old)
+	a.throttle.resetAll()
+	a.ui.SetActiveProfile(name, statusLines(statuses), countFailed(statuses))
+	a.updateMetrics()
 }
 
-// updateLoop runs the periodic metric updates
+// updateLoop runs the periodic metric updates. Panels on a fixed window
+// are left alone since their data doesn't change.
 func (a *App) updateLoop() {
 	for {
 		select {
 		case <-a.ctx.Done():
 			return
 		case <-a.updateTicker.C:
-			a.updateMetrics()
+			a.updatePanels(false)
+		}
+	}
+}
+
+// playlistLoop advances to the next page of panels on every playlist tick
+func (a *App) playlistLoop() {
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.playlistTicker.C:
+			// Queue without blocking so Stop never waits on the UI event loop
+			a.goTracked(a.ui.AdvancePlaylist)
+		}
+	}
+}
+
+// headerLoop refreshes the header clock, uptime and data freshness
+func (a *App) headerLoop() {
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.headerTicker.C:
+			// Queue without blocking so Stop never waits on the UI event loop
+			a.goTracked(a.ui.RefreshHeader)
 		}
 	}
 }
 
-// updateMetrics fetches new data from the backend and updates the UI
+// updateMetrics fetches new data from the backend and updates the UI,
+// returning once every panel is done. Panels throttled after repeated
+// failures are skipped until their next attempt is due.
 func (a *App) updateMetrics() {
-	ctx, cancel := context.WithTimeout(a.ctx, 3*time.Second)
+	a.updatePanels(true)
+}
+
+// updatePanels is updateMetrics, skipping the panels on a fixed window
+// unless fixed is set
+func (a *App) updatePanels(fixed bool) {
+	ctx, cancel := a.queryContext()
 	defer cancel()
 
-	for i, query := range a.config.Queries {
+	shared := newFetches()
+	now := time.Now()
+	var pending sync.WaitGroup
+	for i, query := range a.queries() {
+		if !a.throttle.due(i, now) || (query.Window != nil && !fixed) || a.scheduledIdle(i, query, now) {
+			continue
+		}
+
+		pending.Add(1)
 		go func(idx int, q backend.Query) {
-			timeSeries, err := a.backend.QueryTimeSeries(ctx, q.Expr)
+			defer pending.Done()
+			a.refreshPanel(ctx, shared, idx, q)
+		}(i, query)
+	}
+	pending.Wait()
+}
 
-			if err != nil {
-				a.ui.UpdateTimeSeries(idx, nil, err)
-				return
+// retry refreshes a panel right away and returns it to the normal refresh
+// interval if it was throttled
+func (a *App) retry(idx int) {
+	queries := a.queries()
+	if idx < 0 || idx >= len(queries) || !a.track() {
+		return
+	}
+	defer a.wg.Done()
+	a.throttle.reset(idx)
+
+	ctx, cancel := a.queryContext()
+	defer cancel()
+	a.refreshPanel(ctx, newFetches(), idx, queries[idx])
+}
+
+// queries returns a copy of the queries of the panels
+func (a *App) queries() []backend.Query {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]backend.Query(nil), a.config.Queries...)
+}
+
+// setRanges takes the ranges picked in the TUI and refreshes every panel
+// with them. Queries still running for the old ranges are canceled, so
+// they can't overwrite the new data. It runs on the UI event loop.
+func (a *App) setRanges(queries []backend.Query) {
+	a.mu.Lock()
+	for i := range a.config.Queries {
+		a.config.Queries[i].Range = queries[i].Range
+		a.config.Queries[i].Window = queries[i].Window
+	}
+	a.cancelQueries()
+	a.queryCtx, a.cancelQueries = context.WithCancel(a.ctx)
+	a.mu.Unlock()
+
+	a.goTracked(a.updateMetrics)
+}
+
+// recordResult tells the UI when a panel gets throttled. Canceled refreshes
+// say nothing about the backend and are not counted.
+func (a *App) recordResult(idx int, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	failures, next, throttled := a.throttle.record(idx, err, time.Now())
+	if throttled {
+		a.ui.SetThrottled(idx, failures, next)
+	}
+}
+
+// updatePanel refreshes one panel and returns the error it displays. The
+// panel is left as it is if the refresh was abandoned.
+func (a *App) updatePanel(ctx context.Context, shared *fetches, idx int, q backend.Query) (err error) {
+	ctx, span := a.startPanelSpan(ctx, q)
+	defer func() { span.End(err) }()
+	ctx = backend.WithRaw(ctx, a.config.RawFor(q))
+
+	if q.PanelType() == backend.PanelSLO {
+		good, total, err := a.fetchSLO(ctx, q)
+		if a.abandoned(ctx) {
+			return context.Canceled
+		}
+		a.ui.UpdateSLO(idx, good, total, err)
+		return err
+	}
+	if q.PanelType() == backend.PanelHealth {
+		report, err := a.fetchHealth(ctx, q)
+		if a.abandoned(ctx) {
+			return context.Canceled
+		}
+		a.ui.UpdateHealth(idx, report, err)
+		return err
+	}
+
+	var timeSeries *backend.TimeSeriesResult
+	switch q.PanelType() {
+	case backend.PanelJoin:
+		timeSeries, err = a.fetchJoin(ctx, q)
+	case backend.PanelBurnRate:
+		timeSeries, err = a.fetchBurnRate(ctx, q)
+	case backend.PanelStatus:
+		timeSeries, err = a.fetchStatus(ctx, q)
+	default:
+		timeSeries, err = a.fetchGraph(ctx, shared, q)
+	}
+	if a.abandoned(ctx) {
+		return context.Canceled
+	}
+	if err != nil {
+		a.ui.UpdateTimeSeries(idx, nil, err)
+		return err
+	}
+
+	if q.PanelType() == backend.PanelHistogram {
+		timeSeries = backend.HistogramQuantiles(timeSeries, q.Histogram.Levels())
+	}
+	// Converted before thresholds, alerts and exports see the values
+	timeSeries = convert.Apply(q.Convert, timeSeries)
+	if q.Record != "" {
+		a.sinks.RecordDerived(q.ID, q.Record, timeSeries)
+	}
+	timeSeries = a.downsample(timeSeries)
+	a.ui.UpdateTimeSeries(idx, timeSeries, nil)
+	if q.PanelType() != backend.PanelJoin {
+		a.checkThresholds(q, timeSeries)
+	}
+	return nil
+}
+
+// fetchGraph fetches the series of a graph panel, sharing the query with
+// other panels of the same refresh
+func (a *App) fetchGraph(ctx context.Context, shared *fetches, q backend.Query) (*backend.TimeSeriesResult, error) {
+	key := a.config.BackendFor(q) + "\x00" + rangeKey(q) + "\x00" + strconv.FormatBool(a.config.RawFor(q)) + "\x00" + q.Expr
+	timeSeries, err := shared.get(key, func() (*backend.TimeSeriesResult, error) {
+		tr := q.TimeRange()
+		if q.Evaluation == backend.EvaluationInstantOverRange {
+			// The expression looks back over the range on its own
+			tr.Start = tr.End
+		}
+		return a.backendFor(q).QueryRange(ctx, q.Expr, tr)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Panels created by expand_by only show their own series
+	if len(q.Match) > 0 {
+		timeSeries = &backend.TimeSeriesResult{
+			Points:     backend.MatchSeries(timeSeries.Points, q.Match),
+			Histograms: backend.MatchHistograms(timeSeries.Histograms, q.Match),
+			Truncation: timeSeries.Truncation,
+			Warnings:   timeSeries.Warnings,
+		}
+	}
+	return timeSeries, nil
+}
+
+// rangeKey identifies the time range of a query among the fetches of a
+// refresh. Calendar panels fetch the same range at a finer step, and
+// instant_over_range panels only its end.
+func rangeKey(q backend.Query) string {
+	step := q.TimeRange().Step.String() + "\x00" + q.Evaluation
+	if q.Window != nil {
+		return q.Window.Start.String() + "\x00" + q.Window.End.String() + "\x00" + step
+	}
+	return q.Range + "\x00" + q.Offset + "\x00" + step
+}
+
+// checkThresholds records a breach when the latest value changes alert level.
+// The levels of panels with a muted tag are followed without recording.
+func (a *App) checkThresholds(q backend.Query, timeSeries *backend.TimeSeriesResult) {
+	latest, ok := alert.Latest(timeSeries)
+	if !ok {
+		return
+	}
+	a.recordAlertLevel(q, latest)
+
+	tr, changed := a.alerts.Observe(q, latest)
+	if !changed || a.alertsMuted(q) {
+		return
+	}
+
+	if a.breachLog != nil {
+		// A failed write still leaves the breach visible in the TUI
+		_ = a.breachLog.Append(*tr)
+	}
+	a.ui.AddBreach(*tr)
+}
+
+// setMuted replaces the tags whose panels raise no alerts
+func (a *App) setMuted(tags []string) {
+	muted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		muted[tag] = true
+	}
+	a.mu.Lock()
+	a.muted = muted
+	a.mu.Unlock()
+}
+
+// alertsMuted reports whether a tag of the query is muted
+func (a *App) alertsMuted(q backend.Query) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, tag := range q.Tags {
+		if a.muted[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// backendFor returns the backend a query runs against
+func (a *App) backendFor(q backend.Query) backend.Backend {
+	return a.backend(a.config.BackendFor(q))
+}
+
+// backend returns the backend with the given name of the active profile
+func (a *App) backend(name string) backend.Backend {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.backends[name]
+}
+
+// fetchSLO fetches the good and total series of an SLO panel over its
+// window, which ends with the panel's range
+func (a *App) fetchSLO(ctx context.Context, q backend.Query) (good, total *backend.TimeSeriesResult, err error) {
+	window, err := backend.ParseDuration(q.SLO.WindowOrDefault())
+	if err != nil {
+		return nil, nil, err
+	}
+	tr := backend.RangeEndingAt(window, q.TimeRange().End)
+
+	b := a.backendFor(q)
+	good, err = b.QueryRange(ctx, q.SLO.Good, tr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("good query: %w", err)
+	}
+
+	total, err = b.QueryRange(ctx, q.SLO.Total, tr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("total query: %w", err)
+	}
+	return good, total, nil
+}
+
+// fetchBurnRate fetches the error ratio of a burn rate panel over every
+// window of the alerts and combines the burn rates into one result. Windows
+// that fail become warnings unless all of them do.
+func (a *App) fetchBurnRate(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+	tr := q.TimeRange()
+	b := a.backendFor(q)
+
+	rates := &backend.TimeSeriesResult{}
+	windows := backend.BurnRateWindows()
+	failed := 0
+	for _, window := range windows {
+		ratio, err := b.QueryRange(ctx, q.BurnRate.Expr(window), tr)
+		if err != nil {
+			err = fmt.Errorf("%s window: %w", window, err)
+			if failed++; failed == len(windows) || ctx.Err() != nil {
+				return nil, err
 			}
+			rates.Warnings = append(rates.Warnings, err.Error())
+			continue
+		}
+		rates.Points = append(rates.Points, q.BurnRate.BurnRates(window, ratio)...)
+		rates.Warnings = append(rates.Warnings, ratio.Warnings...)
+		if rates.Truncation == nil {
+			rates.Truncation = ratio.Truncation
+		}
+	}
+	return rates, nil
+}
 
-			a.ui.UpdateTimeSeries(idx, timeSeries, nil)
-		}(i, query)
+// fetchStatus fetches every check of a status panel, possibly from
+// different backends, and combines their series labeled with the check.
+// Checks that fail become warnings unless all of them do.
+func (a *App) fetchStatus(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+	tr := q.TimeRange()
+
+	checks := &backend.TimeSeriesResult{}
+	failed := 0
+	for _, check := range q.Status.Checks {
+		result, err := a.backend(a.config.CheckBackend(q, check)).QueryRange(ctx, check.Expr, tr)
+		if err != nil {
+			err = fmt.Errorf("check %s: %w", check.Name, err)
+			if failed++; failed == len(q.Status.Checks) || ctx.Err() != nil {
+				return nil, err
+			}
+			checks.Warnings = append(checks.Warnings, err.Error())
+			continue
+		}
+		checks.Points = append(checks.Points, backend.WithLabel(result.Points, backend.StatusCheckLabel, check.Name)...)
+		checks.Warnings = append(checks.Warnings, result.Warnings...)
+		if checks.Truncation == nil {
+			checks.Truncation = result.Truncation
+		}
+	}
+	return checks, nil
+}
+
+// fetchJoin fetches both sides of a join panel, possibly from different
+// backends, and combines them
+func (a *App) fetchJoin(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+	tr := q.TimeRange()
+	leftName, rightName := a.config.JoinBackends(q)
+
+	left, err := a.backend(leftName).QueryRange(ctx, q.Join.Left.Expr, tr)
+	if err != nil {
+		return nil, fmt.Errorf("left query: %w", err)
+	}
+
+	right, err := a.backend(rightName).QueryRange(ctx, q.Join.Right.Expr, tr)
+	if err != nil {
+		return nil, fmt.Errorf("right query: %w", err)
 	}
+
+	// A truncated side leaves the join incomplete too
+	truncation := left.Truncation
+	if truncation == nil {
+		truncation = right.Truncation
+	}
+	points := join.Join(left.Points, right.Points, q.Join.Op, q.Join.Interpolation, tr.Step)
+	warnings := append(append([]string(nil), left.Warnings...), right.Warnings...)
+	return &backend.TimeSeriesResult{Points: points, Truncation: truncation, Warnings: warnings}, nil
+}
+
+// queryContext returns the context of one refresh, bounded by queryTimeout
+// and canceled when the application stops or switches backends
+func (a *App) queryContext() (context.Context, context.CancelFunc) {
+	a.mu.RLock()
+	parent := a.queryCtx
+	a.mu.RUnlock()
+	return context.WithTimeout(parent, queryTimeout)
+}
+
+// track counts the calling goroutine among the ones Stop waits for, so it
+// can't outlive the application. It returns false once the application is
+// stopping; otherwise the caller must call a.wg.Done when finished.
+func (a *App) track() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ctx.Err() != nil {
+		return false
+	}
+	a.wg.Add(1)
+	return true
+}
+
+// goTracked runs f in a goroutine Stop waits for, unless the application
+// is already stopping
+func (a *App) goTracked(f func()) {
+	if !a.track() {
+		return
+	}
+	go func() {
+		defer a.wg.Done()
+		f()
+	}()
 }
@@ -1,15 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"promviz/internal/alert"
 	"promviz/internal/backend"
 	"promviz/internal/backend/influxdb"
 	"promviz/internal/backend/prom"
 	"promviz/internal/config"
+	"promviz/internal/state"
 )
 
 func TestCreateBackendPrometheus(t *testing.T) {
@@ -143,6 +153,50 @@ queries:
 	}
 }
 
+// closingBackend counts how often it is closed
+type closingBackend struct {
+	backend.Backend
+	closed int
+}
+
+func (b *closingBackend) Close() error {
+	b.closed++
+	return nil
+}
+
+func TestNewAppBreachLogError(t *testing.T) {
+	tmpDir := t.TempDir()
+	blocker := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	configContent := fmt.Sprintf(`backend: mock
+alert_log: %q
+queries:
+  - name: Test Query
+    expr: test_metric
+    thresholds:
+      warn: 1
+`, filepath.Join(blocker, "breaches.jsonl"))
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(configPath); err == nil || !strings.Contains(err.Error(), "breach log") {
+		t.Errorf("Expected the breach log error, got %v", err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	b := &closingBackend{}
+	a := &App{backends: map[string]backend.Backend{"prometheus": b}}
+	a.release()
+	if b.closed != 1 {
+		t.Errorf("Expected the backend to be closed once, got %d", b.closed)
+	}
+}
+
 func TestNewAppConfigError(t *testing.T) {
 	// Test with non-existent config file
 	_, err := New("nonexistent.yaml")
@@ -262,6 +316,607 @@ queries:
 	}
 }
 
+func TestNewAppUnknownPanel(t *testing.T) {
+	configContent := `backend: mock
+queries:
+  - name: Test Query
+    expr: test_metric
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	_, err = New(configPath, WithPanel("Other Query"))
+	if err == nil {
+		t.Fatal("New should return error for an unknown panel")
+	}
+
+	if !strings.Contains(err.Error(), `no query named "Other Query"`) {
+		t.Errorf("Error should mention the unknown panel, got: %v", err)
+	}
+}
+
+func TestNewAppWindow(t *testing.T) {
+	configContent := `backend: mock
+queries:
+  - name: Test Query
+    expr: test_metric
+    range: 1h
+  - name: Other Query
+    expr: other_metric
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	a, err := New(configPath, WithWindow("2024-05-02 13:00 to 15:00"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.Local)
+	for _, q := range a.config.Queries {
+		if tr := q.TimeRange(); !tr.Start.Equal(start) || !tr.End.Equal(start.Add(2*time.Hour)) {
+			t.Errorf("%s: expected the fixed window, got %v to %v", q.Name, tr.Start, tr.End)
+		}
+	}
+
+	_, err = New(configPath, WithWindow("2024-05-02 13:00"))
+	if err == nil || !strings.Contains(err.Error(), "expected START to END") {
+		t.Errorf("Expected an error for a window without end, got %v", err)
+	}
+}
+
+func TestNewAppExpandBy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/labels") {
+			w.Write([]byte(`{"status": "success", "data": ["__name__"]}`))
+			return
+		}
+		w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"__name__": "up", "instance": "b"}, "values": [[1609459200, "1"]]},
+			{"metric": {"__name__": "up", "instance": "a"}, "values": [[1609459200, "0"]]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	configContent := `prometheus:
+  url: "` + server.URL + `"
+
+queries:
+  - name: Up
+    expr: up
+    expand_by: instance
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	a, err := New(configPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer a.Stop()
+
+	queries := a.config.Queries
+	if len(queries) != 2 || queries[0].Name != "Up (a)" || queries[1].Name != "Up (b)" {
+		t.Fatalf("Expected one panel per instance, got %+v", queries)
+	}
+	if queries[0].Match["instance"] != "a" {
+		t.Errorf("Expected panel to match instance a, got %v", queries[0].Match)
+	}
+}
+
+func TestNewAppRestoresState(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	configContent := `backend: mock
+queries:
+  - name: Test Query
+    expr: test_metric
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+	path, err := state.Path([]byte(configContent))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := state.Save(path, state.State{Refresh: 30 * time.Second}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	a, err := New(configPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer a.Stop()
+
+	if a.refresh != 30*time.Second || a.statePath != path {
+		t.Errorf("Expected the saved refresh interval from %s, got %v from %s", path, a.refresh, a.statePath)
+	}
+
+	// The default interval isn't written, so it follows later defaults
+	a.saveState(state.State{Refresh: updateInterval, Hidden: []string{"test-query"}})
+	saved, err := state.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if saved.Refresh != 0 || len(saved.Hidden) != 1 {
+		t.Errorf("Unexpected saved state %+v", saved)
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	prometheus := func(hits *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			if strings.HasSuffix(r.URL.Path, "/labels") {
+				w.Write([]byte(`{"status": "success", "data": ["__name__"]}`))
+				return
+			}
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": []}}`))
+		}))
+	}
+	var prodHits, stagingHits atomic.Int32
+	prod, staging := prometheus(&prodHits), prometheus(&stagingHits)
+	defer prod.Close()
+	defer staging.Close()
+
+	configContent := `prometheus:
+  url: "` + prod.URL + `"
+profiles:
+  - name: staging
+    prometheus:
+      url: "` + staging.URL + `"
+  - name: broken
+    prometheus:
+      url: "http://127.0.0.1:1"
+      connect_timeout: 100ms
+queries:
+  - name: Up
+    expr: up
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	a, err := New(configPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer a.Stop()
+
+	a.switchProfile("staging")
+	if a.profile != "staging" {
+		t.Fatalf("Expected the staging profile active, got %s", a.profile)
+	}
+	before := stagingHits.Load()
+	if _, err := a.backend("prometheus").QueryRange(context.Background(), "up", backend.DefaultTimeRange()); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if stagingHits.Load() == before {
+		t.Error("Expected queries to go to the staging server")
+	}
+
+	// An unreachable profile keeps the current backends
+	a.switchProfile("broken")
+	if a.profile != "staging" {
+		t.Errorf("Expected staging to stay active, got %s", a.profile)
+	}
+}
+
 // Mock tests would require more complex setup with test servers
 // For now, we focus on the configuration and backend creation logic
 // Integration tests with actual servers would be in a separate test suite
+
+// hangingPrometheus answers connectivity checks but holds every range query
+// until the client gives up, reporting each query on started and each
+// abandoned one on canceled
+func hangingPrometheus(started, canceled chan<- struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasSuffix(r.URL.Path, "/query_range") {
+			w.Write([]byte(`{"status": "success", "data": ["__name__"]}`))
+			return
+		}
+		// Reading the body lets the server notice the client going away
+		r.ParseForm()
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+		select {
+		case canceled <- struct{}{}:
+		default:
+		}
+	}))
+}
+
+// newHangingApp creates an application whose default backend hangs, with a
+// staging profile answering right away
+func newHangingApp(t *testing.T, hanging string) *App {
+	t.Helper()
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/labels") {
+			w.Write([]byte(`{"status": "success", "data": ["__name__"]}`))
+			return
+		}
+		w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": []}}`))
+	}))
+	t.Cleanup(staging.Close)
+
+	configContent := `prometheus:
+  url: "` + hanging + `"
+profiles:
+  - name: staging
+    prometheus:
+      url: "` + staging.URL + `"
+queries:
+  - name: Up
+    expr: up
+  - name: Load
+    expr: node_load1
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+	a, err := New(configPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return a
+}
+
+func TestStopCancelsRefresh(t *testing.T) {
+	started, canceled := make(chan struct{}, 2), make(chan struct{}, 2)
+	server := hangingPrometheus(started, canceled)
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+
+	a.goTracked(a.updateMetrics)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("Refresh did not start")
+	}
+
+	start := time.Now()
+	a.Stop()
+	if elapsed := time.Since(start); elapsed >= queryTimeout {
+		t.Errorf("Stop should cancel in-flight queries rather than wait for their timeout, took %s", elapsed)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Expected the query to be canceled")
+	}
+
+	// Canceled refreshes don't count as failures, and nothing starts once
+	// stopped
+	if len(a.throttle.panels) != 0 {
+		t.Errorf("Expected no failures recorded, got %v", a.throttle.panels)
+	}
+	if a.track() {
+		t.Error("No goroutine should be tracked after Stop")
+	}
+}
+
+func TestSwitchProfileCancelsQueries(t *testing.T) {
+	started, canceled := make(chan struct{}, 2), make(chan struct{}, 2)
+	server := hangingPrometheus(started, canceled)
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	a.goTracked(a.updateMetrics)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("Refresh did not start")
+	}
+
+	a.switchProfile("staging")
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Expected queries against the old backend to be canceled")
+	}
+}
+
+// TestStartStopCycles stops applications in the middle of refreshes, retries
+// and profile switches; run with -race to check the shutdown for data races
+func TestStartStopCycles(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+
+	for i := 0; i < 10; i++ {
+		a := newHangingApp(t, server.URL)
+		for j := 0; j < 3; j++ {
+			a.goTracked(a.updateMetrics)
+		}
+		go a.retry(0)
+		go a.switchProfile("staging")
+		go a.switchProfile(config.DefaultProfile)
+
+		done := make(chan struct{})
+		go func() {
+			a.Stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(queryTimeout):
+			t.Fatalf("Cycle %d: Stop did not return", i)
+		}
+	}
+}
+
+// rawBackend records whether each query was to be sent verbatim
+type rawBackend struct {
+	stuckBackend
+	mu      sync.Mutex
+	queries []string
+}
+
+func (b *rawBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queries = append(b.queries, fmt.Sprintf("%s raw=%v", expr, backend.IsRaw(ctx)))
+	return &backend.TimeSeriesResult{}, nil
+}
+
+func TestUpdatePanelRaw(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rawBackend{}
+	a.backends["prometheus"] = rb
+	a.config.Raw = true
+	wrapped, verbatim := false, true
+
+	ctx, cancel := a.queryContext()
+	defer cancel()
+	shared := newFetches()
+	for i, q := range []backend.Query{
+		{Name: "Wrapped", Expr: "up", Raw: &wrapped},
+		{Name: "Default", Expr: "up"},
+		{Name: "Verbatim", Expr: "up", Raw: &verbatim},
+	} {
+		if err := a.updatePanel(ctx, shared, i%2, q); err != nil {
+			t.Fatalf("%s: %v", q.Name, err)
+		}
+	}
+
+	// Panels only share a query sent the same way
+	want := []string{"up raw=false", "up raw=true"}
+	if strings.Join(rb.queries, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Expected queries %v, got %v", want, rb.queries)
+	}
+}
+
+func TestCheckThresholdsMuted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "success", "data": ["__name__"]}`))
+	}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	log, err := alert.NewLog(filepath.Join(t.TempDir(), "breaches.jsonl"))
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	a.breachLog = log
+
+	crit := 10.0
+	db := backend.Query{ID: "pg", Name: "Postgres", Tags: []string{"db"}, Thresholds: &backend.Thresholds{Crit: &crit}}
+	api := backend.Query{ID: "api", Name: "API", Tags: []string{"critical"}, Thresholds: &backend.Thresholds{Crit: &crit}}
+	breach := &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: time.Now(), Value: 20}}}
+
+	a.setMuted([]string{"db"})
+	a.checkThresholds(db, breach)
+	a.checkThresholds(api, breach)
+
+	recent, err := log.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Query != "API" {
+		t.Errorf("Expected only the unmuted breach recorded, got %+v", recent)
+	}
+
+	// Unmuting records the next change of level, not the one already seen
+	a.setMuted(nil)
+	a.checkThresholds(db, breach)
+	if recent, _ := log.Recent(10); len(recent) != 1 {
+		t.Errorf("Expected no breach for an unchanged level, got %+v", recent)
+	}
+}
+
+// rangeBackend records the range of each query
+type rangeBackend struct {
+	stuckBackend
+	mu      sync.Mutex
+	ranges  map[string]backend.TimeRange
+	failing map[string]bool // expressions that return an error
+}
+
+func (b *rangeBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ranges[expr] = tr
+	if b.failing[expr] {
+		return nil, errors.New("store unavailable")
+	}
+	return &backend.TimeSeriesResult{}, nil
+}
+
+func TestSetRanges(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	window := backend.FixedRange(start, start.Add(2*time.Hour))
+	queries := a.queries()
+	queries[0].Range = "1h"
+	queries[1].Window = &window
+	a.setRanges(queries)
+	a.wg.Wait()
+
+	if got := rb.ranges["up"]; got.End.Sub(got.Start) != time.Hour {
+		t.Errorf("Expected the last hour for up, got %v", got)
+	}
+	if got := rb.ranges["node_load1"]; got != window {
+		t.Errorf("Expected the fixed window for node_load1, got %v", got)
+	}
+	if a.config.Queries[0].Range != "1h" || a.config.Queries[1].Window == nil {
+		t.Errorf("Expected the ranges kept for later refreshes, got %+v", a.config.Queries)
+	}
+}
+
+func TestUpdatePanelsFixed(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	window := backend.FixedRange(start, start.Add(2*time.Hour))
+	a.config.Queries[0].Window = &window
+
+	// Periodic refreshes leave the fixed window alone
+	a.updatePanels(false)
+	if _, ok := rb.ranges["up"]; ok || len(rb.ranges) != 1 {
+		t.Errorf("Expected only the relative panel refreshed, got %v", rb.ranges)
+	}
+
+	a.updateMetrics()
+	if got := rb.ranges["up"]; got != window {
+		t.Errorf("Expected the fixed window fetched, got %v", got)
+	}
+}
+
+func TestFetchGraphInstantOverRange(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+	shared := newFetches()
+	instant := backend.Query{Name: "Errors", Expr: "increase(errors_total[1h])", Range: "1h", Evaluation: backend.EvaluationInstantOverRange}
+	if _, err := a.fetchGraph(context.Background(), shared, instant); err != nil {
+		t.Fatalf("fetchGraph failed: %v", err)
+	}
+	if got := rb.ranges[instant.Expr]; !got.Start.Equal(got.End) || got.End.IsZero() {
+		t.Errorf("Expected one evaluation at the end of the range, got %v", got)
+	}
+
+	graph := instant
+	graph.Evaluation = ""
+	if rangeKey(graph) == rangeKey(instant) {
+		t.Error("An instant_over_range panel should not share the fetch of the whole range")
+	}
+}
+
+func TestFetchBurnRate(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+	q := backend.Query{Name: "Burn", Type: backend.PanelBurnRate, Range: "1h",
+		BurnRate: &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}}
+	if _, err := a.fetchBurnRate(context.Background(), q); err != nil {
+		t.Fatalf("fetchBurnRate failed: %v", err)
+	}
+	for _, window := range []string{"5m", "1h", "30m", "6h"} {
+		if got, ok := rb.ranges["errors["+window+"]"]; !ok || got.End.Sub(got.Start) != time.Hour {
+			t.Errorf("Expected the %s error ratio over the panel range, got %v", window, got)
+		}
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+	q := backend.Query{Name: "Services", Type: backend.PanelStatus, Range: "5m", Status: &backend.StatusConfig{
+		By:     "service",
+		Checks: []backend.StatusCheck{{Name: "up", Expr: "up"}, {Name: "errors", Expr: "error_ratio < bool 0.01"}},
+	}}
+	if _, err := a.fetchStatus(context.Background(), q); err != nil {
+		t.Fatalf("fetchStatus failed: %v", err)
+	}
+	for _, check := range q.Status.Checks {
+		if _, ok := rb.ranges[check.Expr]; !ok {
+			t.Errorf("Expected check %s to be fetched", check.Name)
+		}
+	}
+}
+
+func TestFetchStatusPartial(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange), failing: map[string]bool{"up": true}}
+	a.backends["prometheus"] = rb
+	q := backend.Query{Name: "Services", Type: backend.PanelStatus, Range: "5m", Status: &backend.StatusConfig{
+		By:     "service",
+		Checks: []backend.StatusCheck{{Name: "up", Expr: "up"}, {Name: "errors", Expr: "error_ratio < bool 0.01"}},
+	}}
+	result, err := a.fetchStatus(context.Background(), q)
+	if err != nil {
+		t.Fatalf("One failing check should not fail the panel, got %v", err)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0] != "check up: store unavailable" {
+		t.Errorf("Expected a warning for the failing check, got %v", result.Warnings)
+	}
+
+	rb.failing["error_ratio < bool 0.01"] = true
+	if _, err := a.fetchStatus(context.Background(), q); err == nil || !strings.Contains(err.Error(), "check errors") {
+		t.Errorf("Expected an error once every check fails, got %v", err)
+	}
+}
+
+func TestRangeKey(t *testing.T) {
+	graph := backend.Query{Name: "Requests", Expr: "up", Range: "4w"}
+	calendar := backend.Query{Name: "Requests per day", Type: backend.PanelCalendar, Expr: "up", Range: "4w"}
+	if rangeKey(graph) == rangeKey(calendar) {
+		t.Error("A calendar panel should not share the coarser fetch of a graph panel")
+	}
+	if rangeKey(graph) != rangeKey(backend.Query{Name: "Other", Expr: "up", Range: "4w"}) {
+		t.Error("Panels with the same range should share fetches")
+	}
+}
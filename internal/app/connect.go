@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/config"
+)
+
+// BackendStatus is the outcome of connecting to one backend at startup
+type BackendStatus struct {
+	Name    string
+	Elapsed time.Duration
+	Err     error
+}
+
+// String describes the status for the diagnostics view
+func (s BackendStatus) String() string {
+	elapsed := s.Elapsed.Round(time.Millisecond)
+	if s.Err != nil {
+		return fmt.Sprintf("%s: failed after %s: %v", s.Name, elapsed, s.Err)
+	}
+	return fmt.Sprintf("%s: connected in %s", s.Name, elapsed)
+}
+
+// ConnectBackends creates every backend used by the queries and checks their
+// connectivity concurrently, each bounded by its own connect timeout.
+// Backends that fail the check are still returned so their panels recover
+// once the server becomes reachable; an error is returned only if none connect.
+func ConnectBackends(cfg *config.Config) (map[string]backend.Backend, []BackendStatus, error) {
+	return connectBackends(context.Background(), cfg)
+}
+
+// connectBackends is ConnectBackends giving up early once ctx is done
+func connectBackends(ctx context.Context, cfg *config.Config) (map[string]backend.Backend, []BackendStatus, error) {
+	names := cfg.UsedBackends()
+	backends := make(map[string]backend.Backend, len(names))
+
+	for _, name := range names {
+		b, err := createNamedBackend(cfg, name)
+		if err != nil {
+			closeAll(backends)
+			return nil, nil, fmt.Errorf("failed to create backend: %w", err)
+		}
+		backends[name] = b
+	}
+
+	statuses := make([]BackendStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			start := time.Now()
+			err := connect(ctx, backends[name], cfg.ConnectTimeout(name))
+			statuses[i] = BackendStatus{Name: name, Elapsed: time.Since(start), Err: err}
+		}(i, name)
+	}
+	wg.Wait()
+
+	if countFailed(statuses) == len(statuses) {
+		closeAll(backends)
+		if len(statuses) == 1 {
+			return nil, nil, statuses[0].Err
+		}
+		var errs []error
+		for _, s := range statuses {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, s.Err))
+		}
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return backends, statuses, nil
+}
+
+// ConnectBackend creates the default backend and tests its connection
+func ConnectBackend(cfg *config.Config) (backend.Backend, error) {
+	b, err := createBackend(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend: %w", err)
+	}
+
+	if err := connect(context.Background(), b, cfg.ConnectTimeout(cfg.Backend)); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// connect runs the connectivity check of a backend, giving up after timeout
+// or once parent is done even if the client does not honor context
+// cancellation
+func connect(parent context.Context, b backend.Backend, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Connect(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to connect to %s: timed out after %s", b.Name(), timeout)
+	}
+}
+
+// countFailed returns how many backends could not be reached
+func countFailed(statuses []BackendStatus) int {
+	failed := 0
+	for _, s := range statuses {
+		if s.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// statusLines renders statuses for the diagnostics view
+func statusLines(statuses []BackendStatus) []string {
+	lines := make([]string, len(statuses))
+	for i, s := range statuses {
+		lines[i] = s.String()
+	}
+	return lines
+}
+
+// closeAll closes every backend in the map
+func closeAll(backends map[string]backend.Backend) {
+	for _, b := range backends {
+		b.Close()
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/prom"
+	"promviz/internal/config"
+)
+
+// stuckBackend never finishes connecting and ignores cancellation
+type stuckBackend struct {
+	release chan struct{}
+}
+
+func (s *stuckBackend) Connect(ctx context.Context) error {
+	<-s.release
+	return nil
+}
+
+func (s *stuckBackend) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return nil, nil
+}
+
+func (s *stuckBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	return nil, nil
+}
+
+func (s *stuckBackend) Close() error { return nil }
+
+func (s *stuckBackend) Name() string { return "stuck" }
+
+func TestConnectTimeout(t *testing.T) {
+	b := &stuckBackend{release: make(chan struct{})}
+	defer close(b.release)
+
+	start := time.Now()
+	err := connect(context.Background(), b, 50*time.Millisecond)
+
+	if err == nil {
+		t.Fatal("connect should time out")
+	}
+	if !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Error should mention the timeout, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("connect should not wait for a client that ignores cancellation")
+	}
+}
+
+func TestConnectCanceled(t *testing.T) {
+	b := &stuckBackend{release: make(chan struct{})}
+	defer close(b.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	err := connect(ctx, b, time.Minute)
+
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("connect should give up once canceled")
+	}
+}
+
+func TestConnectBackendsPartialFailure(t *testing.T) {
+	cfg := &config.Config{
+		Backend:    "mock",
+		Prometheus: prom.Config{URL: "http://localhost:1"},
+		Queries: []backend.Query{
+			{Name: "Mock", Expr: "cpu_usage"},
+			{Name: "Prometheus", Expr: "up", Backend: "prometheus"},
+		},
+	}
+
+	backends, statuses, err := ConnectBackends(cfg)
+	if err != nil {
+		t.Fatalf("One reachable backend should be enough, got %v", err)
+	}
+
+	if len(backends) != 2 {
+		t.Errorf("Expected both backends to be kept, got %d", len(backends))
+	}
+	if len(statuses) != 2 || statuses[0].Name != "mock" || statuses[1].Name != "prometheus" {
+		t.Fatalf("Expected statuses in order of use, got %+v", statuses)
+	}
+	if statuses[0].Err != nil {
+		t.Errorf("Mock backend should connect, got %v", statuses[0].Err)
+	}
+	if statuses[1].Err == nil {
+		t.Error("Unreachable Prometheus should report an error")
+	}
+	if countFailed(statuses) != 1 {
+		t.Errorf("Expected 1 failed backend, got %d", countFailed(statuses))
+	}
+	if !strings.HasPrefix(statuses[0].String(), "mock: connected in") {
+		t.Errorf("Unexpected status line: %s", statuses[0])
+	}
+}
+
+func TestConnectBackendsAllFail(t *testing.T) {
+	cfg := &config.Config{
+		Backend:    "prometheus",
+		Prometheus: prom.Config{URL: "http://localhost:1"},
+		Queries: []backend.Query{
+			{Name: "Prometheus", Expr: "up"},
+		},
+	}
+
+	_, _, err := ConnectBackends(cfg)
+	if err == nil {
+		t.Fatal("ConnectBackends should fail when no backend is reachable")
+	}
+	if !strings.Contains(err.Error(), "failed to connect to Prometheus") {
+		t.Errorf("Expected the backend's own error, got: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/config"
+	"promviz/internal/keychain"
+)
+
+// The OS keychain, replaced in tests
+var (
+	lookupCredential = keychain.Get
+	storeCredential  = keychain.Set
+)
+
+// keychainCredential reads the credential of the named backend from its
+// keychain entry
+func keychainCredential(name, entry string) (string, error) {
+	secret, err := lookupCredential(entry)
+	if errors.Is(err, keychain.ErrNotFound) {
+		return "", fmt.Errorf("%s credential: %w, run promviz login %s to store it", name, err, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s credential: %w", name, err)
+	}
+	return secret, nil
+}
+
+// decorator is implemented by the backends wrapping another one
+type decorator interface {
+	unwrap() backend.Backend
+}
+
+// reauthenticator returns the client under the decorators of b if its
+// credentials can be replaced
+func reauthenticator(b backend.Backend) (backend.Reauthenticator, bool) {
+	for {
+		if r, ok := b.(backend.Reauthenticator); ok {
+			return r, true
+		}
+		d, ok := b.(decorator)
+		if !ok {
+			return nil, false
+		}
+		b = d.unwrap()
+	}
+}
+
+// authBackend prompts for a new credential once the backend rejects the
+// current one, e.g. because a token expired mid-incident
+type authBackend struct {
+	backend.Backend
+	backend.Reauthenticator
+	name    string
+	timeout time.Duration // connect timeout checking a new credential
+	entry   string        // keychain entry new credentials are saved to, empty if kept in the config
+	app     *App
+}
+
+// QueryTimeSeries implements backend.Backend
+func (b *authBackend) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return b.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange implements backend.Backend
+func (b *authBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	result, err := b.Backend.QueryRange(ctx, expr, tr)
+	var authErr *backend.AuthError
+	if errors.As(err, &authErr) {
+		b.app.promptCredential(b)
+	}
+	return result, err
+}
+
+// unwrap returns the decorated backend
+func (b *authBackend) unwrap() backend.Backend {
+	return b.Backend
+}
+
+// watchCredentials wraps every backend whose credentials can be replaced
+// in an authBackend
+func (a *App) watchCredentials(backends map[string]backend.Backend, cfg *config.Config) {
+	for name, b := range backends {
+		r, ok := reauthenticator(b)
+		if !ok {
+			continue
+		}
+		auth := &authBackend{Backend: b, Reauthenticator: r, name: name, timeout: cfg.ConnectTimeout(name), app: a}
+		if entry, ok := cfg.KeychainEntry(name); ok {
+			auth.entry = entry
+		}
+		backends[name] = auth
+	}
+}
+
+// promptCredential asks for a new credential of a backend once, until one
+// is accepted or the profile is switched. Dismissing the prompt leaves the
+// panels failing with the authentication error.
+func (a *App) promptCredential(b *authBackend) {
+	a.mu.Lock()
+	if a.backends[b.name] != b || a.prompted[b.name] {
+		// Replaced by a profile switch, or already asked
+		a.mu.Unlock()
+		return
+	}
+	a.prompted[b.name] = true
+	a.mu.Unlock()
+
+	a.ui.PromptCredential(b.name, b.CredentialName())
+}
+
+// setCredential applies a credential entered in the prompt to the live
+// client and refreshes all panels once the backend accepts it. Credentials
+// kept in the keychain are updated there too.
+func (a *App) setCredential(name, secret string) error {
+	if !a.track() {
+		return errors.New("shutting down")
+	}
+	defer a.wg.Done()
+
+	a.mu.RLock()
+	b, ok := a.backends[name].(*authBackend)
+	a.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%s no longer takes credentials", name)
+	}
+
+	if err := b.SetCredential(secret); err != nil {
+		return err
+	}
+	if err := connect(a.ctx, b, b.timeout); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	delete(a.prompted, name)
+	a.mu.Unlock()
+
+	a.throttle.resetAll()
+	a.goTracked(a.updateMetrics)
+
+	if b.entry != "" {
+		if err := storeCredential(b.entry, secret); err != nil {
+			return fmt.Errorf("applied, but not saved: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/influxdb"
+	"promviz/internal/config"
+	"promviz/internal/keychain"
+)
+
+// tokenBackend is a backend rejecting every token but valid
+type tokenBackend struct {
+	stuckBackend
+	mu    sync.Mutex
+	token string
+	valid string
+}
+
+func (b *tokenBackend) check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.token != b.valid {
+		return &backend.AuthError{Err: errors.New("server returned HTTP 401 Unauthorized")}
+	}
+	return nil
+}
+
+func (b *tokenBackend) Connect(ctx context.Context) error { return b.check() }
+
+func (b *tokenBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return &backend.TimeSeriesResult{}, nil
+}
+
+func (b *tokenBackend) CredentialName() string { return "token" }
+
+func (b *tokenBackend) SetCredential(secret string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token = secret
+	return nil
+}
+
+func TestReauthenticator(t *testing.T) {
+	tb := &tokenBackend{}
+	if r, ok := reauthenticator(&limitedBackend{Backend: tb}); !ok || r != tb {
+		t.Errorf("Expected the client under the decorators, got %v", r)
+	}
+	if _, ok := reauthenticator(&limitedBackend{Backend: &stuckBackend{}}); ok {
+		t.Error("Expected no reauthenticator for a backend without credentials")
+	}
+}
+
+func TestCredentialPrompt(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	tb := &tokenBackend{token: "expired", valid: "fresh"}
+	a.backends["prometheus"] = tb
+	a.watchCredentials(a.backends, a.config)
+
+	ctx, cancel := a.queryContext()
+	defer cancel()
+	a.refreshPanel(ctx, newFetches(), 0, a.config.Queries[0])
+	if !a.prompted["prometheus"] {
+		t.Fatal("Expected a prompt for a new credential after an auth error")
+	}
+
+	if err := a.setCredential("prometheus", "wrong"); err == nil {
+		t.Error("Expected a rejected credential to fail")
+	}
+	if !a.prompted["prometheus"] {
+		t.Error("A rejected credential should keep the prompt open")
+	}
+
+	if err := a.setCredential("prometheus", "fresh"); err != nil {
+		t.Fatalf("setCredential failed: %v", err)
+	}
+	if a.prompted["prometheus"] {
+		t.Error("An accepted credential should allow prompting again")
+	}
+	if err := tb.check(); err != nil {
+		t.Errorf("Expected the live client to use the new credential, got %v", err)
+	}
+
+	if err := a.setCredential("influxdb", "token"); err == nil {
+		t.Error("Expected an error for a backend without credentials")
+	}
+}
+
+// fakeKeychain replaces the OS keychain for the test
+func fakeKeychain(t *testing.T, entries map[string]string) {
+	t.Helper()
+	lookup, store := lookupCredential, storeCredential
+	t.Cleanup(func() { lookupCredential, storeCredential = lookup, store })
+
+	lookupCredential = func(entry string) (string, error) {
+		secret, ok := entries[entry]
+		if !ok {
+			return "", fmt.Errorf("keychain entry %q: %w", entry, keychain.ErrNotFound)
+		}
+		return secret, nil
+	}
+	storeCredential = func(entry, secret string) error {
+		entries[entry] = secret
+		return nil
+	}
+}
+
+func TestKeychainBackend(t *testing.T) {
+	cfg := &config.Config{
+		Backend: "influxdb",
+		InfluxDB: influxdb.Config{
+			URL:               "http://localhost:8086",
+			Org:               "test-org",
+			Bucket:            "test-bucket",
+			CredentialOptions: backend.CredentialOptions{CredentialStore: backend.CredentialKeychain, CredentialEntry: "influx-prod"},
+		},
+	}
+
+	fakeKeychain(t, map[string]string{})
+	_, err := createNamedBackend(cfg, "influxdb")
+	if err == nil || !strings.Contains(err.Error(), "run promviz login influxdb") {
+		t.Errorf("Expected a missing entry to point at promviz login, got %v", err)
+	}
+
+	// Without the token from the keychain the client would refuse to be created
+	fakeKeychain(t, map[string]string{"influx-prod": "s3cr3t"})
+	b, err := createNamedBackend(cfg, "influxdb")
+	if err != nil {
+		t.Fatalf("Expected the token to be read from the keychain, got %v", err)
+	}
+	b.Close()
+	if cfg.InfluxDB.Token != "" {
+		t.Error("The token from the keychain must not end up in the config")
+	}
+}
+
+func TestCredentialPromptSavesToKeychain(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	entries := map[string]string{"prom": "expired"}
+	fakeKeychain(t, entries)
+	a.backends["prometheus"] = &tokenBackend{token: "expired", valid: "fresh"}
+	a.watchCredentials(a.backends, a.config)
+	a.backends["prometheus"].(*authBackend).entry = "prom"
+
+	if err := a.setCredential("prometheus", "wrong"); err == nil {
+		t.Fatal("Expected a rejected credential to fail")
+	}
+	if entries["prom"] != "expired" {
+		t.Error("A rejected credential must not be saved")
+	}
+	if err := a.setCredential("prometheus", "fresh"); err != nil {
+		t.Fatalf("setCredential failed: %v", err)
+	}
+	if entries["prom"] != "fresh" {
+		t.Errorf("Expected the accepted credential in the keychain, got %q", entries["prom"])
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// describer returns the client under the decorators of b if it can look
+// up the metadata of a metric
+func describer(b backend.Backend) (backend.Describer, bool) {
+	for {
+		if d, ok := b.(backend.Describer); ok {
+			return d, true
+		}
+		dec, ok := b.(decorator)
+		if !ok {
+			return nil, false
+		}
+		b = dec.unwrap()
+	}
+}
+
+// describePanels looks up the metadata of the metrics behind graph,
+// calendar and histogram panels that leave out their unit or description,
+// so the UI can fill them in. Panels sharing a query share one lookup, and
+// failed ones leave the panel as configured.
+func (a *App) describePanels() {
+	type key struct{ backend, expr string }
+	found := make(map[key]backend.Metadata)
+	for i, q := range a.queries() {
+		if q.Expr == "" || (q.Unit != "" && q.Description != "") {
+			continue
+		}
+		t := q.PanelType()
+		if t != backend.PanelGraph && t != backend.PanelCalendar && t != backend.PanelHistogram {
+			continue
+		}
+		name := a.config.BackendFor(q)
+		k := key{name, q.Expr}
+		md, ok := found[k]
+		if !ok {
+			d, ok := describer(a.backend(name))
+			if !ok {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(a.ctx, a.config.ConnectTimeout(name))
+			md, _ = d.Describe(ctx, q.Expr)
+			cancel()
+			found[k] = md
+		}
+		if t == backend.PanelHistogram {
+			// Quantiles are in the unit of the observations, not a rate
+			md.Unit = strings.TrimSuffix(md.Unit, backend.PerSecond)
+		}
+		if md != (backend.Metadata{}) {
+			a.ui.SetMetadata(i, md)
+		}
+	}
+}
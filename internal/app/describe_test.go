@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+// describingBackend is a backend with metadata, recording the expressions
+// it was asked about
+type describingBackend struct {
+	stuckBackend
+	exprs []string
+}
+
+func (b *describingBackend) Describe(ctx context.Context, expr string) (backend.Metadata, error) {
+	b.exprs = append(b.exprs, expr)
+	return backend.Metadata{Help: "Load average", Unit: ""}, nil
+}
+
+func TestDescriber(t *testing.T) {
+	db := &describingBackend{}
+	if d, ok := describer(&limitedBackend{Backend: &tracedBackend{Backend: db}}); !ok || d != db {
+		t.Errorf("Expected the client under the decorators, got %v", d)
+	}
+	if _, ok := describer(&limitedBackend{Backend: &stuckBackend{}}); ok {
+		t.Error("Expected no describer for a backend without metadata")
+	}
+}
+
+func TestDescribePanels(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	db := &describingBackend{}
+	a.backends["prometheus"] = &limitedBackend{Backend: db}
+	a.config.Queries = append(a.config.Queries,
+		backend.Query{Name: "Load again", Expr: "node_load1"},
+		backend.Query{Name: "Described", Expr: "up", Unit: "percent", Description: "Targets up"},
+	)
+
+	a.describePanels()
+	if len(db.exprs) != 2 || db.exprs[0] != "up" || db.exprs[1] != "node_load1" {
+		t.Errorf("Expected each query described once, got %v", db.exprs)
+	}
+}
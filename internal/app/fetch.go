@@ -0,0 +1,49 @@
+package app
+
+import (
+	"sync"
+
+	"promviz/internal/backend"
+)
+
+// fetches shares range query results between the panels of one refresh
+// that run the same expression against the same backend, such as the panels
+// created by expand_by
+type fetches struct {
+	mu      sync.Mutex
+	results map[string]*fetch
+}
+
+// fetch is one shared query, run by the first panel that needs it
+type fetch struct {
+	once sync.Once
+	ts   *backend.TimeSeriesResult
+	err  error
+}
+
+func newFetches() *fetches {
+	return &fetches{results: make(map[string]*fetch)}
+}
+
+// get returns the result for key, calling run only for the first caller.
+// The result is shared and must not be modified. A panic in run fails
+// every caller rather than leaving the others with no result.
+func (f *fetches) get(key string, run func() (*backend.TimeSeriesResult, error)) (*backend.TimeSeriesResult, error) {
+	f.mu.Lock()
+	r, ok := f.results[key]
+	if !ok {
+		r = &fetch{}
+		f.results[key] = r
+	}
+	f.mu.Unlock()
+
+	r.once.Do(func() {
+		defer func() {
+			if v := recover(); v != nil {
+				r.err = panicError(v)
+			}
+		}()
+		r.ts, r.err = run()
+	})
+	return r.ts, r.err
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestFetchesShareResults(t *testing.T) {
+	f := newFetches()
+	var calls atomic.Int32
+	run := func() (*backend.TimeSeriesResult, error) {
+		calls.Add(1)
+		return &backend.TimeSeriesResult{Points: []backend.DataPoint{{Value: 1}}}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts, err := f.get("prometheus\x00up", run)
+			if err != nil || len(ts.Points) != 1 {
+				t.Errorf("Unexpected result %v, %v", ts, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected one query for the shared key, got %d", calls.Load())
+	}
+
+	f.get("prometheus\x00node_load1", run)
+	if calls.Load() != 2 {
+		t.Errorf("Expected a separate query for another key, got %d", calls.Load())
+	}
+}
+
+func TestFetchesPanic(t *testing.T) {
+	f := newFetches()
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = f.get("key", func() (*backend.TimeSeriesResult, error) {
+				panic("client bug")
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, errPanicked) {
+			t.Errorf("Caller %d: expected the panic as an error, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"promviz/internal/backend"
+)
+
+// healthReporter returns the client under the decorators of b if it can
+// report on its own health
+func healthReporter(b backend.Backend) (backend.HealthReporter, bool) {
+	for {
+		if r, ok := b.(backend.HealthReporter); ok {
+			return r, true
+		}
+		d, ok := b.(decorator)
+		if !ok {
+			return nil, false
+		}
+		b = d.unwrap()
+	}
+}
+
+// fetchHealth fetches the health report of the backend of a health panel
+func (a *App) fetchHealth(ctx context.Context, q backend.Query) (*backend.HealthReport, error) {
+	b := a.backendFor(q)
+	r, ok := healthReporter(b)
+	if !ok {
+		return nil, fmt.Errorf("%s backend does not report its health", b.Name())
+	}
+
+	var buckets []string
+	if q.Health != nil {
+		buckets = q.Health.Buckets
+	}
+	return r.Health(ctx, buckets)
+}
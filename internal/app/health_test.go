@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+// healthBackend is a backend reporting on its health, recording the
+// buckets it was asked about
+type healthBackend struct {
+	stuckBackend
+	buckets []string
+}
+
+func (b *healthBackend) Health(ctx context.Context, buckets []string) (*backend.HealthReport, error) {
+	b.buckets = buckets
+	return &backend.HealthReport{Status: backend.HealthPass}, nil
+}
+
+func TestHealthReporter(t *testing.T) {
+	hb := &healthBackend{}
+	if r, ok := healthReporter(&limitedBackend{Backend: &tracedBackend{Backend: hb}}); !ok || r != hb {
+		t.Errorf("Expected the client under the decorators, got %v", r)
+	}
+	if _, ok := healthReporter(&limitedBackend{Backend: &stuckBackend{}}); ok {
+		t.Error("Expected no health reporter for a backend without health checks")
+	}
+}
+
+func TestFetchHealth(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	q := backend.Query{Name: "Influx", Type: backend.PanelHealth, Health: &backend.HealthConfig{Buckets: []string{"metrics"}}}
+	if _, err := a.fetchHealth(context.Background(), q); err == nil || !strings.Contains(err.Error(), "does not report its health") {
+		t.Errorf("Expected an error for a backend without health checks, got %v", err)
+	}
+
+	hb := &healthBackend{}
+	a.backends["prometheus"] = &limitedBackend{Backend: hb}
+	report, err := a.fetchHealth(context.Background(), q)
+	if err != nil || report.Status != backend.HealthPass {
+		t.Fatalf("Unexpected report %+v, %v", report, err)
+	}
+	if len(hb.buckets) != 1 || hb.buckets[0] != "metrics" {
+		t.Errorf("Expected the configured buckets, got %v", hb.buckets)
+	}
+
+	q.Health = nil
+	if _, err := a.fetchHealth(context.Background(), q); err != nil || hb.buckets != nil {
+		t.Errorf("Expected no buckets without a health section, got %v, %v", hb.buckets, err)
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+
+	"promviz/internal/backend"
+)
+
+// limitedBackend cuts the results of a backend down to the configured
+// limits before they reach the panels
+type limitedBackend struct {
+	backend.Backend
+	limits backend.Limits
+}
+
+// QueryTimeSeries implements backend.Backend
+func (b *limitedBackend) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return b.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange implements backend.Backend
+func (b *limitedBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	result, err := b.Backend.QueryRange(ctx, expr, tr)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Limit(result, b.limits), nil
+}
+
+// unwrap returns the decorated backend
+func (b *limitedBackend) unwrap() backend.Backend {
+	return b.Backend
+}
+
+// limitBackends wraps every backend in a limitedBackend
+func limitBackends(backends map[string]backend.Backend, limits backend.Limits) {
+	for name, b := range backends {
+		backends[name] = &limitedBackend{Backend: b, limits: limits}
+	}
+}
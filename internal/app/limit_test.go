@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/mock"
+)
+
+func TestLimitedBackend(t *testing.T) {
+	backends := map[string]backend.Backend{"mock": mock.NewClient(&mock.Config{Seed: 1})}
+	limitBackends(backends, backend.Limits{MaxPoints: 10})
+
+	result, err := backends["mock"].QueryRange(context.Background(), "up", backend.LastTimeRange(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Points) > 10 {
+		t.Errorf("Expected at most 10 points, got %d", len(result.Points))
+	}
+	if result.Truncation == nil || result.Truncation.KeptPoints != len(result.Points) {
+		t.Errorf("Expected the truncation to be recorded, got %+v", result.Truncation)
+	}
+
+	// Results within the limits pass through untouched
+	backends = map[string]backend.Backend{"mock": mock.NewClient(&mock.Config{Seed: 1})}
+	limitBackends(backends, backend.Limits{})
+	result, err = backends["mock"].QueryRange(context.Background(), "up", backend.LastTimeRange(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Truncation != nil {
+		t.Errorf("Expected no truncation within the default limits, got %+v", result.Truncation)
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/resources"
+)
+
+// memoryCheckInterval is how often the memory in use is compared to the
+// budget
+const memoryCheckInterval = 5 * time.Second
+
+// downsamplePoints is how many points a panel keeps while memory runs
+// short, still more than a terminal has columns
+const downsamplePoints = 2000
+
+// limitResources tunes the runtime to the configured limits and those of
+// the container, if limits are configured
+func (a *App) limitResources() {
+	if a.config.Resources == nil {
+		return
+	}
+	a.budget = a.config.Resources.Resolve(resources.Detect(resources.CgroupRoot))
+	applied := resources.Apply(a.budget)
+	a.ui.SetResourceLimits(describeLimits(a.budget, applied, a.config.NumberFormat()))
+}
+
+// describeLimits renders the budget and the runtime settings made for it
+// for the diagnostics view
+func describeLimits(b resources.Budget, applied resources.Applied, f numfmt.Format) string {
+	var parts []string
+	if b.Memory > 0 {
+		parts = append(parts, f.Bytes(float64(b.Memory))+" memory")
+	} else {
+		parts = append(parts, "no memory limit")
+	}
+	if b.CPU > 0 {
+		parts = append(parts, f.Float(b.CPU, 2)+" CPU")
+	} else {
+		parts = append(parts, "no CPU limit")
+	}
+	runtime := fmt.Sprintf("GOMAXPROCS %d", applied.Procs)
+	if applied.MemoryLimit > 0 {
+		runtime += ", GOMEMLIMIT " + f.Bytes(float64(applied.MemoryLimit))
+	}
+	return strings.Join(parts, ", ") + " (" + runtime + ")"
+}
+
+// memoryLoop compares the memory in use to the budget until the
+// application stops
+func (a *App) memoryLoop() {
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.memoryTicker.C:
+			// Queue without blocking so Stop never waits on the UI event loop
+			a.goTracked(func() { a.checkMemory(resources.Usage()) })
+		}
+	}
+}
+
+// checkMemory downsamples the panels once the memory in use nears the
+// budget, and lets them keep all their points again once it has dropped
+// well below
+func (a *App) checkMemory(used uint64) {
+	share := a.budget.Share(used)
+	switch {
+	case share >= resources.DownsampleAt && a.downsampling.CompareAndSwap(false, true):
+		a.ui.Downsample(downsamplePoints)
+	case share < resources.RecoverAt:
+		a.downsampling.Store(false)
+	}
+	a.ui.SetMemoryPressure(used, a.budget.Memory, a.downsampling.Load())
+}
+
+// downsample thins out a result while memory runs short
+func (a *App) downsample(result *backend.TimeSeriesResult) *backend.TimeSeriesResult {
+	if !a.downsampling.Load() {
+		return result
+	}
+	return backend.Limit(result, backend.Limits{MaxSeries: math.MaxInt, MaxPoints: downsamplePoints})
+}
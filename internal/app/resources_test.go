@@ -0,0 +1,61 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/resources"
+	"promviz/internal/ui"
+)
+
+func TestCheckMemory(t *testing.T) {
+	a := &App{
+		ui:     ui.NewTUI([]backend.Query{{Name: "Up", Expr: "up"}}, nil),
+		budget: resources.Budget{Memory: 1000},
+	}
+
+	end := time.Now()
+	points := make([]backend.DataPoint, 5000)
+	for i := range points {
+		points[i] = backend.DataPoint{Timestamp: end.Add(time.Duration(i-len(points)) * time.Second), Value: float64(i)}
+	}
+	result := &backend.TimeSeriesResult{Points: points}
+
+	a.checkMemory(500)
+	if a.downsampling.Load() || a.downsample(result) != result {
+		t.Fatal("Results should be kept whole well within the budget")
+	}
+
+	a.checkMemory(800)
+	if !a.downsampling.Load() {
+		t.Fatal("Expected downsampling near the budget")
+	}
+	thinned := a.downsample(result)
+	if len(thinned.Points) != downsamplePoints || thinned.Points[len(thinned.Points)-1] != points[len(points)-1] {
+		t.Errorf("Expected %d points ending at the newest, got %d", downsamplePoints, len(thinned.Points))
+	}
+
+	// Downsampling continues until memory has dropped well below
+	a.checkMemory(700)
+	if !a.downsampling.Load() {
+		t.Error("Expected downsampling to continue just below the threshold")
+	}
+	a.checkMemory(500)
+	if a.downsampling.Load() {
+		t.Error("Expected downsampling to stop once memory dropped")
+	}
+}
+
+func TestDescribeLimits(t *testing.T) {
+	got := describeLimits(resources.Budget{Memory: 256 << 20, CPU: 0.5}, resources.Applied{Procs: 1, MemoryLimit: 230 << 20}, numfmt.Format{})
+	if want := "256.0 MiB memory, 0.50 CPU (GOMAXPROCS 1, GOMEMLIMIT 230.0 MiB)"; got != want {
+		t.Errorf("describeLimits() = %q, want %q", got, want)
+	}
+	got = describeLimits(resources.Budget{}, resources.Applied{Procs: 4}, numfmt.Format{})
+	if !strings.Contains(got, "no memory limit, no CPU limit") || strings.Contains(got, "GOMEMLIMIT") {
+		t.Errorf("Expected no limits described, got %q", got)
+	}
+}
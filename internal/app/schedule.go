@@ -0,0 +1,36 @@
+package app
+
+import (
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// scheduledIdle reports whether a panel is outside its active hours at now
+// and isn't refreshed, telling the UI when that changes. Panels on a fixed
+// window are never idle, their data doesn't change.
+func (a *App) scheduledIdle(idx int, q backend.Query, now time.Time) bool {
+	hours := a.config.ActiveHoursFor(q)
+	var until time.Time
+	if q.Window == nil && !hours.Active(now) {
+		until = hours.Next(now)
+	}
+
+	a.mu.Lock()
+	changed := !a.idle[idx].Equal(until)
+	if until.IsZero() {
+		delete(a.idle, idx)
+	} else {
+		a.idle[idx] = until
+	}
+	a.mu.Unlock()
+
+	if changed {
+		desc := ""
+		if hours != nil {
+			desc = hours.String()
+		}
+		a.ui.SetIdle(idx, until, desc)
+	}
+	return !until.IsZero()
+}
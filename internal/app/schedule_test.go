@@ -0,0 +1,44 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/schedule"
+)
+
+func TestScheduledIdle(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+
+	rb := &rangeBackend{ranges: make(map[string]backend.TimeRange)}
+	a.backends["prometheus"] = rb
+
+	// A window that closed a minute ago and opens again in an hour
+	now := time.Now()
+	a.config.Queries[0].ActiveHours = &schedule.Hours{From: now.Add(time.Hour).Format("15:04"), To: now.Add(-time.Minute).Format("15:04")}
+
+	a.updatePanels(false)
+	if _, ok := rb.ranges["up"]; ok {
+		t.Error("Expected the panel outside its active hours left alone")
+	}
+	if _, ok := rb.ranges["node_load1"]; !ok {
+		t.Error("Expected the panel without active hours refreshed")
+	}
+	if until := a.idle[0]; !until.After(now) {
+		t.Errorf("Expected the panel idle until its window opens, got %v", until)
+	}
+
+	// Opening the window refreshes the panel again
+	a.config.Queries[0].ActiveHours = nil
+	a.updatePanels(false)
+	if _, ok := rb.ranges["up"]; !ok {
+		t.Error("Expected the panel refreshed within its active hours")
+	}
+	if _, ok := a.idle[0]; ok {
+		t.Error("Expected the panel no longer idle")
+	}
+}
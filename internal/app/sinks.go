@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+	"promviz/internal/sink"
+)
+
+// sinkFlushInterval is how often buffered points are written to the sinks
+const sinkFlushInterval = 10 * time.Second
+
+// sinkBackend hands every result of a backend to the sinks, before result
+// limits apply and whether or not a panel shows it
+type sinkBackend struct {
+	backend.Backend
+	name   string
+	writer *sink.Writer
+}
+
+// QueryTimeSeries implements backend.Backend
+func (b *sinkBackend) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return b.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange implements backend.Backend
+func (b *sinkBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	result, err := b.Backend.QueryRange(ctx, expr, tr)
+	if err != nil {
+		return nil, err
+	}
+	b.writer.Record(b.name, expr, result)
+	return result, nil
+}
+
+// unwrap returns the decorated backend
+func (b *sinkBackend) unwrap() backend.Backend {
+	return b.Backend
+}
+
+// sinkBackends wraps every backend in a sinkBackend, unless there are no
+// sinks
+func sinkBackends(backends map[string]backend.Backend, writer *sink.Writer) {
+	if writer == nil {
+		return
+	}
+	for name, b := range backends {
+		backends[name] = &sinkBackend{Backend: b, name: name, writer: writer}
+	}
+}
+
+// recordAlertLevel hands the alert level of a panel with thresholds to the
+// sinks as a derived series, whether or not its tags are muted
+func (a *App) recordAlertLevel(q backend.Query, latest backend.DataPoint) {
+	if q.Thresholds == nil {
+		return
+	}
+	a.sinks.RecordDerived(q.ID, sink.AlertMetric, &backend.TimeSeriesResult{
+		Points: []backend.DataPoint{{
+			Timestamp: latest.Timestamp,
+			Value:     float64(alert.Evaluate(q.Thresholds, latest.Value)),
+			Series:    backend.SeriesName("", map[string]string{sink.PanelLabel: q.ID}),
+		}},
+	})
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/mock"
+	"promviz/internal/sink"
+)
+
+func TestRecordAlertLevel(t *testing.T) {
+	dir := t.TempDir()
+	// The CSV sink stands in for remote_write, which validation requires
+	writer, err := sink.New([]sink.Config{{Type: sink.TypeCSV, Dir: dir, Series: sink.SeriesDerived}})
+	if err != nil {
+		t.Fatalf("sink.New failed: %v", err)
+	}
+	a := &App{sinks: writer}
+
+	warn, crit := 80.0, 90.0
+	q := backend.Query{ID: "cpu", Thresholds: &backend.Thresholds{Warn: &warn, Crit: &crit}}
+	now := time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	a.recordAlertLevel(q, backend.DataPoint{Timestamp: now, Value: 85})
+	a.recordAlertLevel(backend.Query{ID: "plain"}, backend.DataPoint{Timestamp: now, Value: 85})
+	if err := writer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "promviz-2024-05-02.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `2024-05-02T13:00:00Z,,cpu,"promviz_alert_level{panel=""cpu""}",1` + "\n"
+	if !strings.HasSuffix(string(data), expected) || strings.Count(string(data), "\n") != 2 {
+		t.Errorf("Expected the warning level of the panel with thresholds only, got:\n%s", data)
+	}
+}
+
+func TestSinkBackend(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := sink.New([]sink.Config{{Type: sink.TypeCSV, Dir: dir}})
+	if err != nil {
+		t.Fatalf("sink.New failed: %v", err)
+	}
+	backends := map[string]backend.Backend{"mock": mock.NewClient(&mock.Config{Seed: 1})}
+	sinkBackends(backends, writer)
+	limitBackends(backends, backend.Limits{MaxPoints: 10})
+
+	// The sinks get the whole result, not just what fits the limits
+	result, err := backends["mock"].QueryRange(context.Background(), "cpu_usage", backend.LastTimeRange(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "promviz-*.csv"))
+	var rows int
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows += strings.Count(string(data), "\n") - 1
+		if !strings.Contains(string(data), ",mock,cpu_usage,") {
+			t.Errorf("Expected the backend and expression in the rows, got:\n%s", data)
+		}
+	}
+	if rows <= len(result.Points) {
+		t.Errorf("Expected more rows than the %d points kept for the panel, got %d", len(result.Points), rows)
+	}
+
+	// Without sinks backends are left as they are
+	plain := map[string]backend.Backend{"mock": mock.NewClient(&mock.Config{Seed: 1})}
+	sinkBackends(plain, nil)
+	if _, ok := plain["mock"].(*sinkBackend); ok {
+		t.Error("Backends should not be wrapped without sinks")
+	}
+}
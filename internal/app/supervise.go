@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// hangGrace is how long a panel refresh may run past the end of its query
+// context before the panel is given up on, e.g. when a backend client
+// ignores cancellation
+const hangGrace = time.Second
+
+var (
+	// errPanicked marks the error of a refresh that panicked
+	errPanicked = errors.New("panel crashed")
+
+	// errHung is shown on a panel whose refresh didn't return in time
+	errHung = errors.New("backend client not responding; retrying once it returns")
+
+	// errBusy is returned for a panel whose previous refresh is still running
+	errBusy = errors.New("previous refresh still running")
+)
+
+// panicError converts the value of a recovered panic into an error
+func panicError(v interface{}) error {
+	return fmt.Errorf("%w: %v", errPanicked, v)
+}
+
+// supervisor runs panel refreshes so a panic or a hung backend client only
+// fails its own panel rather than the whole process
+type supervisor struct {
+	mu   sync.Mutex
+	busy map[int]bool // panels whose last refresh hasn't returned yet
+}
+
+func newSupervisor() *supervisor {
+	return &supervisor{busy: make(map[int]bool)}
+}
+
+// run refreshes panel idx with f and returns its error, or the panic f
+// raised as an error. If f is still running hangGrace after ctx is done,
+// run returns errHung and leaves it behind; until it returns, further runs
+// of the panel return errBusy instead of piling up.
+func (s *supervisor) run(ctx context.Context, idx int, f func() error) error {
+	s.mu.Lock()
+	if s.busy[idx] {
+		s.mu.Unlock()
+		return errBusy
+	}
+	s.busy[idx] = true
+	s.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			if v := recover(); v != nil {
+				err = panicError(v)
+			}
+			s.mu.Lock()
+			delete(s.busy, idx)
+			s.mu.Unlock()
+			done <- err
+		}()
+		err = f()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(hangGrace):
+		return errHung
+	}
+}
+
+// refreshPanel refreshes one panel under the supervisor and records the
+// outcome. A panel that crashed or hung shows the error; its next refresh
+// starts over.
+func (a *App) refreshPanel(ctx context.Context, shared *fetches, idx int, q backend.Query) {
+	err := a.supervisor.run(ctx, idx, func() error {
+		return a.updatePanel(ctx, shared, idx, q)
+	})
+	if err == errBusy || a.abandoned(ctx) {
+		return
+	}
+	if errors.Is(err, errPanicked) || err == errHung {
+		switch q.PanelType() {
+		case backend.PanelSLO:
+			a.ui.UpdateSLO(idx, nil, nil, err)
+		case backend.PanelHealth:
+			a.ui.UpdateHealth(idx, nil, err)
+		default:
+			a.ui.UpdateTimeSeries(idx, nil, err)
+		}
+	}
+	a.recordResult(idx, err)
+}
+
+// abandoned reports whether the refresh running under ctx no longer
+// matters, because the application is stopping or the backends it queried
+// were replaced
+func (a *App) abandoned(ctx context.Context) bool {
+	return a.ctx.Err() != nil || errors.Is(ctx.Err(), context.Canceled)
+}
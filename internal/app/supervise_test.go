@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestSupervisorPanic(t *testing.T) {
+	s := newSupervisor()
+	err := s.run(context.Background(), 0, func() error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+	if !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "assignment to entry in nil map") {
+		t.Errorf("Expected the panic as an error, got %v", err)
+	}
+
+	// The panel restarts normally on its next refresh
+	if err := s.run(context.Background(), 0, func() error { return nil }); err != nil {
+		t.Errorf("Expected the next run to succeed, got %v", err)
+	}
+}
+
+func TestSupervisorHung(t *testing.T) {
+	s := newSupervisor()
+	release := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := s.run(ctx, 0, func() error {
+		<-release
+		return nil
+	})
+	if err != errHung {
+		t.Fatalf("Expected errHung, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > hangGrace+time.Second {
+		t.Errorf("run should give up on a hung refresh, took %s", elapsed)
+	}
+
+	// Other panels keep refreshing, the hung one is skipped until it returns
+	if err := s.run(context.Background(), 1, func() error { return nil }); err != nil {
+		t.Errorf("Expected another panel to refresh, got %v", err)
+	}
+	if err := s.run(context.Background(), 0, func() error { return nil }); err != errBusy {
+		t.Errorf("Expected errBusy while the hung refresh runs, got %v", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for s.run(context.Background(), 0, func() error { return nil }) == errBusy {
+		if time.Now().After(deadline) {
+			t.Fatal("Panel should restart once the hung refresh returns")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// panicBackend is a backend whose client crashes on every query
+type panicBackend struct {
+	stuckBackend
+}
+
+func (panicBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	panic("client bug")
+}
+
+func TestRefreshPanelPanic(t *testing.T) {
+	server := hangingPrometheus(make(chan struct{}), make(chan struct{}))
+	defer server.Close()
+	a := newHangingApp(t, server.URL)
+	defer a.Stop()
+	a.backends["prometheus"] = &panicBackend{}
+
+	ctx, cancel := a.queryContext()
+	defer cancel()
+	a.refreshPanel(ctx, newFetches(), 0, a.config.Queries[0])
+
+	if f := a.throttle.panels[0]; f == nil || f.count != 1 {
+		t.Errorf("Expected the crash recorded as a failure of the panel, got %+v", f)
+	}
+}
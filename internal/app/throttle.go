@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// throttleAfter is the number of consecutive failures after which a panel's
+// refresh backs off
+const throttleAfter = 3
+
+// maxBackoff caps the wait between attempts of a throttled panel
+const maxBackoff = 5 * time.Minute
+
+// throttle backs off the refresh of panels whose queries keep failing, so a
+// broken query or an unreachable backend isn't hit on every tick
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration // normal refresh interval
+	panels   map[int]*failures
+}
+
+// failures tracks the consecutive failures of one panel
+type failures struct {
+	count int
+	next  time.Time // no attempt before this time once throttled
+}
+
+func newThrottle(interval time.Duration) *throttle {
+	return &throttle{interval: interval, panels: make(map[int]*failures)}
+}
+
+// setInterval changes the normal refresh interval, which the first wait of
+// a throttled panel builds on
+func (t *throttle) setInterval(interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interval = interval
+}
+
+// due reports whether the panel should be refreshed at now
+func (t *throttle) due(index int, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, ok := t.panels[index]
+	return !ok || !now.Before(f.next)
+}
+
+// record notes the outcome of a refresh. Once the panel has failed
+// throttleAfter times in a row it returns the number of failures and the
+// time of the next attempt, doubling the wait with every further failure.
+// A rate limited panel is throttled right away, for at least as long as
+// the backend asked.
+func (t *throttle) record(index int, err error, now time.Time) (count int, next time.Time, throttled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err == nil {
+		delete(t.panels, index)
+		return 0, time.Time{}, false
+	}
+
+	f, ok := t.panels[index]
+	if !ok {
+		f = &failures{}
+		t.panels[index] = f
+	}
+	f.count++
+	var rateLimited *backend.RateLimitedError
+	limited := errors.As(err, &rateLimited)
+	if f.count < throttleAfter && !limited {
+		return f.count, time.Time{}, false
+	}
+
+	backoff := t.interval
+	for i := throttleAfter; i <= f.count && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	if limited && rateLimited.RetryAfter > backoff {
+		backoff = rateLimited.RetryAfter
+	}
+	f.next = now.Add(backoff)
+	return f.count, f.next, true
+}
+
+// reset returns the panel to the normal refresh interval
+func (t *throttle) reset(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.panels, index)
+}
+
+// resetAll returns every panel to the normal refresh interval, e.g. after
+// switching to other backends
+func (t *throttle) resetAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.panels = make(map[int]*failures)
+}
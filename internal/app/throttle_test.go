@@ -0,0 +1,116 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestThrottleBacksOff(t *testing.T) {
+	th := newThrottle(5 * time.Second)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("connection refused")
+
+	// The first failures keep the normal cadence
+	for i := 1; i < throttleAfter; i++ {
+		if _, _, throttled := th.record(0, failure, now); throttled {
+			t.Fatalf("Panel throttled after %d failures", i)
+		}
+		if !th.due(0, now) {
+			t.Fatalf("Panel should stay due after %d failures", i)
+		}
+	}
+
+	count, next, throttled := th.record(0, failure, now)
+	if !throttled || count != throttleAfter {
+		t.Fatalf("Expected throttling after %d failures, got %d, %v", throttleAfter, count, throttled)
+	}
+	if want := now.Add(10 * time.Second); !next.Equal(want) {
+		t.Errorf("Expected next attempt at %v, got %v", want, next)
+	}
+	if th.due(0, now.Add(9*time.Second)) {
+		t.Error("Throttled panel should not be due before its next attempt")
+	}
+	if !th.due(0, next) {
+		t.Error("Throttled panel should be due at its next attempt")
+	}
+	if !th.due(1, now) {
+		t.Error("Other panels should not be throttled")
+	}
+
+	// Every further failure doubles the wait, up to maxBackoff
+	_, next, _ = th.record(0, failure, now)
+	if want := now.Add(20 * time.Second); !next.Equal(want) {
+		t.Errorf("Expected the wait to double to %v, got %v", want, next)
+	}
+	for i := 0; i < 20; i++ {
+		_, next, _ = th.record(0, failure, now)
+	}
+	if want := now.Add(maxBackoff); !next.Equal(want) {
+		t.Errorf("Expected the wait to be capped at %v, got %v", want, next)
+	}
+
+	// A success restores the normal cadence
+	if _, _, throttled := th.record(0, nil, now); throttled {
+		t.Error("Success should not throttle")
+	}
+	if !th.due(0, now) {
+		t.Error("Panel should be due again after a success")
+	}
+}
+
+func TestThrottleRateLimited(t *testing.T) {
+	th := newThrottle(5 * time.Second)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	limited := fmt.Errorf("query failed: %w", &backend.RateLimitedError{Err: errors.New("429"), RetryAfter: time.Minute})
+
+	count, next, throttled := th.record(0, limited, now)
+	if !throttled || count != 1 {
+		t.Fatalf("Expected a rate limited panel to be throttled right away, got %d, %v", count, throttled)
+	}
+	if want := now.Add(time.Minute); !next.Equal(want) {
+		t.Errorf("Expected the next attempt after Retry-After at %v, got %v", want, next)
+	}
+
+	// Without Retry-After the panel waits the normal interval
+	_, next, _ = th.record(1, &backend.RateLimitedError{Err: errors.New("429")}, now)
+	if want := now.Add(5 * time.Second); !next.Equal(want) {
+		t.Errorf("Expected the next attempt at %v, got %v", want, next)
+	}
+}
+
+func TestThrottleReset(t *testing.T) {
+	th := newThrottle(5 * time.Second)
+	now := time.Now()
+	for i := 0; i < throttleAfter; i++ {
+		th.record(2, errors.New("timeout"), now)
+	}
+	if th.due(2, now) {
+		t.Fatal("Panel should be throttled")
+	}
+
+	th.reset(2)
+	if !th.due(2, now) {
+		t.Error("Reset should make the panel due right away")
+	}
+	if _, _, throttled := th.record(2, errors.New("timeout"), now); throttled {
+		t.Error("Reset should also clear the failure count")
+	}
+}
+
+func TestThrottleSetInterval(t *testing.T) {
+	th := newThrottle(5 * time.Second)
+	th.setInterval(time.Minute)
+
+	now := time.Now()
+	var next time.Time
+	for i := 0; i < throttleAfter; i++ {
+		_, next, _ = th.record(1, errors.New("timeout"), now)
+	}
+	if want := now.Add(2 * time.Minute); !next.Equal(want) {
+		t.Errorf("Expected the backoff to build on the new interval, got next attempt at %v, want %v", next, want)
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/tracing"
+)
+
+// traceFlushInterval is how often finished spans are exported
+const traceFlushInterval = 10 * time.Second
+
+// tracedBackend runs each query of a backend in a client span, whose trace
+// context the HTTP backends pass on in a traceparent header
+type tracedBackend struct {
+	backend.Backend
+	name   string
+	tracer *tracing.Tracer
+}
+
+// QueryTimeSeries implements backend.Backend
+func (b *tracedBackend) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return b.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange implements backend.Backend
+func (b *tracedBackend) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	ctx, span := b.tracer.StartClient(ctx, "query "+b.name, map[string]string{
+		"promviz.backend": b.name,
+		"db.system":       b.Backend.Name(),
+		"db.statement":    expr,
+	})
+	result, err := b.Backend.QueryRange(ctx, expr, tr)
+	span.End(err)
+	return result, err
+}
+
+// unwrap returns the decorated backend
+func (b *tracedBackend) unwrap() backend.Backend {
+	return b.Backend
+}
+
+// traceBackends wraps every backend in a tracedBackend
+func traceBackends(backends map[string]backend.Backend, tracer *tracing.Tracer) {
+	for name, b := range backends {
+		backends[name] = &tracedBackend{Backend: b, name: name, tracer: tracer}
+	}
+}
+
+// startPanelSpan begins the span of one panel refresh, the parent of the
+// spans of its queries
+func (a *App) startPanelSpan(ctx context.Context, q backend.Query) (context.Context, *tracing.Span) {
+	return a.tracer.Start(ctx, "refresh "+q.ID, map[string]string{
+		"promviz.panel":    q.Name,
+		"promviz.query_id": q.ID,
+	})
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/mock"
+	"promviz/internal/tracing"
+)
+
+// spanRecorder is a backend that remembers the span context of its last query
+type spanRecorder struct {
+	backend.Backend
+	sc tracing.SpanContext
+	ok bool
+}
+
+func (r *spanRecorder) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	r.sc, r.ok = tracing.FromContext(ctx)
+	return r.Backend.QueryRange(ctx, expr, tr)
+}
+
+func TestTracedBackend(t *testing.T) {
+	recorder := &spanRecorder{Backend: mock.NewClient(&mock.Config{})}
+	backends := map[string]backend.Backend{"mock": recorder}
+	tracer := tracing.New(tracing.Config{}, nil)
+	traceBackends(backends, tracer)
+
+	a := &App{tracer: tracer}
+	ctx, _ := a.startPanelSpan(context.Background(), backend.Query{Name: "CPU", ID: "cpu"})
+	panel, _ := tracing.FromContext(ctx)
+
+	if _, err := backends["mock"].QueryRange(ctx, "up", backend.DefaultTimeRange()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !recorder.ok {
+		t.Fatal("Query should run in a span")
+	}
+	if recorder.sc.TraceID != panel.TraceID || recorder.sc.SpanID == panel.SpanID {
+		t.Errorf("Query should run in a child span of the panel refresh, got %+v", recorder.sc)
+	}
+}
+
+func TestStartPanelSpanUntraced(t *testing.T) {
+	a := &App{}
+	ctx, span := a.startPanelSpan(context.Background(), backend.Query{Name: "CPU", ID: "cpu"})
+	if span != nil {
+		t.Error("No span should start without a tracer")
+	}
+	if _, ok := tracing.FromContext(ctx); ok {
+		t.Error("Context should carry no span without a tracer")
+	}
+}
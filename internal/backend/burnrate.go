@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"math"
+	"strings"
+)
+
+// BurnRateLabel is the label burn rate series carry their window under
+const BurnRateLabel = "window"
+
+// WindowPlaceholder stands for the window in the error ratio of a burn rate
+// panel
+const WindowPlaceholder = "$window"
+
+// BurnRateAlert fires when the burn rate over both its long and its short
+// window reaches Factor. The short window makes it stop soon after the
+// errors do.
+type BurnRateAlert struct {
+	Name        string
+	Long, Short string
+	Factor      float64
+}
+
+// BurnRateAlerts are the multi-window, multi-burn-rate alerts for a 30-day
+// error budget: 2% of it spent within an hour, or 5% within six hours
+var BurnRateAlerts = []BurnRateAlert{
+	{Name: "Fast burn", Long: "1h", Short: "5m", Factor: 14.4},
+	{Name: "Slow burn", Long: "6h", Short: "30m", Factor: 6},
+}
+
+// BurnRateWindows returns the windows of BurnRateAlerts, long before short
+func BurnRateWindows() []string {
+	var windows []string
+	for _, a := range BurnRateAlerts {
+		windows = append(windows, a.Long, a.Short)
+	}
+	return windows
+}
+
+// Expr returns the error ratio over window
+func (c *BurnRateConfig) Expr(window string) string {
+	return strings.ReplaceAll(c.ErrorRatio, WindowPlaceholder, window)
+}
+
+// BurnRates turns the error ratio over window into burn rates, the ratio
+// relative to the one the objective allows, labeled with BurnRateLabel.
+// NaN ratios, such as from windows without any events, are dropped.
+func (c *BurnRateConfig) BurnRates(window string, ratio *TimeSeriesResult) []DataPoint {
+	allowed := 1 - c.Objective/100
+	var points []DataPoint
+	for _, p := range ratio.Points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		p.Value /= allowed
+		points = append(points, p)
+	}
+	return WithLabel(points, BurnRateLabel, window)
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBurnRateExpr(t *testing.T) {
+	c := &BurnRateConfig{ErrorRatio: `sum(rate(errors_total[$window])) / sum(rate(requests_total[$window]))`}
+	want := `sum(rate(errors_total[5m])) / sum(rate(requests_total[5m]))`
+	if got := c.Expr("5m"); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	windows := BurnRateWindows()
+	if len(windows) != 4 || windows[0] != "1h" || windows[1] != "5m" || windows[2] != "6h" || windows[3] != "30m" {
+		t.Errorf("Expected the windows of both alerts, got %v", windows)
+	}
+}
+
+func TestBurnRates(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &BurnRateConfig{Objective: 99.9}
+	ratio := &TimeSeriesResult{Points: []DataPoint{
+		{Timestamp: at, Value: 0.0144, Series: "{}"},
+		{Timestamp: at.Add(time.Minute), Value: math.NaN(), Series: "{}"},
+		{Timestamp: at, Value: 0.001, Series: `{job="api"}`},
+	}}
+
+	points := c.BurnRates("1h", ratio)
+	if len(points) != 2 {
+		t.Fatalf("Expected NaN ratios dropped, got %v", points)
+	}
+	if points[0].Series != `{window="1h"}` || math.Abs(points[0].Value-14.4) > 1e-9 {
+		t.Errorf("Expected a 14.4x burn rate labeled with its window, got %+v", points[0])
+	}
+	if points[1].Series != `{job="api",window="1h"}` || math.Abs(points[1].Value-1) > 1e-9 {
+		t.Errorf("Expected the other labels kept, got %+v", points[1])
+	}
+}
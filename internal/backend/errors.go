@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuthError reports credentials the backend rejected
+type AuthError struct{ Err error }
+
+func (e *AuthError) Error() string { return e.Err.Error() }
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// NotFoundError reports a missing endpoint, database or bucket
+type NotFoundError struct{ Err error }
+
+func (e *NotFoundError) Error() string { return e.Err.Error() }
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// TimeoutError reports a query the backend gave up on. Queries the client
+// gave up on wrap context.DeadlineExceeded instead.
+type TimeoutError struct{ Err error }
+
+func (e *TimeoutError) Error() string { return e.Err.Error() }
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// RateLimitedError reports a backend refusing queries for a while
+type RateLimitedError struct {
+	Err        error
+	RetryAfter time.Duration // zero if the backend didn't say
+}
+
+func (e *RateLimitedError) Error() string { return e.Err.Error() }
+func (e *RateLimitedError) Unwrap() error { return e.Err }
+
+// SyntaxError reports a query the backend could not parse
+type SyntaxError struct{ Err error }
+
+func (e *SyntaxError) Error() string { return e.Err.Error() }
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// StatusError returns err as the typed error matching an HTTP status code,
+// or err itself for statuses without one
+func StatusError(status int, retryAfter time.Duration, err error) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{Err: err}
+	case http.StatusNotFound:
+		return &NotFoundError{Err: err}
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return &TimeoutError{Err: err}
+	case http.StatusTooManyRequests:
+		return &RateLimitedError{Err: err, RetryAfter: retryAfter}
+	}
+	return err
+}
+
+// StatusTransport turns responses with a status that has a typed error,
+// such as 401 or 429, into that error, so clients that only report the
+// status in their message still let callers tell errors apart
+type StatusTransport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *StatusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	statusErr := fmt.Errorf("server returned HTTP %s", resp.Status)
+	typed := StatusError(resp.StatusCode, retryAfter(resp.Header.Get("Retry-After")), statusErr)
+	if typed == statusErr {
+		return resp, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // lets the connection be reused
+	resp.Body.Close()
+	return nil, typed
+}
+
+// retryAfter parses the seconds form of a Retry-After header, zero if it
+// is missing or a date
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
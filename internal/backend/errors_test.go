@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusError(t *testing.T) {
+	base := errors.New("failed")
+	tests := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{401, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{403, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{404, func(err error) bool { var e *NotFoundError; return errors.As(err, &e) }},
+		{504, func(err error) bool { var e *TimeoutError; return errors.As(err, &e) }},
+		{429, func(err error) bool {
+			var e *RateLimitedError
+			return errors.As(err, &e) && e.RetryAfter == time.Minute
+		}},
+		{500, func(err error) bool { return err == base }},
+	}
+	for _, tt := range tests {
+		err := StatusError(tt.status, time.Minute, base)
+		if !tt.check(err) {
+			t.Errorf("Status %d: unexpected error %T", tt.status, err)
+		}
+		if !errors.Is(err, base) {
+			t.Errorf("Status %d: expected the error to wrap the original", tt.status)
+		}
+	}
+}
+
+func TestStatusTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/busy":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/bad":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &StatusTransport{Base: http.DefaultTransport}}
+
+	_, err := client.Get(server.URL + "/auth")
+	var auth *AuthError
+	if !errors.As(err, &auth) {
+		t.Errorf("Expected an AuthError for 401, got %v", err)
+	}
+
+	_, err = client.Get(server.URL + "/busy")
+	var rateLimited *RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 30*time.Second {
+		t.Errorf("Expected a RateLimitedError waiting 30s for 429, got %v", err)
+	}
+
+	// Other responses are passed on for the client to read
+	for _, path := range []string{"/", "/bad"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+// Health statuses of a HealthReport
+const (
+	HealthPass = "pass"
+	HealthFail = "fail"
+)
+
+// BucketCardinality is the number of series stored in a bucket
+type BucketCardinality struct {
+	Bucket string `json:"bucket"`
+	Series int64  `json:"series"`
+	Err    string `json:"error,omitempty"` // why the count is missing
+}
+
+// FailingTask is a task whose latest run failed
+type FailingTask struct {
+	Name    string    `json:"name"`
+	Error   string    `json:"error,omitempty"`
+	LastRun time.Time `json:"last_run,omitempty"` // zero if unknown
+}
+
+// HealthReport is what a backend reports about itself
+type HealthReport struct {
+	Status       string              `json:"status"` // HealthPass or HealthFail
+	Version      string              `json:"version,omitempty"`
+	Message      string              `json:"message,omitempty"`
+	Buckets      []BucketCardinality `json:"buckets,omitempty"`
+	FailingTasks []FailingTask       `json:"failing_tasks,omitempty"`
+	TasksErr     string              `json:"tasks_error,omitempty"` // why tasks could not be listed
+}
+
+// HealthReporter is implemented by backends that can report on their own
+// health, for health panels
+type HealthReporter interface {
+	// Health checks the server and counts the series of the buckets, the
+	// configured one if none are given
+	Health(ctx context.Context, buckets []string) (*HealthReport, error)
+}
@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// BucketLabel is the label holding the upper bound of a classic histogram
+// bucket series
+const BucketLabel = "le"
+
+// QuantileLabel is the label telling apart the series HistogramQuantiles
+// computes, e.g. quantile="p99"
+const QuantileLabel = "quantile"
+
+// Bucket is one bucket of a histogram sample
+type Bucket struct {
+	Lower          float64 `json:"lower"`
+	Upper          float64 `json:"upper"`
+	LowerInclusive bool    `json:"lower_inclusive,omitempty"`
+	UpperInclusive bool    `json:"upper_inclusive,omitempty"`
+	Count          float64 `json:"count"` // observations in this bucket alone, not cumulative
+}
+
+// HistogramPoint is a histogram sample of one series, such as a Prometheus
+// native histogram
+type HistogramPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Series    string    `json:"series,omitempty"`
+	Count     float64   `json:"count"`
+	Sum       float64   `json:"sum"` // NaN when unknown, e.g. for classic histograms
+	Buckets   []Bucket  `json:"buckets"`
+}
+
+// ClassicHistograms assembles the cumulative bucket series of classic
+// histograms, told apart by their le label, into one histogram sample per
+// series and timestamp. Buckets are sorted by upper bound and hold the
+// count of their own range. Points of other series are returned in rest,
+// in their original order.
+func ClassicHistograms(points []DataPoint) (histograms []HistogramPoint, rest []DataPoint) {
+	type key struct {
+		series string
+		at     int64
+	}
+	type bound struct {
+		le    float64
+		count float64
+	}
+	bounds := make(map[key][]bound)
+	var order []key
+	for _, p := range points {
+		labels := ParseSeriesName(p.Series)
+		raw, ok := labels[BucketLabel]
+		le, err := strconv.ParseFloat(raw, 64)
+		if !ok || err != nil {
+			rest = append(rest, p)
+			continue
+		}
+
+		name := labels[NameLabel]
+		delete(labels, NameLabel)
+		delete(labels, BucketLabel)
+		k := key{SeriesName(name, labels), p.Timestamp.UnixNano()}
+		if _, ok := bounds[k]; !ok {
+			order = append(order, k)
+		}
+		bounds[k] = append(bounds[k], bound{le, p.Value})
+	}
+
+	for _, k := range order {
+		b := bounds[k]
+		sort.Slice(b, func(i, j int) bool { return b[i].le < b[j].le })
+
+		h := HistogramPoint{Timestamp: time.Unix(0, k.at), Series: k.series, Sum: math.NaN()}
+		lower, below := math.Inf(-1), 0.0
+		for _, upper := range b {
+			h.Buckets = append(h.Buckets, Bucket{Lower: lower, Upper: upper.le, UpperInclusive: true, Count: upper.count - below})
+			lower, below = upper.le, upper.count
+		}
+		h.Count = below
+		histograms = append(histograms, h)
+	}
+	return histograms, rest
+}
+
+// MatchHistograms returns the histogram samples of the series carrying all
+// the labels
+func MatchHistograms(histograms []HistogramPoint, match map[string]string) []HistogramPoint {
+	if len(match) == 0 {
+		return histograms
+	}
+
+	matched := make(map[string]bool)
+	var result []HistogramPoint
+	for _, h := range histograms {
+		ok, seen := matched[h.Series]
+		if !seen {
+			ok = hasLabels(h.Series, match)
+			matched[h.Series] = ok
+		}
+		if ok {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// Quantile estimates the q-quantile, between 0 and 1, of the observations
+// in h like Prometheus' histogram_quantile does: by linear interpolation
+// within the bucket the quantile falls into. A quantile in the lowest
+// bucket of a classic histogram is interpolated from zero if the bucket's
+// bound is positive, and one in a bucket without upper bound is its lower
+// bound. Buckets must be sorted. It returns NaN without observations.
+func (h HistogramPoint) Quantile(q float64) float64 {
+	total := 0.0
+	for _, b := range h.Buckets {
+		total += b.Count
+	}
+	if total <= 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+
+	rank := q * total
+	below := 0.0
+	for i, b := range h.Buckets {
+		if b.Count <= 0 || (below+b.Count < rank && i < len(h.Buckets)-1) {
+			below += b.Count
+			continue
+		}
+		lower, upper := b.Lower, b.Upper
+		if math.IsInf(upper, 1) {
+			return lower
+		}
+		if math.IsInf(lower, -1) {
+			if upper <= 0 {
+				return upper
+			}
+			lower = 0
+		}
+		fraction := (rank - below) / b.Count
+		if fraction < 0 {
+			fraction = 0
+		} else if fraction > 1 {
+			fraction = 1
+		}
+		return lower + (upper-lower)*fraction
+	}
+	return math.NaN()
+}
+
+// HistogramQuantiles computes the quantiles at levels, in percent, of the
+// native histograms in result and of the bucket series of classic
+// histograms among its points. Each histogram series becomes one series
+// per level, labeled with QuantileLabel; other points are dropped, as are
+// samples without observations.
+func HistogramQuantiles(result *TimeSeriesResult, levels []float64) *TimeSeriesResult {
+	classic, _ := ClassicHistograms(result.Points)
+	histograms := append(append([]HistogramPoint(nil), result.Histograms...), classic...)
+
+	// Series names are worked out once per series and level
+	names := make(map[string][]string)
+	quantiles := &TimeSeriesResult{Truncation: result.Truncation, Warnings: result.Warnings}
+	for _, h := range histograms {
+		series, ok := names[h.Series]
+		if !ok {
+			for _, level := range levels {
+				labels := ParseSeriesName(h.Series)
+				name := labels[NameLabel]
+				delete(labels, NameLabel)
+				labels[QuantileLabel] = "p" + strconv.FormatFloat(level, 'g', -1, 64)
+				series = append(series, SeriesName(name, labels))
+			}
+			names[h.Series] = series
+		}
+		for i, level := range levels {
+			v := h.Quantile(level / 100)
+			if math.IsNaN(v) {
+				continue
+			}
+			quantiles.Points = append(quantiles.Points, DataPoint{Timestamp: h.Timestamp, Value: v, Series: series[i]})
+		}
+	}
+	return quantiles
+}
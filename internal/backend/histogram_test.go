@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClassicHistograms(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []DataPoint{
+		{Timestamp: at, Value: 10, Series: `latency_bucket{job="api",le="+Inf"}`},
+		{Timestamp: at, Value: 6, Series: `latency_bucket{job="api",le="0.5"}`},
+		{Timestamp: at, Value: 1, Series: `up{job="api"}`},
+		{Timestamp: at, Value: 9, Series: `latency_bucket{job="api",le="1"}`},
+	}
+
+	histograms, rest := ClassicHistograms(points)
+	if len(rest) != 1 || rest[0].Series != `up{job="api"}` {
+		t.Errorf("Expected the other series in rest, got %v", rest)
+	}
+	if len(histograms) != 1 {
+		t.Fatalf("Expected 1 histogram sample, got %d", len(histograms))
+	}
+
+	h := histograms[0]
+	if h.Series != `latency_bucket{job="api"}` || !h.Timestamp.Equal(at) || h.Count != 10 || !math.IsNaN(h.Sum) {
+		t.Errorf("Unexpected histogram sample %+v", h)
+	}
+	want := []Bucket{
+		{Lower: math.Inf(-1), Upper: 0.5, UpperInclusive: true, Count: 6},
+		{Lower: 0.5, Upper: 1, UpperInclusive: true, Count: 3},
+		{Lower: 1, Upper: math.Inf(1), UpperInclusive: true, Count: 1},
+	}
+	if len(h.Buckets) != len(want) {
+		t.Fatalf("Expected %d buckets, got %v", len(want), h.Buckets)
+	}
+	for i, b := range h.Buckets {
+		if b != want[i] {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want[i], b)
+		}
+	}
+}
+
+func TestMatchHistograms(t *testing.T) {
+	histograms := []HistogramPoint{
+		{Series: `latency{job="api"}`, Count: 1},
+		{Series: `latency{job="web"}`, Count: 2},
+	}
+	got := MatchHistograms(histograms, map[string]string{"job": "web"})
+	if len(got) != 1 || got[0].Count != 2 {
+		t.Errorf("Expected the samples of job web, got %v", got)
+	}
+	if got := MatchHistograms(histograms, nil); len(got) != 2 {
+		t.Errorf("Expected all samples without labels to match, got %v", got)
+	}
+}
+
+func TestLimitKeepsHistograms(t *testing.T) {
+	histograms := []HistogramPoint{{Series: "latency", Count: 1}}
+	result := &TimeSeriesResult{Points: seriesPoints(10, "a", "b"), Histograms: histograms}
+	if got := Limit(result, Limits{MaxSeries: 1}); len(got.Histograms) != 1 {
+		t.Errorf("Expected histogram samples to survive truncation, got %v", got.Histograms)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	// 6 observations up to 0.5, 3 more up to 1 and 1 above
+	h := HistogramPoint{Buckets: []Bucket{
+		{Lower: math.Inf(-1), Upper: 0.5, Count: 6},
+		{Lower: 0.5, Upper: 1, Count: 3},
+		{Lower: 1, Upper: math.Inf(1), Count: 1},
+	}}
+	tests := map[float64]float64{
+		0:    0,
+		0.3:  0.25,
+		0.6:  0.5,
+		0.75: 0.75,
+		0.9:  1,
+		0.99: 1,
+	}
+	for q, want := range tests {
+		if got := h.Quantile(q); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+
+	// Native histograms have buckets on both sides of zero
+	native := HistogramPoint{Buckets: []Bucket{
+		{Lower: -2, Upper: -1, Count: 2},
+		{Lower: -0.001, Upper: 0.001, Count: 0},
+		{Lower: 1, Upper: 2, Count: 2},
+	}}
+	if got := native.Quantile(0.25); got != -1.5 {
+		t.Errorf("Expected -1.5 for the lower half, got %v", got)
+	}
+	if got := native.Quantile(0.75); got != 1.5 {
+		t.Errorf("Expected the empty zero bucket skipped, got %v", got)
+	}
+
+	if !math.IsNaN((HistogramPoint{}).Quantile(0.5)) {
+		t.Error("Expected NaN without observations")
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := &TimeSeriesResult{
+		Points: []DataPoint{
+			{Timestamp: at, Value: 10, Series: `{job="api",le="+Inf"}`},
+			{Timestamp: at, Value: 10, Series: `{job="api",le="1"}`},
+			{Timestamp: at, Value: 1, Series: `up{job="api"}`},
+		},
+		Histograms: []HistogramPoint{
+			{Timestamp: at, Series: `{job="web"}`, Count: 4, Buckets: []Bucket{{Lower: 0, Upper: 4, Count: 4}}},
+			{Timestamp: at.Add(time.Minute), Series: `{job="web"}`},
+		},
+		Truncation: &Truncation{Series: 3, KeptSeries: 2},
+	}
+
+	quantiles := HistogramQuantiles(result, []float64{50, 99.9})
+	want := []DataPoint{
+		{Timestamp: at, Value: 2, Series: `{job="web",quantile="p50"}`},
+		{Timestamp: at, Value: 3.996, Series: `{job="web",quantile="p99.9"}`},
+		{Timestamp: at, Value: 0.5, Series: `{job="api",quantile="p50"}`},
+		{Timestamp: at, Value: 0.999, Series: `{job="api",quantile="p99.9"}`},
+	}
+	if len(quantiles.Points) != len(want) {
+		t.Fatalf("Expected %d points, got %v", len(want), quantiles.Points)
+	}
+	for i, p := range quantiles.Points {
+		if p.Series != want[i].Series || math.Abs(p.Value-want[i].Value) > 1e-9 || !p.Timestamp.Equal(at) {
+			t.Errorf("Point %d: expected %v, got %v", i, want[i], p)
+		}
+	}
+	if quantiles.Truncation != result.Truncation {
+		t.Error("Expected the truncation of the result kept")
+	}
+}
@@ -2,14 +2,22 @@ package influxdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"promviz/internal/backend"
+	"promviz/internal/tracing"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	ihttp "github.com/influxdata/influxdb-client-go/v2/api/http"
+	"github.com/influxdata/influxdb-client-go/v2/api/query"
 )
 
 // Config holds InfluxDB-specific configuration
@@ -18,6 +26,10 @@ type Config struct {
 	Token  string `yaml:"token"`
 	Org    string `yaml:"org"`
 	Bucket string `yaml:"bucket"`
+
+	Transport                 *backend.TransportConfig `yaml:"transport,omitempty"`
+	backend.ClientOptions     `yaml:",inline"`
+	backend.CredentialOptions `yaml:",inline"`
 }
 
 // GetURL returns the InfluxDB server URL
@@ -27,8 +39,10 @@ func (c *Config) GetURL() string {
 
 // Client wraps the InfluxDB client
 type Client struct {
+	mu       sync.RWMutex // guards client and queryAPI, replaced along with the token
 	client   influxdb2.Client
 	queryAPI api.QueryAPI
+	options  *influxdb2.Options
 	config   *Config
 }
 
@@ -48,16 +62,54 @@ func NewClient(config *Config) (*Client, error) {
 	}
 
 	// Create InfluxDB client
-	client := influxdb2.NewClient(config.URL, config.Token)
+	options := influxdb2.DefaultOptions()
+	options.SetHTTPClient(&http.Client{
+		Timeout: time.Duration(options.HTTPRequestTimeout()) * time.Second,
+		// Propagates the trace context of traced queries
+		Transport: &tracing.Transport{Base: config.Transport.NewTransport()},
+	})
+	client := influxdb2.NewClientWithOptions(config.URL, config.Token, options)
 	queryAPI := client.QueryAPI(config.Org)
 
 	return &Client{
 		client:   client,
 		queryAPI: queryAPI,
+		options:  options,
 		config:   config,
 	}, nil
 }
 
+// api returns the query API of the current token
+func (c *Client) api() api.QueryAPI {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.queryAPI
+}
+
+// CredentialName implements backend.Reauthenticator
+func (c *Client) CredentialName() string {
+	return "token"
+}
+
+// SetCredential implements backend.Reauthenticator. Queries already
+// running finish with the old token.
+func (c *Client) SetCredential(token string) error {
+	if token == "" {
+		return fmt.Errorf("InfluxDB token is required")
+	}
+
+	client := influxdb2.NewClientWithOptions(c.config.URL, token, c.options)
+	c.mu.Lock()
+	old := c.client
+	c.client = client
+	c.queryAPI = client.QueryAPI(c.config.Org)
+	c.mu.Unlock()
+
+	// The HTTP client is shared, so this leaves its connections open
+	old.Close()
+	return nil
+}
+
 // Connect establishes connection to InfluxDB and tests connectivity
 func (c *Client) Connect(ctx context.Context) error {
 	// Test connection by running a simple query
@@ -67,9 +119,9 @@ func (c *Client) Connect(ctx context.Context) error {
 		|> limit(n: 1)
 	`, c.config.Bucket)
 
-	result, err := c.queryAPI.Query(ctx, query)
+	result, err := c.api().Query(ctx, query)
 	if err != nil {
-		return fmt.Errorf("failed to connect to InfluxDB at %s: %w", c.config.URL, err)
+		return classify(fmt.Errorf("failed to connect to InfluxDB at %s: %w", c.config.URL, err))
 	}
 
 	// Close the result to free resources
@@ -80,24 +132,33 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
-// QueryTimeSeries executes a Flux query and returns time series data
+// QueryTimeSeries executes a Flux query over the last 5 minutes and returns time series data
 func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
-	// If the expression doesn't contain bucket reference, wrap it with bucket info
+	return c.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange executes a Flux query over the given time range. Full Flux
+// queries, and every expression under backend.WithRaw, are sent verbatim
+// and keep their own range.
+func (c *Client) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
+	// Other expressions are the predicate of a filter on the bucket
 	query := expr
-	if !strings.Contains(query, "from(bucket:") {
+	if !backend.IsRaw(ctx) && !isFullQuery(expr) {
 		query = fmt.Sprintf(`
 			from(bucket: "%s")
-			|> range(start: -5m)
+			|> range(start: %s, stop: %s)
 			|> filter(fn: (r) => %s)
-			|> aggregateWindow(every: 1m, fn: mean, createEmpty: true)
+			|> aggregateWindow(every: %ds, fn: mean, createEmpty: true)
 			|> fill(value: 0.0)
 			|> sort(columns: ["_time"], desc: true)
-		`, c.config.Bucket, expr)
+		`, c.config.Bucket,
+			tr.Start.UTC().Format(time.RFC3339), tr.End.UTC().Format(time.RFC3339),
+			expr, int64(tr.Step.Seconds()))
 	}
 
-	result, err := c.queryAPI.Query(ctx, query)
+	result, err := c.api().Query(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, classify(fmt.Errorf("query failed: %w", err))
 	}
 	defer result.Close()
 
@@ -128,6 +189,7 @@ func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.Tim
 			points = append(points, backend.DataPoint{
 				Timestamp: timestamp,
 				Value:     value,
+				Series:    seriesName(record),
 			})
 		}
 	}
@@ -139,8 +201,20 @@ func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.Tim
 	return &backend.TimeSeriesResult{Points: points}, nil
 }
 
+// fullQuery matches the parts only a full Flux query has: a from() source,
+// a pipe forward or an import
+var fullQuery = regexp.MustCompile(`(?m)\bfrom\s*\(|\|>|^\s*import\s+"`)
+
+// isFullQuery reports whether expr is a full Flux query, sent verbatim,
+// rather than a filter predicate wrapped into one
+func isFullQuery(expr string) bool {
+	return fullQuery.MatchString(expr)
+}
+
 // Close closes the connection to InfluxDB
 func (c *Client) Close() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -151,3 +225,35 @@ func (c *Client) Close() error {
 func (c *Client) Name() string {
 	return "influxdb"
 }
+
+// seriesName identifies the series of a record by its measurement, field
+// and tags
+func seriesName(record *query.FluxRecord) string {
+	labels := make(map[string]string)
+	for k, v := range record.Values() {
+		if k == "_field" {
+			labels["field"] = fmt.Sprint(v)
+			continue
+		}
+		if strings.HasPrefix(k, "_") || k == "result" || k == "table" {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			labels[k] = s
+		}
+	}
+	return backend.SeriesName(record.Measurement(), labels)
+}
+
+// classify returns the typed backend error for the HTTP errors of the
+// client library. Flux that fails to compile is rejected as invalid.
+func classify(err error) error {
+	var httpErr *ihttp.Error
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+	if httpErr.StatusCode == http.StatusBadRequest && httpErr.Code == "invalid" {
+		return &backend.SyntaxError{Err: err}
+	}
+	return backend.StatusError(httpErr.StatusCode, time.Duration(httpErr.RetryAfter)*time.Second, err)
+}
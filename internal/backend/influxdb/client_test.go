@@ -2,11 +2,15 @@ package influxdb
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"promviz/internal/backend"
 )
 
 func TestConfigGetURL(t *testing.T) {
@@ -447,3 +451,126 @@ func TestClientQueryError(t *testing.T) {
 		t.Errorf("Error should mention query failure, got: %v", err)
 	}
 }
+
+func TestClientQueryTypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		{http.StatusBadRequest, `{"code":"invalid","message":"compilation failed: undefined identifier \"invalid\""}`, func(err error) bool { var e *backend.SyntaxError; return errors.As(err, &e) }},
+		{http.StatusUnauthorized, `{"code":"unauthorized","message":"unauthorized access"}`, func(err error) bool { var e *backend.AuthError; return errors.As(err, &e) }},
+		{http.StatusNotFound, `{"code":"not found","message":"bucket \"test-bucket\" not found"}`, func(err error) bool { var e *backend.NotFoundError; return errors.As(err, &e) }},
+		{http.StatusInternalServerError, `{"code":"internal error","message":"panic"}`, func(err error) bool { return err != nil }},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+
+		client, err := NewClient(&Config{URL: server.URL, Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		_, err = client.QueryTimeSeries(context.Background(), "invalid")
+		if !tt.check(err) {
+			t.Errorf("%s: unexpected error %T: %v", tt.body, err, err)
+		}
+		server.Close()
+	}
+}
+
+func TestIsFullQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		full bool
+	}{
+		{`from(bucket: "metrics") |> range(start: -5m)`, true},
+		{`from(bucket:"metrics") |> range(start: -5m)`, true},
+		{`from( bucketID: "0123456789abcdef" ) |> range(start: -5m)`, true},
+		{"import \"experimental/aggregate\"\nfrom(bucket: \"metrics\")", true},
+		{`buckets() |> filter(fn: (r) => r.name == "metrics")`, true},
+		{`r._measurement == "cpu" and r._field == "usage_user"`, false},
+		{`r.host == "importer"`, false},
+	}
+	for _, tt := range tests {
+		if got := isFullQuery(tt.expr); got != tt.full {
+			t.Errorf("isFullQuery(%q) = %v, want %v", tt.expr, got, tt.full)
+		}
+	}
+}
+
+func TestClientQueryRaw(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = body.Query
+		w.Header().Set("Content-Type", "application/csv")
+		w.Write([]byte("\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	predicate := `r._measurement == "cpu"`
+	if _, err := client.QueryTimeSeries(context.Background(), predicate); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !strings.Contains(received, `from(bucket: "test-bucket")`) || !strings.Contains(received, "filter(fn: (r) => "+predicate+")") {
+		t.Errorf("Expected the predicate to be wrapped, got %q", received)
+	}
+
+	if _, err := client.QueryTimeSeries(backend.WithRaw(context.Background(), true), predicate); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if received != predicate {
+		t.Errorf("Expected the expression verbatim, got %q", received)
+	}
+}
+
+func TestClientSetCredential(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Token fresh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/csv")
+		w.Write([]byte("\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Token: "expired-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	var authErr *backend.AuthError
+	if err := client.Connect(context.Background()); !errors.As(err, &authErr) {
+		t.Fatalf("Expected an AuthError with the expired token, got %v", err)
+	}
+
+	if err := client.SetCredential(""); err == nil {
+		t.Error("Expected an empty token to be rejected")
+	}
+	if err := client.SetCredential("fresh-token"); err != nil {
+		t.Fatalf("SetCredential failed: %v", err)
+	}
+	if err := client.Connect(context.Background()); err != nil {
+		t.Errorf("Expected the new token to be used, got %v", err)
+	}
+	if client.CredentialName() != "token" {
+		t.Errorf("Unexpected credential name %q", client.CredentialName())
+	}
+}
@@ -0,0 +1,129 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"promviz/internal/backend"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+// cardinalityQuery counts the series a bucket stored over the last day
+const cardinalityQuery = `import "influxdata/influxdb"
+influxdb.cardinality(bucket: %q, start: -1d)`
+
+// maxTasks is the most tasks checked for failed runs, the API's page limit
+const maxTasks = 500
+
+// Health implements backend.HealthReporter. Buckets or tasks that can't be
+// read are noted in the report rather than failing it, since the token may
+// lack the permission for them.
+func (c *Client) Health(ctx context.Context, buckets []string) (*backend.HealthReport, error) {
+	report, err := c.health(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buckets) == 0 {
+		buckets = []string{c.config.Bucket}
+	}
+	for _, bucket := range buckets {
+		report.Buckets = append(report.Buckets, c.cardinality(ctx, bucket))
+	}
+	c.failingTasks(ctx, report)
+	return report, nil
+}
+
+// health reads the health endpoint, which answers 503 along with the
+// failed checks when the server is unhealthy
+func (c *Client) health(ctx context.Context) (*backend.HealthReport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.config.URL, "/")+"/health", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.options.HTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var check struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&check); err != nil || check.Status == "" {
+		return nil, backend.StatusError(resp.StatusCode, 0, fmt.Errorf("health check failed: HTTP %s", resp.Status))
+	}
+
+	report := &backend.HealthReport{Status: backend.HealthPass, Version: check.Version, Message: check.Message}
+	if check.Status != backend.HealthPass || resp.StatusCode != http.StatusOK {
+		report.Status = backend.HealthFail
+	}
+	return report, nil
+}
+
+// cardinality counts the series of a bucket
+func (c *Client) cardinality(ctx context.Context, bucket string) backend.BucketCardinality {
+	count := backend.BucketCardinality{Bucket: bucket}
+	result, err := c.api().Query(ctx, fmt.Sprintf(cardinalityQuery, bucket))
+	if err != nil {
+		count.Err = classify(err).Error()
+		return count
+	}
+	defer result.Close()
+
+	for result.Next() {
+		switch v := result.Record().Value().(type) {
+		case int64:
+			count.Series += v
+		case float64:
+			count.Series += int64(v)
+		}
+	}
+	if err := result.Err(); err != nil {
+		count.Err = err.Error()
+	}
+	return count
+}
+
+// failingTasks adds the active tasks whose latest run failed to report
+func (c *Client) failingTasks(ctx context.Context, report *backend.HealthReport) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	tasks, err := client.TasksAPI().FindTasks(ctx, &api.TaskFilter{
+		OrgName: c.config.Org,
+		Status:  domain.TaskStatusTypeActive,
+		Limit:   maxTasks,
+	})
+	if err != nil {
+		report.TasksErr = err.Error()
+		return
+	}
+
+	for _, task := range tasks {
+		if task.LastRunStatus == nil || *task.LastRunStatus != domain.TaskLastRunStatusFailed {
+			continue
+		}
+		failing := backend.FailingTask{Name: task.Name}
+		if task.LastRunError != nil {
+			failing.Error = *task.LastRunError
+		}
+		if task.LatestCompleted != nil {
+			failing.LastRun = *task.LatestCompleted
+		}
+		report.FailingTasks = append(report.FailingTasks, failing)
+	}
+	sort.Slice(report.FailingTasks, func(i, j int) bool {
+		return report.FailingTasks[i].Name < report.FailingTasks[j].Name
+	})
+}
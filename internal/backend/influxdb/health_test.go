@@ -0,0 +1,154 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+// createHealthServer serves the health, query and tasks endpoints, with
+// the cardinality of a bucket named "missing" failing
+func createHealthServer(status int, health string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(health))
+		case r.URL.Path == "/api/v2/query":
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `\"missing\"`) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"not found","message":"bucket \"missing\" not found"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/csv")
+			w.Write([]byte(`#datatype,string,long,long
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,1234
+`))
+		case r.URL.Path == "/api/v2/tasks":
+			if r.URL.Query().Get("org") != "test-org" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"tasks":[
+				{"id":"1","orgID":"o","name":"rollup","flux":"","status":"active","lastRunStatus":"success"},
+				{"id":"2","orgID":"o","name":"downsample","flux":"","status":"active","lastRunStatus":"failed","lastRunError":"bucket not found","latestCompleted":"2023-01-01T00:00:00Z"},
+				{"id":"3","orgID":"o","name":"alerts","flux":"","status":"active","lastRunStatus":"failed"}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClientHealth(t *testing.T) {
+	server := createHealthServer(http.StatusOK, `{"name":"influxdb","status":"pass","message":"ready for queries and writes","version":"v2.7.1"}`)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL + "/", Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	report, err := client.Health(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if report.Status != backend.HealthPass || report.Version != "v2.7.1" || report.Message != "ready for queries and writes" {
+		t.Errorf("Unexpected status %+v", report)
+	}
+	if len(report.Buckets) != 1 || report.Buckets[0] != (backend.BucketCardinality{Bucket: "test-bucket", Series: 1234}) {
+		t.Errorf("Expected the configured bucket to be counted, got %+v", report.Buckets)
+	}
+	if report.TasksErr != "" {
+		t.Fatalf("Unexpected tasks error %q", report.TasksErr)
+	}
+	if len(report.FailingTasks) != 2 {
+		t.Fatalf("Expected 2 failing tasks, got %+v", report.FailingTasks)
+	}
+	if report.FailingTasks[0].Name != "alerts" || report.FailingTasks[1].Name != "downsample" {
+		t.Errorf("Expected failing tasks sorted by name, got %+v", report.FailingTasks)
+	}
+	if task := report.FailingTasks[1]; task.Error != "bucket not found" || task.LastRun.IsZero() {
+		t.Errorf("Expected the error and last run of the task, got %+v", task)
+	}
+}
+
+func TestClientHealthBuckets(t *testing.T) {
+	server := createHealthServer(http.StatusOK, `{"status":"pass"}`)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	report, err := client.Health(context.Background(), []string{"metrics", "missing"})
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if len(report.Buckets) != 2 {
+		t.Fatalf("Expected 2 buckets, got %+v", report.Buckets)
+	}
+	if report.Buckets[0].Bucket != "metrics" || report.Buckets[0].Series != 1234 || report.Buckets[0].Err != "" {
+		t.Errorf("Unexpected count %+v", report.Buckets[0])
+	}
+	if report.Buckets[1].Bucket != "missing" || !strings.Contains(report.Buckets[1].Err, "not found") {
+		t.Errorf("Expected the missing bucket to carry its error, got %+v", report.Buckets[1])
+	}
+}
+
+func TestClientHealthFailing(t *testing.T) {
+	server := createHealthServer(http.StatusServiceUnavailable, `{"status":"fail","message":"storage engine unavailable","version":"v2.7.1"}`)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	report, err := client.Health(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if report.Status != backend.HealthFail || report.Message != "storage engine unavailable" {
+		t.Errorf("Expected a failing report, got %+v", report)
+	}
+}
+
+func TestClientHealthErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("unauthorized"))
+	}))
+	client, err := NewClient(&Config{URL: server.URL, Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	var authErr *backend.AuthError
+	if _, err := client.Health(context.Background(), nil); !errors.As(err, &authErr) {
+		t.Errorf("Expected an AuthError, got %v", err)
+	}
+
+	server.Close()
+	if _, err := client.Health(context.Background(), nil); err == nil {
+		t.Error("Expected an error with the server down")
+	}
+}
@@ -0,0 +1,46 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// fieldFilter and measurementFilter find the field and measurement a Flux
+// predicate compares with, as r._field == "x" or r["_field"] == "x"
+var (
+	fieldFilter       = regexp.MustCompile(`r(?:\._field|\["_field"\])\s*==\s*"([^"]*)"`)
+	measurementFilter = regexp.MustCompile(`r(?:\._measurement|\["_measurement"\])\s*==\s*"([^"]*)"`)
+)
+
+// Describe implements backend.Describer. InfluxDB keeps no units or help
+// text, so the field expr filters on is described by its name, e.g.
+// used_percent of measurement mem in percent or bytes_recv in bytes. Queries of several fields or
+// mapping values have no unit.
+func (c *Client) Describe(ctx context.Context, expr string) (backend.Metadata, error) {
+	fields := fieldFilter.FindAllStringSubmatch(expr, -1)
+	if len(fields) == 0 {
+		return backend.Metadata{}, nil
+	}
+	field := fields[0][1]
+
+	md := backend.Metadata{Help: fmt.Sprintf("Field %s", field)}
+	if m := measurementFilter.FindStringSubmatch(expr); m != nil {
+		md.Help += fmt.Sprintf(" of measurement %s", m[1])
+	}
+	if len(fields) == 1 && !strings.Contains(expr, "map(") {
+		md.Unit = backend.UnitFromName(field)
+		if md.Unit == "" {
+			// Telegraf also leads with the unit, as in bytes_recv
+			word, _, _ := strings.Cut(field, "_")
+			md.Unit = backend.UnitFromName(word)
+		}
+		if md.Unit != "" && strings.Contains(expr, "derivative(") {
+			md.Unit += backend.PerSecond
+		}
+	}
+	return md, nil
+}
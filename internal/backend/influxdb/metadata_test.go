@@ -0,0 +1,40 @@
+package influxdb
+
+import (
+	"context"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestClientDescribe(t *testing.T) {
+	client, err := NewClient(&Config{URL: "http://localhost:8086", Token: "test-token", Org: "test-org", Bucket: "test-bucket"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var _ backend.Describer = client
+
+	tests := []struct {
+		expr string
+		want backend.Metadata
+	}{
+		{`r._measurement == "mem" and r._field == "used_percent"`,
+			backend.Metadata{Help: "Field used_percent of measurement mem", Unit: "percent"}},
+		{`from(bucket: "b") |> filter(fn: (r) => r["_measurement"] == "net" and r["_field"] == "bytes_recv") |> derivative(nonNegative: true)`,
+			backend.Metadata{Help: "Field bytes_recv of measurement net", Unit: "bytes/s"}},
+		{`r._field == "used_percent" |> map(fn: (r) => ({r with _value: r._value / 100.0}))`,
+			backend.Metadata{Help: "Field used_percent"}},
+		{`r._field == "rx_bytes" or r._field == "tx_bytes"`,
+			backend.Metadata{Help: "Field rx_bytes"}},
+		{`r._measurement == "cpu"`, backend.Metadata{}},
+	}
+	for _, tt := range tests {
+		md, err := client.Describe(context.Background(), tt.expr)
+		if err != nil {
+			t.Fatalf("Describe(%q) failed: %v", tt.expr, err)
+		}
+		if md != tt.want {
+			t.Errorf("Describe(%q) = %+v, want %+v", tt.expr, md, tt.want)
+		}
+	}
+}
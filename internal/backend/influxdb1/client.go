@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"promviz/internal/backend"
@@ -20,6 +22,9 @@ type Config struct {
 	Password string `yaml:"password"`
 	Database string `yaml:"database"`
 	UseHTTPS bool   `yaml:"use_https,omitempty"`
+
+	backend.ClientOptions     `yaml:",inline"`
+	backend.CredentialOptions `yaml:",inline"`
 }
 
 // GetURL returns the InfluxDB v1 server URL
@@ -29,6 +34,7 @@ func (c *Config) GetURL() string {
 
 // Client wraps the InfluxDB v1 client
 type Client struct {
+	mu     sync.RWMutex // guards client, replaced along with the password
 	client client.Client
 	config *Config
 }
@@ -42,24 +48,60 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, fmt.Errorf("InfluxDB v1 database is required")
 	}
 
-	// Create InfluxDB v1 client configuration
+	influxClient, err := newHTTPClient(config, config.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		client: influxClient,
+		config: config,
+	}, nil
+}
+
+// newHTTPClient creates the InfluxDB v1 client logging in with password
+func newHTTPClient(config *Config, password string) (client.Client, error) {
 	conf := client.HTTPConfig{
 		Addr:     config.URL,
 		Username: config.Username,
-		Password: config.Password,
+		Password: password,
 		Timeout:  time.Duration(30) * time.Second,
 	}
 
-	// Create client
 	influxClient, err := client.NewHTTPClient(conf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create InfluxDB v1 client: %w", err)
 	}
+	return influxClient, nil
+}
 
-	return &Client{
-		client: influxClient,
-		config: config,
-	}, nil
+// httpClient returns the client of the current password
+func (c *Client) httpClient() client.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// CredentialName implements backend.Reauthenticator
+func (c *Client) CredentialName() string {
+	return "password"
+}
+
+// SetCredential implements backend.Reauthenticator. Queries already
+// running finish with the old password.
+func (c *Client) SetCredential(password string) error {
+	influxClient, err := newHTTPClient(c.config, password)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old := c.client
+	c.client = influxClient
+	c.mu.Unlock()
+
+	// Only closes idle connections
+	return old.Close()
 }
 
 // Connect establishes connection to InfluxDB v1 and tests connectivity
@@ -70,29 +112,38 @@ func (c *Client) Connect(ctx context.Context) error {
 		Database: "",
 	}
 
-	response, err := c.client.Query(query)
+	response, err := c.httpClient().QueryCtx(ctx, query)
 	if err != nil {
-		return fmt.Errorf("failed to connect to InfluxDB v1 at %s: %w", c.config.URL, err)
+		return classify(fmt.Errorf("failed to connect to InfluxDB v1 at %s: %w", c.config.URL, err))
 	}
 
 	if response.Error() != nil {
-		return fmt.Errorf("InfluxDB v1 query error: %w", response.Error())
+		return classify(fmt.Errorf("InfluxDB v1 query error: %w", response.Error()))
 	}
 
 	return nil
 }
 
-// QueryTimeSeries executes an InfluxQL query and returns time series data
+// QueryTimeSeries executes an InfluxQL query over the last 5 minutes and returns time series data
 func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
-	// Build the InfluxQL query - default to 5 minutes of data with 1-minute intervals
+	return c.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange executes an InfluxQL query over the given time range. Full
+// statements, and every expression under backend.WithRaw, are sent
+// verbatim and keep their own time bounds.
+func (c *Client) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
 	var queryStr string
-	if strings.Contains(strings.ToUpper(expr), "SELECT") {
+	if backend.IsRaw(ctx) || isFullQuery(expr) {
 		// Full InfluxQL query provided
 		queryStr = expr
 	} else {
 		// Simple expression - wrap in SELECT statement with time series aggregation
 		measurement := c.getDefaultMeasurement(expr)
-		queryStr = fmt.Sprintf("SELECT mean(\"%s\") FROM \"%s\" WHERE time >= now() - 5m GROUP BY time(1m) fill(0) ORDER BY time DESC", expr, measurement)
+		queryStr = fmt.Sprintf("SELECT mean(\"%s\") FROM \"%s\" WHERE time >= '%s' AND time <= '%s' GROUP BY time(%ds) fill(0) ORDER BY time DESC",
+			expr, measurement,
+			tr.Start.UTC().Format(time.RFC3339), tr.End.UTC().Format(time.RFC3339),
+			int64(tr.Step.Seconds()))
 	}
 
 	query := client.Query{
@@ -100,13 +151,13 @@ func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.Tim
 		Database: c.config.Database,
 	}
 
-	response, err := c.client.Query(query)
+	response, err := c.httpClient().QueryCtx(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, classify(fmt.Errorf("query failed: %w", err))
 	}
 
 	if response.Error() != nil {
-		return nil, fmt.Errorf("InfluxDB v1 query error: %w", response.Error())
+		return nil, classify(fmt.Errorf("InfluxDB v1 query error: %w", response.Error()))
 	}
 
 	// Process the response
@@ -115,54 +166,86 @@ func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.Tim
 	}
 
 	result := response.Results[0]
+	warnings := resultWarnings(result)
 	if len(result.Series) == 0 {
-		return &backend.TimeSeriesResult{Points: []backend.DataPoint{}}, nil
-	}
-
-	series := result.Series[0]
-	if len(series.Values) == 0 {
-		return &backend.TimeSeriesResult{Points: []backend.DataPoint{}}, nil
+		return &backend.TimeSeriesResult{Points: []backend.DataPoint{}, Warnings: warnings}, nil
 	}
 
-	// Convert to time series data points
+	// Convert to time series data points; GROUP BY tag queries return one
+	// series per tag value
 	var points []backend.DataPoint
-	for _, values := range series.Values {
-		if len(values) < 2 {
-			continue
-		}
+	for _, series := range result.Series {
+		name := backend.SeriesName(series.Name, series.Tags)
+		for _, values := range series.Values {
+			if len(values) < 2 {
+				continue
+			}
 
-		// Parse timestamp (first column)
-		timestampStr, ok := values[0].(string)
-		if !ok {
-			continue
-		}
-		timestamp, err := time.Parse(time.RFC3339, timestampStr)
-		if err != nil {
-			continue
-		}
+			// Parse timestamp (first column)
+			timestampStr, ok := values[0].(string)
+			if !ok {
+				continue
+			}
+			timestamp, err := time.Parse(time.RFC3339, timestampStr)
+			if err != nil {
+				continue
+			}
+
+			// Parse value (second column)
+			if values[1] == nil {
+				// Skip null values or use 0 for fill(0)
+				points = append(points, backend.DataPoint{
+					Timestamp: timestamp,
+					Value:     0,
+					Series:    name,
+				})
+				continue
+			}
+
+			value, err := c.convertToFloat64(values[1])
+			if err != nil {
+				continue
+			}
 
-		// Parse value (second column)
-		if values[1] == nil {
-			// Skip null values or use 0 for fill(0)
 			points = append(points, backend.DataPoint{
 				Timestamp: timestamp,
-				Value:     0,
+				Value:     value,
+				Series:    name,
 			})
-			continue
 		}
+	}
 
-		value, err := c.convertToFloat64(values[1])
-		if err != nil {
-			continue
-		}
+	if points == nil {
+		points = []backend.DataPoint{}
+	}
+	return &backend.TimeSeriesResult{Points: points, Warnings: warnings}, nil
+}
 
-		points = append(points, backend.DataPoint{
-			Timestamp: timestamp,
-			Value:     value,
-		})
+// resultWarnings returns the warning messages of a result and a warning per
+// series InfluxDB cut short
+func resultWarnings(result client.Result) []string {
+	var warnings []string
+	for _, m := range result.Messages {
+		if m != nil && strings.EqualFold(m.Level, "warning") {
+			warnings = append(warnings, m.Text)
+		}
 	}
+	for _, series := range result.Series {
+		if series.Partial {
+			warnings = append(warnings, fmt.Sprintf("series %s is partial", backend.SeriesName(series.Name, series.Tags)))
+		}
+	}
+	return warnings
+}
 
-	return &backend.TimeSeriesResult{Points: points}, nil
+// fullQuery matches expressions starting with an InfluxQL statement in any
+// case, after any comment lines
+var fullQuery = regexp.MustCompile(`(?i)^\s*(--[^\n]*\n\s*)*(select|show|explain)\s`)
+
+// isFullQuery reports whether expr is a full InfluxQL statement, sent
+// verbatim, rather than a field name wrapped into one
+func isFullQuery(expr string) bool {
+	return fullQuery.MatchString(expr)
 }
 
 // convertToFloat64 converts various types to float64
@@ -211,6 +294,8 @@ func (c *Client) getDefaultMeasurement(expr string) string {
 
 // Close closes the connection to InfluxDB v1
 func (c *Client) Close() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.client != nil {
 		return c.client.Close()
 	}
@@ -221,3 +306,28 @@ func (c *Client) Close() error {
 func (c *Client) Name() string {
 	return "influxdb1"
 }
+
+// statusPattern finds the HTTP status in the errors of the client library
+var statusPattern = regexp.MustCompile(`status(?: code)?:? (\d{3})`)
+
+// classify returns the typed backend error for the errors of InfluxDB v1,
+// which the client library only passes on as messages: the server's own,
+// or one with the status code when the body held none
+func classify(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "authorization failed"):
+		return &backend.AuthError{Err: err}
+	case strings.Contains(msg, "database not found"):
+		return &backend.NotFoundError{Err: err}
+	case strings.Contains(msg, "error parsing query"):
+		return &backend.SyntaxError{Err: err}
+	case strings.Contains(msg, "query-timeout limit exceeded"):
+		return &backend.TimeoutError{Err: err}
+	}
+	if match := statusPattern.FindStringSubmatch(msg); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		return backend.StatusError(status, 0, err)
+	}
+	return err
+}
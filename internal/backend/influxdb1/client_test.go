@@ -2,8 +2,14 @@ package influxdb1
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"promviz/internal/backend"
 )
 
 func TestConfigGetURL(t *testing.T) {
@@ -184,6 +190,151 @@ func TestClientQueryFailure(t *testing.T) {
 	}
 }
 
+func TestClientQueryErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		{http.StatusUnauthorized, `{"error":"authorization failed"}`, func(err error) bool { var e *backend.AuthError; return errors.As(err, &e) }},
+		{http.StatusOK, `{"results":[{"statement_id":0,"error":"database not found: telegraf"}]}`, func(err error) bool { var e *backend.NotFoundError; return errors.As(err, &e) }},
+		{http.StatusBadRequest, `{"error":"error parsing query: found EOF, expected FROM at line 1, char 22"}`, func(err error) bool { var e *backend.SyntaxError; return errors.As(err, &e) }},
+		{http.StatusOK, `{"results":[{"statement_id":0,"error":"query-timeout limit exceeded"}]}`, func(err error) bool { var e *backend.TimeoutError; return errors.As(err, &e) }},
+		{http.StatusTooManyRequests, `too many requests`, func(err error) bool { var e *backend.RateLimitedError; return errors.As(err, &e) }},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(tt.body, "{") {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+
+		client, err := NewClient(&Config{URL: server.URL, Database: "telegraf"})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		_, err = client.QueryTimeSeries(context.Background(), "SELECT mean(usage_idle)")
+		if err == nil || !tt.check(err) {
+			t.Errorf("%s: unexpected error %T: %v", tt.body, err, err)
+		}
+		server.Close()
+	}
+}
+
+func TestClientQueryCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stalls until the request is abandoned
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient(&Config{URL: server.URL, Database: "telegraf"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.QueryTimeSeries(ctx, "SELECT mean(usage_idle) FROM cpu")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the query to be canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The query should return once its context is canceled")
+	}
+}
+
+func TestIsFullQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		full bool
+	}{
+		{"SELECT mean(usage_idle) FROM cpu", true},
+		{"select mean(usage_idle) from cpu group by time(1m)", true},
+		{"  Select last(value) FROM temp", true},
+		{"show measurements", true},
+		{"-- idle CPU\nselect mean(usage_idle) from cpu", true},
+		{"usage_idle", false},
+		{"selected_requests", false},
+		{"bytes_selected", false},
+	}
+	for _, tt := range tests {
+		if got := isFullQuery(tt.expr); got != tt.full {
+			t.Errorf("isFullQuery(%q) = %v, want %v", tt.expr, got, tt.full)
+		}
+	}
+}
+
+func TestClientQueryRaw(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.FormValue("q")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Database: "telegraf"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if _, err := client.QueryTimeSeries(context.Background(), "selected_requests"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !strings.HasPrefix(received, `SELECT mean("selected_requests")`) {
+		t.Errorf("Expected a field name to be wrapped, got %q", received)
+	}
+
+	raw := `SELECT last("value") FROM "temp"; SELECT last("value") FROM "humidity"`
+	if _, err := client.QueryTimeSeries(backend.WithRaw(context.Background(), true), raw); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if received != raw {
+		t.Errorf("Expected the expression verbatim, got %q", received)
+	}
+}
+
+func TestClientQueryPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"statement_id":0,
+			"messages":[{"level":"warning","text":"shard 12 is unavailable"},{"level":"info","text":"deprecated syntax"}],
+			"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","mean"],"values":[["2024-01-01T12:00:00Z",42]],"partial":true}]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Database: "telegraf"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	result, err := client.QueryTimeSeries(context.Background(), "usage")
+	if err != nil {
+		t.Fatalf("A partial result should not be an error, got %v", err)
+	}
+	if len(result.Points) != 1 || result.Points[0].Value != 42 {
+		t.Errorf("Expected the returned point, got %v", result.Points)
+	}
+	expected := []string{"shard 12 is unavailable", `series cpu{host="a"} is partial`}
+	if strings.Join(result.Warnings, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected warnings %q, got %q", expected, result.Warnings)
+	}
+}
+
 func TestGetDefaultMeasurement(t *testing.T) {
 	config := &Config{
 		URL:      "http://localhost:8086",
@@ -264,3 +415,37 @@ func TestConvertToFloat64(t *testing.T) {
 		})
 	}
 }
+
+func TestClientSetCredential(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if user, password, _ := r.BasicAuth(); user != "admin" || password != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"authorization failed"}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL, Username: "admin", Password: "expired", Database: "telegraf"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	var authErr *backend.AuthError
+	if err := client.Connect(context.Background()); !errors.As(err, &authErr) {
+		t.Fatalf("Expected an AuthError with the expired password, got %v", err)
+	}
+
+	if err := client.SetCredential("fresh"); err != nil {
+		t.Fatalf("SetCredential failed: %v", err)
+	}
+	if err := client.Connect(context.Background()); err != nil {
+		t.Errorf("Expected the new password to be used, got %v", err)
+	}
+	if client.CredentialName() != "password" {
+		t.Errorf("Unexpected credential name %q", client.CredentialName())
+	}
+}
@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Defaults of Limits, generous enough for any panel a terminal can show
+const (
+	DefaultMaxSeries = 500
+	DefaultMaxPoints = 100000
+)
+
+// Limits caps the size of the result of one query, so a query matching far
+// more series than expected can't freeze the UI or exhaust memory
+type Limits struct {
+	MaxSeries int `yaml:"max_series,omitempty"` // defaults to DefaultMaxSeries
+	MaxPoints int `yaml:"max_points,omitempty"` // defaults to DefaultMaxPoints
+}
+
+// WithDefaults returns the limits with unset fields set to their defaults
+func (l Limits) WithDefaults() Limits {
+	if l.MaxSeries <= 0 {
+		l.MaxSeries = DefaultMaxSeries
+	}
+	if l.MaxPoints <= 0 {
+		l.MaxPoints = DefaultMaxPoints
+	}
+	return l
+}
+
+// Truncation records how much of a result was dropped to fit its limits
+type Truncation struct {
+	Series     int `json:"series"`      // series returned by the backend
+	KeptSeries int `json:"kept_series"` // series kept
+	Points     int `json:"points"`      // points returned by the backend
+	KeptPoints int `json:"kept_points"` // points kept
+}
+
+// String describes the truncation, e.g. "truncated: 12k→1k points"
+func (t Truncation) String() string {
+	var parts []string
+	if t.KeptSeries < t.Series {
+		parts = append(parts, fmt.Sprintf("%s→%s series", formatCount(t.Series), formatCount(t.KeptSeries)))
+	}
+	if t.KeptPoints < t.Points {
+		parts = append(parts, fmt.Sprintf("%s→%s points", formatCount(t.Points), formatCount(t.KeptPoints)))
+	}
+	return "truncated: " + strings.Join(parts, ", ")
+}
+
+// formatCount writes n compactly, e.g. 1.5k or 12k
+func formatCount(n int) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e6, "M"}, {1e3, "k"}} {
+		if v := float64(n) / unit.size; v >= 1 {
+			if v < 10 {
+				v = math.Round(v*10) / 10
+			} else {
+				v = math.Round(v)
+			}
+			return strconv.FormatFloat(v, 'f', -1, 64) + unit.suffix
+		}
+	}
+	return strconv.Itoa(n)
+}
+
+// Limit applies limits to result. Results within the limits are returned
+// unchanged. Otherwise the series sorting first by name are kept, and
+// series with more than their share of the points are thinned out evenly,
+// always keeping their newest point, so the same data is always truncated
+// the same way. Truncation of the returned result describes what was
+// dropped.
+func Limit(result *TimeSeriesResult, limits Limits) *TimeSeriesResult {
+	limits = limits.WithDefaults()
+	if result == nil || (len(result.Points) <= limits.MaxPoints && len(result.Points) <= limits.MaxSeries) {
+		return result
+	}
+
+	// Group the points by series
+	bySeries := make(map[string][]DataPoint)
+	for _, p := range result.Points {
+		bySeries[p.Series] = append(bySeries[p.Series], p)
+	}
+	if len(bySeries) <= limits.MaxSeries && len(result.Points) <= limits.MaxPoints {
+		return result
+	}
+	names := make([]string, 0, len(bySeries))
+	for name := range bySeries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	kept := len(names)
+	if kept > limits.MaxSeries {
+		kept = limits.MaxSeries
+	}
+	if kept > limits.MaxPoints {
+		kept = limits.MaxPoints
+	}
+	total := 0
+	for _, name := range names[:kept] {
+		total += len(bySeries[name])
+	}
+	share := total
+	if total > limits.MaxPoints {
+		share = limits.MaxPoints / kept
+	}
+
+	points := make([]DataPoint, 0, total)
+	for _, name := range names[:kept] {
+		series := bySeries[name]
+		sort.SliceStable(series, func(i, j int) bool {
+			return series[i].Timestamp.Before(series[j].Timestamp)
+		})
+		points = append(points, thin(series, share)...)
+	}
+
+	return &TimeSeriesResult{
+		Points:     points,
+		Histograms: result.Histograms,
+		Warnings:   result.Warnings,
+		Truncation: &Truncation{
+			Series:     len(names),
+			KeptSeries: kept,
+			Points:     len(result.Points),
+			KeptPoints: len(points),
+		},
+	}
+}
+
+// thin returns n of points spread evenly over them, including the first
+// and the last
+func thin(points []DataPoint, n int) []DataPoint {
+	if len(points) <= n {
+		return points
+	}
+	if n == 1 {
+		return points[len(points)-1:]
+	}
+	kept := make([]DataPoint, n)
+	last := len(points) - 1
+	for i := range kept {
+		kept[i] = points[last-(n-1-i)*last/(n-1)]
+	}
+	return kept
+}
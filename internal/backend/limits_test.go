@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// seriesPoints returns count points of each of the named series, one minute
+// apart, with values counting up from zero
+func seriesPoints(count int, names ...string) []DataPoint {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var points []DataPoint
+	for i := 0; i < count; i++ {
+		for _, name := range names {
+			points = append(points, DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: float64(i), Series: name})
+		}
+	}
+	return points
+}
+
+func TestLimitWithinLimits(t *testing.T) {
+	result := &TimeSeriesResult{Points: seriesPoints(10, "a", "b")}
+	if got := Limit(result, Limits{}); got != result {
+		t.Error("Expected a result within the limits to be returned unchanged")
+	}
+	if got := Limit(nil, Limits{}); got != nil {
+		t.Errorf("Expected nil for a nil result, got %v", got)
+	}
+}
+
+func TestLimitSeries(t *testing.T) {
+	result := &TimeSeriesResult{Points: seriesPoints(3, "c", "a", "d", "b")}
+	got := Limit(result, Limits{MaxSeries: 2})
+
+	if len(got.Points) != 6 {
+		t.Fatalf("Expected the 6 points of 2 series, got %d", len(got.Points))
+	}
+	for i, p := range got.Points {
+		if want := []string{"a", "b"}[i/3]; p.Series != want {
+			t.Errorf("Point %d: expected series %s, got %s", i, want, p.Series)
+		}
+	}
+	want := Truncation{Series: 4, KeptSeries: 2, Points: 12, KeptPoints: 6}
+	if got.Truncation == nil || *got.Truncation != want {
+		t.Errorf("Expected truncation %+v, got %+v", want, got.Truncation)
+	}
+}
+
+func TestLimitPoints(t *testing.T) {
+	result := &TimeSeriesResult{Points: seriesPoints(100, "a", "b")}
+	got := Limit(result, Limits{MaxPoints: 10})
+
+	if len(got.Points) != 10 {
+		t.Fatalf("Expected 10 points, got %d", len(got.Points))
+	}
+	// Each series keeps 5 points spread over it, from the first to the newest
+	for i, want := range []float64{0, 25, 50, 75, 99} {
+		if got.Points[i].Value != want || got.Points[i].Series != "a" {
+			t.Errorf("Point %d: expected a=%g, got %s=%g", i, want, got.Points[i].Series, got.Points[i].Value)
+		}
+	}
+	if last := got.Points[9]; last.Series != "b" || last.Value != 99 {
+		t.Errorf("Expected the newest point of b last, got %+v", last)
+	}
+	if got.Truncation.KeptSeries != 2 || got.Truncation.Points != 200 || got.Truncation.KeptPoints != 10 {
+		t.Errorf("Unexpected truncation %+v", got.Truncation)
+	}
+
+	// The same data is always truncated the same way
+	again := Limit(&TimeSeriesResult{Points: seriesPoints(100, "a", "b")}, Limits{MaxPoints: 10})
+	for i := range got.Points {
+		if got.Points[i] != again.Points[i] {
+			t.Fatalf("Point %d differs between runs: %+v and %+v", i, got.Points[i], again.Points[i])
+		}
+	}
+}
+
+func TestLimitMoreSeriesThanPoints(t *testing.T) {
+	var names []string
+	for i := 0; i < 20; i++ {
+		names = append(names, fmt.Sprintf("s%02d", i))
+	}
+	got := Limit(&TimeSeriesResult{Points: seriesPoints(5, names...)}, Limits{MaxPoints: 8})
+
+	if len(got.Points) != 8 || got.Truncation.KeptSeries != 8 {
+		t.Fatalf("Expected the newest point of 8 series, got %d points of %d series", len(got.Points), got.Truncation.KeptSeries)
+	}
+	for _, p := range got.Points {
+		if p.Value != 4 {
+			t.Errorf("Expected only newest points, got %+v", p)
+		}
+	}
+}
+
+func TestTruncationString(t *testing.T) {
+	tests := []struct {
+		truncation Truncation
+		expected   string
+	}{
+		{Truncation{Series: 1, KeptSeries: 1, Points: 12000, KeptPoints: 1000}, "truncated: 12k→1k points"},
+		{Truncation{Series: 800, KeptSeries: 500, Points: 900, KeptPoints: 500}, "truncated: 800→500 series, 900→500 points"},
+		{Truncation{Series: 2500000, KeptSeries: 500, Points: 2500000, KeptPoints: 500}, "truncated: 2.5M→500 series, 2.5M→500 points"},
+	}
+	for _, tt := range tests {
+		if got := tt.truncation.String(); got != tt.expected {
+			t.Errorf("%+v: expected %q, got %q", tt.truncation, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"context"
+	"strings"
+)
+
+// Units a panel's values can be formatted in. Other units are written
+// after the value as they are, e.g. "req".
+const (
+	UnitBytes   = "bytes"
+	UnitSeconds = "seconds"
+	UnitPercent = "percent" // 0 to 100
+	UnitRatio   = "ratio"   // 0 to 1, shown as a percentage
+)
+
+// PerSecond is appended to a unit for rates, e.g. "bytes/s"
+const PerSecond = "/s"
+
+// Metadata describes the metric an expression queries, as far as its
+// backend knows it. Unknown fields are empty.
+type Metadata struct {
+	Type string // e.g. "counter" or "gauge"
+	Help string // description of the metric
+	Unit string // one of the Unit constants, possibly with PerSecond
+}
+
+// Describer is implemented by backends that can describe the metrics of
+// an expression, e.g. from their metadata
+type Describer interface {
+	// Describe returns what the backend knows about the metric expr
+	// queries
+	Describe(ctx context.Context, expr string) (Metadata, error)
+}
+
+// nameUnits maps the last word of a metric or field name to its unit,
+// following the Prometheus naming conventions
+var nameUnits = map[string]string{
+	"bytes":   UnitBytes,
+	"seconds": UnitSeconds,
+	"percent": UnitPercent,
+	"ratio":   UnitRatio,
+}
+
+// UnitFromName guesses the unit of a metric or field from its name, e.g.
+// bytes for node_memory_MemFree_bytes or percent for used_percent. A
+// trailing _total of counters is ignored.
+func UnitFromName(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), "_total")
+	if i := strings.LastIndexAny(name, "_."); i >= 0 {
+		name = name[i+1:]
+	}
+	return nameUnits[name]
+}
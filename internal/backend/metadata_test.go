@@ -0,0 +1,23 @@
+package backend
+
+import "testing"
+
+func TestUnitFromName(t *testing.T) {
+	tests := map[string]string{
+		"node_memory_MemFree_bytes":           UnitBytes,
+		"node_network_receive_bytes_total":    UnitBytes,
+		"http_request_duration_seconds":       UnitSeconds,
+		"used_percent":                        UnitPercent,
+		"disk.used_Percent":                   UnitPercent,
+		"cache_hit_ratio":                     UnitRatio,
+		"http_requests_total":                 "",
+		"bytes":                               UnitBytes,
+		"":                                    "",
+		"process_resident_memory_bytes_extra": "",
+	}
+	for name, want := range tests {
+		if got := UnitFromName(name); got != want {
+			t.Errorf("UnitFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -44,17 +44,26 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
-// QueryTimeSeries simulates executing a query and returns time series data
+// QueryTimeSeries simulates executing a query over the last 5 minutes and returns time series data
 func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
+	return c.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
+
+// QueryRange simulates executing a query over the given time range
+func (c *Client) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
 	// Simulate query processing time
 	time.Sleep(time.Duration(c.rand.Intn(50)) * time.Millisecond)
 
-	// Generate 5 minutes of data with 1-minute intervals
+	// Generate one point per step, ending at the range end
 	var points []backend.DataPoint
-	now := time.Now()
+	step := tr.Step
+	if step <= 0 {
+		step = time.Minute
+	}
+	steps := int(tr.End.Sub(tr.Start) / step)
 
-	for i := 4; i >= 0; i-- {
-		timestamp := now.Add(-time.Duration(i) * time.Minute)
+	for i := steps - 1; i >= 0; i-- {
+		timestamp := tr.End.Add(-time.Duration(i) * step)
 
 		// Generate value based on the query expression
 		var baseValue float64
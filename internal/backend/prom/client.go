@@ -2,11 +2,12 @@ package prom
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"promviz/internal/backend"
+	"promviz/internal/tracing"
 
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -16,6 +17,9 @@ import (
 // Config holds Prometheus-specific configuration
 type Config struct {
 	URL string `yaml:"url"`
+
+	Transport             *backend.TransportConfig `yaml:"transport,omitempty"`
+	backend.ClientOptions `yaml:",inline"`
 }
 
 // GetURL returns the Prometheus server URL
@@ -32,9 +36,14 @@ type Client struct {
 
 // NewClient creates a new Prometheus backend client
 func NewClient(config *Config) (*Client, error) {
-	client, err := api.NewClient(api.Config{
+	apiConfig := api.Config{
 		Address: config.URL,
-	})
+		// Propagates the trace context of traced queries and reports auth,
+		// not found and rate limit responses as typed errors
+		RoundTripper: &backend.StatusTransport{Base: &tracing.Transport{Base: config.Transport.NewTransport()}},
+	}
+
+	client, err := api.NewClient(apiConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Prometheus client: %w", err)
 	}
@@ -51,51 +60,122 @@ func (c *Client) Connect(ctx context.Context) error {
 	// Test connection by trying to fetch label names
 	_, _, err := c.api.LabelNames(ctx, nil, time.Now().Add(-time.Minute), time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to connect to Prometheus at %s: %w", c.config.URL, err)
+		return classify(fmt.Errorf("failed to connect to Prometheus at %s: %w", c.config.URL, err))
 	}
 	return nil
 }
 
-// QueryTimeSeries executes a PromQL range query and returns time series data
+// QueryTimeSeries executes a PromQL range query over the last 5 minutes and returns time series data
 func (c *Client) QueryTimeSeries(ctx context.Context, expr string) (*backend.TimeSeriesResult, error) {
-	// Query for the last 5 minutes with 1-minute step
-	end := time.Now()
-	start := end.Add(-5 * time.Minute)
-	step := time.Minute
+	return c.QueryRange(ctx, expr, backend.DefaultTimeRange())
+}
 
+// QueryRange executes a PromQL range query over the given time range
+func (c *Client) QueryRange(ctx context.Context, expr string, tr backend.TimeRange) (*backend.TimeSeriesResult, error) {
 	result, warnings, err := c.api.QueryRange(ctx, expr, v1.Range{
-		Start: start,
-		End:   end,
-		Step:  step,
+		Start: tr.Start,
+		End:   tr.End,
+		Step:  tr.Step,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
-	}
-
-	if len(warnings) > 0 {
-		log.Printf("Warnings: %v", warnings)
+		return nil, classify(fmt.Errorf("query failed: %w", err))
 	}
 
 	switch result.Type() {
 	case model.ValMatrix:
 		matrix := result.(model.Matrix)
 		var points []backend.DataPoint
+		var histograms []backend.HistogramPoint
 
 		for _, sampleStream := range matrix {
+			series := sampleStream.Metric.String()
 			for _, sample := range sampleStream.Values {
 				points = append(points, backend.DataPoint{
 					Timestamp: sample.Timestamp.Time(),
 					Value:     float64(sample.Value),
+					Series:    series,
 				})
 			}
+			for _, sample := range sampleStream.Histograms {
+				histograms = append(histograms, convertHistogram(series, sample))
+			}
 		}
 
-		return &backend.TimeSeriesResult{Points: points}, nil
+		// Thanos and other federating queriers return what they could
+		// gather, with a warning per store that failed
+		return &backend.TimeSeriesResult{Points: points, Histograms: histograms, Warnings: warnings}, nil
 	default:
 		return nil, fmt.Errorf("unsupported result type for range query: %v", result.Type())
 	}
 }
 
+// classify returns the typed backend error for the error types of the
+// Prometheus API
+func classify(err error) error {
+	var apiErr *v1.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Type {
+	case v1.ErrBadData:
+		return &backend.SyntaxError{Err: err}
+	case v1.ErrTimeout:
+		return &backend.TimeoutError{Err: err}
+	}
+	return err
+}
+
+// convertHistogram converts a native histogram sample of a series. The
+// boundaries of a bucket tell which of its bounds are inclusive: 0 the
+// upper, 1 the lower, 2 neither and 3 both.
+func convertHistogram(series string, sample model.SampleHistogramPair) backend.HistogramPoint {
+	h := backend.HistogramPoint{
+		Timestamp: sample.Timestamp.Time(),
+		Series:    series,
+	}
+	if sample.Histogram == nil {
+		return h
+	}
+	h.Count = float64(sample.Histogram.Count)
+	h.Sum = float64(sample.Histogram.Sum)
+	for _, b := range sample.Histogram.Buckets {
+		h.Buckets = append(h.Buckets, backend.Bucket{
+			Lower:          float64(b.Lower),
+			Upper:          float64(b.Upper),
+			LowerInclusive: b.Boundaries == 1 || b.Boundaries == 3,
+			UpperInclusive: b.Boundaries == 0 || b.Boundaries == 3,
+			Count:          float64(b.Count),
+		})
+	}
+	return h
+}
+
+// MetricNames returns the names of the metrics with series in the last hour
+func (c *Client) MetricNames(ctx context.Context) ([]string, error) {
+	return c.LabelValues(ctx, model.MetricNameLabel, "")
+}
+
+// LabelValues returns the values of a label in the last hour, limited to the
+// series of metric unless it is empty
+func (c *Client) LabelValues(ctx context.Context, label, metric string) ([]string, error) {
+	var matches []string
+	if metric != "" {
+		matches = []string{metric}
+	}
+
+	end := time.Now()
+	values, _, err := c.api.LabelValues(ctx, label, matches, end.Add(-time.Hour), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s values: %w", label, err)
+	}
+
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = string(v)
+	}
+	return result, nil
+}
+
 // Close closes the connection (no-op for Prometheus client)
 func (c *Client) Close() error {
 	// Prometheus client doesn't require explicit closing
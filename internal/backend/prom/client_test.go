@@ -2,11 +2,16 @@ package prom
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"promviz/internal/backend"
 )
 
 func TestConfigGetURL(t *testing.T) {
@@ -211,6 +216,64 @@ func TestClientQueryMatrix(t *testing.T) {
 	if secondValue != expectedSecond {
 		t.Errorf("Expected second value %f, got %f", expectedSecond, secondValue)
 	}
+
+	// Points are labeled with their series
+	if series := timeSeries.Points[0].Series; series != "cpu_usage" {
+		t.Errorf("Expected series cpu_usage, got %q", series)
+	}
+}
+
+func TestClientQueryNativeHistogram(t *testing.T) {
+	mockResponse := `{
+		"status": "success",
+		"data": {
+			"resultType": "matrix",
+			"result": [
+				{
+					"metric": {"__name__": "http_request_duration_seconds", "job": "api"},
+					"histograms": [
+						[1609459200, {"count": "12", "sum": "3.5", "buckets": [
+							[0, "0", "0.25", "8"],
+							[0, "0.25", "0.5", "3"],
+							[3, "1", "1", "1"]
+						]}]
+					]
+				}
+			]
+		}
+	}`
+
+	server := createMockPrometheusServer(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	result, err := client.QueryTimeSeries(context.Background(), "http_request_duration_seconds")
+	if err != nil {
+		t.Fatalf("QueryTimeSeries should not return error, got %v", err)
+	}
+
+	if len(result.Points) != 0 {
+		t.Errorf("Histogram samples should not be flattened into points, got %v", result.Points)
+	}
+	if len(result.Histograms) != 1 {
+		t.Fatalf("Expected 1 histogram sample, got %d", len(result.Histograms))
+	}
+	h := result.Histograms[0]
+	if h.Count != 12 || h.Sum != 3.5 || h.Series != `http_request_duration_seconds{job="api"}` {
+		t.Errorf("Unexpected histogram sample %+v", h)
+	}
+	if len(h.Buckets) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(h.Buckets))
+	}
+	if b := h.Buckets[0]; b.Lower != 0 || b.Upper != 0.25 || b.Count != 8 || b.LowerInclusive || !b.UpperInclusive {
+		t.Errorf("Unexpected first bucket %+v", b)
+	}
+	if b := h.Buckets[2]; !b.LowerInclusive || !b.UpperInclusive {
+		t.Errorf("Expected a bucket closed on both ends, got %+v", b)
+	}
 }
 
 func TestClientQueryMatrix2(t *testing.T) {
@@ -263,6 +326,45 @@ func TestClientQueryMatrix2(t *testing.T) {
 	}
 }
 
+func TestClientQueryPartialResponse(t *testing.T) {
+	// Thanos returns the series of the stores it reached with a warning for
+	// each one it didn't
+	mockResponse := `{
+		"status": "success",
+		"warnings": ["receive-2: context deadline exceeded"],
+		"data": {
+			"resultType": "matrix",
+			"result": [
+				{
+					"metric": {"__name__": "up", "store": "receive-1"},
+					"values": [
+						[1609459200, "1"]
+					]
+				}
+			]
+		}
+	}`
+
+	server := createMockPrometheusServer(mockResponse, http.StatusOK)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	timeSeries, err := client.QueryTimeSeries(context.Background(), "up")
+	if err != nil {
+		t.Fatalf("A partial response should not be an error, got %v", err)
+	}
+	if len(timeSeries.Points) != 1 {
+		t.Errorf("Expected the series that was returned, got %d points", len(timeSeries.Points))
+	}
+	if len(timeSeries.Warnings) != 1 || timeSeries.Warnings[0] != "receive-2: context deadline exceeded" {
+		t.Errorf("Expected the warning in the result, got %v", timeSeries.Warnings)
+	}
+}
+
 func TestClientQueryEmptyMatrix(t *testing.T) {
 	// Mock empty matrix response
 	mockResponse := `{
@@ -327,6 +429,32 @@ func TestClientQueryError(t *testing.T) {
 	if !strings.Contains(err.Error(), "query failed") {
 		t.Errorf("Error should mention query failure, got: %v", err)
 	}
+
+	var syntaxErr *backend.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a bad_data error to be a SyntaxError, got %T", err)
+	}
+}
+
+func TestClientQueryAuthError(t *testing.T) {
+	server := createMockPrometheusServer("Unauthorized", http.StatusUnauthorized)
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	_, err = client.QueryTimeSeries(context.Background(), "up")
+	var authErr *backend.AuthError
+	if !errors.As(err, &authErr) {
+		t.Errorf("Expected an AuthError for 401, got %v", err)
+	}
+
+	err = client.Connect(context.Background())
+	if !errors.As(err, &authErr) {
+		t.Errorf("Expected Connect to return an AuthError for 401, got %v", err)
+	}
 }
 
 func TestClientQueryUnsupportedType(t *testing.T) {
@@ -360,3 +488,80 @@ func TestClientQueryUnsupportedType(t *testing.T) {
 		t.Errorf("Error should mention query failure, got: %v", err)
 	}
 }
+
+func TestClientTransportReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	config := &Config{
+		URL:       server.URL,
+		Transport: &backend.TransportConfig{MaxIdleConns: 4, IdleConnTimeout: time.Minute},
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := client.Connect(context.Background()); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 1 {
+		t.Errorf("Expected sequential requests to share one connection, got %d", connections)
+	}
+}
+
+func TestClientLabelValues(t *testing.T) {
+	var gotPath, gotMatch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		r.ParseForm()
+		gotMatch = r.Form.Get("match[]")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "success", "data": ["node", "node-edge"]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	var _ backend.Explorer = client
+
+	values, err := client.LabelValues(context.Background(), "job", "node_cpu_seconds_total")
+	if err != nil {
+		t.Fatalf("LabelValues failed: %v", err)
+	}
+	if gotPath != "/api/v1/label/job/values" || gotMatch != "node_cpu_seconds_total" {
+		t.Errorf("Unexpected request %s with match %q", gotPath, gotMatch)
+	}
+	if len(values) != 2 || values[0] != "node" {
+		t.Errorf("Unexpected values %v", values)
+	}
+
+	if _, err := client.MetricNames(context.Background()); err != nil {
+		t.Fatalf("MetricNames failed: %v", err)
+	}
+	if gotPath != "/api/v1/label/__name__/values" || gotMatch != "" {
+		t.Errorf("Unexpected request %s with match %q", gotPath, gotMatch)
+	}
+}
@@ -0,0 +1,179 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// promqlWords are the keywords and aggregation operators of PromQL, which
+// look like metric names but aren't
+var promqlWords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+	"offset": true, "bool": true, "and": true, "or": true, "unless": true, "atan2": true,
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+	"limitk": true, "limit_ratio": true, "inf": true, "nan": true,
+}
+
+// labelListWords are followed by a list of label names rather than an
+// expression
+var labelListWords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// familySuffixes are the suffixes of the series of a metric family, such
+// as the buckets of a histogram, which metadata is not kept under
+var familySuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created"}
+
+// scanExpr returns the metric names a PromQL expression selects, in order,
+// and whether it does arithmetic outside of label matchers, durations and
+// strings
+func scanExpr(expr string) (names []string, arithmetic bool) {
+	isIdentStart := func(c byte) bool { return c == '_' || c == ':' || (c|0x20) >= 'a' && (c|0x20) <= 'z' }
+	isIdent := func(c byte) bool { return isIdentStart(c) || c >= '0' && c <= '9' }
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+
+	// skip returns the index after the group opened at i, skipping strings
+	skip := func(i int, open, close byte) int {
+		depth := 0
+		for ; i < len(expr); i++ {
+			switch c := expr[i]; {
+			case c == '"' || c == '\'' || c == '`':
+				i = skipString(expr, i) - 1
+			case c == open:
+				depth++
+			case c == close:
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return i
+	}
+	next := func(i int) int {
+		for i < len(expr) && (expr[i] == ' ' || expr[i] == '\t' || expr[i] == '\n') {
+			i++
+		}
+		return i
+	}
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipString(expr, i)
+		case c == '{':
+			i = skip(i, '{', '}')
+		case c == '[':
+			i = skip(i, '[', ']')
+		case c == '#':
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case isDigit(c) || c == '.' && i+1 < len(expr) && isDigit(expr[i+1]):
+			// Numbers and durations such as 1e3, 0x1f or 5m
+			for i < len(expr) && (isIdent(expr[i]) || expr[i] == '.') {
+				i++
+			}
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && isIdent(expr[i]) {
+				i++
+			}
+			word := expr[start:i]
+			j := next(i)
+			switch {
+			case labelListWords[strings.ToLower(word)] && j < len(expr) && expr[j] == '(':
+				i = skip(j, '(', ')')
+			case promqlWords[strings.ToLower(word)] || j < len(expr) && expr[j] == '(':
+				// Keywords and function names
+			default:
+				names = append(names, word)
+			}
+		default:
+			if strings.IndexByte("+-*/%^", c) >= 0 {
+				arithmetic = true
+			}
+			i++
+		}
+	}
+	return names, arithmetic
+}
+
+// skipString returns the index after the string literal starting at i
+func skipString(expr string, i int) int {
+	quote := expr[i]
+	for i++; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return i
+}
+
+// Describe implements backend.Describer with the metadata Prometheus keeps
+// of the first metric expr selects. The unit is only given for expressions
+// of a single metric without arithmetic, which could change it, and is
+// per second for rates other than of histogram buckets.
+func (c *Client) Describe(ctx context.Context, expr string) (backend.Metadata, error) {
+	names, arithmetic := scanExpr(expr)
+	if len(names) == 0 {
+		return backend.Metadata{}, nil
+	}
+
+	// Series such as the buckets of a histogram carry the metadata of
+	// their family
+	name := names[0]
+	candidates := []string{name}
+	for _, suffix := range familySuffixes {
+		if family := strings.TrimSuffix(name, suffix); family != name {
+			candidates = append(candidates, family)
+		}
+	}
+
+	var md backend.Metadata
+	family := name
+	for _, candidate := range candidates {
+		metadata, err := c.api.Metadata(ctx, candidate, "")
+		if err != nil {
+			return backend.Metadata{}, classify(fmt.Errorf("failed to get metadata of %s: %w", candidate, err))
+		}
+		if entries := metadata[candidate]; len(entries) > 0 {
+			md = backend.Metadata{Type: string(entries[0].Type), Help: entries[0].Help, Unit: entries[0].Unit}
+			family = candidate
+			break
+		}
+	}
+
+	// Only the units panels can format are kept
+	if unit := backend.UnitFromName(md.Unit); unit != "" {
+		md.Unit = unit
+	} else {
+		md.Unit = backend.UnitFromName(family)
+	}
+	if distinct(names) > 1 || arithmetic {
+		md.Unit = ""
+	}
+	if md.Unit != "" && strings.Contains(expr, "rate(") && !strings.Contains(expr, "histogram_quantile(") {
+		md.Unit += backend.PerSecond
+	}
+	return md, nil
+}
+
+// distinct counts the different names
+func distinct(names []string) int {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+	return len(seen)
+}
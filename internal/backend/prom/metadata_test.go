@@ -0,0 +1,84 @@
+package prom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestScanExpr(t *testing.T) {
+	tests := []struct {
+		expr       string
+		names      []string
+		arithmetic bool
+	}{
+		{"up", []string{"up"}, false},
+		{`rate(node_network_receive_bytes_total{device="eth0"}[5m])`, []string{"node_network_receive_bytes_total"}, false},
+		{`sum by (job) (rate(http_requests_total{code=~"5.."}[1m] offset 5m))`, []string{"http_requests_total"}, false},
+		{`histogram_quantile(0.99, sum without (instance) (rate(req_seconds_bucket[5m])))`, []string{"req_seconds_bucket"}, false},
+		{`node_memory_MemTotal_bytes - node_memory_MemFree_bytes`, []string{"node_memory_MemTotal_bytes", "node_memory_MemFree_bytes"}, true},
+		{`errors_total{path="/a-b"} * 100`, []string{"errors_total"}, true},
+		{`{__name__="up"}`, nil, false},
+		{`max_over_time(temp_celsius[1h:5m]) # hottest`, []string{"temp_celsius"}, false},
+	}
+	for _, tt := range tests {
+		names, arithmetic := scanExpr(tt.expr)
+		if !reflect.DeepEqual(names, tt.names) || arithmetic != tt.arithmetic {
+			t.Errorf("scanExpr(%q) = %v, %v; want %v, %v", tt.expr, names, arithmetic, tt.names, tt.arithmetic)
+		}
+	}
+}
+
+func TestClientDescribe(t *testing.T) {
+	var asked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metric := r.URL.Query().Get("metric")
+		asked = append(asked, metric)
+		w.Header().Set("Content-Type", "application/json")
+		switch metric {
+		case "node_network_receive_bytes_total":
+			w.Write([]byte(`{"status":"success","data":{"node_network_receive_bytes_total":[{"type":"counter","help":"Network device statistic receive_bytes.","unit":""}]}}`))
+		case "req_seconds":
+			w.Write([]byte(`{"status":"success","data":{"req_seconds":[{"type":"histogram","help":"Request latency.","unit":"seconds"}]}}`))
+		default:
+			w.Write([]byte(`{"status":"success","data":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var _ backend.Describer = client
+
+	tests := []struct {
+		expr string
+		want backend.Metadata
+	}{
+		{"rate(node_network_receive_bytes_total[5m])",
+			backend.Metadata{Type: "counter", Help: "Network device statistic receive_bytes.", Unit: "bytes/s"}},
+		{"histogram_quantile(0.9, rate(req_seconds_bucket[5m]))",
+			backend.Metadata{Type: "histogram", Help: "Request latency.", Unit: "seconds"}},
+		{"node_network_receive_bytes_total * 8",
+			backend.Metadata{Type: "counter", Help: "Network device statistic receive_bytes."}},
+		{"process_resident_memory_bytes", backend.Metadata{Unit: "bytes"}},
+		{"vector(1)", backend.Metadata{}},
+	}
+	for _, tt := range tests {
+		md, err := client.Describe(context.Background(), tt.expr)
+		if err != nil {
+			t.Fatalf("Describe(%q) failed: %v", tt.expr, err)
+		}
+		if md != tt.want {
+			t.Errorf("Describe(%q) = %+v, want %+v", tt.expr, md, tt.want)
+		}
+	}
+	if want := []string{"req_seconds_bucket", "req_seconds"}; !reflect.DeepEqual(asked[1:3], want) {
+		t.Errorf("Expected the histogram family looked up after the bucket series, got %v", asked)
+	}
+}
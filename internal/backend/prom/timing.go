@@ -0,0 +1,65 @@
+package prom
+
+import (
+	"strings"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Timing describes how a PromQL expression places itself in time, on top
+// of the range the dashboard evaluates it over
+type Timing struct {
+	At       bool          // an @ modifier pins the evaluation time
+	Offset   bool          // an offset modifier shifts the evaluation
+	Subquery time.Duration // range of the longest subquery, such as 1h for [1h:5m]
+}
+
+// ExprTiming finds the @ and offset modifiers and the subqueries of a
+// PromQL expression, skipping strings, label matchers and comments
+func ExprTiming(expr string) Timing {
+	var timing Timing
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipString(expr, i)
+		case c == '{':
+			for i < len(expr) && expr[i] != '}' {
+				if q := expr[i]; q == '"' || q == '\'' || q == '`' {
+					i = skipString(expr, i)
+					continue
+				}
+				i++
+			}
+		case c == '#':
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case c == '@':
+			timing.At = true
+			i++
+		case c == '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return timing
+			}
+			rng, _, subquery := strings.Cut(expr[i+1:i+end], ":")
+			if d, err := backend.ParseDuration(strings.TrimSpace(rng)); subquery && err == nil && d > timing.Subquery {
+				timing.Subquery = d
+			}
+			i += end + 1
+		case c == '_' || (c|0x20) >= 'a' && (c|0x20) <= 'z':
+			start := i
+			for i < len(expr) && (expr[i] == '_' || expr[i] == ':' || (expr[i]|0x20) >= 'a' && (expr[i]|0x20) <= 'z' || expr[i] >= '0' && expr[i] <= '9') {
+				i++
+			}
+			if strings.EqualFold(expr[start:i], "offset") {
+				timing.Offset = true
+			}
+		default:
+			i++
+		}
+	}
+	return timing
+}
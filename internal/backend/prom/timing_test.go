@@ -0,0 +1,27 @@
+package prom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExprTiming(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Timing
+	}{
+		{`rate(http_requests_total[5m])`, Timing{}},
+		{`rate(http_requests_total[5m] @ end())`, Timing{At: true}},
+		{`sum(up offset 1h)`, Timing{Offset: true}},
+		{`max_over_time(rate(errors_total[5m])[1h:1m])`, Timing{Subquery: time.Hour}},
+		{`max_over_time(x[30m:]) + max_over_time(y[2h:5m] OFFSET 1d)`, Timing{Offset: true, Subquery: 2 * time.Hour}},
+		{`up{path="/a@b", offset="1h"}`, Timing{}},
+		{`offset_seconds + label_replace(up, "x", "@[1h:]", "", "")`, Timing{}},
+		{"up # offset 1h @ 0\n", Timing{}},
+	}
+	for _, tt := range tests {
+		if got := ExprTiming(tt.expr); got != tt.want {
+			t.Errorf("ExprTiming(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package backend
+
+import "context"
+
+// rawKey is the context key of WithRaw
+type rawKey struct{}
+
+// WithRaw returns a context telling backends whether to send the query
+// expressions run under it verbatim. Backends that wrap simple expressions
+// into full queries, like the InfluxDB ones, otherwise guess whether an
+// expression already is one.
+func WithRaw(ctx context.Context, raw bool) context.Context {
+	return context.WithValue(ctx, rawKey{}, raw)
+}
+
+// IsRaw reports whether query expressions run under ctx are sent verbatim
+func IsRaw(ctx context.Context) bool {
+	raw, _ := ctx.Value(rawKey{}).(bool)
+	return raw
+}
@@ -0,0 +1,19 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithRaw(t *testing.T) {
+	ctx := context.Background()
+	if IsRaw(ctx) {
+		t.Error("Expressions should be wrapped unless asked otherwise")
+	}
+	if !IsRaw(WithRaw(ctx, true)) {
+		t.Error("Expected raw expressions under WithRaw(true)")
+	}
+	if IsRaw(WithRaw(WithRaw(ctx, true), false)) {
+		t.Error("Expected the innermost WithRaw to win")
+	}
+}
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NameLabel is the label ParseSeriesName reports the series name under
+const NameLabel = "__name__"
+
+// SeriesName formats a series identity like Prometheus does, e.g.
+// `cpu{host="a",region="eu"}`, with the labels sorted by name
+func SeriesName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// ParseSeriesName returns the labels of a series identity as formatted by
+// SeriesName, with the name under NameLabel. Malformed label pairs are
+// skipped.
+func ParseSeriesName(series string) map[string]string {
+	labels := make(map[string]string)
+
+	name, rest, found := strings.Cut(series, "{")
+	if name != "" {
+		labels[NameLabel] = name
+	}
+	if !found {
+		return labels
+	}
+	rest = strings.TrimSuffix(rest, "}")
+
+	for rest != "" {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			break
+		}
+		if unquoted, err := strconv.Unquote(quoted); err == nil {
+			labels[strings.TrimSpace(key)] = unquoted
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(value[len(quoted):]), ",")
+		rest = strings.TrimSpace(rest)
+	}
+	return labels
+}
+
+// WithLabel returns the points with label set to value on their series
+func WithLabel(points []DataPoint, label, value string) []DataPoint {
+	names := make(map[string]string)
+	labeled := make([]DataPoint, len(points))
+	for i, p := range points {
+		series, ok := names[p.Series]
+		if !ok {
+			labels := ParseSeriesName(p.Series)
+			name := labels[NameLabel]
+			delete(labels, NameLabel)
+			labels[label] = value
+			series = SeriesName(name, labels)
+			names[p.Series] = series
+		}
+		p.Series = series
+		labeled[i] = p
+	}
+	return labeled
+}
+
+// MatchSeries returns the points of the series carrying all the labels
+func MatchSeries(points []DataPoint, match map[string]string) []DataPoint {
+	if len(match) == 0 {
+		return points
+	}
+
+	matched := make(map[string]bool)
+	var result []DataPoint
+	for _, p := range points {
+		ok, seen := matched[p.Series]
+		if !seen {
+			ok = hasLabels(p.Series, match)
+			matched[p.Series] = ok
+		}
+		if ok {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// hasLabels reports whether the series carries all the labels
+func hasLabels(series string, match map[string]string) bool {
+	labels := ParseSeriesName(series)
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"testing"
+)
+
+func TestSeriesName(t *testing.T) {
+	if got := SeriesName("cpu", nil); got != "cpu" {
+		t.Errorf("Expected bare name without labels, got %q", got)
+	}
+	got := SeriesName("cpu", map[string]string{"region": "eu", "host": "a"})
+	if got != `cpu{host="a",region="eu"}` {
+		t.Errorf("Expected sorted labels, got %q", got)
+	}
+}
+
+func TestParseSeriesName(t *testing.T) {
+	tests := []struct {
+		series   string
+		expected map[string]string
+	}{
+		{"cpu", map[string]string{NameLabel: "cpu"}},
+		{`{instance="a:9100", job="node"}`, map[string]string{"instance": "a:9100", "job": "node"}},
+		{`up{path="/a,b=\"c\""}`, map[string]string{NameLabel: "up", "path": `/a,b="c"`}},
+		{"", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		got := ParseSeriesName(tt.series)
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.series, tt.expected, got)
+			continue
+		}
+		for k, v := range tt.expected {
+			if got[k] != v {
+				t.Errorf("%s: expected %s=%q, got %q", tt.series, k, v, got[k])
+			}
+		}
+	}
+
+	// Round trip
+	labels := map[string]string{"host": "web-1", "region": "eu"}
+	got := ParseSeriesName(SeriesName("cpu", labels))
+	if got["host"] != "web-1" || got["region"] != "eu" || got[NameLabel] != "cpu" {
+		t.Errorf("Round trip failed, got %v", got)
+	}
+}
+
+func TestMatchSeries(t *testing.T) {
+	points := []DataPoint{
+		{Value: 1, Series: `up{instance="a"}`},
+		{Value: 2, Series: `up{instance="b"}`},
+		{Value: 3, Series: `up{instance="a"}`},
+	}
+
+	got := MatchSeries(points, map[string]string{"instance": "a"})
+	if len(got) != 2 || got[0].Value != 1 || got[1].Value != 3 {
+		t.Errorf("Expected the points of instance a, got %v", got)
+	}
+	if got := MatchSeries(points, nil); len(got) != 3 {
+		t.Errorf("No match should keep all points, got %v", got)
+	}
+}
+
+func TestWithLabel(t *testing.T) {
+	points := []DataPoint{
+		{Value: 1, Series: `up{job="api"}`},
+		{Value: 2, Series: ""},
+	}
+	labeled := WithLabel(points, "check", "up")
+	if labeled[0].Series != `up{check="up",job="api"}` || labeled[0].Value != 1 {
+		t.Errorf("Expected the label added to the others, got %+v", labeled[0])
+	}
+	if labeled[1].Series != `{check="up"}` {
+		t.Errorf("Expected an unnamed series labeled, got %+v", labeled[1])
+	}
+	if points[0].Series != `up{job="api"}` {
+		t.Error("The original points must not be modified")
+	}
+}
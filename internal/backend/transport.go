@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes connection reuse for HTTP based backends. Zero
+// values keep the Go defaults.
+type TransportConfig struct {
+	MaxIdleConns    int           `yaml:"max_idle_conns,omitempty"`    // idle connections kept per server
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout,omitempty"` // e.g. "5m"
+	HTTP2           *bool         `yaml:"http2,omitempty"`             // defaults to true
+	KeepAlive       time.Duration `yaml:"keep_alive,omitempty"`        // TCP keep-alive period, e.g. "30s"
+	Compression     *bool         `yaml:"compression,omitempty"`       // request gzip responses, defaults to true
+}
+
+// NewTransport builds an HTTP transport from the settings. A nil config
+// returns the default transport.
+func (c *TransportConfig) NewTransport() http.RoundTripper {
+	if c == nil {
+		return http.DefaultTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.MaxIdleConns > 0 {
+		// All requests go to a single server, so the per-host limit is the one that matters
+		transport.MaxIdleConns = c.MaxIdleConns
+		transport.MaxIdleConnsPerHost = c.MaxIdleConns
+	}
+	if c.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.KeepAlive > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: c.KeepAlive}
+		transport.DialContext = dialer.DialContext
+	}
+	if c.Compression != nil {
+		// Go requests and decodes gzip transparently unless compression is disabled
+		transport.DisableCompression = !*c.Compression
+	}
+	if c.HTTP2 != nil && !*c.HTTP2 {
+		// A non-nil empty map disables the HTTP/2 upgrade
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewTransportDefault(t *testing.T) {
+	var c *TransportConfig
+	if c.NewTransport() != http.DefaultTransport {
+		t.Error("Nil config should use the default transport")
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	http2 := false
+	c := &TransportConfig{
+		MaxIdleConns:    20,
+		IdleConnTimeout: 5 * time.Minute,
+		HTTP2:           &http2,
+		KeepAlive:       15 * time.Second,
+	}
+
+	transport, ok := c.NewTransport().(*http.Transport)
+	if !ok {
+		t.Fatal("Expected an *http.Transport")
+	}
+
+	if transport.MaxIdleConns != 20 || transport.MaxIdleConnsPerHost != 20 {
+		t.Errorf("Expected 20 idle connections, got %d/%d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 5*time.Minute {
+		t.Errorf("Expected idle timeout 5m, got %v", transport.IdleConnTimeout)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("HTTP/2 should be disabled")
+	}
+	if transport.DialContext == nil {
+		t.Error("Expected a dialer with keep-alive")
+	}
+
+	if http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost == 20 {
+		t.Error("The default transport must not be modified")
+	}
+}
+
+func TestNewTransportCompression(t *testing.T) {
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"status":"success"}`))
+		gz.Close()
+	}))
+	defer server.Close()
+
+	enabled, disabled := true, false
+	tests := []struct {
+		name     string
+		enabled  *bool
+		expected string
+	}{
+		{"default", nil, "gzip"},
+		{"enabled", &enabled, "gzip"},
+		{"disabled", &disabled, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &TransportConfig{Compression: tt.enabled}
+			client := &http.Client{Transport: c.NewTransport()}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if acceptEncoding != tt.expected {
+				t.Errorf("Expected Accept-Encoding %q, got %q", tt.expected, acceptEncoding)
+			}
+			if tt.expected == "gzip" && string(body) != `{"status":"success"}` {
+				t.Errorf("Compressed response should be decoded, got %q", body)
+			}
+		})
+	}
+}
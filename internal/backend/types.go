@@ -2,24 +2,463 @@ package backend
 
 import (
 	"context"
+	"sort"
 	"time"
+
+	"github.com/prometheus/common/model"
+
+	"promviz/internal/schedule"
 )
 
 // DataPoint represents a single metric data point
 type DataPoint struct {
 	Timestamp time.Time `json:"timestamp"`
 	Value     float64   `json:"value"`
+	Series    string    `json:"series,omitempty"` // identifies the series in a multi-series result
 }
 
 // TimeSeriesResult represents a time series of metric data points
 type TimeSeriesResult struct {
-	Points []DataPoint `json:"points"`
+	Points     []DataPoint      `json:"points"`
+	Histograms []HistogramPoint `json:"histograms,omitempty"` // native histogram samples, kept apart from the flat points
+	Truncation *Truncation      `json:"truncation,omitempty"` // set when Limit dropped part of the result
+	Warnings   []string         `json:"warnings,omitempty"`   // parts of the query that failed, e.g. unreachable stores of a partial response
+}
+
+// TimeRange describes the window and resolution of a range query
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+// DefaultTimeRange returns the last 5 minutes at 1-minute resolution
+func DefaultTimeRange() TimeRange {
+	return LastTimeRange(5 * time.Minute)
+}
+
+// LastTimeRange returns a range covering the given duration up to now.
+// The step is chosen to yield roughly 60 points, but never less than a minute.
+func LastTimeRange(d time.Duration) TimeRange {
+	return RangeEndingAt(d, time.Now())
+}
+
+// RangeEndingAt is like LastTimeRange but ends at the given time
+func RangeEndingAt(d time.Duration, end time.Time) TimeRange {
+	step := d / 60
+	if step < time.Minute {
+		step = time.Minute
+	}
+	return TimeRange{Start: end.Add(-d), End: end, Step: step}
+}
+
+// FixedRange returns the range from start to end, with the step chosen as
+// for LastTimeRange
+func FixedRange(start, end time.Time) TimeRange {
+	return RangeEndingAt(end.Sub(start), end)
+}
+
+// ParseDuration parses a Prometheus-style duration such as "5m", "30d" or "1w"
+func ParseDuration(s string) (time.Duration, error) {
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
+
+// Panel types supported by a query
+const (
+	PanelGraph     = "graph"
+	PanelSLO       = "slo"
+	PanelJoin      = "join"
+	PanelBool      = "bool"
+	PanelHealth    = "health"
+	PanelCalendar  = "calendar"
+	PanelHistogram = "histogram"
+	PanelBurnRate  = "burn_rate"
+	PanelStatus    = "status"
+)
+
+// How a graph panel evaluates its expression over the range
+const (
+	EvaluationRange            = "range"              // at every step of the range
+	EvaluationInstantOverRange = "instant_over_range" // once, at the end of the range
+)
+
+// Calendar buckets and how their values are combined
+const (
+	BucketDay  = "day"
+	BucketHour = "hour"
+
+	AggregateAvg = "avg"
+	AggregateSum = "sum"
+	AggregateMin = "min"
+	AggregateMax = "max"
+)
+
+// CalendarConfig sets how a calendar panel buckets its metric
+type CalendarConfig struct {
+	Bucket    string `yaml:"bucket,omitempty"`    // "day" (default) or "hour"
+	Aggregate string `yaml:"aggregate,omitempty"` // "avg" (default), "sum", "min" or "max"
+}
+
+// BucketSize returns the bucket of a calendar, a day by default
+func (c *CalendarConfig) BucketSize() string {
+	if c == nil || c.Bucket == "" {
+		return BucketDay
+	}
+	return c.Bucket
+}
+
+// Combine returns how values in a bucket are combined, averaged by default
+func (c *CalendarConfig) Combine() string {
+	if c == nil || c.Aggregate == "" {
+		return AggregateAvg
+	}
+	return c.Aggregate
+}
+
+// defaultRange returns how far a calendar looks back without a range
+func (c *CalendarConfig) defaultRange() time.Duration {
+	if c.BucketSize() == BucketHour {
+		return 7 * 24 * time.Hour
+	}
+	return 28 * 24 * time.Hour
+}
+
+// resolution returns the step a calendar's buckets are filled from
+func (c *CalendarConfig) resolution() time.Duration {
+	if c.BucketSize() == BucketHour {
+		return 5 * time.Minute
+	}
+	return time.Hour
+}
+
+// HistogramConfig sets the quantiles a histogram panel plots
+type HistogramConfig struct {
+	Quantiles []float64 `yaml:"quantiles,omitempty"` // in percent, defaults to DefaultQuantiles
+}
+
+// Levels returns the configured quantiles, or DefaultQuantiles
+func (h *HistogramConfig) Levels() []float64 {
+	if h == nil || len(h.Quantiles) == 0 {
+		return DefaultQuantiles
+	}
+	return h.Quantiles
+}
+
+// HealthConfig selects what a health panel reports on
+type HealthConfig struct {
+	Buckets []string `yaml:"buckets,omitempty"` // buckets to count series of, defaults to the backend's bucket
+}
+
+// SLOConfig describes a service level objective computed from a good/total query pair
+type SLOConfig struct {
+	Good      string  `yaml:"good"`
+	Total     string  `yaml:"total"`
+	Objective float64 `yaml:"objective"` // target in percent, e.g. 99.9
+	Window    string  `yaml:"window"`    // e.g. "30d", defaults to DefaultSLOWindow
+}
+
+// DefaultSLOWindow is the window of an SLO without one
+const DefaultSLOWindow = "30d"
+
+// WindowOrDefault returns the window of the SLO, or DefaultSLOWindow if unset
+func (c *SLOConfig) WindowOrDefault() string {
+	if c.Window == "" {
+		return DefaultSLOWindow
+	}
+	return c.Window
+}
+
+// BurnRateConfig describes the multi-window burn rate alerts of a service
+// level objective, computed from one error ratio expression
+type BurnRateConfig struct {
+	ErrorRatio string  `yaml:"error_ratio"` // failed over total events, with $window as the range
+	Objective  float64 `yaml:"objective"`   // target in percent, e.g. 99.9
+}
+
+// StatusCheckLabel is the label the series of a status panel carry the
+// name of their check under
+const StatusCheckLabel = "check"
+
+// StatusConfig lists the checks of a status panel, shown as a matrix with a
+// row per value of the By label and a column per check
+type StatusConfig struct {
+	By     string        `yaml:"by"` // label naming the rows, e.g. "service"
+	Checks []StatusCheck `yaml:"checks"`
+}
+
+// StatusCheck is a column of a status panel. A series passes while its
+// latest value is non-zero.
+type StatusCheck struct {
+	Name    string `yaml:"name"`
+	Expr    string `yaml:"expr"`
+	Backend string `yaml:"backend,omitempty"` // defaults to the backend of the query
+}
+
+// JoinConfig combines two queries, possibly on different backends, point by
+// point on the timestamps of the left series
+type JoinConfig struct {
+	Left          JoinSide `yaml:"left"`
+	Right         JoinSide `yaml:"right"`
+	Op            string   `yaml:"op"`                      // "add", "sub", "mul" or "div"
+	Interpolation string   `yaml:"interpolation,omitempty"` // "linear" (default), "previous" or "none"
+}
+
+// JoinSide is one operand of a join panel
+type JoinSide struct {
+	Expr    string `yaml:"expr"`
+	Backend string `yaml:"backend,omitempty"` // defaults to the backend of the query
+}
+
+// DefaultConnectTimeout bounds the startup connectivity check of a backend
+const DefaultConnectTimeout = 5 * time.Second
+
+// ClientOptions holds connection settings shared by the network backends
+type ClientOptions struct {
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"` // e.g. "2s", defaults to 5s
+}
+
+// Timeout returns the connect timeout, falling back to DefaultConnectTimeout
+func (o ClientOptions) Timeout() time.Duration {
+	if o.ConnectTimeout <= 0 {
+		return DefaultConnectTimeout
+	}
+	return o.ConnectTimeout
+}
+
+// CredentialKeychain is the credential store keeping secrets in the OS
+// keychain instead of the config file
+const CredentialKeychain = "keychain"
+
+// CredentialOptions says where the backends with a token or password find
+// it when it isn't written in the config
+type CredentialOptions struct {
+	CredentialStore string `yaml:"credential_store,omitempty"` // "keychain", or empty for the config itself
+	CredentialEntry string `yaml:"credential_entry,omitempty"` // keychain entry, defaults to the backend name
+}
+
+// UsesKeychain reports whether the credential is kept in the OS keychain
+func (o CredentialOptions) UsesKeychain() bool {
+	return o.CredentialStore == CredentialKeychain
+}
+
+// Entry returns the keychain entry of the credential of the named backend
+func (o CredentialOptions) Entry(backendName string) string {
+	if o.CredentialEntry != "" {
+		return o.CredentialEntry
+	}
+	return backendName
+}
+
+// Thresholds defines warning and critical levels for a query's latest value
+type Thresholds struct {
+	Warn  *float64 `yaml:"warn,omitempty"`
+	Crit  *float64 `yaml:"crit,omitempty"`
+	Below bool     `yaml:"below,omitempty"` // breach when the value drops below the levels
+}
+
+// DefaultExpandLimit caps the panels created by expand_by
+const DefaultExpandLimit = 20
+
+// DefaultQuantiles are the percentiles computed when none are configured
+var DefaultQuantiles = []float64{50, 90, 99}
+
+// Percentiles computes quantiles of a panel's values client-side, for
+// backends without native percentile functions
+type Percentiles struct {
+	Quantiles []float64 `yaml:"quantiles,omitempty"` // in percent, defaults to DefaultQuantiles
+	Window    string    `yaml:"window,omitempty"`    // rolling window, e.g. "10m"; the whole range if unset
+	Overlay   bool      `yaml:"overlay,omitempty"`   // draw the quantiles as lines over the graph
+}
+
+// Levels returns the configured quantiles, or DefaultQuantiles
+func (p *Percentiles) Levels() []float64 {
+	if len(p.Quantiles) == 0 {
+		return DefaultQuantiles
+	}
+	return p.Quantiles
+}
+
+// ConvertConfig converts the values of a panel before they are shown, from
+// one unit to another, by a factor, or both
+type ConvertConfig struct {
+	From     string  `yaml:"from,omitempty"`     // e.g. "bytes"
+	To       string  `yaml:"to,omitempty"`       // e.g. "GiB"
+	Multiply float64 `yaml:"multiply,omitempty"` // e.g. a price per unit
+	Divide   float64 `yaml:"divide,omitempty"`
+	Unit     string  `yaml:"unit,omitempty"` // the unit after multiply and divide, e.g. "USD"
+}
+
+// Target returns the unit of the converted values: unit, else to
+func (c *ConvertConfig) Target() string {
+	if c.Unit != "" {
+		return c.Unit
+	}
+	return c.To
+}
+
+// Statistics a panel's stats row can show
+const (
+	StatMin      = "min"
+	StatMax      = "max"
+	StatAvg      = "avg"
+	StatLast     = "last"
+	StatTimeAvg  = "time_avg" // mean weighted by the time each value covers
+	StatIntegral = "integral" // area under the series, e.g. kWh from kW
+)
+
+// StatsConfig selects the statistics shown under a panel's current value
+// and written to its exports
+type StatsConfig struct {
+	Show         []string `yaml:"show"`
+	IntegralUnit string   `yaml:"integral_unit,omitempty"` // time the integral is counted in, e.g. "1h"; defaults to "1s"
+}
+
+// Per returns the time the integral is counted in
+func (s *StatsConfig) Per() time.Duration {
+	if s.IntegralUnit == "" {
+		return time.Second
+	}
+	d, err := ParseDuration(s.IntegralUnit)
+	if err != nil || d <= 0 {
+		return time.Second
+	}
+	return d
 }
 
 // Query represents a named query configuration
 type Query struct {
-	Name string `yaml:"name"`
-	Expr string `yaml:"expr"`
+	ID         string     `yaml:"id,omitempty"` // stable identity, derived from name if unset
+	Name       string     `yaml:"name"`
+	Expr       string     `yaml:"expr"`
+	Backend    string     `yaml:"backend,omitempty"`    // overrides the top-level backend
+	Raw        *bool      `yaml:"raw,omitempty"`        // send expr verbatim, overrides the top-level raw
+	Type       string     `yaml:"type,omitempty"`       // "graph" (default), "slo", "join", "bool", "health", "calendar", "histogram", "burn_rate" or "status"
+	Range      string     `yaml:"range,omitempty"`      // e.g. "1h", defaults to 5m
+	Offset     string     `yaml:"offset,omitempty"`     // shift the range into the past, e.g. "1h"
+	MaxAge     string     `yaml:"max_age,omitempty"`    // newest point older than this marks the panel stale
+	Window     *TimeRange `yaml:"-"`                    // fixed range picked in the TUI, replaces range and offset
+	Evaluation string     `yaml:"evaluation,omitempty"` // "range" (default) or "instant_over_range"
+
+	ActiveHours *schedule.Hours `yaml:"active_hours,omitempty"` // when the panel is refreshed, overrides the top-level active_hours
+	SLO         *SLOConfig      `yaml:"slo,omitempty"`
+	Join        *JoinConfig     `yaml:"join,omitempty"`
+	Health      *HealthConfig   `yaml:"health,omitempty"`
+	Thresholds  *Thresholds     `yaml:"thresholds,omitempty"`
+
+	ValueMappings []ValueMapping `yaml:"value_mappings,omitempty"` // show these values as text
+
+	Percentiles *Percentiles `yaml:"percentiles,omitempty"`
+	Stats       *StatsConfig `yaml:"stats,omitempty"` // statistics shown under the current value
+	TopN        int          `yaml:"top_n,omitempty"` // only plot the N series with the highest current value
+
+	Calendar  *CalendarConfig  `yaml:"calendar,omitempty"`  // buckets of a calendar panel
+	Histogram *HistogramConfig `yaml:"histogram,omitempty"` // quantiles of a histogram panel
+	BurnRate  *BurnRateConfig  `yaml:"burn_rate,omitempty"` // error ratio of a burn rate panel
+	Status    *StatusConfig    `yaml:"status,omitempty"`    // checks of a status panel
+
+	ExpandBy    string            `yaml:"expand_by,omitempty"`    // create one panel per value of this label
+	ExpandLimit int               `yaml:"expand_limit,omitempty"` // most panels expand_by creates, defaults to DefaultExpandLimit
+	Match       map[string]string `yaml:"-"`                      // only plot series with these labels, set by expand_by
+
+	Description string         `yaml:"description,omitempty"` // shown in the details view
+	Unit        string         `yaml:"unit,omitempty"`        // e.g. "bytes", "seconds", "percent" or "req/s"
+	Convert     *ConvertConfig `yaml:"convert,omitempty"`     // applied to the values before anything else
+	Record      string         `yaml:"record,omitempty"`      // metric name the panel's series are written to derived sinks as
+	RunbookURL  string         `yaml:"runbook_url,omitempty"`
+	Tags        []string       `yaml:"tags,omitempty"` // e.g. "db" or "critical", to act on panels in bulk
+}
+
+// Tags returns the tags of queries, sorted
+func Tags(queries []Query) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, q := range queries {
+		for _, tag := range q.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// HasTag reports whether the query carries the tag
+func (q Query) HasTag(tag string) bool {
+	for _, t := range q.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// PanelType returns the panel type, defaulting to a graph
+func (q Query) PanelType() string {
+	if q.Type == "" {
+		return PanelGraph
+	}
+	return q.Type
+}
+
+// TimeRange returns the range to query for a graph panel
+func (q Query) TimeRange() TimeRange {
+	return q.TimeRangeAt(time.Now())
+}
+
+// TimeRangeAt returns the range of a graph panel queried at the given
+// time, ending offset before it. A fixed window doesn't move with time.
+// Calendar panels look back weeks by default and are fetched at a
+// resolution fine enough to fill their buckets.
+func (q Query) TimeRangeAt(now time.Time) TimeRange {
+	calendar := q.PanelType() == PanelCalendar
+	d := 5 * time.Minute
+	if calendar {
+		d = q.Calendar.defaultRange()
+	}
+	if q.Range != "" {
+		if parsed, err := ParseDuration(q.Range); err == nil {
+			d = parsed
+		}
+	}
+
+	tr := RangeEndingAt(d, now.Add(-q.Shift()))
+	if q.Window != nil {
+		tr = *q.Window
+	}
+	if calendar {
+		tr.Step = q.Calendar.resolution()
+	}
+	return tr
+}
+
+// Shift returns the offset of the query, or 0 if unset
+func (q Query) Shift() time.Duration {
+	if q.Offset == "" {
+		return 0
+	}
+	d, err := ParseDuration(q.Offset)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// Staleness returns the max_age of the query, or 0 if unset
+func (q Query) Staleness() time.Duration {
+	if q.MaxAge == "" {
+		return 0
+	}
+	d, err := ParseDuration(q.MaxAge)
+	if err != nil {
+		return 0
+	}
+	return d
 }
 
 // Backend defines the interface for metric data sources
@@ -27,9 +466,12 @@ type Backend interface {
 	// Connect establishes connection to the backend
 	Connect(ctx context.Context) error
 
-	// QueryTimeSeries executes a query and returns time series data
+	// QueryTimeSeries executes a query over the default time range and returns time series data
 	QueryTimeSeries(ctx context.Context, expr string) (*TimeSeriesResult, error)
 
+	// QueryRange executes a query over the given time range and returns time series data
+	QueryRange(ctx context.Context, expr string, tr TimeRange) (*TimeSeriesResult, error)
+
 	// Close closes the connection to the backend
 	Close() error
 
@@ -37,6 +479,25 @@ type Backend interface {
 	Name() string
 }
 
+// Explorer is implemented by backends that can list the metrics they store
+type Explorer interface {
+	// MetricNames returns the names of all metrics
+	MetricNames(ctx context.Context) ([]string, error)
+
+	// LabelValues returns the values of a label on the series of a metric
+	LabelValues(ctx context.Context, label, metric string) ([]string, error)
+}
+
+// Reauthenticator is implemented by backends whose credentials can be
+// replaced while they run, e.g. after a token expired
+type Reauthenticator interface {
+	// CredentialName says what the credential is, e.g. "token"
+	CredentialName() string
+
+	// SetCredential replaces the credential sent with every further query
+	SetCredential(secret string) error
+}
+
 // Config represents backend-specific configuration
 type Config interface {
 	GetURL() string
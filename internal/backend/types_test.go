@@ -2,6 +2,7 @@ package backend
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -63,6 +64,131 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+// TestQueryPanelType tests the default panel type
+func TestQueryPanelType(t *testing.T) {
+	if got := (Query{Name: "q", Expr: "e"}).PanelType(); got != PanelGraph {
+		t.Errorf("Expected default panel type '%s', got '%s'", PanelGraph, got)
+	}
+
+	if got := (Query{Name: "q", Type: PanelSLO}).PanelType(); got != PanelSLO {
+		t.Errorf("Expected panel type '%s', got '%s'", PanelSLO, got)
+	}
+}
+
+// TestQueryTags tests tag matching and collection
+func TestQueryTags(t *testing.T) {
+	queries := []Query{
+		{Name: "a", Tags: []string{"db", "critical"}},
+		{Name: "b"},
+		{Name: "c", Tags: []string{"critical", "api"}},
+	}
+
+	if !queries[0].HasTag("db") || queries[0].HasTag("api") || queries[1].HasTag("db") {
+		t.Error("HasTag should only match the query's own tags")
+	}
+
+	want := []string{"api", "critical", "db"}
+	if got := Tags(queries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected tags %v, got %v", want, got)
+	}
+	if got := Tags(queries[1:2]); len(got) != 0 {
+		t.Errorf("Expected no tags for untagged queries, got %v", got)
+	}
+}
+
+// TestLastTimeRange tests range and step calculation
+func TestLastTimeRange(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		step     time.Duration
+	}{
+		{5 * time.Minute, time.Minute},
+		{time.Hour, time.Minute},
+		{30 * 24 * time.Hour, 12 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		tr := LastTimeRange(tt.duration)
+		if tr.End.Sub(tr.Start) != tt.duration {
+			t.Errorf("Expected range of %v, got %v", tt.duration, tr.End.Sub(tr.Start))
+		}
+		if tr.Step != tt.step {
+			t.Errorf("Expected step %v for %v, got %v", tt.step, tt.duration, tr.Step)
+		}
+	}
+}
+
+// TestQueryTimeRange tests the per-query range
+func TestQueryTimeRange(t *testing.T) {
+	tr := Query{Name: "q", Expr: "e"}.TimeRange()
+	if tr.End.Sub(tr.Start) != 5*time.Minute {
+		t.Errorf("Expected default range of 5m, got %v", tr.End.Sub(tr.Start))
+	}
+
+	tr = Query{Name: "q", Expr: "e", Range: "6h"}.TimeRange()
+	if tr.End.Sub(tr.Start) != 6*time.Hour {
+		t.Errorf("Expected range of 6h, got %v", tr.End.Sub(tr.Start))
+	}
+	if tr.Step != 6*time.Minute {
+		t.Errorf("Expected step of 6m, got %v", tr.Step)
+	}
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr = Query{Name: "q", Expr: "e", Range: "1h"}.TimeRangeAt(end)
+	if !tr.End.Equal(end) || !tr.Start.Equal(end.Add(-time.Hour)) {
+		t.Errorf("Expected range ending at %v, got %v to %v", end, tr.Start, tr.End)
+	}
+
+	// An offset moves the whole range into the past
+	tr = Query{Name: "q", Expr: "e", Range: "1h", Offset: "1h"}.TimeRangeAt(end)
+	if !tr.End.Equal(end.Add(-time.Hour)) || !tr.Start.Equal(end.Add(-2*time.Hour)) {
+		t.Errorf("Expected range shifted back by 1h, got %v to %v", tr.Start, tr.End)
+	}
+	if tr.Step != time.Minute {
+		t.Errorf("Offset should not change the step, got %v", tr.Step)
+	}
+
+	// A fixed window replaces range and offset
+	window := FixedRange(end.Add(-48*time.Hour), end.Add(-24*time.Hour))
+	tr = Query{Name: "q", Expr: "e", Range: "1h", Offset: "1h", Window: &window}.TimeRangeAt(end)
+	if tr != window || tr.Step != 24*time.Minute {
+		t.Errorf("Expected the fixed window with a 24m step, got %v to %v every %v", tr.Start, tr.End, tr.Step)
+	}
+
+	// Calendar panels look back weeks and fill their buckets from a finer step
+	for _, tt := range []struct {
+		q     Query
+		span  time.Duration
+		step  time.Duration
+		about string
+	}{
+		{Query{Type: PanelCalendar}, 28 * 24 * time.Hour, time.Hour, "daily default"},
+		{Query{Type: PanelCalendar, Calendar: &CalendarConfig{Bucket: BucketHour}}, 7 * 24 * time.Hour, 5 * time.Minute, "hourly default"},
+		{Query{Type: PanelCalendar, Range: "8w"}, 8 * 7 * 24 * time.Hour, time.Hour, "configured range"},
+		{Query{Type: PanelCalendar, Window: &window}, 24 * time.Hour, time.Hour, "fixed window"},
+	} {
+		tr := tt.q.TimeRangeAt(end)
+		if tr.End.Sub(tr.Start) != tt.span || tr.Step != tt.step {
+			t.Errorf("%s: expected %v every %v, got %v every %v", tt.about, tt.span, tt.step, tr.End.Sub(tr.Start), tr.Step)
+		}
+	}
+}
+
+// TestParseDuration tests Prometheus-style duration parsing
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("30d")
+	if err != nil {
+		t.Fatalf("ParseDuration should not return error, got %v", err)
+	}
+	if d != 30*24*time.Hour {
+		t.Errorf("Expected 720h, got %v", d)
+	}
+
+	if _, err := ParseDuration("thirty days"); err == nil {
+		t.Error("ParseDuration should return error for invalid duration")
+	}
+}
+
 // MockBackend implements Backend interface for testing
 type MockBackend struct {
 	connectFunc         func(ctx context.Context) error
@@ -88,6 +214,10 @@ func (m *MockBackend) QueryTimeSeries(ctx context.Context, expr string) (*TimeSe
 	}, nil
 }
 
+func (m *MockBackend) QueryRange(ctx context.Context, expr string, tr TimeRange) (*TimeSeriesResult, error) {
+	return m.QueryTimeSeries(ctx, expr)
+}
+
 func (m *MockBackend) Close() error {
 	if m.closeFunc != nil {
 		return m.closeFunc()
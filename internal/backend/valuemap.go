@@ -0,0 +1,36 @@
+package backend
+
+import "regexp"
+
+// ValueMapping shows a value of a state metric as text, e.g. 0 as "DOWN"
+type ValueMapping struct {
+	Value float64 `yaml:"value"`
+	Text  string  `yaml:"text"`
+	Color string  `yaml:"color,omitempty"` // a MappingColors name or "#rrggbb"
+}
+
+// MappingColors are the named colors a value mapping can use
+var MappingColors = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray", "white"}
+
+// hexColor matches colors written as "#rrggbb"
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// ValidColor reports whether a value mapping can use color
+func ValidColor(color string) bool {
+	for _, c := range MappingColors {
+		if color == c {
+			return true
+		}
+	}
+	return hexColor.MatchString(color)
+}
+
+// MapValue returns the mapping of v, if any
+func MapValue(mappings []ValueMapping, v float64) (ValueMapping, bool) {
+	for _, m := range mappings {
+		if m.Value == v {
+			return m, true
+		}
+	}
+	return ValueMapping{}, false
+}
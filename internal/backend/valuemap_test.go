@@ -0,0 +1,34 @@
+package backend
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMapValue(t *testing.T) {
+	mappings := []ValueMapping{
+		{Value: 0, Text: "DOWN", Color: "red"},
+		{Value: 1, Text: "UP", Color: "green"},
+	}
+	if m, ok := MapValue(mappings, 1); !ok || m.Text != "UP" {
+		t.Errorf("Expected 1 mapped to UP, got %+v", m)
+	}
+	for _, v := range []float64{2, 0.5, math.NaN()} {
+		if m, ok := MapValue(mappings, v); ok {
+			t.Errorf("Expected %v unmapped, got %+v", v, m)
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for _, color := range []string{"red", "gray", "#ff8800", "#FF8800"} {
+		if !ValidColor(color) {
+			t.Errorf("Expected %q to be valid", color)
+		}
+	}
+	for _, color := range []string{"", "Red", "crimson", "#f80", "ff8800"} {
+		if ValidColor(color) {
+			t.Errorf("Expected %q to be invalid", color)
+		}
+	}
+}
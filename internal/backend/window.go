@@ -0,0 +1,38 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WindowLayout is how the times of a fixed window are written
+const WindowLayout = "2006-01-02 15:04"
+
+// ParseWindow parses a fixed window such as "2024-05-02 13:00 to 15:00" in
+// the given location. An end without a date is on the day of the start.
+func ParseWindow(s string, loc *time.Location) (TimeRange, error) {
+	from, to, ok := strings.Cut(s, " to ")
+	if !ok {
+		return TimeRange{}, fmt.Errorf("invalid window %q, expected START to END", s)
+	}
+	start, err := time.ParseInLocation(WindowLayout, strings.TrimSpace(from), loc)
+	if err != nil {
+		return TimeRange{}, fmt.Errorf("invalid window start %q, expected %s", strings.TrimSpace(from), WindowLayout)
+	}
+
+	to = strings.TrimSpace(to)
+	end, err := time.ParseInLocation(WindowLayout, to, loc)
+	if err != nil {
+		clock, clockErr := time.ParseInLocation("15:04", to, loc)
+		if clockErr != nil {
+			return TimeRange{}, fmt.Errorf("invalid window end %q, expected %s or 15:04", to, WindowLayout)
+		}
+		end = time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	if !end.After(start) {
+		return TimeRange{}, fmt.Errorf("window end %s is not after its start", to)
+	}
+	return FixedRange(start, end), nil
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window string
+		end    time.Time
+	}{
+		{"2024-05-02 13:00 to 15:00", start.Add(2 * time.Hour)},
+		{" 2024-05-02 13:00  to  2024-05-03 01:00 ", start.Add(12 * time.Hour)},
+	}
+	for _, tt := range tests {
+		tr, err := ParseWindow(tt.window, time.UTC)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.window, err)
+		}
+		if !tr.Start.Equal(start) || !tr.End.Equal(tt.end) {
+			t.Errorf("%q: expected %v to %v, got %v to %v", tt.window, start, tt.end, tr.Start, tr.End)
+		}
+	}
+
+	if tr, _ := ParseWindow("2024-05-02 13:00 to 15:00", time.UTC); tr.Step != 2*time.Minute {
+		t.Errorf("Expected the step chosen as for relative ranges, got %v", tr.Step)
+	}
+
+	for _, tc := range []struct{ window, err string }{
+		{"2024-05-02 13:00", "expected START to END"},
+		{"yesterday to 15:00", "invalid window start"},
+		{"2024-05-02 13:00 to tomorrow", "invalid window end"},
+		{"2024-05-02 13:00 to 12:00", "not after its start"},
+	} {
+		if _, err := ParseWindow(tc.window, time.UTC); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: expected error %q, got %v", tc.window, tc.err, err)
+		}
+	}
+}
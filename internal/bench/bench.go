@@ -0,0 +1,155 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/mock"
+	"promviz/internal/stats"
+	"promviz/internal/ui"
+)
+
+// Options sets the dashboard rendered by Run
+type Options struct {
+	Panels   int           // graph panels on the dashboard
+	Points   int           // points per panel
+	Duration time.Duration // how long frames are drawn
+	Width    int           // simulated terminal size
+	Height   int
+}
+
+// pointStep is the spacing of the generated points
+const pointStep = 15 * time.Second
+
+// mockExprs are the expressions the mock backend generates distinct values for
+var mockExprs = []string{"cpu_usage", "memory_usage", "disk_usage", "network_bytes"}
+
+// Report holds the frame times and allocations measured by Run
+type Report struct {
+	Panels         int           `json:"panels"`
+	Points         int           `json:"points"`
+	Frames         int           `json:"frames"`
+	Elapsed        time.Duration `json:"elapsed_ns"`
+	Mean           time.Duration `json:"mean_ns"`
+	P50            time.Duration `json:"p50_ns"`
+	P90            time.Duration `json:"p90_ns"`
+	P99            time.Duration `json:"p99_ns"`
+	Max            time.Duration `json:"max_ns"`
+	AllocsPerFrame uint64        `json:"allocs_per_frame"`
+	BytesPerFrame  uint64        `json:"bytes_per_frame"`
+	GCRuns         uint32        `json:"gc_runs"`
+}
+
+// FPS returns the frames drawn per second
+func (r *Report) FPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Frames) / r.Elapsed.Seconds()
+}
+
+// Run fetches the data of a dashboard from the mock backend and then
+// renders and draws it on a simulated terminal until the duration is over
+// or ctx is done, measuring every frame
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Panels < 1 || opts.Points < 1 {
+		return nil, fmt.Errorf("panels and points must be at least 1")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+
+	// A fixed window makes every panel draw all of its points
+	end := time.Now()
+	window := backend.TimeRange{Start: end.Add(-time.Duration(opts.Points) * pointStep), End: end, Step: pointStep}
+	client := mock.NewClient(&mock.Config{Seed: 1})
+	queries := make([]backend.Query, opts.Panels)
+	results := make([]*backend.TimeSeriesResult, opts.Panels)
+	for i := range queries {
+		expr := mockExprs[i%len(mockExprs)]
+		queries[i] = backend.Query{Name: fmt.Sprintf("Panel %d", i+1), Expr: expr, Window: &window}
+		result, err := client.QueryRange(ctx, expr, window)
+		if err != nil {
+			return nil, fmt.Errorf("mock query %d: %w", i+1, err)
+		}
+		results[i] = result
+	}
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	tui := ui.NewTUI(queries, nil)
+	tui.SetScreen(screen)
+	screen.SetSize(opts.Width, opts.Height)
+	defer screen.Fini()
+
+	// Lay the panels out once so graphs are sized like on a real terminal
+	tui.DrawFrame(results)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var frames []float64
+	start := time.Now()
+	for time.Since(start) < opts.Duration && ctx.Err() == nil {
+		frameStart := time.Now()
+		tui.DrawFrame(results)
+		frames = append(frames, float64(time.Since(frameStart)))
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if len(frames) == 0 {
+		return nil, ctx.Err()
+	}
+
+	var total float64
+	for _, f := range frames {
+		total += f
+	}
+	n := uint64(len(frames))
+	return &Report{
+		Panels:         opts.Panels,
+		Points:         opts.Points,
+		Frames:         len(frames),
+		Elapsed:        elapsed,
+		Mean:           time.Duration(total / float64(len(frames))),
+		P50:            time.Duration(stats.Percentile(frames, 50)),
+		P90:            time.Duration(stats.Percentile(frames, 90)),
+		P99:            time.Duration(stats.Percentile(frames, 99)),
+		Max:            time.Duration(stats.Percentile(frames, 100)),
+		AllocsPerFrame: (after.Mallocs - before.Mallocs) / n,
+		BytesPerFrame:  (after.TotalAlloc - before.TotalAlloc) / n,
+		GCRuns:         after.NumGC - before.NumGC,
+	}, nil
+}
+
+// WriteText writes the report as aligned text
+func WriteText(w io.Writer, r *Report) error {
+	fmt.Fprintf(w, "Rendered %d panels of %d points: %d frames in %s (%.1f frames/s)\n\n",
+		r.Panels, r.Points, r.Frames, r.Elapsed.Round(time.Millisecond), r.FPS())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "frame time\tmean\t%s\n", r.Mean.Round(time.Microsecond))
+	fmt.Fprintf(tw, "\tp50\t%s\n", r.P50.Round(time.Microsecond))
+	fmt.Fprintf(tw, "\tp90\t%s\n", r.P90.Round(time.Microsecond))
+	fmt.Fprintf(tw, "\tp99\t%s\n", r.P99.Round(time.Microsecond))
+	fmt.Fprintf(tw, "\tmax\t%s\n", r.Max.Round(time.Microsecond))
+	fmt.Fprintf(tw, "allocations\tper frame\t%d\n", r.AllocsPerFrame)
+	fmt.Fprintf(tw, "\tbytes per frame\t%d\n", r.BytesPerFrame)
+	fmt.Fprintf(tw, "\tGC runs\t%d\n", r.GCRuns)
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Options{Panels: 2, Points: 100, Duration: 50 * time.Millisecond, Width: 120, Height: 30})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Panels != 2 || report.Points != 100 || report.Frames == 0 {
+		t.Errorf("Expected frames of 2 panels of 100 points, got %+v", report)
+	}
+	if report.P50 <= 0 || report.P50 > report.P99 || report.P99 > report.Max {
+		t.Errorf("Expected ordered frame times, got p50 %v, p99 %v, max %v", report.P50, report.P99, report.Max)
+	}
+	if report.AllocsPerFrame == 0 || report.BytesPerFrame == 0 {
+		t.Errorf("Expected allocations to be counted, got %+v", report)
+	}
+
+	var text bytes.Buffer
+	if err := WriteText(&text, report); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, want := range []string{"Rendered 2 panels of 100 points", "frame time", "p99", "bytes per frame"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Expected %q in the report, got:\n%s", want, text.String())
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := WriteJSON(&encoded, report); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil || decoded.Frames != report.Frames {
+		t.Errorf("Expected the report to round-trip as JSON, got %+v (%v)", decoded, err)
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Panels: 0, Points: 10, Duration: time.Second},
+		{Panels: 1, Points: 0, Duration: time.Second},
+		{Panels: 1, Points: 10},
+	} {
+		if _, err := Run(context.Background(), opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, Options{Panels: 1, Points: 10, Duration: time.Second, Width: 80, Height: 24}); err == nil {
+		t.Error("Expected an error when canceled before the first frame")
+	}
+}
@@ -0,0 +1,122 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/stats"
+)
+
+// Window describes one of the two compared time ranges
+type Window struct {
+	Start   time.Time     `json:"start"`
+	End     time.Time     `json:"end"`
+	Summary stats.Summary `json:"summary"`
+}
+
+// Report holds the statistics of the current window and the shifted baseline
+type Report struct {
+	Expr     string `json:"expr"`
+	Current  Window `json:"current"`
+	Baseline Window `json:"baseline"`
+}
+
+// ParseOffset parses the baseline offset, accepting "24h_ago" as well as "24h"
+func ParseOffset(s string) (time.Duration, error) {
+	d, err := backend.ParseDuration(strings.TrimSuffix(s, "_ago"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q: %w", s, err)
+	}
+	return d, nil
+}
+
+// Run queries the expression over the last rangeDur and over the same
+// window shifted back by offset, and summarizes both
+func Run(ctx context.Context, b backend.Backend, expr string, rangeDur, offset time.Duration) (*Report, error) {
+	current := backend.LastTimeRange(rangeDur)
+	baseline := backend.TimeRange{
+		Start: current.Start.Add(-offset),
+		End:   current.End.Add(-offset),
+		Step:  current.Step,
+	}
+
+	currentSeries, err := b.QueryRange(ctx, expr, current)
+	if err != nil {
+		return nil, fmt.Errorf("current window: %w", err)
+	}
+	baselineSeries, err := b.QueryRange(ctx, expr, baseline)
+	if err != nil {
+		return nil, fmt.Errorf("baseline window: %w", err)
+	}
+
+	return &Report{
+		Expr:     expr,
+		Current:  Window{Start: current.Start, End: current.End, Summary: stats.Summarize(currentSeries.Points)},
+		Baseline: Window{Start: baseline.Start, End: baseline.End, Summary: stats.Summarize(baselineSeries.Points)},
+	}, nil
+}
+
+// row is one statistic in the comparison table
+type row struct {
+	name              string
+	current, baseline float64
+}
+
+// rows lists the compared statistics in display order
+func (r *Report) rows() []row {
+	c, b := r.Current.Summary, r.Baseline.Summary
+	return []row{
+		{"count", float64(c.Count), float64(b.Count)},
+		{"min", c.Min, b.Min},
+		{"max", c.Max, b.Max},
+		{"avg", c.Avg, b.Avg},
+		{"p50", c.P50, b.P50},
+		{"p90", c.P90, b.P90},
+		{"p99", c.P99, b.P99},
+		{"last", c.Last, b.Last},
+	}
+}
+
+// WriteTable prints the comparison as an aligned text table with numbers
+// written in format f
+func WriteTable(w io.Writer, r *Report, f numfmt.Format) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "stat\tcurrent\tbaseline\tdelta\tdelta %%\t\n")
+	for _, row := range r.rows() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n",
+			row.name, f.Sprintf("%.4g", row.current), f.Sprintf("%.4g", row.baseline),
+			f.Sprintf("%+.4g", row.current-row.baseline), formatPercent(row.current, row.baseline, f))
+	}
+	return tw.Flush()
+}
+
+// WriteJSON prints the comparison as JSON including the per-stat deltas
+func WriteJSON(w io.Writer, r *Report) error {
+	deltas := make(map[string]float64)
+	for _, row := range r.rows() {
+		deltas[row.name] = row.current - row.baseline
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		*Report
+		Deltas map[string]float64 `json:"deltas"`
+	}{r, deltas})
+}
+
+// formatPercent renders the relative change, or "n/a" without a baseline
+func formatPercent(current, baseline float64, f numfmt.Format) string {
+	if baseline == 0 || math.IsNaN(baseline) {
+		return "n/a"
+	}
+	return f.Sprintf("%+.1f", (current-baseline)/math.Abs(baseline)*100) + "%"
+}
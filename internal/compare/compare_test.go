@@ -0,0 +1,141 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend/mock"
+	"promviz/internal/numfmt"
+	"promviz/internal/stats"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"24h_ago", 24 * time.Hour},
+		{"1d_ago", 24 * time.Hour},
+		{"1w", 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOffset(tt.input)
+		if err != nil {
+			t.Errorf("ParseOffset(%q) should not return error, got %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseOffset(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := ParseOffset("yesterday"); err == nil {
+		t.Error("ParseOffset should return error for invalid offset")
+	}
+}
+
+func TestRun(t *testing.T) {
+	client := mock.NewClient(&mock.Config{Seed: 12345})
+
+	report, err := Run(context.Background(), client, "cpu_usage", time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Run should not return error, got %v", err)
+	}
+
+	if report.Current.Summary.Count == 0 || report.Baseline.Summary.Count == 0 {
+		t.Fatal("Both windows should have data")
+	}
+
+	if got := report.Current.Start.Sub(report.Baseline.Start); got != 24*time.Hour {
+		t.Errorf("Baseline should be shifted by 24h, got %v", got)
+	}
+
+	if report.Current.Summary.Min < 50 || report.Current.Summary.Max > 80 {
+		t.Errorf("Unexpected current summary for mock cpu_usage: %+v", report.Current.Summary)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	report := &Report{
+		Expr:     "up",
+		Current:  Window{Summary: summaryWithAvg(12)},
+		Baseline: Window{Summary: summaryWithAvg(10)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, report, numfmt.Format{}); err != nil {
+		t.Fatalf("WriteTable should not return error, got %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "delta %") {
+		t.Errorf("Table should have a header, got:\n%s", output)
+	}
+
+	var avgLine string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "avg") {
+			avgLine = line
+		}
+	}
+	if !strings.Contains(avgLine, "+2") || !strings.Contains(avgLine, "+20.0%") {
+		t.Errorf("Expected avg delta +2 (+20.0%%), got %q", avgLine)
+	}
+
+	// Zero baseline has no relative change
+	if !strings.Contains(output, "n/a") {
+		t.Errorf("Expected n/a for zero baselines, got:\n%s", output)
+	}
+}
+
+func TestWriteTableLocalized(t *testing.T) {
+	report := &Report{
+		Expr:     "up",
+		Current:  Window{Summary: summaryWithAvg(1234.5)},
+		Baseline: Window{Summary: summaryWithAvg(1000)},
+	}
+
+	de, _ := numfmt.Locale("de")
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, report, de); err != nil {
+		t.Fatalf("WriteTable should not return error, got %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "1.234") || !strings.Contains(buf.String(), "+23,4%") {
+		t.Errorf("Expected German separators, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := &Report{
+		Expr:     "up",
+		Current:  Window{Summary: summaryWithAvg(12)},
+		Baseline: Window{Summary: summaryWithAvg(10)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("WriteJSON should not return error, got %v", err)
+	}
+
+	var decoded struct {
+		Expr   string             `json:"expr"`
+		Deltas map[string]float64 `json:"deltas"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output should be valid JSON, got %v", err)
+	}
+
+	if decoded.Expr != "up" || decoded.Deltas["avg"] != 2 {
+		t.Errorf("Unexpected JSON output: %s", buf.String())
+	}
+}
+
+func summaryWithAvg(avg float64) stats.Summary {
+	return stats.Summary{Count: 1, Avg: avg}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"promviz/internal/backend"
+)
+
+var (
+	queriesKey = regexp.MustCompile(`^queries:\s*(#.*)?$`)
+	listItem   = regexp.MustCompile(`^(\s*)- `)
+)
+
+// AppendQueries adds queries to the end of the queries list in a config file.
+// The file is edited as text so comments and formatting are preserved; the
+// result is parsed again, rejecting unknown fields, before it is written back.
+func AppendQueries(path string, queries []backend.Query) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var before Config
+	if _, err := decodeFields(data, &before, true); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	updated, err := insertQueries(string(data), queries)
+	if err != nil {
+		return err
+	}
+
+	var after Config
+	if _, err := decodeFields([]byte(updated), &after, true); err != nil {
+		return fmt.Errorf("edited config is not valid YAML: %w", err)
+	}
+	if len(after.Queries) != len(before.Queries)+len(queries) {
+		return fmt.Errorf("could not locate the queries list in %s", path)
+	}
+
+	return ioutil.WriteFile(path, []byte(updated), 0644)
+}
+
+// insertQueries renders the queries as YAML list items and places them after
+// the last line of the top-level queries block, matching its indentation
+func insertQueries(content string, queries []backend.Query) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(queries); err != nil {
+		return "", fmt.Errorf("failed to render queries: %w", err)
+	}
+	rendered := buf.String()
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if queriesKey.MatchString(line) {
+			start = i
+			break
+		}
+	}
+
+	// No queries block yet: add one at the end of the file
+	if start == -1 {
+		block := "queries:\n" + indent(rendered, "  ")
+		return strings.TrimRight(content, "\n") + "\n\n" + block, nil
+	}
+
+	// The block ends at the next top-level key; remember its last content line
+	itemIndent := "  "
+	last := start
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			break
+		}
+		if m := listItem.FindStringSubmatch(line); m != nil && last == start {
+			itemIndent = m[1]
+		}
+		last = i
+	}
+
+	items := strings.Split(strings.TrimRight(indent(rendered, itemIndent), "\n"), "\n")
+	result := append([]string{}, lines[:last+1]...)
+	result = append(result, items...)
+	result = append(result, lines[last+1:]...)
+	return strings.Join(result, "\n") + "\n", nil
+}
+
+// indent prefixes every non-empty line with the given indentation
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
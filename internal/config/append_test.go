@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestAppendQueries(t *testing.T) {
+	configContent := `# Production dashboard
+prometheus:
+  url: "http://localhost:9090"
+
+queries:
+  - name: CPU Usage # busiest panel
+    expr: rate(cpu_usage[5m])
+
+playlist:
+  interval: 30s
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	err := AppendQueries(configPath, []backend.Query{{Name: "Targets Up", Expr: `sum(up{job="api"})`, Range: "1h"}})
+	if err != nil {
+		t.Fatalf("AppendQueries should not return error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), "# busiest panel") || !strings.Contains(string(data), "# Production dashboard") {
+		t.Errorf("Comments should be preserved, got:\n%s", data)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Edited config should load, got %v", err)
+	}
+
+	if len(config.Queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(config.Queries))
+	}
+	if config.Queries[1].Expr != `sum(up{job="api"})` || config.Queries[1].Range != "1h" {
+		t.Errorf("Unexpected appended query: %+v", config.Queries[1])
+	}
+	if config.Playlist == nil {
+		t.Error("Sections after the queries block should be kept")
+	}
+}
+
+func TestAppendQueriesWithoutQueriesBlock(t *testing.T) {
+	configContent := `backend: mock
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	if err := AppendQueries(configPath, []backend.Query{{Name: "Up", Expr: "up"}}); err != nil {
+		t.Fatalf("AppendQueries should not return error, got %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Edited config should load, got %v", err)
+	}
+	if len(config.Queries) != 1 || config.Queries[0].Name != "Up" {
+		t.Errorf("Expected the new query to be added, got %+v", config.Queries)
+	}
+}
+
+func TestAppendQueriesUnknownField(t *testing.T) {
+	configContent := `backend: mock
+queries:
+  - name: Up
+    experssion: up
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	err := AppendQueries(configPath, []backend.Query{{Name: "Down", Expr: "down"}})
+	if err == nil || !strings.Contains(err.Error(), "line 4: field experssion not found") {
+		t.Errorf("Expected the unknown field and its line, got %v", err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != configContent {
+		t.Errorf("The file should be left as it was, got:\n%s", data)
+	}
+}
+
+func TestAppendQueriesFileNotFound(t *testing.T) {
+	err := AppendQueries("nonexistent.yaml", []backend.Query{{Name: "Up", Expr: "up"}})
+	if err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("Expected read error, got %v", err)
+	}
+}
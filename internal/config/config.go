@@ -1,48 +1,260 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
-	"gopkg.in/yaml.v2"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
 
 	"promviz/internal/backend"
 	"promviz/internal/backend/influxdb"
 	"promviz/internal/backend/influxdb1"
 	"promviz/internal/backend/mock"
 	"promviz/internal/backend/prom"
+	"promviz/internal/convert"
+	"promviz/internal/i18n"
+	"promviz/internal/join"
+	"promviz/internal/numfmt"
+	"promviz/internal/resources"
+	"promviz/internal/schedule"
+	"promviz/internal/sink"
+	"promviz/internal/topn"
+	"promviz/internal/tracing"
 )
 
 // Config represents the complete application configuration
 type Config struct {
-	Backend    string           `yaml:"backend"` // "prometheus", "influxdb", "influxdb1", "mock", etc.
-	Prometheus prom.Config      `yaml:"prometheus,omitempty"`
-	InfluxDB   influxdb.Config  `yaml:"influxdb,omitempty"`
-	InfluxDB1  influxdb1.Config `yaml:"influxdb1,omitempty"`
-	Mock       mock.Config      `yaml:"mock,omitempty"`
-	Queries    []backend.Query  `yaml:"queries"`
+	Backend     string            `yaml:"backend"` // "prometheus", "influxdb", "influxdb1", "mock", etc.
+	Prometheus  prom.Config       `yaml:"prometheus,omitempty"`
+	InfluxDB    influxdb.Config   `yaml:"influxdb,omitempty"`
+	InfluxDB1   influxdb1.Config  `yaml:"influxdb1,omitempty"`
+	Mock        mock.Config       `yaml:"mock,omitempty"`
+	Queries     []backend.Query   `yaml:"queries"`
+	Playlist    *PlaylistConfig   `yaml:"playlist,omitempty"`
+	Header      *HeaderConfig     `yaml:"header,omitempty"`
+	Numbers     *NumbersConfig    `yaml:"numbers,omitempty"`
+	Language    string            `yaml:"language,omitempty"`      // language of the dashboard, e.g. "de"; defaults to English
+	AlertLog    string            `yaml:"alert_log,omitempty"`     // JSON-lines file of threshold transitions
+	Tracing     *tracing.Config   `yaml:"tracing,omitempty"`       // traces promviz's own queries
+	Sinks       []sink.Config     `yaml:"sinks,omitempty"`         // where fetched series are written as well
+	Profiles    []Profile         `yaml:"profiles,omitempty"`      // backend environments to switch between
+	Limits      *backend.Limits   `yaml:"result_limits,omitempty"` // caps the series and points kept per query
+	Resources   *resources.Limits `yaml:"limits,omitempty"`        // caps promviz's own memory and CPU
+	Raw         bool              `yaml:"raw,omitempty"`           // send expressions verbatim instead of wrapping simple ones
+	ActiveHours *schedule.Hours   `yaml:"active_hours,omitempty"`  // when panels are refreshed, always if unset
+
+	Extends      string    `yaml:"extends,omitempty"`       // base config this file overlays
+	Snippets     []Snippet `yaml:"snippets,omitempty"`      // reusable expression fragments
+	SnippetFiles []string  `yaml:"snippet_files,omitempty"` // shared snippet files, relative to the config
+
+	Warnings []string `yaml:"-"` // problems tolerated when loading in lenient mode
+}
+
+// PlaylistConfig controls automatic rotation through pages of panels
+type PlaylistConfig struct {
+	Interval time.Duration `yaml:"interval"` // e.g. "30s"
+}
+
+// HeaderConfig turns on the header row with the dashboard name, clock,
+// uptime and data freshness
+type HeaderConfig struct {
+	Title string `yaml:"title,omitempty"` // defaults to the config file name
+}
+
+// HeaderTitle returns the name shown in the header for the config loaded
+// from path
+func (c *Config) HeaderTitle(path string) string {
+	if c.Header != nil && c.Header.Title != "" {
+		return c.Header.Title
+	}
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ResultLimits returns the configured limits on query results, with
+// defaults for the ones not set
+func (c *Config) ResultLimits() backend.Limits {
+	if c.Limits == nil {
+		return backend.Limits{}.WithDefaults()
+	}
+	return c.Limits.WithDefaults()
+}
+
+// NumbersConfig sets how values are written in the dashboard and in table
+// output, e.g. "1.234,56" instead of "1234.56"
+type NumbersConfig struct {
+	Locale    string `yaml:"locale,omitempty"`    // e.g. "de" or "fr-CH"
+	Decimal   string `yaml:"decimal,omitempty"`   // overrides the decimal separator of the locale
+	Thousands string `yaml:"thousands,omitempty"` // overrides the thousands separator of the locale
+}
+
+// NumberFormat returns the configured number format, plain if unset
+func (c *Config) NumberFormat() numfmt.Format {
+	n := c.Numbers
+	if n == nil {
+		return numfmt.Format{}
+	}
+
+	f, _ := numfmt.Locale(n.Locale)
+	if n.Decimal != "" {
+		f.Decimal = n.Decimal
+	}
+	if n.Thousands != "" {
+		f.Thousands = n.Thousands
+	}
+	return f
+}
+
+// Catalog returns the translations of the configured language
+func (c *Config) Catalog() i18n.Catalog {
+	catalog, _ := i18n.Language(c.Language)
+	return catalog
 }
 
-// LoadConfig loads and validates configuration from a YAML file
+// validateNumbers checks the number format settings
+func (c *Config) validateNumbers() error {
+	n := c.Numbers
+	if n.Locale != "" {
+		if _, ok := numfmt.Locale(n.Locale); !ok {
+			return fieldError("numbers.locale", "unknown numbers.locale: %s", n.Locale)
+		}
+	}
+	for _, sep := range []struct{ field, value string }{
+		{"decimal", n.Decimal},
+		{"thousands", n.Thousands},
+	} {
+		if utf8.RuneCountInString(sep.value) > 1 || strings.ContainsAny(sep.value, "0123456789+-eE") {
+			return fieldError("numbers."+sep.field, "numbers.%s must be a single non-digit character", sep.field)
+		}
+	}
+	f := c.NumberFormat()
+	decimal := f.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	if f.Thousands == decimal {
+		return fieldError("numbers", "numbers.decimal and numbers.thousands must differ")
+	}
+	return nil
+}
+
+// LoadConfig loads and validates configuration from a YAML file. Unknown
+// keys, duplicate query names, unused backend sections and expressions
+// whose timing conflicts with their range are tolerated and reported in
+// Warnings.
 func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, false)
+}
+
+// LoadConfigStrict is like LoadConfig but fails on anything LoadConfig
+// would only warn about
+func LoadConfigStrict(path string) (*Config, error) {
+	return loadConfig(path, true)
+}
+
+func loadConfig(path string, strict bool) (*Config, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	config.Warnings, err = decodeFields(data, &config, strict)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Validation errors refer to lines of the file, or of the merged layers
+	annotate := func(err error) error { return withLine(data, err) }
+	if config.Extends != "" {
+		layers, err := loadLayers(path, strict)
+		if err != nil {
+			return nil, err
+		}
+		config = Config{Warnings: layers.warnings}
+		if err := layers.root.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		annotate = func(err error) error { return withNodeLine(layers.root, layers.files, err) }
+	}
+
+	if err := config.expandSnippets(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", annotate(err))
+	}
+
 	// Validate configuration
 	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", annotate(err))
+	}
+
+	problems := append(config.duplicateNames(), config.unusedSections()...)
+	for _, err := range append(problems, config.timingConflicts()...) {
+		if strict {
+			return nil, fmt.Errorf("invalid configuration: %w", annotate(err))
+		}
+		config.Warnings = append(config.Warnings, annotate(err).Error())
 	}
 
 	return &config, nil
 }
 
+// decodeFields decodes a YAML document into v. Unknown fields are always
+// detected so typos don't go unnoticed; outside strict mode they are returned
+// as warnings instead of failing.
+func decodeFields(data []byte, v interface{}, strict bool) ([]string, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(v); err != nil && err != io.EOF {
+		unknown, ok := unknownFields(err)
+		if strict || !ok {
+			return nil, err
+		}
+		return unknown, nil
+	}
+	return nil, nil
+}
+
+// unknownFields returns the messages of a decode error if it consists only of
+// unknown field reports, which the lenient mode downgrades to warnings
+func unknownFields(err error) ([]string, bool) {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil, false
+	}
+	for _, msg := range typeErr.Errors {
+		if !strings.Contains(msg, " not found in type ") {
+			return nil, false
+		}
+	}
+	return typeErr.Errors, true
+}
+
+// duplicateNames reports queries that reuse the name of an earlier query
+func (c *Config) duplicateNames() []error {
+	var errs []error
+	first := make(map[string]int)
+	for i, query := range c.Queries {
+		if j, ok := first[query.Name]; ok {
+			errs = append(errs, queryError(i, fieldError("name", "duplicate name %q (first used by query %d)", query.Name, j)))
+			continue
+		}
+		first[query.Name] = i
+	}
+	return errs
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Default to Prometheus if no backend specified
@@ -50,51 +262,854 @@ func (c *Config) Validate() error {
 		c.Backend = "prometheus"
 	}
 
-	// Validate backend-specific configuration
-	switch c.Backend {
+	if err := c.validateBackend(c.Backend); err != nil {
+		return err
+	}
+
+	if len(c.Queries) == 0 {
+		return fieldError("queries", "at least one query is required")
+	}
+
+	if c.Playlist != nil && c.Playlist.Interval < time.Second {
+		return fieldError("playlist.interval", "playlist.interval must be at least 1s")
+	}
+
+	if c.Numbers != nil {
+		if err := c.validateNumbers(); err != nil {
+			return err
+		}
+	}
+
+	if c.Language != "" {
+		if _, ok := i18n.Language(c.Language); !ok {
+			return fieldError("language", "unknown language: %s (supported: %s)", c.Language, strings.Join(i18n.Languages(), ", "))
+		}
+	}
+
+	if c.Tracing != nil && c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fieldError("tracing.endpoint", "tracing.endpoint must be an http(s) URL")
+		}
+	}
+
+	for i, s := range c.Sinks {
+		if err := s.Validate(); err != nil {
+			return fieldError(fmt.Sprintf("sinks[%d]", i), "sink %d: %w", i, err)
+		}
+	}
+
+	if l := c.Limits; l != nil {
+		if l.MaxSeries < 0 {
+			return fieldError("result_limits.max_series", "result_limits.max_series must not be negative")
+		}
+		if l.MaxPoints < 0 {
+			return fieldError("result_limits.max_points", "result_limits.max_points must not be negative")
+		}
+	}
+
+	if r := c.Resources; r != nil {
+		if r.Memory != "" {
+			if _, err := resources.ParseBytes(r.Memory); err != nil {
+				return fieldError("limits.memory", "invalid limits.memory: %w", err)
+			}
+		}
+		if r.CPU < 0 {
+			return fieldError("limits.cpu", "limits.cpu must not be negative")
+		}
+	}
+
+	if c.ActiveHours != nil {
+		if err := c.ActiveHours.Validate(); err != nil {
+			return fieldError("active_hours", "invalid active_hours: %w", err)
+		}
+	}
+
+	for i, query := range c.Queries {
+		if err := validateCommon(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := c.validateQueryBackend(i, "backend", query.Backend); err != nil {
+			return err
+		}
+		if err := validateQuery(query); err != nil {
+			return queryError(i, err)
+		}
+		if query.PanelType() == backend.PanelHealth && c.BackendFor(query) != "influxdb" {
+			return queryError(i, fieldError("type", "health panels require the influxdb backend, got %s", c.BackendFor(query)))
+		}
+		if query.PanelType() == backend.PanelJoin {
+			if err := c.validateQueryBackend(i, "join.left.backend", query.Join.Left.Backend); err != nil {
+				return err
+			}
+			if err := c.validateQueryBackend(i, "join.right.backend", query.Join.Right.Backend); err != nil {
+				return err
+			}
+		}
+		if query.PanelType() == backend.PanelStatus {
+			for j, check := range query.Status.Checks {
+				if err := c.validateQueryBackend(i, fmt.Sprintf("status.checks[%d].backend", j), check.Backend); err != nil {
+					return err
+				}
+			}
+		}
+		if err := c.validateEvaluation(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateThresholds(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validatePercentiles(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateValueMappings(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateStats(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateConvert(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := c.validateRecord(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateTopN(query); err != nil {
+			return queryError(i, err)
+		}
+		if err := validateExpand(query); err != nil {
+			return queryError(i, err)
+		}
+	}
+
+	if err := c.validateProfiles(); err != nil {
+		return err
+	}
+
+	return c.assignIDs()
+}
+
+// queryID matches explicit query IDs
+var queryID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// assignIDs checks explicit query IDs and derives missing ones from query
+// names. IDs derived from duplicate names get a numeric suffix.
+func (c *Config) assignIDs() error {
+	used := make(map[string]int)
+	for i, query := range c.Queries {
+		if query.ID == "" {
+			continue
+		}
+		if !queryID.MatchString(query.ID) {
+			return queryError(i, fieldError("id", "id may only contain letters, digits, '-' and '_'"))
+		}
+		if j, ok := used[query.ID]; ok {
+			return queryError(i, fieldError("id", "duplicate id %q (first used by query %d)", query.ID, j))
+		}
+		used[query.ID] = i
+	}
+
+	for i := range c.Queries {
+		if c.Queries[i].ID != "" {
+			continue
+		}
+		base := slugify(c.Queries[i].Name)
+		id := base
+		for n := 2; ; n++ {
+			if _, taken := used[id]; !taken {
+				break
+			}
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		c.Queries[i].ID = id
+		used[id] = i
+	}
+
+	return nil
+}
+
+// slugify turns a query name into an ID, e.g. "CPU Usage (%)" into "cpu-usage".
+// Like explicit IDs, it only keeps ASCII letters and digits.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "query"
+	}
+	return b.String()
+}
+
+// validateBackend checks the configuration section of the named backend
+func (c *Config) validateBackend(name string) error {
+	switch name {
 	case "prometheus":
 		if c.Prometheus.URL == "" {
-			return fmt.Errorf("prometheus.url is required")
+			return fieldError("prometheus.url", "prometheus.url is required")
 		}
 	case "influxdb":
 		if c.InfluxDB.URL == "" {
-			return fmt.Errorf("influxdb.url is required")
+			return fieldError("influxdb.url", "influxdb.url is required")
 		}
-		if c.InfluxDB.Token == "" {
-			return fmt.Errorf("influxdb.token is required")
+		if err := validateCredentials("influxdb", "token", c.InfluxDB.Token, true, c.InfluxDB.CredentialOptions); err != nil {
+			return err
 		}
 		if c.InfluxDB.Org == "" {
-			return fmt.Errorf("influxdb.org is required")
+			return fieldError("influxdb.org", "influxdb.org is required")
 		}
 		if c.InfluxDB.Bucket == "" {
-			return fmt.Errorf("influxdb.bucket is required")
+			return fieldError("influxdb.bucket", "influxdb.bucket is required")
 		}
 	case "influxdb1":
 		if c.InfluxDB1.URL == "" {
-			return fmt.Errorf("influxdb1.url is required")
+			return fieldError("influxdb1.url", "influxdb1.url is required")
 		}
 		if c.InfluxDB1.Database == "" {
-			return fmt.Errorf("influxdb1.database is required")
+			return fieldError("influxdb1.database", "influxdb1.database is required")
+		}
+		if err := validateCredentials("influxdb1", "password", c.InfluxDB1.Password, false, c.InfluxDB1.CredentialOptions); err != nil {
+			return err
 		}
 	case "mock":
 		// Mock backend has no required configuration
 	default:
-		return fmt.Errorf("unsupported backend: %s (supported: prometheus, influxdb, influxdb1, mock)", c.Backend)
+		return fieldError("backend", "unsupported backend: %s (supported: prometheus, influxdb, influxdb1, mock)", name)
 	}
+	return nil
+}
 
-	if len(c.Queries) == 0 {
-		return fmt.Errorf("at least one query is required")
+// validateCredentials checks where a backend section takes its credential
+// from. A credential kept in the keychain must not be written in the config
+// too.
+func validateCredentials(section, field, value string, required bool, o backend.CredentialOptions) error {
+	switch o.CredentialStore {
+	case "":
+		if value == "" && required {
+			return fieldError(section+"."+field, "%s.%s is required", section, field)
+		}
+	case backend.CredentialKeychain:
+		if value != "" {
+			return fieldError(section+"."+field, "%s.%s must be empty with credential_store: keychain, run promviz login %s to store it", section, field, section)
+		}
+	default:
+		return fieldError(section+".credential_store", "unsupported credential_store %q (supported: keychain)", o.CredentialStore)
+	}
+	return nil
+}
+
+// CredentialEntry returns the keychain entry holding the credential of the
+// named backend, and what the credential is, e.g. "token"
+func (c *Config) CredentialEntry(name string) (entry, credential string, err error) {
+	switch name {
+	case "influxdb":
+		return c.InfluxDB.Entry(name), "token", nil
+	case "influxdb1":
+		return c.InfluxDB1.Entry(name), "password", nil
+	default:
+		return "", "", fmt.Errorf("backend %s has no token or password (supported: influxdb, influxdb1)", name)
+	}
+}
+
+// KeychainEntry returns the keychain entry of the named backend, and
+// whether the backend keeps its credential there
+func (c *Config) KeychainEntry(name string) (string, bool) {
+	var o backend.CredentialOptions
+	switch name {
+	case "influxdb":
+		o = c.InfluxDB.CredentialOptions
+	case "influxdb1":
+		o = c.InfluxDB1.CredentialOptions
+	default:
+		return "", false
+	}
+	return o.Entry(name), o.UsesKeychain()
+}
+
+// validateQueryBackend checks a backend override of query i. An unsupported
+// name is reported at path within the query; a backend section missing
+// required settings is reported at that section.
+func (c *Config) validateQueryBackend(i int, path, name string) error {
+	if name == "" || name == c.Backend {
+		return nil
+	}
+	err := c.validateBackend(name)
+	var fe *FieldError
+	if errors.As(err, &fe) && fe.Path == "backend" {
+		return queryError(i, fieldError(path, "%w", fe.Err))
+	}
+	return err
+}
+
+// BackendFor returns the name of the backend a query runs against
+func (c *Config) BackendFor(query backend.Query) string {
+	if query.Backend != "" {
+		return query.Backend
+	}
+	return c.Backend
+}
+
+// ActiveHoursFor returns when a query is refreshed: its own active_hours,
+// else the top-level one. Nil means always.
+func (c *Config) ActiveHoursFor(query backend.Query) *schedule.Hours {
+	if query.ActiveHours != nil {
+		return query.ActiveHours
+	}
+	return c.ActiveHours
+}
+
+// RawFor reports whether the expressions of a query are sent verbatim
+func (c *Config) RawFor(query backend.Query) bool {
+	if query.Raw != nil {
+		return *query.Raw
+	}
+	return c.Raw
+}
+
+// JoinBackends returns the backends the left and right side of a join panel
+// run against
+func (c *Config) JoinBackends(query backend.Query) (left, right string) {
+	left, right = c.BackendFor(query), c.BackendFor(query)
+	if query.Join == nil {
+		return left, right
+	}
+	if query.Join.Left.Backend != "" {
+		left = query.Join.Left.Backend
+	}
+	if query.Join.Right.Backend != "" {
+		right = query.Join.Right.Backend
+	}
+	return left, right
+}
+
+// CheckBackend returns the backend a check of a status panel runs against
+func (c *Config) CheckBackend(query backend.Query, check backend.StatusCheck) string {
+	if check.Backend != "" {
+		return check.Backend
+	}
+	return c.BackendFor(query)
+}
+
+// UsedBackends returns the backends referenced by the queries in order of
+// first use
+func (c *Config) UsedBackends() []string {
+	var names []string
+	seen := make(map[string]bool)
+	use := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, query := range c.Queries {
+		if query.PanelType() == backend.PanelJoin {
+			left, right := c.JoinBackends(query)
+			use(left)
+			use(right)
+			continue
+		}
+		if query.PanelType() == backend.PanelStatus && query.Status != nil {
+			for _, check := range query.Status.Checks {
+				use(c.CheckBackend(query, check))
+			}
+			continue
+		}
+		use(c.BackendFor(query))
+	}
+	return names
+}
+
+// unusedSections reports the backend sections no query runs against. A
+// section under the wrong key or indentation is otherwise silently ignored.
+func (c *Config) unusedSections() []error {
+	used := map[string]bool{c.Backend: true}
+	for _, name := range c.UsedBackends() {
+		used[name] = true
+	}
+
+	var errs []error
+	check := func(path, name string, section interface{}) {
+		if used[name] || reflect.ValueOf(section).IsZero() {
+			return
+		}
+		errs = append(errs, fieldError(path, "%s section is ignored: backend is %s and no query sets backend: %s", path, c.Backend, name))
+	}
+	check("prometheus", "prometheus", c.Prometheus)
+	check("influxdb", "influxdb", c.InfluxDB)
+	check("influxdb1", "influxdb1", c.InfluxDB1)
+	check("mock", "mock", c.Mock)
+	for i, p := range c.Profiles {
+		check(fmt.Sprintf("profiles[%d].prometheus", i), "prometheus", p.Prometheus)
+		check(fmt.Sprintf("profiles[%d].influxdb", i), "influxdb", p.InfluxDB)
+		check(fmt.Sprintf("profiles[%d].influxdb1", i), "influxdb1", p.InfluxDB1)
 	}
+	return errs
+}
+
+// ConnectTimeout returns how long to wait for the named backend at startup
+func (c *Config) ConnectTimeout(name string) time.Duration {
+	switch name {
+	case "prometheus":
+		return c.Prometheus.Timeout()
+	case "influxdb":
+		return c.InfluxDB.Timeout()
+	case "influxdb1":
+		return c.InfluxDB1.Timeout()
+	default:
+		return backend.DefaultConnectTimeout
+	}
+}
+
+// validateCommon checks the fields shared by all panel types
+func validateCommon(query backend.Query) error {
+	if query.Name == "" {
+		return fieldError("name", "name is required")
+	}
+	if query.Range != "" {
+		if _, err := backend.ParseDuration(query.Range); err != nil {
+			return fieldError("range", "invalid range: %w", err)
+		}
+	}
+	if query.MaxAge != "" {
+		if _, err := backend.ParseDuration(query.MaxAge); err != nil {
+			return fieldError("max_age", "invalid max_age: %w", err)
+		}
+	}
+	if query.Offset != "" {
+		if _, err := backend.ParseDuration(query.Offset); err != nil {
+			return fieldError("offset", "invalid offset: %w", err)
+		}
+	}
+	if query.RunbookURL != "" {
+		if u, err := url.Parse(query.RunbookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fieldError("runbook_url", "runbook_url must be an http(s) URL")
+		}
+	}
+	for _, tag := range query.Tags {
+		if !queryID.MatchString(tag) {
+			return fieldError("tags", "tags may only contain letters, digits, _ and -, got %q", tag)
+		}
+	}
+	if query.ActiveHours != nil {
+		if err := query.ActiveHours.Validate(); err != nil {
+			return fieldError("active_hours", "invalid active_hours: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateEvaluation checks how a query is evaluated over its range. Only
+// PromQL expressions can look back over the range on their own.
+func (c *Config) validateEvaluation(query backend.Query) error {
+	switch query.Evaluation {
+	case "", backend.EvaluationRange:
+		return nil
+	case backend.EvaluationInstantOverRange:
+	default:
+		return fieldError("evaluation", "evaluation must be range or instant_over_range, got %q", query.Evaluation)
+	}
+	if query.PanelType() != backend.PanelGraph {
+		return fieldError("evaluation", "evaluation: instant_over_range is only supported on graph panels")
+	}
+	if name := c.BackendFor(query); name != "prometheus" {
+		return fieldError("evaluation", "evaluation: instant_over_range requires the prometheus backend, got %s", name)
+	}
+	return nil
+}
 
+// timingConflicts reports PromQL expressions whose own @ and offset
+// modifiers or subqueries conflict with the range of their panel, which
+// gives plausible but wrong graphs
+func (c *Config) timingConflicts() []error {
+	var errs []error
 	for i, query := range c.Queries {
-		if query.Name == "" {
-			return fmt.Errorf("query %d: name is required", i)
+		switch query.PanelType() {
+		case backend.PanelGraph, backend.PanelBool, backend.PanelCalendar, backend.PanelHistogram:
+		default:
+			continue
+		}
+		if query.Expr == "" || c.BackendFor(query) != "prometheus" {
+			continue
+		}
+
+		timing := prom.ExprTiming(query.Expr)
+		if timing.Offset && query.Offset != "" {
+			errs = append(errs, queryError(i, fieldError("offset", "expr has an offset of its own, which adds to offset: %s", query.Offset)))
+		}
+		if query.Evaluation == backend.EvaluationInstantOverRange {
+			continue
+		}
+		if timing.At {
+			errs = append(errs, queryError(i, fieldError("expr", "expr pins its evaluation time with @, so every point of the range has the same value; set evaluation: instant_over_range to evaluate it once")))
+		}
+		if rng := query.TimeRange(); timing.Subquery > 0 && timing.Subquery >= rng.End.Sub(rng.Start) {
+			errs = append(errs, queryError(i, fieldError("expr", "expr has a subquery over %s, as long as the panel's range, evaluated at every point; set evaluation: instant_over_range to evaluate it once over the range", model.Duration(timing.Subquery))))
+		}
+	}
+	return errs
+}
+
+// validateThresholds checks that warning and critical levels are ordered
+func validateThresholds(query backend.Query) error {
+	th := query.Thresholds
+	if th == nil {
+		return nil
+	}
+	if t := query.PanelType(); t != backend.PanelGraph && t != backend.PanelHistogram {
+		return fieldError("thresholds", "thresholds are only supported on graph and histogram panels")
+	}
+	if th.Warn == nil && th.Crit == nil {
+		return fieldError("thresholds", "thresholds require warn or crit")
+	}
+	if th.Warn != nil && th.Crit != nil {
+		if !th.Below && *th.Warn > *th.Crit {
+			return fieldError("thresholds.warn", "thresholds.warn must not exceed thresholds.crit")
+		}
+		if th.Below && *th.Warn < *th.Crit {
+			return fieldError("thresholds.warn", "thresholds.warn must not be below thresholds.crit when below is set")
+		}
+	}
+	return nil
+}
+
+// labelName matches label names expand_by can refer to
+var labelName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateExpand checks the expand_by settings of a query
+func validateExpand(query backend.Query) error {
+	if query.ExpandBy == "" {
+		if query.ExpandLimit != 0 {
+			return fieldError("expand_limit", "expand_limit requires expand_by")
+		}
+		return nil
+	}
+	if query.PanelType() != backend.PanelGraph {
+		return fieldError("expand_by", "expand_by is only supported on graph panels")
+	}
+	if !labelName.MatchString(query.ExpandBy) {
+		return fieldError("expand_by", "expand_by must be a label name, got %q", query.ExpandBy)
+	}
+	if query.ExpandLimit < 0 {
+		return fieldError("expand_limit", "expand_limit must not be negative")
+	}
+	return nil
+}
+
+// validateTopN checks the series limit of a top_n query
+func validateTopN(query backend.Query) error {
+	if query.TopN == 0 {
+		return nil
+	}
+	if query.PanelType() != backend.PanelGraph {
+		return fieldError("top_n", "top_n is only supported on graph panels")
+	}
+	if query.TopN < 0 || query.TopN > topn.MaxN {
+		return fieldError("top_n", "top_n must be between 1 and %d, got %d", topn.MaxN, query.TopN)
+	}
+	if query.Percentiles != nil {
+		return fieldError("top_n", "top_n cannot be combined with percentiles")
+	}
+	return nil
+}
+
+// validatePercentiles checks the client-side quantiles of a query
+func validatePercentiles(query backend.Query) error {
+	p := query.Percentiles
+	if p == nil {
+		return nil
+	}
+	if t := query.PanelType(); t == backend.PanelSLO || t == backend.PanelBool || t == backend.PanelHealth || t == backend.PanelCalendar || t == backend.PanelHistogram || t == backend.PanelBurnRate || t == backend.PanelStatus {
+		return fieldError("percentiles", "percentiles are not supported on %s panels", t)
+	}
+	for _, level := range p.Quantiles {
+		if level <= 0 || level > 100 {
+			return fieldError("percentiles.quantiles", "percentiles.quantiles must be between 0 (exclusive) and 100, got %v", level)
+		}
+	}
+	if p.Window != "" {
+		d, err := backend.ParseDuration(p.Window)
+		if err != nil {
+			return fieldError("percentiles.window", "invalid percentiles.window: %w", err)
+		}
+		if d <= 0 {
+			return fieldError("percentiles.window", "percentiles.window must be positive")
+		}
+	}
+	return nil
+}
+
+// validateStats checks the statistics of a query's stats row
+func validateStats(query backend.Query) error {
+	st := query.Stats
+	if st == nil {
+		return nil
+	}
+	if t := query.PanelType(); t != backend.PanelGraph && t != backend.PanelJoin {
+		return fieldError("stats", "stats are only supported on graph and join panels")
+	}
+	if len(st.Show) == 0 {
+		return fieldError("stats.show", "stats.show must list at least one statistic")
+	}
+	for _, name := range st.Show {
+		switch name {
+		case backend.StatMin, backend.StatMax, backend.StatAvg, backend.StatLast, backend.StatTimeAvg, backend.StatIntegral:
+		default:
+			return fieldError("stats.show", "stats.show must be min, max, avg, last, time_avg or integral, got %q", name)
+		}
+	}
+	if st.IntegralUnit != "" {
+		d, err := backend.ParseDuration(st.IntegralUnit)
+		if err != nil {
+			return fieldError("stats.integral_unit", "invalid stats.integral_unit: %w", err)
+		}
+		if d <= 0 {
+			return fieldError("stats.integral_unit", "stats.integral_unit must be positive")
+		}
+	}
+	return nil
+}
+
+// validateConvert checks the conversion applied to a query's values
+func validateConvert(query backend.Query) error {
+	c := query.Convert
+	if c == nil {
+		return nil
+	}
+	switch query.PanelType() {
+	case backend.PanelGraph, backend.PanelJoin, backend.PanelCalendar, backend.PanelHistogram:
+	default:
+		return fieldError("convert", "convert is only supported on graph, join, calendar and histogram panels")
+	}
+	if query.Unit != "" {
+		return fieldError("convert", "convert and unit cannot both be set; use convert.unit")
+	}
+	if err := convert.Validate(c); err != nil {
+		return fieldError("convert", "invalid convert: %w", err)
+	}
+	return nil
+}
+
+// metricName matches Prometheus metric names
+var metricName = regexp.MustCompile(`^[A-Za-z_:][A-Za-z0-9_:]*$`)
+
+// validateRecord checks the metric name a query's series are written as
+func (c *Config) validateRecord(query backend.Query) error {
+	if query.Record == "" {
+		return nil
+	}
+	if t := query.PanelType(); t == backend.PanelSLO || t == backend.PanelHealth {
+		return fieldError("record", "record is not supported on %s panels", t)
+	}
+	if !metricName.MatchString(query.Record) {
+		return fieldError("record", "record must be a metric name, got %q", query.Record)
+	}
+	for _, s := range c.Sinks {
+		if s.Derived() {
+			return nil
+		}
+	}
+	return fieldError("record", "record needs a sink with series: derived or all")
+}
+
+// validateValueMappings checks the texts a query shows values as
+func validateValueMappings(query backend.Query) error {
+	if len(query.ValueMappings) == 0 {
+		return nil
+	}
+	if t := query.PanelType(); t != backend.PanelGraph && t != backend.PanelJoin && t != backend.PanelCalendar {
+		return fieldError("value_mappings", "value_mappings are only supported on graph, join and calendar panels")
+	}
+	seen := make(map[float64]bool)
+	for _, m := range query.ValueMappings {
+		if m.Text == "" {
+			return fieldError("value_mappings", "value_mappings: text is required for value %v", m.Value)
+		}
+		if seen[m.Value] {
+			return fieldError("value_mappings", "value_mappings: duplicate value %v", m.Value)
+		}
+		seen[m.Value] = true
+		if m.Color != "" && !backend.ValidColor(m.Color) {
+			return fieldError("value_mappings", "value_mappings: color must be one of %s or #rrggbb, got %q",
+				strings.Join(backend.MappingColors, ", "), m.Color)
+		}
+	}
+	return nil
+}
+
+// HasThresholds reports whether any query defines alert thresholds
+func (c *Config) HasThresholds() bool {
+	for _, query := range c.Queries {
+		if query.Thresholds != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AlertLogPath returns the breach log file, defaulting to the user cache directory
+func (c *Config) AlertLogPath() (string, error) {
+	if c.AlertLog != "" {
+		return c.AlertLog, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory for alert log: %w", err)
+	}
+	return filepath.Join(dir, "promviz", "breaches.jsonl"), nil
+}
+
+// validateQuery checks the panel-type specific fields of a query
+func validateQuery(query backend.Query) error {
+	switch query.PanelType() {
+	case backend.PanelGraph, backend.PanelBool:
+		if query.Expr == "" {
+			return fieldError("expr", "expr is required")
+		}
+	case backend.PanelSLO:
+		if query.SLO == nil {
+			return fieldError("slo", "slo section is required for type slo")
+		}
+		if query.SLO.Good == "" || query.SLO.Total == "" {
+			return fieldError("slo", "slo.good and slo.total are required")
+		}
+		if query.SLO.Objective <= 0 || query.SLO.Objective >= 100 {
+			return fieldError("slo.objective", "slo.objective must be between 0 and 100 (exclusive), got %v", query.SLO.Objective)
+		}
+		if _, err := backend.ParseDuration(query.SLO.WindowOrDefault()); err != nil {
+			return fieldError("slo.window", "invalid slo.window: %w", err)
+		}
+	case backend.PanelBurnRate:
+		if query.BurnRate == nil {
+			return fieldError("burn_rate", "burn_rate section is required for type burn_rate")
+		}
+		if query.BurnRate.ErrorRatio == "" {
+			return fieldError("burn_rate.error_ratio", "burn_rate.error_ratio is required")
+		}
+		if !strings.Contains(query.BurnRate.ErrorRatio, backend.WindowPlaceholder) {
+			return fieldError("burn_rate.error_ratio", "burn_rate.error_ratio must use %s as the range of its rates", backend.WindowPlaceholder)
+		}
+		if query.BurnRate.Objective <= 0 || query.BurnRate.Objective >= 100 {
+			return fieldError("burn_rate.objective", "burn_rate.objective must be between 0 and 100 (exclusive), got %v", query.BurnRate.Objective)
+		}
+	case backend.PanelStatus:
+		if query.Status == nil {
+			return fieldError("status", "status section is required for type status")
+		}
+		if query.Status.By == "" {
+			return fieldError("status.by", "status.by is required")
+		}
+		if len(query.Status.Checks) == 0 {
+			return fieldError("status.checks", "status.checks must list at least one check")
+		}
+		names := make(map[string]bool)
+		for i, check := range query.Status.Checks {
+			path := fmt.Sprintf("status.checks[%d]", i)
+			if check.Name == "" {
+				return fieldError(path+".name", "%s.name is required", path)
+			}
+			if names[check.Name] {
+				return fieldError(path+".name", "duplicate check %q", check.Name)
+			}
+			names[check.Name] = true
+			if check.Expr == "" {
+				return fieldError(path+".expr", "%s.expr is required", path)
+			}
+		}
+	case backend.PanelJoin:
+		if query.Join == nil {
+			return fieldError("join", "join section is required for type join")
+		}
+		if query.Join.Left.Expr == "" {
+			return fieldError("join.left.expr", "join.left.expr is required")
+		}
+		if query.Join.Right.Expr == "" {
+			return fieldError("join.right.expr", "join.right.expr is required")
+		}
+		if query.Join.Op == "" {
+			return fieldError("join.op", "join.op is required")
 		}
+		if err := join.Validate(query.Join.Op, query.Join.Interpolation); err != nil {
+			return fieldError("join", "invalid join: %w", err)
+		}
+	case backend.PanelHealth:
+		if query.Health == nil {
+			break
+		}
+		for _, bucket := range query.Health.Buckets {
+			if bucket == "" {
+				return fieldError("health.buckets", "health.buckets must not contain empty names")
+			}
+		}
+	case backend.PanelCalendar:
 		if query.Expr == "" {
-			return fmt.Errorf("query %d: expr is required", i)
+			return fieldError("expr", "expr is required")
+		}
+		if query.Calendar == nil {
+			break
+		}
+		switch query.Calendar.Bucket {
+		case "", backend.BucketDay, backend.BucketHour:
+		default:
+			return fieldError("calendar.bucket", "calendar.bucket must be day or hour, got %q", query.Calendar.Bucket)
+		}
+		switch query.Calendar.Aggregate {
+		case "", backend.AggregateAvg, backend.AggregateSum, backend.AggregateMin, backend.AggregateMax:
+		default:
+			return fieldError("calendar.aggregate", "calendar.aggregate must be avg, sum, min or max, got %q", query.Calendar.Aggregate)
 		}
+	case backend.PanelHistogram:
+		if query.Expr == "" {
+			return fieldError("expr", "expr is required")
+		}
+		for _, level := range query.Histogram.Levels() {
+			if level < 0 || level > 100 {
+				return fieldError("histogram.quantiles", "histogram.quantiles must be between 0 and 100, got %v", level)
+			}
+		}
+	default:
+		return fieldError("type", "unsupported type: %s (supported: graph, slo, join, bool, health, calendar, histogram, burn_rate, status)", query.Type)
 	}
+	return nil
+}
+
+// SelectQuery narrows the configuration down to the query with the given name or ID
+func (c *Config) SelectQuery(name string) error {
+	// IDs are unique, names may not be
+	for _, query := range c.Queries {
+		if query.ID == name {
+			c.Queries = []backend.Query{query}
+			return nil
+		}
+	}
+	for _, query := range c.Queries {
+		if query.Name == name {
+			c.Queries = []backend.Query{query}
+			return nil
+		}
+	}
+	return fmt.Errorf("no query named %q", name)
+}
 
+// SelectTag narrows the configuration down to the queries with the given tag
+func (c *Config) SelectTag(tag string) error {
+	var tagged []backend.Query
+	for _, query := range c.Queries {
+		if query.HasTag(tag) {
+			tagged = append(tagged, query)
+		}
+	}
+	if len(tagged) == 0 {
+		return fmt.Errorf("no query tagged %q", tag)
+	}
+	c.Queries = tagged
 	return nil
 }
 
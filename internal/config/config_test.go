@@ -1,15 +1,21 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"promviz/internal/backend"
 	"promviz/internal/backend/influxdb"
 	"promviz/internal/backend/influxdb1"
 	"promviz/internal/backend/prom"
+	"promviz/internal/resources"
+	"promviz/internal/schedule"
+	"promviz/internal/sink"
+	"promviz/internal/tracing"
 )
 
 func TestLoadConfigPrometheus(t *testing.T) {
@@ -352,6 +358,35 @@ func TestValidateInfluxDBMissingFields(t *testing.T) {
 			},
 			errorMsg: "influxdb.bucket is required",
 		},
+		{
+			name: "Token with keychain",
+			config: &Config{
+				Backend: "influxdb",
+				InfluxDB: influxdb.Config{
+					URL:               "http://localhost:8086",
+					Token:             "test-token",
+					Org:               "test-org",
+					Bucket:            "test-bucket",
+					CredentialOptions: backend.CredentialOptions{CredentialStore: "keychain"},
+				},
+				Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+			},
+			errorMsg: "influxdb.token must be empty with credential_store: keychain",
+		},
+		{
+			name: "Unsupported credential store",
+			config: &Config{
+				Backend: "influxdb",
+				InfluxDB: influxdb.Config{
+					URL:               "http://localhost:8086",
+					Org:               "test-org",
+					Bucket:            "test-bucket",
+					CredentialOptions: backend.CredentialOptions{CredentialStore: "vault"},
+				},
+				Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+			},
+			errorMsg: `unsupported credential_store "vault"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -368,6 +403,49 @@ func TestValidateInfluxDBMissingFields(t *testing.T) {
 	}
 }
 
+func TestKeychainCredentials(t *testing.T) {
+	config := &Config{
+		Backend: "influxdb",
+		InfluxDB: influxdb.Config{
+			URL:               "http://localhost:8086",
+			Org:               "test-org",
+			Bucket:            "test-bucket",
+			CredentialOptions: backend.CredentialOptions{CredentialStore: "keychain"},
+		},
+		InfluxDB1: influxdb1.Config{
+			CredentialOptions: backend.CredentialOptions{CredentialStore: "keychain", CredentialEntry: "influx-eu"},
+		},
+		Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("A token kept in the keychain should not be required, got %v", err)
+	}
+
+	tests := []struct {
+		backend, entry, credential string
+	}{
+		{"influxdb", "influxdb", "token"},
+		{"influxdb1", "influx-eu", "password"},
+	}
+	for _, tt := range tests {
+		entry, credential, err := config.CredentialEntry(tt.backend)
+		if err != nil || entry != tt.entry || credential != tt.credential {
+			t.Errorf("%s: expected entry %s for the %s, got %s, %s, %v", tt.backend, tt.entry, tt.credential, entry, credential, err)
+		}
+	}
+	if _, _, err := config.CredentialEntry("prometheus"); err == nil {
+		t.Error("Expected an error for a backend without credentials")
+	}
+
+	if entry, ok := config.KeychainEntry("influxdb1"); !ok || entry != "influx-eu" {
+		t.Errorf("Expected influxdb1 to use keychain entry influx-eu, got %s, %v", entry, ok)
+	}
+	config.InfluxDB.CredentialStore = ""
+	if _, ok := config.KeychainEntry("influxdb"); ok {
+		t.Error("Expected influxdb to take its token from the config")
+	}
+}
+
 func TestValidateUnsupportedBackend(t *testing.T) {
 	config := &Config{
 		Backend: "unsupported",
@@ -470,6 +548,181 @@ func TestValidateInvalidQueries(t *testing.T) {
 			},
 			errorMsg: "query 0: expr is required",
 		},
+		{
+			name: "Calendar with unknown bucket",
+			queries: []backend.Query{
+				{Name: "Requests", Type: "calendar", Expr: "up", Calendar: &backend.CalendarConfig{Bucket: "week"}},
+			},
+			errorMsg: "query 0: calendar.bucket must be day or hour",
+		},
+		{
+			name: "Calendar with unknown aggregate",
+			queries: []backend.Query{
+				{Name: "Requests", Type: "calendar", Expr: "up", Calendar: &backend.CalendarConfig{Aggregate: "p99"}},
+			},
+			errorMsg: "query 0: calendar.aggregate must be avg, sum, min or max",
+		},
+		{
+			name: "Histogram without expression",
+			queries: []backend.Query{
+				{Name: "Latency", Type: "histogram"},
+			},
+			errorMsg: "query 0: expr is required",
+		},
+		{
+			name: "Histogram with quantile out of range",
+			queries: []backend.Query{
+				{Name: "Latency", Type: "histogram", Expr: "rate(req_seconds[5m])", Histogram: &backend.HistogramConfig{Quantiles: []float64{50, 999}}},
+			},
+			errorMsg: "query 0: histogram.quantiles must be between 0 and 100, got 999",
+		},
+		{
+			name: "Tag with spaces",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "up", Tags: []string{"bad tag"}},
+			},
+			errorMsg: "query 0: tags may only contain letters, digits, _ and -",
+		},
+		{
+			name: "SLO query missing slo section",
+			queries: []backend.Query{
+				{Name: "Availability", Type: "slo"},
+			},
+			errorMsg: "query 0: slo section is required",
+		},
+		{
+			name: "SLO query missing total",
+			queries: []backend.Query{
+				{Name: "Availability", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Objective: 99.9}},
+			},
+			errorMsg: "query 0: slo.good and slo.total are required",
+		},
+		{
+			name: "SLO query with invalid objective",
+			queries: []backend.Query{
+				{Name: "Availability", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Total: "total", Objective: 100}},
+			},
+			errorMsg: "query 0: slo.objective must be between 0 and 100",
+		},
+		{
+			name: "SLO query with invalid window",
+			queries: []backend.Query{
+				{Name: "Availability", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Total: "total", Objective: 99.9, Window: "a month"}},
+			},
+			errorMsg: "query 0: invalid slo.window",
+		},
+		{
+			name: "Invalid query range",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Range: "an hour"},
+			},
+			errorMsg: "query 0: invalid range",
+		},
+		{
+			name: "Invalid max age",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", MaxAge: "soon"},
+			},
+			errorMsg: "query 0: invalid max_age",
+		},
+		{
+			name: "Invalid offset",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Offset: "-1h"},
+			},
+			errorMsg: "query 0: invalid offset",
+		},
+		{
+			name: "Runbook URL without scheme",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", RunbookURL: "wiki/runbooks/cpu"},
+			},
+			errorMsg: "query 0: runbook_url must be an http(s) URL",
+		},
+		{
+			name: "Unsupported query type",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Type: "pie"},
+			},
+			errorMsg: "query 0: unsupported type: pie",
+		},
+		{
+			name: "Thresholds without levels",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Thresholds: &backend.Thresholds{}},
+			},
+			errorMsg: "query 0: thresholds require warn or crit",
+		},
+		{
+			name: "Thresholds out of order",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Thresholds: &backend.Thresholds{Warn: floatPtr(90), Crit: floatPtr(80)}},
+			},
+			errorMsg: "query 0: thresholds.warn must not exceed thresholds.crit",
+		},
+		{
+			name: "Percentile out of range",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Percentiles: &backend.Percentiles{Quantiles: []float64{50, 150}}},
+			},
+			errorMsg: "query 0: percentiles.quantiles must be between 0 (exclusive) and 100, got 150",
+		},
+		{
+			name: "Invalid percentile window",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", Percentiles: &backend.Percentiles{Window: "a while"}},
+			},
+			errorMsg: "query 0: invalid percentiles.window",
+		},
+		{
+			name: "Percentiles on SLO panel",
+			queries: []backend.Query{
+				{Name: "Availability", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Total: "total", Objective: 99.9}, Percentiles: &backend.Percentiles{}},
+			},
+			errorMsg: "query 0: percentiles are not supported on slo panels",
+		},
+		{
+			name: "Bool panel without expr",
+			queries: []backend.Query{
+				{Name: "Probe", Type: "bool"},
+			},
+			errorMsg: "query 0: expr is required",
+		},
+		{
+			name: "Thresholds on bool panel",
+			queries: []backend.Query{
+				{Name: "Probe", Expr: "probe_success", Type: "bool", Thresholds: &backend.Thresholds{Crit: floatPtr(1)}},
+			},
+			errorMsg: "query 0: thresholds are only supported on graph and histogram panels",
+		},
+		{
+			name: "Top N too large",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", TopN: 20},
+			},
+			errorMsg: "query 0: top_n must be between 1 and 8, got 20",
+		},
+		{
+			name: "Top N with percentiles",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", TopN: 3, Percentiles: &backend.Percentiles{}},
+			},
+			errorMsg: "query 0: top_n cannot be combined with percentiles",
+		},
+		{
+			name: "Expand by invalid label",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", ExpandBy: "host name"},
+			},
+			errorMsg: `query 0: expand_by must be a label name, got "host name"`,
+		},
+		{
+			name: "Expand limit without expand by",
+			queries: []backend.Query{
+				{Name: "Test", Expr: "test_metric", ExpandLimit: 5},
+			},
+			errorMsg: "query 0: expand_limit requires expand_by",
+		},
 		{
 			name: "Multiple invalid queries",
 			queries: []backend.Query{
@@ -499,57 +752,1209 @@ func TestValidateInvalidQueries(t *testing.T) {
 	}
 }
 
-func TestGetPrometheusConfig(t *testing.T) {
-	config := &Config{
-		Prometheus: prom.Config{URL: "http://localhost:9090"},
+func TestLoadConfigSLOQuery(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+
+queries:
+  - name: API Availability
+    type: slo
+    slo:
+      good: sum(rate(http_requests_total{code!~"5.."}[5m]))
+      total: sum(rate(http_requests_total[5m]))
+      objective: 99.9
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
 	}
 
-	promConfig := config.GetPrometheusConfig()
-	if promConfig.URL != "http://localhost:9090" {
-		t.Errorf("Expected Prometheus URL 'http://localhost:9090', got '%s'", promConfig.URL)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
+	}
+
+	query := config.Queries[0]
+	if query.PanelType() != backend.PanelSLO {
+		t.Errorf("Expected panel type 'slo', got '%s'", query.PanelType())
+	}
+	if query.SLO == nil {
+		t.Fatal("SLO section should be parsed")
+	}
+	if query.SLO.Objective != 99.9 {
+		t.Errorf("Expected objective 99.9, got %v", query.SLO.Objective)
+	}
+	if query.SLO.Window != "" || query.SLO.WindowOrDefault() != "30d" {
+		t.Errorf("Expected default window '30d' without changing the config, got %q and %q", query.SLO.Window, query.SLO.WindowOrDefault())
 	}
 }
 
-func TestGetInfluxDBConfig(t *testing.T) {
-	config := &Config{
-		InfluxDB: influxdb.Config{
-			URL:    "http://localhost:8086",
-			Token:  "test-token",
-			Org:    "test-org",
-			Bucket: "test-bucket",
-		},
+func TestLoadConfigRaw(t *testing.T) {
+	configContent := `backend: influxdb1
+influxdb1:
+  url: "http://localhost:8086"
+  database: telegraf
+raw: true
+
+queries:
+  - name: Temperature
+    expr: select last(value) from temp
+  - name: Idle
+    expr: usage_idle
+    raw: false
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
 	}
 
-	influxConfig := config.GetInfluxDBConfig()
-	if influxConfig.URL != "http://localhost:8086" {
-		t.Errorf("Expected InfluxDB URL 'http://localhost:8086', got '%s'", influxConfig.URL)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
 	}
-	if influxConfig.Token != "test-token" {
-		t.Errorf("Expected InfluxDB token 'test-token', got '%s'", influxConfig.Token)
+	if !config.RawFor(config.Queries[0]) {
+		t.Error("Expected the top-level raw to apply to queries without their own")
+	}
+	if config.RawFor(config.Queries[1]) {
+		t.Error("Expected raw: false on a query to override the top-level raw")
+	}
+
+	config.Raw = false
+	if config.RawFor(config.Queries[0]) {
+		t.Error("Expected expressions to be wrapped by default")
 	}
 }
 
-func TestGetInfluxDB1Config(t *testing.T) {
-	config := &Config{
-		InfluxDB1: influxdb1.Config{
-			URL:      "http://localhost:8086",
-			Username: "admin",
-			Password: "password",
-			Database: "telegraf",
-		},
+func TestLoadConfigPlaylist(t *testing.T) {
+	configContent := `backend: mock
+playlist:
+  interval: 15s
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
 	}
 
-	influx1Config := config.GetInfluxDB1Config()
-	if influx1Config.URL != "http://localhost:8086" {
-		t.Errorf("Expected InfluxDB v1 URL 'http://localhost:8086', got '%s'", influx1Config.URL)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
 	}
-	if influx1Config.Username != "admin" {
-		t.Errorf("Expected InfluxDB v1 username 'admin', got '%s'", influx1Config.Username)
+
+	if config.Playlist == nil {
+		t.Fatal("Playlist should be parsed")
 	}
-	if influx1Config.Password != "password" {
-		t.Errorf("Expected InfluxDB v1 password 'password', got '%s'", influx1Config.Password)
+	if config.Playlist.Interval != 15*time.Second {
+		t.Errorf("Expected playlist interval 15s, got %v", config.Playlist.Interval)
 	}
-	if influx1Config.Database != "telegraf" {
-		t.Errorf("Expected InfluxDB v1 database 'telegraf', got '%s'", influx1Config.Database)
+}
+
+func TestHeaderTitle(t *testing.T) {
+	config := &Config{Header: &HeaderConfig{}}
+	if got := config.HeaderTitle("/etc/promviz/payments.yaml"); got != "payments" {
+		t.Errorf("Expected the config file name, got %q", got)
+	}
+
+	config.Header.Title = "Payments on-call"
+	if got := config.HeaderTitle("/etc/promviz/payments.yaml"); got != "Payments on-call" {
+		t.Errorf("Expected the configured title, got %q", got)
+	}
+}
+
+func TestNumberFormat(t *testing.T) {
+	config := &Config{}
+	if !config.NumberFormat().Plain() {
+		t.Error("Numbers should be plain by default")
+	}
+
+	config.Numbers = &NumbersConfig{Locale: "de"}
+	if f := config.NumberFormat(); f.Decimal != "," || f.Thousands != "." {
+		t.Errorf("Expected the German format, got %+v", f)
+	}
+
+	config.Numbers = &NumbersConfig{Locale: "de", Thousands: "'"}
+	if f := config.NumberFormat(); f.Decimal != "," || f.Thousands != "'" {
+		t.Errorf("Expected the thousands separator overridden, got %+v", f)
+	}
+}
+
+func TestValidateNumbers(t *testing.T) {
+	tests := []struct {
+		numbers  NumbersConfig
+		errorMsg string
+	}{
+		{NumbersConfig{Locale: "de-AT"}, ""},
+		{NumbersConfig{Decimal: ","}, ""},
+		{NumbersConfig{Locale: "klingon"}, "unknown numbers.locale: klingon"},
+		{NumbersConfig{Decimal: "::"}, "numbers.decimal must be a single non-digit character"},
+		{NumbersConfig{Thousands: "0"}, "numbers.thousands must be a single non-digit character"},
+		{NumbersConfig{Locale: "en", Decimal: ","}, "numbers.decimal and numbers.thousands must differ"},
+	}
+
+	for _, tt := range tests {
+		numbers := tt.numbers
+		config := &Config{
+			Backend: "mock",
+			Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+			Numbers: &numbers,
+		}
+		err := config.Validate()
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.numbers, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.numbers, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestValidateLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		errorMsg string
+	}{
+		{"", ""},
+		{"de", ""},
+		{"en-US", ""},
+		{"klingon", "unknown language: klingon (supported: de, en)"},
+	}
+
+	for _, tt := range tests {
+		config := &Config{
+			Backend:  "mock",
+			Queries:  []backend.Query{{Name: "Test", Expr: "test"}},
+			Language: tt.language,
+		}
+		err := config.Validate()
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.language, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.language, tt.errorMsg, err)
+		}
+	}
+
+	config := &Config{Language: "de"}
+	if got := config.Catalog().T("No data available"); got != "Keine Daten verfügbar" {
+		t.Errorf("Expected the German catalog, got %q", got)
+	}
+}
+
+func TestValidatePlaylistInterval(t *testing.T) {
+	config := &Config{
+		Backend:  "mock",
+		Queries:  []backend.Query{{Name: "Test", Expr: "test"}},
+		Playlist: &PlaylistConfig{Interval: 0},
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate should return error for a zero playlist interval")
+	}
+
+	if !strings.Contains(err.Error(), "playlist.interval must be at least 1s") {
+		t.Errorf("Error should mention playlist interval, got: %v", err)
+	}
+}
+
+func TestValidateTracing(t *testing.T) {
+	tests := []struct {
+		tracing  tracing.Config
+		errorMsg string
+	}{
+		{tracing.Config{}, ""},
+		{tracing.Config{Endpoint: "http://localhost:4318"}, ""},
+		{tracing.Config{Endpoint: "localhost:4318"}, "tracing.endpoint must be an http(s) URL"},
+		{tracing.Config{Endpoint: "grpc://collector:4317"}, "tracing.endpoint must be an http(s) URL"},
+	}
+
+	for _, tt := range tests {
+		cfg := tt.tracing
+		config := &Config{
+			Backend: "mock",
+			Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+			Tracing: &cfg,
+		}
+		err := config.Validate()
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.tracing, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.tracing, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestValidateResultLimits(t *testing.T) {
+	tests := []struct {
+		limits   backend.Limits
+		errorMsg string
+	}{
+		{backend.Limits{}, ""},
+		{backend.Limits{MaxSeries: 50, MaxPoints: 5000}, ""},
+		{backend.Limits{MaxSeries: -1}, "result_limits.max_series must not be negative"},
+		{backend.Limits{MaxPoints: -1}, "result_limits.max_points must not be negative"},
+	}
+
+	for _, tt := range tests {
+		limits := tt.limits
+		config := &Config{
+			Backend: "mock",
+			Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+			Limits:  &limits,
+		}
+		err := config.Validate()
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.limits, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.limits, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	tests := []struct {
+		limits   resources.Limits
+		errorMsg string
+	}{
+		{resources.Limits{}, ""},
+		{resources.Limits{Memory: "256Mi", CPU: 0.5}, ""},
+		{resources.Limits{Memory: "lots"}, "invalid limits.memory"},
+		{resources.Limits{Memory: "-1Gi"}, "invalid limits.memory"},
+		{resources.Limits{CPU: -1}, "limits.cpu must not be negative"},
+	}
+
+	for _, tt := range tests {
+		limits := tt.limits
+		config := &Config{
+			Backend:   "mock",
+			Queries:   []backend.Query{{Name: "Test", Expr: "test"}},
+			Resources: &limits,
+		}
+		err := config.Validate()
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.limits, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.limits, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestResultLimits(t *testing.T) {
+	config := &Config{}
+	if got := config.ResultLimits(); got.MaxSeries != backend.DefaultMaxSeries || got.MaxPoints != backend.DefaultMaxPoints {
+		t.Errorf("Expected the default limits, got %+v", got)
+	}
+	config.Limits = &backend.Limits{MaxPoints: 1000}
+	if got := config.ResultLimits(); got.MaxSeries != backend.DefaultMaxSeries || got.MaxPoints != 1000 {
+		t.Errorf("Expected the configured point limit with the default series limit, got %+v", got)
+	}
+}
+
+func TestLoadConfigLenient(t *testing.T) {
+	configContent := `backend: mock
+refresh: 10s
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: CPU Usage
+    expr: cpu_usage_by_host
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should tolerate unknown keys and duplicates, got %v", err)
+	}
+
+	if len(config.Warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", config.Warnings)
+	}
+	if !strings.Contains(config.Warnings[0], "line 2: field refresh not found") {
+		t.Errorf("Expected unknown key warning, got %q", config.Warnings[0])
+	}
+	if !strings.Contains(config.Warnings[1], `query 1: duplicate name "CPU Usage" (first used by query 0) (queries[1].name, line 7)`) {
+		t.Errorf("Expected duplicate name warning, got %q", config.Warnings[1])
+	}
+
+	_, err = LoadConfigStrict(configPath)
+	if err == nil || !strings.Contains(err.Error(), "field refresh not found") {
+		t.Errorf("LoadConfigStrict should fail on the unknown key, got %v", err)
+	}
+}
+
+func TestLoadConfigStrictDuplicateNames(t *testing.T) {
+	configContent := `backend: mock
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: CPU Usage
+    expr: cpu_usage_by_host
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	_, err = LoadConfigStrict(configPath)
+	if err == nil {
+		t.Fatal("LoadConfigStrict should reject duplicate query names")
+	}
+	if !strings.Contains(err.Error(), "duplicate name") {
+		t.Errorf("Error should mention the duplicate name, got: %v", err)
+	}
+}
+
+func TestLoadConfigUnusedSections(t *testing.T) {
+	configContent := `backend: prometheus
+prometheus:
+  url: "http://localhost:9090"
+influxdb:
+  url: "http://localhost:8086"
+  token: "token"
+influxdb1:
+  url: "http://localhost:8087"
+  database: telegraf
+profiles:
+  - name: staging
+    prometheus:
+      url: "http://staging:9090"
+    influxdb:
+      url: "http://staging:8086"
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: Disk
+    expr: disk_used
+    backend: influxdb1
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
+	}
+	expected := []string{
+		"influxdb section is ignored: backend is prometheus and no query sets backend: influxdb (influxdb, line 5)",
+		"profiles[0].influxdb section is ignored: backend is prometheus and no query sets backend: influxdb (profiles[0].influxdb, line 15)",
+	}
+	if strings.Join(config.Warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected warnings %q, got %q", expected, config.Warnings)
+	}
+
+	_, err = LoadConfigStrict(configPath)
+	if err == nil || !strings.Contains(err.Error(), "influxdb section is ignored") {
+		t.Errorf("LoadConfigStrict should fail on the ignored section, got %v", err)
+	}
+}
+
+func TestValidateAssignsQueryIDs(t *testing.T) {
+	config := &Config{
+		Backend: "mock",
+		Queries: []backend.Query{
+			{Name: "CPU Usage (%)", Expr: "cpu"},
+			{Name: "CPU Usage (%)", Expr: "cpu_by_host"},
+			{Name: "Memory", Expr: "mem", ID: "mem_used"},
+			{Name: "!!!", Expr: "other"},
+			{Name: "Größe (MB)", Expr: "size"},
+			{Name: "Задержка", Expr: "latency"},
+		},
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate should not return error, got %v", err)
+	}
+
+	// Derived IDs follow the rules of explicit ones
+	expected := []string{"cpu-usage", "cpu-usage-2", "mem_used", "query", "gr-e-mb", "query-2"}
+	for i, id := range expected {
+		if config.Queries[i].ID != id {
+			t.Errorf("Query %d: expected ID %q, got %q", i, id, config.Queries[i].ID)
+		}
+		if !queryID.MatchString(config.Queries[i].ID) {
+			t.Errorf("Query %d: ID %q is not a valid explicit ID", i, config.Queries[i].ID)
+		}
+	}
+}
+
+func TestValidateDuplicateQueryIDs(t *testing.T) {
+	config := &Config{
+		Backend: "mock",
+		Queries: []backend.Query{
+			{Name: "CPU", Expr: "cpu", ID: "cpu"},
+			{Name: "CPU by host", Expr: "cpu_by_host", ID: "cpu"},
+		},
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate should reject duplicate IDs")
+	}
+	if !strings.Contains(err.Error(), `query 1: duplicate id "cpu" (first used by query 0)`) {
+		t.Errorf("Error should name the duplicate ID, got: %v", err)
+	}
+
+	config.Queries[1].ID = "cpu by host"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "id may only contain") {
+		t.Errorf("Validate should reject IDs with spaces, got: %v", err)
+	}
+}
+
+func TestValidatePerQueryBackend(t *testing.T) {
+	config := &Config{
+		Backend:    "prometheus",
+		Prometheus: prom.Config{URL: "http://localhost:9090"},
+		Queries: []backend.Query{
+			{Name: "CPU", Expr: "cpu"},
+			{Name: "Disk", Expr: "disk", Backend: "influxdb1"},
+			{Name: "Memory", Expr: "mem", Backend: "prometheus"},
+		},
+	}
+
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "influxdb1.url is required") {
+		t.Fatalf("Validate should check the section of per-query backends, got %v", err)
+	}
+
+	config.InfluxDB1 = influxdb1.Config{URL: "http://localhost:8086", Database: "telegraf", ClientOptions: backend.ClientOptions{ConnectTimeout: 2 * time.Second}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate should not return error, got %v", err)
+	}
+
+	used := config.UsedBackends()
+	if len(used) != 2 || used[0] != "prometheus" || used[1] != "influxdb1" {
+		t.Errorf("Expected backends in order of use, got %v", used)
+	}
+	if config.ConnectTimeout("influxdb1") != 2*time.Second {
+		t.Errorf("Expected configured connect timeout, got %v", config.ConnectTimeout("influxdb1"))
+	}
+	if config.ConnectTimeout("prometheus") != backend.DefaultConnectTimeout {
+		t.Errorf("Expected default connect timeout, got %v", config.ConnectTimeout("prometheus"))
+	}
+
+	config.Queries[1].Backend = "graphite"
+	err = config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "query 1: unsupported backend: graphite") {
+		t.Errorf("Validate should reject unknown per-query backends, got %v", err)
+	}
+}
+
+func TestLoadConfigMultipleBackends(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+  connect_timeout: 2s
+mock:
+  seed: 42
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: Demo
+    expr: memory_usage
+    backend: mock
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfigStrict(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigStrict should not return error, got %v", err)
+	}
+
+	if config.Prometheus.ConnectTimeout != 2*time.Second {
+		t.Errorf("Expected connect timeout 2s, got %v", config.Prometheus.ConnectTimeout)
+	}
+	if config.BackendFor(config.Queries[1]) != "mock" {
+		t.Errorf("Expected second query on mock backend, got %s", config.BackendFor(config.Queries[1]))
+	}
+}
+
+func TestLoadConfigJoinPanel(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+mock:
+  seed: 42
+
+queries:
+  - name: Requests per Order
+    type: join
+    join:
+      left:
+        expr: sum(rate(http_requests_total[5m]))
+      right:
+        expr: orders
+        backend: mock
+      op: div
+      interpolation: previous
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfigStrict(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigStrict should not return error, got %v", err)
+	}
+
+	j := config.Queries[0].Join
+	if j == nil || j.Op != "div" || j.Interpolation != "previous" || j.Right.Backend != "mock" {
+		t.Fatalf("Unexpected join: %+v", j)
+	}
+	left, right := config.JoinBackends(config.Queries[0])
+	if left != "prometheus" || right != "mock" {
+		t.Errorf("Expected prometheus/mock join, got %s/%s", left, right)
+	}
+	used := config.UsedBackends()
+	if len(used) != 2 || used[0] != "prometheus" || used[1] != "mock" {
+		t.Errorf("Expected both join backends to be used, got %v", used)
+	}
+}
+
+func TestValidateJoinPanel(t *testing.T) {
+	valid := func() *backend.JoinConfig {
+		return &backend.JoinConfig{
+			Left:  backend.JoinSide{Expr: "requests"},
+			Right: backend.JoinSide{Expr: "orders", Backend: "mock"},
+			Op:    "div",
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(j *backend.JoinConfig) *backend.JoinConfig
+		expected string
+	}{
+		{"missing section", func(j *backend.JoinConfig) *backend.JoinConfig { return nil }, "join section is required"},
+		{"missing left", func(j *backend.JoinConfig) *backend.JoinConfig { j.Left.Expr = ""; return j }, "join.left.expr is required"},
+		{"missing right", func(j *backend.JoinConfig) *backend.JoinConfig { j.Right.Expr = ""; return j }, "join.right.expr is required"},
+		{"missing op", func(j *backend.JoinConfig) *backend.JoinConfig { j.Op = ""; return j }, "join.op is required"},
+		{"bad op", func(j *backend.JoinConfig) *backend.JoinConfig { j.Op = "pow"; return j }, "unsupported op: pow"},
+		{"bad interpolation", func(j *backend.JoinConfig) *backend.JoinConfig { j.Interpolation = "cubic"; return j }, "unsupported interpolation: cubic"},
+		{"bad backend", func(j *backend.JoinConfig) *backend.JoinConfig { j.Right.Backend = "graphite"; return j }, "unsupported backend: graphite"},
+		{"unconfigured backend", func(j *backend.JoinConfig) *backend.JoinConfig { j.Right.Backend = "influxdb1"; return j }, "influxdb1.url is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries: []backend.Query{
+					{Name: "Ratio", Type: "join", Join: tt.modify(valid())},
+				},
+			}
+			err := config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	config := &Config{
+		Backend:    "prometheus",
+		Prometheus: prom.Config{URL: "http://localhost:9090"},
+		Queries: []backend.Query{
+			{Name: "Ratio", Type: "join", Join: valid(), Thresholds: &backend.Thresholds{Warn: floatPtr(1)}},
+		},
+	}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "only supported on graph and histogram panels") {
+		t.Errorf("Thresholds should not be supported on join panels, got %v", err)
+	}
+}
+
+func TestValidateBurnRatePanel(t *testing.T) {
+	tests := []struct {
+		name     string
+		burnRate *backend.BurnRateConfig
+		expected string
+	}{
+		{"valid", &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}, ""},
+		{"missing section", nil, "burn_rate section is required"},
+		{"missing error ratio", &backend.BurnRateConfig{Objective: 99.9}, "burn_rate.error_ratio is required"},
+		{"missing window", &backend.BurnRateConfig{ErrorRatio: "errors[5m]", Objective: 99.9}, "burn_rate.error_ratio must use $window"},
+		{"objective out of range", &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 100}, "burn_rate.objective must be between 0 and 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries: []backend.Query{
+					{Name: "Burn", Type: "burn_rate", BurnRate: tt.burnRate},
+				},
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateStatusPanel(t *testing.T) {
+	valid := func() *backend.StatusConfig {
+		return &backend.StatusConfig{
+			By: "service",
+			Checks: []backend.StatusCheck{
+				{Name: "up", Expr: "up"},
+				{Name: "disk", Expr: `"disk"."used_percent" < 90`, Backend: "influxdb1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(s *backend.StatusConfig) *backend.StatusConfig
+		expected string
+	}{
+		{"valid", func(s *backend.StatusConfig) *backend.StatusConfig { return s }, ""},
+		{"missing section", func(s *backend.StatusConfig) *backend.StatusConfig { return nil }, "status section is required"},
+		{"missing by", func(s *backend.StatusConfig) *backend.StatusConfig { s.By = ""; return s }, "status.by is required"},
+		{"no checks", func(s *backend.StatusConfig) *backend.StatusConfig { s.Checks = nil; return s }, "status.checks must list at least one check"},
+		{"missing name", func(s *backend.StatusConfig) *backend.StatusConfig { s.Checks[1].Name = ""; return s }, "status.checks[1].name is required"},
+		{"duplicate name", func(s *backend.StatusConfig) *backend.StatusConfig { s.Checks[1].Name = "up"; return s }, `duplicate check "up"`},
+		{"missing expr", func(s *backend.StatusConfig) *backend.StatusConfig { s.Checks[0].Expr = ""; return s }, "status.checks[0].expr is required"},
+		{"bad backend", func(s *backend.StatusConfig) *backend.StatusConfig { s.Checks[1].Backend = "graphite"; return s }, "unsupported backend: graphite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				InfluxDB1:  influxdb1.Config{URL: "http://localhost:8086", Database: "telegraf"},
+				Queries: []backend.Query{
+					{Name: "Services", Type: "status", Status: tt.modify(valid())},
+				},
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if got := config.UsedBackends(); len(got) != 2 || got[1] != "influxdb1" {
+					t.Errorf("Expected the backends of both checks used, got %v", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    backend.Query
+		expected string
+	}{
+		{"valid", backend.Query{Name: "Power", Expr: "power_kw", Stats: &backend.StatsConfig{Show: []string{"time_avg", "integral"}, IntegralUnit: "1h"}}, ""},
+		{"nothing shown", backend.Query{Name: "Power", Expr: "power_kw", Stats: &backend.StatsConfig{}}, "stats.show must list at least one statistic"},
+		{"unknown statistic", backend.Query{Name: "Power", Expr: "power_kw", Stats: &backend.StatsConfig{Show: []string{"median"}}}, `got "median"`},
+		{"bad integral unit", backend.Query{Name: "Power", Expr: "power_kw", Stats: &backend.StatsConfig{Show: []string{"integral"}, IntegralUnit: "hour"}}, "invalid stats.integral_unit"},
+		{"calendar panel", backend.Query{Name: "Power", Expr: "power_kw", Type: "calendar", Stats: &backend.StatsConfig{Show: []string{"avg"}}}, "stats are only supported on graph and join panels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries:    []backend.Query{tt.query},
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    backend.Query
+		expected string
+	}{
+		{"units", backend.Query{Name: "Disk", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes", To: "GiB"}}, ""},
+		{"price", backend.Query{Name: "Cost", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes", To: "GB", Multiply: 0.02, Unit: "USD"}}, ""},
+		{"to missing", backend.Query{Name: "Disk", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes"}}, "from and to must be set together"},
+		{"unlike units", backend.Query{Name: "Disk", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes", To: "h"}}, "cannot convert bytes to h"},
+		{"nothing to do", backend.Query{Name: "Disk", Expr: "disk_bytes", Convert: &backend.ConvertConfig{Unit: "USD"}}, "from and to, multiply or divide is required"},
+		{"with unit", backend.Query{Name: "Disk", Expr: "disk_bytes", Unit: "GiB", Convert: &backend.ConvertConfig{Divide: 1 << 30}}, "convert and unit cannot both be set"},
+		{"slo panel", backend.Query{Name: "Disk", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Total: "total", Objective: 99, Window: "30d"}, Convert: &backend.ConvertConfig{Divide: 2}}, "convert is only supported on graph, join, calendar and histogram panels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries:    []backend.Query{tt.query},
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateSinks(t *testing.T) {
+	config := &Config{
+		Backend: "mock",
+		Queries: []backend.Query{{Name: "Test", Expr: "test"}},
+		Sinks: []sink.Config{
+			{Type: sink.TypeCSV, Dir: "series"},
+			{Type: sink.TypeRemoteWrite, URL: "mimir:9009"},
+		},
+	}
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "sink 1: url must be an http(s) URL") {
+		t.Fatalf("Expected the invalid sink to be reported, got %v", err)
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "sinks[1]" {
+		t.Errorf("Expected the error to point at sinks[1], got %v", err)
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	derived := []sink.Config{{Type: sink.TypeRemoteWrite, URL: "http://mimir:9009/api/v1/push", Series: sink.SeriesDerived}}
+	tests := []struct {
+		name     string
+		query    backend.Query
+		sinks    []sink.Config
+		expected string
+	}{
+		{"valid", backend.Query{Name: "Errors", Type: "join", Join: &backend.JoinConfig{Left: backend.JoinSide{Expr: "a"}, Right: backend.JoinSide{Expr: "b"}, Op: "div"}, Record: "job:errors:ratio"}, derived, ""},
+		{"bad name", backend.Query{Name: "Errors", Expr: "errors", Record: "job-errors"}, derived, `record must be a metric name, got "job-errors"`},
+		{"slo panel", backend.Query{Name: "Availability", Type: "slo", SLO: &backend.SLOConfig{Good: "good", Total: "total", Objective: 99.9, Window: "30d"}, Record: "availability"}, derived, "record is not supported on slo panels"},
+		{"no derived sink", backend.Query{Name: "Errors", Expr: "errors", Record: "errors"}, []sink.Config{{Type: sink.TypeCSV, Dir: "series"}}, "record needs a sink with series: derived or all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend: "mock",
+				Queries: []backend.Query{tt.query},
+				Sinks:   tt.sinks,
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateValueMappings(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    backend.Query
+		expected string
+	}{
+		{"valid", backend.Query{Name: "State", Expr: "up", ValueMappings: []backend.ValueMapping{{Value: 0, Text: "DOWN", Color: "red"}, {Value: 1, Text: "UP", Color: "#00ff00"}}}, ""},
+		{"missing text", backend.Query{Name: "State", Expr: "up", ValueMappings: []backend.ValueMapping{{Value: 0}}}, "value_mappings: text is required for value 0"},
+		{"duplicate value", backend.Query{Name: "State", Expr: "up", ValueMappings: []backend.ValueMapping{{Value: 1, Text: "UP"}, {Value: 1, Text: "ON"}}}, "value_mappings: duplicate value 1"},
+		{"bad color", backend.Query{Name: "State", Expr: "up", ValueMappings: []backend.ValueMapping{{Value: 0, Text: "DOWN", Color: "crimson"}}}, `got "crimson"`},
+		{"bool panel", backend.Query{Name: "State", Expr: "up", Type: "bool", ValueMappings: []backend.ValueMapping{{Value: 0, Text: "DOWN"}}}, "value_mappings are only supported on graph, join and calendar panels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries:    []backend.Query{tt.query},
+			}
+			err := config.Validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateHealthPanel(t *testing.T) {
+	config := &Config{
+		Backend:    "prometheus",
+		Prometheus: prom.Config{URL: "http://localhost:9090"},
+		Queries: []backend.Query{
+			{Name: "Influx", Type: "health"},
+		},
+	}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "query 0: health panels require the influxdb backend, got prometheus") {
+		t.Errorf("Health panels should require InfluxDB v2, got %v", err)
+	}
+
+	config.InfluxDB = influxdb.Config{URL: "http://localhost:8086", Token: "token", Org: "org", Bucket: "metrics"}
+	config.Queries[0].Backend = "influxdb"
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate should not return error, got %v", err)
+	}
+
+	config.Queries[0].Health = &backend.HealthConfig{Buckets: []string{"metrics", ""}}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "health.buckets must not contain empty names") {
+		t.Errorf("Expected empty bucket names to be rejected, got %v", err)
+	}
+
+	config.Queries[0].Health = nil
+	config.Queries[0].Percentiles = &backend.Percentiles{Quantiles: []float64{99}}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "percentiles are not supported on health panels") {
+		t.Errorf("Expected percentiles to be rejected, got %v", err)
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestLoadConfigThresholds(t *testing.T) {
+	configContent := `backend: mock
+alert_log: /tmp/promviz-breaches.jsonl
+
+queries:
+  - name: Free Disk
+    expr: disk_free
+    thresholds:
+      warn: 20
+      crit: 10
+      below: true
+`
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
+	}
+
+	th := config.Queries[0].Thresholds
+	if th == nil || th.Warn == nil || th.Crit == nil {
+		t.Fatal("Thresholds should be parsed")
+	}
+	if *th.Warn != 20 || *th.Crit != 10 || !th.Below {
+		t.Errorf("Unexpected thresholds: warn %v crit %v below %v", *th.Warn, *th.Crit, th.Below)
+	}
+	if !config.HasThresholds() {
+		t.Error("HasThresholds should report configured thresholds")
+	}
+
+	path, err := config.AlertLogPath()
+	if err != nil || path != "/tmp/promviz-breaches.jsonl" {
+		t.Errorf("Expected configured alert log path, got %q (%v)", path, err)
+	}
+}
+
+func TestSelectQuery(t *testing.T) {
+	config := &Config{
+		Queries: []backend.Query{
+			{Name: "CPU Usage", Expr: "cpu_usage"},
+			{Name: "Memory Usage", Expr: "memory_usage"},
+		},
+	}
+
+	if err := config.SelectQuery("Memory Usage"); err != nil {
+		t.Fatalf("SelectQuery should not return error, got %v", err)
+	}
+
+	if len(config.Queries) != 1 || config.Queries[0].Expr != "memory_usage" {
+		t.Errorf("Expected only the memory query to remain, got %v", config.Queries)
+	}
+
+	config.Queries = []backend.Query{
+		{ID: "cpu", Name: "CPU Usage", Expr: "cpu_usage"},
+		{ID: "mem", Name: "Memory Usage", Expr: "memory_usage"},
+	}
+	if err := config.SelectQuery("cpu"); err != nil || config.Queries[0].Name != "CPU Usage" {
+		t.Errorf("SelectQuery should also match IDs, got %v (%v)", config.Queries, err)
+	}
+
+	// An ID wins over an earlier query of the same name
+	config.Queries = []backend.Query{
+		{ID: "cpu", Name: "cpu-2", Expr: "cpu_usage"},
+		{ID: "cpu-2", Name: "CPU Usage", Expr: "cpu_by_host"},
+	}
+	if err := config.SelectQuery("cpu-2"); err != nil || config.Queries[0].Expr != "cpu_by_host" {
+		t.Errorf("SelectQuery should prefer the query with the ID, got %v (%v)", config.Queries, err)
+	}
+
+	err := config.SelectQuery("Disk Usage")
+	if err == nil {
+		t.Fatal("SelectQuery should return error for unknown query")
+	}
+	if !strings.Contains(err.Error(), `no query named "Disk Usage"`) {
+		t.Errorf("Error should name the missing query, got: %v", err)
+	}
+}
+
+func TestSelectTag(t *testing.T) {
+	config := &Config{
+		Queries: []backend.Query{
+			{Name: "CPU Usage", Expr: "cpu_usage", Tags: []string{"host"}},
+			{Name: "Postgres", Expr: "pg_up", Tags: []string{"db", "critical"}},
+			{Name: "Redis", Expr: "redis_up", Tags: []string{"db"}},
+		},
+	}
+
+	if err := config.SelectTag("db"); err != nil {
+		t.Fatalf("SelectTag should not return error, got %v", err)
+	}
+	if len(config.Queries) != 2 || config.Queries[0].Name != "Postgres" || config.Queries[1].Name != "Redis" {
+		t.Errorf("Expected the db queries to remain, got %v", config.Queries)
+	}
+
+	err := config.SelectTag("network")
+	if err == nil || !strings.Contains(err.Error(), `no query tagged "network"`) {
+		t.Errorf("Expected an error naming the missing tag, got %v", err)
+	}
+	if len(config.Queries) != 2 {
+		t.Errorf("A failed SelectTag should leave the queries alone, got %v", config.Queries)
+	}
+}
+
+func TestGetPrometheusConfig(t *testing.T) {
+	config := &Config{
+		Prometheus: prom.Config{URL: "http://localhost:9090"},
+	}
+
+	promConfig := config.GetPrometheusConfig()
+	if promConfig.URL != "http://localhost:9090" {
+		t.Errorf("Expected Prometheus URL 'http://localhost:9090', got '%s'", promConfig.URL)
+	}
+}
+
+func TestGetInfluxDBConfig(t *testing.T) {
+	config := &Config{
+		InfluxDB: influxdb.Config{
+			URL:    "http://localhost:8086",
+			Token:  "test-token",
+			Org:    "test-org",
+			Bucket: "test-bucket",
+		},
+	}
+
+	influxConfig := config.GetInfluxDBConfig()
+	if influxConfig.URL != "http://localhost:8086" {
+		t.Errorf("Expected InfluxDB URL 'http://localhost:8086', got '%s'", influxConfig.URL)
+	}
+	if influxConfig.Token != "test-token" {
+		t.Errorf("Expected InfluxDB token 'test-token', got '%s'", influxConfig.Token)
+	}
+}
+
+func TestGetInfluxDB1Config(t *testing.T) {
+	config := &Config{
+		InfluxDB1: influxdb1.Config{
+			URL:      "http://localhost:8086",
+			Username: "admin",
+			Password: "password",
+			Database: "telegraf",
+		},
+	}
+
+	influx1Config := config.GetInfluxDB1Config()
+	if influx1Config.URL != "http://localhost:8086" {
+		t.Errorf("Expected InfluxDB v1 URL 'http://localhost:8086', got '%s'", influx1Config.URL)
+	}
+	if influx1Config.Username != "admin" {
+		t.Errorf("Expected InfluxDB v1 username 'admin', got '%s'", influx1Config.Username)
+	}
+	if influx1Config.Password != "password" {
+		t.Errorf("Expected InfluxDB v1 password 'password', got '%s'", influx1Config.Password)
+	}
+	if influx1Config.Database != "telegraf" {
+		t.Errorf("Expected InfluxDB v1 database 'telegraf', got '%s'", influx1Config.Database)
+	}
+}
+
+func TestValidateEvaluation(t *testing.T) {
+	tests := []struct {
+		query backend.Query
+		err   string
+	}{
+		{backend.Query{Name: "Errors", Expr: "increase(errors_total[1h])", Evaluation: "instant_over_range"}, ""},
+		{backend.Query{Name: "Errors", Expr: "up", Evaluation: "range"}, ""},
+		{backend.Query{Name: "Errors", Expr: "up", Evaluation: "instant"}, "evaluation must be range or instant_over_range"},
+		{backend.Query{Name: "Errors", Expr: "up", Type: "bool", Evaluation: "instant_over_range"}, "only supported on graph panels"},
+		{backend.Query{Name: "Errors", Expr: "up", Backend: "mock", Evaluation: "instant_over_range"}, "requires the prometheus backend, got mock"},
+	}
+	for _, tt := range tests {
+		config := &Config{
+			Prometheus: prom.Config{URL: "http://localhost:9090"},
+			Queries:    []backend.Query{tt.query},
+		}
+		err := config.Validate()
+		if tt.err == "" && err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", tt.query, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Expected an error containing %q for %+v, got %v", tt.err, tt.query, err)
+		}
+	}
+}
+
+func TestLoadConfigTimingConflicts(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+queries:
+  - name: Pinned
+    expr: sum(rate(http_requests_total[5m] @ end()))
+  - name: Shifted
+    expr: up offset 1d
+    offset: 1h
+  - name: Worst Hour
+    expr: max_over_time(rate(errors_total[5m])[1h:1m])
+    range: 1h
+  - name: Once
+    expr: max_over_time(rate(errors_total[5m])[1h:1m] @ end())
+    range: 1h
+    evaluation: instant_over_range
+  - name: Rolling
+    expr: max_over_time(rate(errors_total[5m])[10m:1m])
+    range: 1h
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig should not return error, got %v", err)
+	}
+	expected := []string{
+		"query 0: expr pins its evaluation time with @, so every point of the range has the same value; set evaluation: instant_over_range to evaluate it once (queries[0].expr, line 5)",
+		"query 1: expr has an offset of its own, which adds to offset: 1h (queries[1].offset, line 8)",
+		"query 2: expr has a subquery over 1h, as long as the panel's range, evaluated at every point; set evaluation: instant_over_range to evaluate it once over the range (queries[2].expr, line 10)",
+	}
+	if strings.Join(config.Warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected warnings %q, got %q", expected, config.Warnings)
+	}
+
+	if _, err := LoadConfigStrict(configPath); err == nil || !strings.Contains(err.Error(), "pins its evaluation time") {
+		t.Errorf("LoadConfigStrict should fail on the conflict, got %v", err)
+	}
+}
+
+func TestValidateActiveHours(t *testing.T) {
+	business := &schedule.Hours{From: "06:00", To: "20:00"}
+	config := &Config{
+		Backend:     "mock",
+		ActiveHours: business,
+		Queries: []backend.Query{
+			{Name: "Batch", Expr: "batch"},
+			{Name: "Nightly", Expr: "nightly", ActiveHours: &schedule.Hours{From: "22:00", To: "04:00"}},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if config.ActiveHoursFor(config.Queries[0]) != business {
+		t.Error("Queries without active_hours should use the top-level one")
+	}
+	if got := config.ActiveHoursFor(config.Queries[1]); got.From != "22:00" {
+		t.Errorf("Expected the query's own active_hours, got %v", got)
+	}
+
+	config.ActiveHours = &schedule.Hours{From: "6", To: "20:00"}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), `invalid active_hours: from must be a time such as 06:00, got "6"`) {
+		t.Errorf("Expected an invalid top-level active_hours, got %v", err)
+	}
+
+	config.ActiveHours = nil
+	config.Queries[1].ActiveHours.Days = []string{"someday"}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "query 1: invalid active_hours: days must be") {
+		t.Errorf("Expected an invalid query active_hours, got %v", err)
 	}
 }
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// layers is a configuration merged from an overlay and the files it extends
+type layers struct {
+	root     *yaml.Node            // merged mapping of all files
+	files    map[*yaml.Node]string // file each node was read from
+	warnings []string
+}
+
+// loadLayers follows the extends chain starting at path and merges the files,
+// base first, so that each overlay overrides the file it extends. Every file
+// is checked for unknown fields on its own so reports point at the right file.
+func loadLayers(path string, strict bool) (*layers, error) {
+	l := &layers{files: make(map[*yaml.Node]string)}
+
+	var chain []string
+	var roots []*yaml.Node
+	var warnings [][]string
+	for next := path; next != ""; {
+		abs, err := filepath.Abs(next)
+		if err != nil {
+			return nil, err
+		}
+		for _, seen := range chain {
+			if seen == abs {
+				return nil, fmt.Errorf("invalid configuration: extends cycle: %s -> %s", strings.Join(chain, " -> "), abs)
+			}
+		}
+		chain = append(chain, abs)
+
+		data, err := ioutil.ReadFile(next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var layer Config
+		unknown, err := decodeFields(data, &layer, strict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %s: %w", next, err)
+		}
+		for i := range unknown {
+			unknown[i] = next + ": " + unknown[i]
+		}
+		warnings = append(warnings, unknown)
+
+		root, err := layerRoot(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %s: %w", next, err)
+		}
+		dir := filepath.Dir(next)
+		resolveSnippetFiles(root, dir)
+		removeKey(root, "extends")
+		tagNodes(root, next, l.files)
+		roots = append(roots, root)
+
+		next = layer.Extends
+		if next != "" && !filepath.IsAbs(next) {
+			next = filepath.Join(dir, next)
+		}
+	}
+
+	// The last file of the chain is the base
+	l.root = roots[len(roots)-1]
+	l.warnings = warnings[len(warnings)-1]
+	for i := len(roots) - 2; i >= 0; i-- {
+		l.root = mergeConfig(l.root, roots[i])
+		l.warnings = append(l.warnings, warnings[i]...)
+	}
+
+	return l, nil
+}
+
+// layerRoot parses a config file into its top-level mapping
+func layerRoot(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: config must be a mapping", root.Line)
+	}
+	return root, nil
+}
+
+// resolveSnippetFiles makes snippet_files absolute, relative to the file
+// listing them rather than to the overlay that is finally loaded
+func resolveSnippetFiles(root *yaml.Node, dir string) {
+	files := mappingValue(root, "snippet_files")
+	if files == nil || files.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, file := range files.Content {
+		if file.Kind == yaml.ScalarNode && !filepath.IsAbs(file.Value) {
+			path := filepath.Join(dir, file.Value)
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			file.Value = path
+		}
+	}
+}
+
+// removeKey deletes key and its value from a mapping node
+func removeKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// tagNodes records the file every node of a tree was read from
+func tagNodes(node *yaml.Node, file string, files map[*yaml.Node]string) {
+	files[node] = file
+	for _, child := range node.Content {
+		tagNodes(child, file, files)
+	}
+}
+
+// mergeConfig applies an overlay to a base config. Queries are merged with
+// mergeQueries; everything else follows mergeNodes.
+func mergeConfig(base, overlay *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		if key.Value == "queries" {
+			if queries := mappingValue(base, "queries"); queries != nil {
+				setValue(base, key, mergeQueries(queries, value))
+				continue
+			}
+		}
+		setValue(base, key, mergeNodes(mappingValue(base, key.Value), value))
+	}
+	return base
+}
+
+// mergeNodes merges mappings key by key; any other overlay value, including
+// lists and null, replaces the base value
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		setValue(base, key, mergeNodes(mappingValue(base, key.Value), value))
+	}
+	return base
+}
+
+// mergeQueries merges overlay queries into the base queries with the same id
+// or name and appends the others
+func mergeQueries(base, overlay *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.SequenceNode || overlay.Kind != yaml.SequenceNode {
+		return overlay
+	}
+	for _, query := range overlay.Content {
+		if i := matchQuery(base, query); i >= 0 {
+			base.Content[i] = mergeNodes(base.Content[i], query)
+		} else {
+			base.Content = append(base.Content, query)
+		}
+	}
+	return base
+}
+
+// matchQuery returns the index of the base query an overlay query refers to,
+// or -1. Queries are matched by id if the overlay sets one, otherwise by name.
+func matchQuery(base, query *yaml.Node) int {
+	id, name := scalarValue(query, "id"), scalarValue(query, "name")
+	for i, candidate := range base.Content {
+		if id != "" {
+			candidateID := scalarValue(candidate, "id")
+			if candidateID == "" {
+				candidateID = slugify(scalarValue(candidate, "name"))
+			}
+			if candidateID == id {
+				return i
+			}
+		} else if name != "" && scalarValue(candidate, "name") == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// scalarValue returns the value of a scalar field of a mapping node, or ""
+func scalarValue(node *yaml.Node, key string) string {
+	if value := mappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+// setValue sets the value of key in a mapping node, appending the pair if the
+// key is new
+func setValue(node, key, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key.Value {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, key, value)
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const overlayBase = `prometheus:
+  url: "http://prometheus.prod:9090"
+  connect_timeout: 10s
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+    thresholds:
+      warn: 70
+      crit: 90
+  - id: mem
+    name: Memory Usage
+    expr: memory_usage
+`
+
+// writeFiles creates the named files in a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigOverlay(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": overlayBase,
+		"team/staging.yaml": `extends: ../base.yaml
+prometheus:
+  url: "http://prometheus.staging:9090"
+
+queries:
+  - name: CPU Usage
+    thresholds:
+      crit: 95
+  - id: mem
+    range: 1h
+  - name: Disk Usage
+    expr: disk_usage
+`,
+	})
+
+	cfg, err := LoadConfigStrict(filepath.Join(dir, "team", "staging.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfigStrict failed: %v", err)
+	}
+
+	if cfg.Prometheus.URL != "http://prometheus.staging:9090" {
+		t.Errorf("Overlay should override the URL, got %q", cfg.Prometheus.URL)
+	}
+	if cfg.Prometheus.ConnectTimeout.String() != "10s" {
+		t.Errorf("Base settings should be kept, got connect_timeout %v", cfg.Prometheus.ConnectTimeout)
+	}
+
+	if len(cfg.Queries) != 3 {
+		t.Fatalf("Expected 3 queries, got %d", len(cfg.Queries))
+	}
+
+	cpu := cfg.Queries[0]
+	if cpu.Expr != "cpu_usage" || *cpu.Thresholds.Warn != 70 || *cpu.Thresholds.Crit != 95 {
+		t.Errorf("Thresholds should be merged field by field, got %+v", cpu)
+	}
+	if mem := cfg.Queries[1]; mem.Expr != "memory_usage" || mem.Range != "1h" {
+		t.Errorf("Query matched by id should be merged, got %+v", mem)
+	}
+	if disk := cfg.Queries[2]; disk.Name != "Disk Usage" {
+		t.Errorf("New queries should be appended, got %+v", disk)
+	}
+}
+
+func TestLoadConfigOverlayReplacesLists(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": overlayBase,
+		"overlay.yaml": `extends: base.yaml
+queries:
+  - name: CPU Usage
+    thresholds: null
+`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "overlay.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Queries[0].Thresholds != nil {
+		t.Errorf("A null overlay value should remove the base value, got %+v", cfg.Queries[0].Thresholds)
+	}
+}
+
+func TestLoadConfigOverlayRelativePath(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"configs/base.yaml": overlayBase,
+		"configs/snip.yaml": "snippets:\n  - name: errors\n    expr: http_errors_total\n",
+		"configs/app.yaml": `extends: base.yaml
+snippet_files: [snip.yaml]
+queries:
+  - name: Errors
+    expr: sum($errors)
+`,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// Snippet files must not be joined with the config directory twice
+	cfg, err := LoadConfig(filepath.Join("configs", "app.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if q := cfg.Queries[2]; q.Expr != "sum(http_errors_total)" {
+		t.Errorf("Expected the snippet to be expanded, got %q", q.Expr)
+	}
+}
+
+func TestLoadConfigOverlayErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": overlayBase,
+		"a.yaml":    "extends: b.yaml\n",
+		"b.yaml":    "extends: a.yaml\n",
+		"bad.yaml": `extends: base.yaml
+queries:
+  - name: Disk Usage
+    range: 1h
+`,
+		"typo.yaml": `extends: base.yaml
+promethues:
+  url: "http://localhost:9090"
+`,
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Errorf("Expected cycle error, got %v", err)
+	}
+
+	_, err = LoadConfig(filepath.Join(dir, "bad.yaml"))
+	expected := "bad.yaml line 3)"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error pointing at %q, got %v", expected, err)
+	}
+
+	cfg, err := LoadConfig(filepath.Join(dir, "typo.yaml"))
+	if err != nil {
+		t.Fatalf("Lenient load should tolerate unknown fields: %v", err)
+	}
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "typo.yaml: line 2: field promethues not found") {
+		t.Errorf("Expected warning naming the overlay file, got %v", cfg.Warnings)
+	}
+}
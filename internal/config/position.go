@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a validation error tied to a field of the configuration file
+type FieldError struct {
+	Path string // dotted path, e.g. "queries[3].expr"
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError creates a FieldError for the field at path
+func fieldError(path, format string, args ...interface{}) error {
+	return &FieldError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// queryError prefixes a query validation error with the query index and
+// extends its field path to be relative to the config root
+func queryError(index int, err error) error {
+	path := fmt.Sprintf("queries[%d]", index)
+
+	var fe *FieldError
+	if errors.As(err, &fe) && fe.Path != "" {
+		path += "." + fe.Path
+	}
+
+	return &FieldError{Path: path, Err: fmt.Errorf("query %d: %w", index, err)}
+}
+
+// withLine annotates a validation error with the field path and the line it
+// refers to in the YAML source, e.g. "(queries[3].expr, line 27)". If the
+// field itself is absent, the line of its closest enclosing node is used.
+func withLine(data []byte, err error) error {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil {
+		return err
+	}
+	return withNodeLine(&root, nil, err)
+}
+
+// withNodeLine is like withLine for an already parsed document. When files
+// maps nodes to the file they were read from, the file is named as well.
+func withNodeLine(root *yaml.Node, files map[*yaml.Node]string, err error) error {
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		return err
+	}
+
+	node := lookupNode(root, fe.Path)
+	if node == nil {
+		return fmt.Errorf("%w (%s)", err, fe.Path)
+	}
+	if file, ok := files[node]; ok {
+		return fmt.Errorf("%w (%s, %s line %d)", err, fe.Path, file, node.Line)
+	}
+	return fmt.Errorf("%w (%s, line %d)", err, fe.Path, node.Line)
+}
+
+// lookupNode walks a dotted path with optional [index] segments through a
+// YAML document and returns the deepest node found, or nil if not even its
+// first segment exists
+func lookupNode(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	var found *yaml.Node
+	for _, segment := range strings.Split(path, ".") {
+		key, index := splitIndex(segment)
+
+		next := mappingValue(node, key)
+		if next == nil {
+			return found
+		}
+		node = next
+		found = node
+
+		if index >= 0 {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return found
+			}
+			node = node.Content[index]
+			found = node
+		}
+	}
+
+	return found
+}
+
+// splitIndex splits "queries[3]" into "queries" and 3; index is -1 if absent
+func splitIndex(segment string) (string, int) {
+	open := strings.Index(segment, "[")
+	if open < 0 || !strings.HasSuffix(segment, "]") {
+		return segment, -1
+	}
+	index, err := strconv.Atoi(segment[open+1 : len(segment)-1])
+	if err != nil {
+		return segment, -1
+	}
+	return segment[:open], index
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
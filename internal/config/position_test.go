@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const positionConfig = `backend: mock
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: Memory Usage
+    experssion: memory_usage
+`
+
+func TestLookupNode(t *testing.T) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(positionConfig), &root); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"backend", 1},
+		{"queries", 4},
+		{"queries[0].expr", 5},
+		{"queries[1]", 6},
+		{"queries[1].expr", 6}, // missing field falls back to its query
+		{"queries[7].expr", 4}, // missing query falls back to the list
+		{"prometheus.url", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := 0
+			if node := lookupNode(&root, tt.path); node != nil {
+				got = node.Line
+			}
+			if got != tt.expected {
+				t.Errorf("Expected line %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(positionConfig), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	_, err := LoadConfigStrict(configPath)
+	if err == nil {
+		t.Fatal("LoadConfigStrict should reject unknown fields")
+	}
+	if !strings.Contains(err.Error(), "line 7: field experssion not found") {
+		t.Errorf("Error should name the unknown field and its line, got: %v", err)
+	}
+
+	// Lenient mode still fails validation on the resulting empty expr
+	_, err = LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "query 1: expr is required") {
+		t.Errorf("Expected the missing expr to be reported, got: %v", err)
+	}
+}
+
+func TestLoadConfigValidationLine(t *testing.T) {
+	content := `backend: mock
+
+queries:
+  - name: CPU Usage
+    expr: cpu_usage
+  - name: Memory Usage
+    range: 1h
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("LoadConfig should fail for a query without expr")
+	}
+	expected := "query 1: expr is required (queries[1].expr, line 6)"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Error should contain %q, got: %v", expected, err)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"promviz/internal/backend/influxdb"
+	"promviz/internal/backend/influxdb1"
+	"promviz/internal/backend/prom"
+)
+
+// DefaultProfile names the top-level backend sections in the profile switcher
+const DefaultProfile = "default"
+
+// Profile is an alternative set of backend sections, e.g. for a staging
+// environment, that the dashboard can switch to at runtime. Sections it sets
+// replace the top-level ones; the others are shared.
+type Profile struct {
+	Name       string            `yaml:"name"`
+	Prometheus *prom.Config      `yaml:"prometheus,omitempty"`
+	InfluxDB   *influxdb.Config  `yaml:"influxdb,omitempty"`
+	InfluxDB1  *influxdb1.Config `yaml:"influxdb1,omitempty"`
+}
+
+// ProfileNames lists the profiles to switch between, starting with the
+// default. It is empty if no profiles are configured.
+func (c *Config) ProfileNames() []string {
+	if len(c.Profiles) == 0 {
+		return nil
+	}
+	names := []string{DefaultProfile}
+	for _, p := range c.Profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// WithProfile returns a copy of the config using the backend sections of the
+// named profile
+func (c *Config) WithProfile(name string) (*Config, error) {
+	if name == DefaultProfile {
+		return c, nil
+	}
+	for _, p := range c.Profiles {
+		if p.Name != name {
+			continue
+		}
+		profiled := *c
+		if p.Prometheus != nil {
+			profiled.Prometheus = *p.Prometheus
+		}
+		if p.InfluxDB != nil {
+			profiled.InfluxDB = *p.InfluxDB
+		}
+		if p.InfluxDB1 != nil {
+			profiled.InfluxDB1 = *p.InfluxDB1
+		}
+		return &profiled, nil
+	}
+	return nil, fmt.Errorf("unknown profile %q", name)
+}
+
+// validateProfiles checks that profiles have unique names and complete
+// sections for every backend the queries use
+func (c *Config) validateProfiles() error {
+	seen := make(map[string]int)
+	for i, p := range c.Profiles {
+		if p.Name == "" {
+			return profileError(i, fieldError("name", "name is required"))
+		}
+		if p.Name == DefaultProfile {
+			return profileError(i, fieldError("name", "name %q is reserved for the top-level backends", DefaultProfile))
+		}
+		if j, ok := seen[p.Name]; ok {
+			return profileError(i, fieldError("name", "duplicate name %q (first used by profile %d)", p.Name, j))
+		}
+		seen[p.Name] = i
+
+		profiled, _ := c.WithProfile(p.Name)
+		for _, name := range c.UsedBackends() {
+			if err := profiled.validateBackend(name); err != nil {
+				return profileError(i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// profileError prefixes a profile validation error with the profile index
+// and extends its field path to be relative to the config root
+func profileError(index int, err error) error {
+	path := fmt.Sprintf("profiles[%d]", index)
+
+	var fe *FieldError
+	if errors.As(err, &fe) && fe.Path != "" {
+		path += "." + fe.Path
+	}
+
+	return &FieldError{Path: path, Err: fmt.Errorf("profile %d: %w", index, err)}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+	"promviz/internal/backend/prom"
+)
+
+func TestLoadConfigProfiles(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://prometheus.prod:9090"
+influxdb1:
+  url: "http://influxdb:8086"
+  database: "telegraf"
+
+profiles:
+  - name: staging
+    prometheus:
+      url: "http://prometheus.staging:9090"
+
+queries:
+  - name: Request Rate
+    expr: sum(rate(http_requests_total[5m]))
+  - name: Disk Free
+    backend: influxdb1
+    expr: 'SELECT mean("free") FROM "disk"'
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if names := config.ProfileNames(); !reflect.DeepEqual(names, []string{"default", "staging"}) {
+		t.Errorf("Unexpected profile names %v", names)
+	}
+
+	staging, err := config.WithProfile("staging")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	if staging.Prometheus.URL != "http://prometheus.staging:9090" {
+		t.Errorf("Expected the staging Prometheus, got %s", staging.Prometheus.URL)
+	}
+	if staging.InfluxDB1.URL != "http://influxdb:8086" {
+		t.Errorf("Sections the profile leaves out should be shared, got %s", staging.InfluxDB1.URL)
+	}
+	if config.Prometheus.URL != "http://prometheus.prod:9090" {
+		t.Error("WithProfile should not change the config")
+	}
+
+	if c, _ := config.WithProfile(DefaultProfile); c != config {
+		t.Error("The default profile should be the config itself")
+	}
+	if _, err := config.WithProfile("qa"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestProfileNamesWithoutProfiles(t *testing.T) {
+	config := &Config{}
+	if names := config.ProfileNames(); names != nil {
+		t.Errorf("Expected no profiles, got %v", names)
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []Profile
+		errorMsg string
+		path     string
+	}{
+		{
+			name:     "Missing name",
+			profiles: []Profile{{}},
+			errorMsg: "profile 0: name is required",
+			path:     "profiles[0].name",
+		},
+		{
+			name:     "Reserved name",
+			profiles: []Profile{{Name: "default"}},
+			errorMsg: `profile 0: name "default" is reserved`,
+			path:     "profiles[0].name",
+		},
+		{
+			name:     "Duplicate name",
+			profiles: []Profile{{Name: "staging"}, {Name: "staging"}},
+			errorMsg: `profile 1: duplicate name "staging" (first used by profile 0)`,
+			path:     "profiles[1].name",
+		},
+		{
+			name:     "Incomplete section",
+			profiles: []Profile{{Name: "staging", Prometheus: &prom.Config{}}},
+			errorMsg: "profile 0: prometheus.url is required",
+			path:     "profiles[0].prometheus.url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Backend:    "prometheus",
+				Prometheus: prom.Config{URL: "http://localhost:9090"},
+				Queries:    []backend.Query{{Name: "Test", Expr: "test"}},
+				Profiles:   tt.profiles,
+			}
+			err := config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Fatalf("Expected error containing %q, got %v", tt.errorMsg, err)
+			}
+			var fe *FieldError
+			if !errors.As(err, &fe) || fe.Path != tt.path {
+				t.Errorf("Expected path %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
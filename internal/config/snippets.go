@@ -0,0 +1,329 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"promviz/internal/backend"
+)
+
+// Snippet is a named expression fragment that queries reference as
+// $name(arg, ...), or $name when it takes no parameters. Parameters are
+// referenced inside the snippet as $param.
+type Snippet struct {
+	Name   string   `yaml:"name"`
+	Params []string `yaml:"params,omitempty"`
+	Expr   string   `yaml:"expr"`
+}
+
+// snippetFile is the layout of a shared file listed in snippet_files
+type snippetFile struct {
+	Snippets []Snippet `yaml:"snippets"`
+}
+
+// identifier matches snippet and parameter names
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// expandSnippets replaces snippet references in query expressions. Snippets
+// from snippet_files (resolved relative to dir) are loaded first, and those
+// defined in the config itself override them.
+func (c *Config) expandSnippets(dir string) error {
+	if len(c.Snippets) == 0 && len(c.SnippetFiles) == 0 {
+		return nil
+	}
+
+	snippets := make(map[string]Snippet)
+	for i, file := range c.SnippetFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		shared, err := loadSnippetFile(file)
+		if err != nil {
+			return &FieldError{Path: fmt.Sprintf("snippet_files[%d]", i), Err: err}
+		}
+		for _, s := range shared {
+			if _, ok := snippets[s.Name]; ok {
+				return fieldError(fmt.Sprintf("snippet_files[%d]", i), "%s: snippet %q is already defined by another snippet file", file, s.Name)
+			}
+			snippets[s.Name] = s
+		}
+	}
+
+	local := make(map[string]bool)
+	for i, s := range c.Snippets {
+		if err := validateSnippet(s); err != nil {
+			return snippetError(i, err)
+		}
+		if local[s.Name] {
+			return snippetError(i, fieldError("name", "duplicate snippet %q", s.Name))
+		}
+		local[s.Name] = true
+		snippets[s.Name] = s
+	}
+
+	for i := range c.Queries {
+		q := &c.Queries[i]
+		expr, err := expand(q.Expr, snippets, nil, nil)
+		if err != nil {
+			return queryError(i, fieldError("expr", "%w", err))
+		}
+		q.Expr = expr
+
+		if q.SLO != nil {
+			if q.SLO.Good, err = expand(q.SLO.Good, snippets, nil, nil); err != nil {
+				return queryError(i, fieldError("slo.good", "%w", err))
+			}
+			if q.SLO.Total, err = expand(q.SLO.Total, snippets, nil, nil); err != nil {
+				return queryError(i, fieldError("slo.total", "%w", err))
+			}
+		}
+
+		if q.BurnRate != nil {
+			// The window placeholder is left for the panel to fill in
+			window := map[string]string{strings.TrimPrefix(backend.WindowPlaceholder, "$"): backend.WindowPlaceholder}
+			if q.BurnRate.ErrorRatio, err = expand(q.BurnRate.ErrorRatio, snippets, window, nil); err != nil {
+				return queryError(i, fieldError("burn_rate.error_ratio", "%w", err))
+			}
+		}
+
+		if q.Status != nil {
+			for j := range q.Status.Checks {
+				check := &q.Status.Checks[j]
+				if check.Expr, err = expand(check.Expr, snippets, nil, nil); err != nil {
+					return queryError(i, fieldError(fmt.Sprintf("status.checks[%d].expr", j), "%w", err))
+				}
+			}
+		}
+
+		if q.Join != nil {
+			if q.Join.Left.Expr, err = expand(q.Join.Left.Expr, snippets, nil, nil); err != nil {
+				return queryError(i, fieldError("join.left.expr", "%w", err))
+			}
+			if q.Join.Right.Expr, err = expand(q.Join.Right.Expr, snippets, nil, nil); err != nil {
+				return queryError(i, fieldError("join.right.expr", "%w", err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadSnippetFile reads the snippets of a shared snippet file
+func loadSnippetFile(path string) ([]Snippet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippet file: %w", err)
+	}
+
+	var file snippetFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: failed to parse YAML: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range file.Snippets {
+		if err := validateSnippet(s); err != nil {
+			return nil, fmt.Errorf("%s: snippet %d: %w", path, i, err)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("%s: duplicate snippet %q", path, s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	return file.Snippets, nil
+}
+
+// snippetError prefixes a snippet validation error with the snippet index
+func snippetError(index int, err error) error {
+	path := fmt.Sprintf("snippets[%d]", index)
+
+	var fe *FieldError
+	if errors.As(err, &fe) && fe.Path != "" {
+		path += "." + fe.Path
+	}
+
+	return &FieldError{Path: path, Err: fmt.Errorf("snippet %d: %w", index, err)}
+}
+
+// validateSnippet checks the name, parameters and body of a snippet
+func validateSnippet(s Snippet) error {
+	if !identifier.MatchString(s.Name) {
+		return fieldError("name", "snippet name %q must be a letter or '_' followed by letters, digits or '_'", s.Name)
+	}
+	if s.Expr == "" {
+		return fieldError("expr", "expr is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range s.Params {
+		if !identifier.MatchString(p) {
+			return fieldError("params", "invalid parameter name %q", p)
+		}
+		if seen[p] {
+			return fieldError("params", "duplicate parameter %q", p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
+
+// expand substitutes parameters and snippet references in s. params is nil
+// at the top level of a query expression; active lists the snippets being
+// expanded so that cycles are reported instead of recursing forever.
+func expand(s string, snippets map[string]Snippet, params map[string]string, active []string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(s) && isIdentByte(s[end], end == i+1) {
+			end++
+		}
+		name := s[i+1 : end]
+		if name == "" {
+			// A lone '$' is not a reference
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		if end < len(s) && s[end] == '(' {
+			args, next, err := splitArgs(s, end)
+			if err != nil {
+				return "", fmt.Errorf("$%s: %w", name, err)
+			}
+			expanded, err := call(name, args, snippets, params, active)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+			i = next
+			continue
+		}
+
+		if value, ok := params[name]; ok {
+			b.WriteString(value)
+		} else if _, ok := snippets[name]; ok {
+			expanded, err := call(name, nil, snippets, params, active)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+		} else if len(active) > 0 {
+			return "", fmt.Errorf("unknown snippet or parameter $%s in snippet $%s", name, active[len(active)-1])
+		} else {
+			return "", fmt.Errorf("unknown snippet $%s", name)
+		}
+		i = end
+	}
+
+	return b.String(), nil
+}
+
+// call expands a reference to the named snippet with the given raw arguments
+func call(name string, args []string, snippets map[string]Snippet, params map[string]string, active []string) (string, error) {
+	snippet, ok := snippets[name]
+	if !ok {
+		return "", fmt.Errorf("unknown snippet $%s", name)
+	}
+	for _, a := range active {
+		if a == name {
+			return "", fmt.Errorf("snippet cycle: $%s -> $%s", strings.Join(active, " -> $"), name)
+		}
+	}
+	if len(args) != len(snippet.Params) {
+		return "", fmt.Errorf("snippet $%s takes %d arguments, got %d", name, len(snippet.Params), len(args))
+	}
+
+	// Arguments may themselves use parameters of the enclosing snippet
+	bound := make(map[string]string, len(args))
+	for i, arg := range args {
+		value, err := expand(arg, snippets, params, active)
+		if err != nil {
+			return "", err
+		}
+		bound[snippet.Params[i]] = value
+	}
+
+	expanded, err := expand(snippet.Expr, snippets, bound, append(active[:len(active):len(active)], name))
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// splitArgs parses the comma-separated arguments of the call whose opening
+// parenthesis is at open, respecting nested brackets and quoted strings. It
+// returns the trimmed arguments and the index just past the closing parenthesis.
+func splitArgs(s string, open int) ([]string, int, error) {
+	var args []string
+	depth := 0
+	start := open + 1
+	var quote byte
+
+	for i := open + 1; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if c != ')' {
+				return nil, 0, fmt.Errorf("unbalanced %q in arguments", c)
+			}
+			last := strings.TrimSpace(s[start:i])
+			if last != "" || len(args) > 0 {
+				args = append(args, last)
+			}
+			return args, i + 1, nil
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return nil, 0, fmt.Errorf("missing closing parenthesis")
+}
+
+// isIdentByte reports whether c may appear in an identifier at this position
+func isIdentByte(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	snippets := map[string]Snippet{
+		"cpu_by_mode": {
+			Name:   "cpu_by_mode",
+			Params: []string{"instance", "mode"},
+			Expr:   `sum(rate(node_cpu_seconds_total{instance=$instance, mode=$mode}[5m]))`,
+		},
+		"busy": {
+			Name:   "busy",
+			Params: []string{"instance"},
+			Expr:   `100 - $cpu_by_mode($instance, "idle")`,
+		},
+		"up": {Name: "up", Expr: `up == 1`},
+		"sel": {
+			Name:   "sel",
+			Params: []string{"labels"},
+			Expr:   `up{$labels}`,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{"no references", `rate(x[5m])`, `rate(x[5m])`},
+		{"call", `$cpu_by_mode("web-1", "user")`, `sum(rate(node_cpu_seconds_total{instance="web-1", mode="user"}[5m]))`},
+		{"nested", `$busy("db-1") > 80`, `100 - sum(rate(node_cpu_seconds_total{instance="db-1", mode="idle"}[5m])) > 80`},
+		{"no parameters", `count($up)`, `count(up == 1)`},
+		{"empty call", `count($up())`, `count(up == 1)`},
+		{"comma in argument", `$sel(job="a", env="b,c")`, ``},
+		{"bracketed argument", `$sel({job="a", env="b"})`, `up{{job="a", env="b"}}`},
+		{"lone dollar", `x $ y`, `x $ y`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expand(tt.expr, snippets, nil, nil)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	snippets := map[string]Snippet{
+		"one":  {Name: "one", Params: []string{"a"}, Expr: `f($a)`},
+		"bad":  {Name: "bad", Expr: `g($missing)`},
+		"ping": {Name: "ping", Expr: `$pong`},
+		"pong": {Name: "pong", Expr: `$ping`},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{"unknown snippet", `$nope(1)`, "unknown snippet $nope"},
+		{"unknown reference", `$nope`, "unknown snippet $nope"},
+		{"argument count", `$one(1, 2)`, "takes 1 arguments, got 2"},
+		{"unknown parameter", `$bad`, "unknown snippet or parameter $missing in snippet $bad"},
+		{"cycle", `$ping`, "snippet cycle: $ping -> $pong -> $ping"},
+		{"unclosed call", `$one(1`, "missing closing parenthesis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expand(tt.expr, snippets, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSnippets(t *testing.T) {
+	tmpDir := t.TempDir()
+	shared := `snippets:
+  - name: rate5m
+    params: [metric]
+    expr: rate($metric[5m])
+  - name: errors
+    expr: http_errors_total
+  - name: ratio
+    params: [window]
+    expr: sum(rate(http_5xx_total[$window])) / sum(rate(http_requests_total[$window]))
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "shared.yaml"), []byte(shared), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+snippet_files: [shared.yaml]
+snippets:
+  - name: errors
+    expr: http_5xx_total
+queries:
+  - name: Error Rate
+    expr: sum($rate5m($errors))
+  - name: Availability
+    type: slo
+    slo:
+      good: sum($rate5m(http_ok_total))
+      total: sum($rate5m(http_requests_total))
+      objective: 99.9
+  - name: Errors per Request
+    type: join
+    join:
+      left: {expr: sum($rate5m($errors))}
+      right: {expr: sum($rate5m(http_requests_total))}
+      op: div
+  - name: Burn Rate
+    type: burn_rate
+    burn_rate:
+      error_ratio: $ratio($window)
+      objective: 99.9
+  - name: Services
+    type: status
+    status:
+      by: job
+      checks:
+        - {name: errors, expr: sum by (job) ($rate5m($errors)) < bool 1}
+`
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	// The local definition of errors overrides the shared one
+	if got := cfg.Queries[0].Expr; got != "sum(rate(http_5xx_total[5m]))" {
+		t.Errorf("Unexpected expansion %q", got)
+	}
+	if got := cfg.Queries[1].SLO.Good; got != "sum(rate(http_ok_total[5m]))" {
+		t.Errorf("Unexpected slo.good expansion %q", got)
+	}
+	if got := cfg.Queries[1].SLO.Total; got != "sum(rate(http_requests_total[5m]))" {
+		t.Errorf("Unexpected slo.total expansion %q", got)
+	}
+	if got := cfg.Queries[2].Join.Left.Expr; got != "sum(rate(http_5xx_total[5m]))" {
+		t.Errorf("Unexpected join.left expansion %q", got)
+	}
+	if got := cfg.Queries[2].Join.Right.Expr; got != "sum(rate(http_requests_total[5m]))" {
+		t.Errorf("Unexpected join.right expansion %q", got)
+	}
+	// The window placeholder is left for the burn rate panel
+	if got := cfg.Queries[3].BurnRate.ErrorRatio; got != "sum(rate(http_5xx_total[$window])) / sum(rate(http_requests_total[$window]))" {
+		t.Errorf("Unexpected burn_rate.error_ratio expansion %q", got)
+	}
+	if got := cfg.Queries[4].Status.Checks[0].Expr; got != "sum by (job) (rate(http_5xx_total[5m])) < bool 1" {
+		t.Errorf("Unexpected status check expansion %q", got)
+	}
+}
+
+func TestLoadConfigSnippetErrorLine(t *testing.T) {
+	configContent := `prometheus:
+  url: "http://localhost:9090"
+snippets:
+  - name: rate5m
+    params: [metric]
+    expr: rate($metric[5m])
+queries:
+  - name: Error Rate
+    expr: $rate5m(a, b)
+`
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("Expected error for wrong argument count")
+	}
+	if !strings.Contains(err.Error(), "(queries[0].expr, line 9)") {
+		t.Errorf("Expected error to point at the expression, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package convert
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// unit is a unit convert knows, as a multiple of the base unit of its
+// dimension
+type unit struct {
+	dimension string
+	factor    float64
+}
+
+// units are the units from and to can name. Data is counted in bytes, time
+// in seconds and fractions as ratios.
+var units = map[string]unit{
+	"bytes": {"data", 1},
+	"B":     {"data", 1},
+	"kB":    {"data", 1e3},
+	"MB":    {"data", 1e6},
+	"GB":    {"data", 1e9},
+	"TB":    {"data", 1e12},
+	"PB":    {"data", 1e15},
+	"KiB":   {"data", 1 << 10},
+	"MiB":   {"data", 1 << 20},
+	"GiB":   {"data", 1 << 30},
+	"TiB":   {"data", 1 << 40},
+	"PiB":   {"data", 1 << 50},
+	"bits":  {"data", 1.0 / 8},
+	"kbit":  {"data", 1e3 / 8},
+	"Mbit":  {"data", 1e6 / 8},
+	"Gbit":  {"data", 1e9 / 8},
+
+	"ns":      {"time", 1e-9},
+	"µs":      {"time", 1e-6},
+	"us":      {"time", 1e-6},
+	"ms":      {"time", 1e-3},
+	"s":       {"time", 1},
+	"seconds": {"time", 1},
+	"min":     {"time", 60},
+	"h":       {"time", 3600},
+	"d":       {"time", 86400},
+	"w":       {"time", 7 * 86400},
+
+	"ratio":   {"fraction", 1},
+	"percent": {"fraction", 0.01},
+}
+
+// Validate checks that the units of a conversion are known and alike and
+// that its factors are usable
+func Validate(c *backend.ConvertConfig) error {
+	if c.From != "" || c.To != "" {
+		if _, err := unitFactor(c.From, c.To); err != nil {
+			return err
+		}
+	}
+	if c.Multiply < 0 || c.Divide < 0 {
+		return fmt.Errorf("multiply and divide must not be negative")
+	}
+	if c.From == "" && c.Multiply == 0 && c.Divide == 0 {
+		return fmt.Errorf("from and to, multiply or divide is required")
+	}
+	return nil
+}
+
+// unitFactor returns what a value in from is multiplied by to be in to. A
+// rate such as "bytes/s" converts to another rate.
+func unitFactor(from, to string) (float64, error) {
+	if from == "" || to == "" {
+		return 0, fmt.Errorf("from and to must be set together")
+	}
+	fromBase, fromRate := strings.CutSuffix(from, backend.PerSecond)
+	toBase, toRate := strings.CutSuffix(to, backend.PerSecond)
+	if fromRate != toRate {
+		return 0, fmt.Errorf("cannot convert %s to %s: only one is per second", from, to)
+	}
+	f, ok := units[fromBase]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	t, ok := units[toBase]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if f.dimension != t.dimension {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	return f.factor / t.factor, nil
+}
+
+// Factor returns what the values of a panel are multiplied by: the unit
+// conversion, if any, times multiply and divided by divide
+func Factor(c *backend.ConvertConfig) float64 {
+	factor := 1.0
+	if c.From != "" {
+		factor, _ = unitFactor(c.From, c.To)
+	}
+	if c.Multiply != 0 {
+		factor *= c.Multiply
+	}
+	if c.Divide != 0 {
+		factor /= c.Divide
+	}
+	return factor
+}
+
+// Apply returns a copy of result with every point converted. Histogram
+// samples are kept as they are. Without a conversion result itself is
+// returned.
+func Apply(c *backend.ConvertConfig, result *backend.TimeSeriesResult) *backend.TimeSeriesResult {
+	if c == nil || result == nil {
+		return result
+	}
+	factor := Factor(c)
+	converted := &backend.TimeSeriesResult{
+		Points:     make([]backend.DataPoint, len(result.Points)),
+		Histograms: result.Histograms,
+		Truncation: result.Truncation,
+		Warnings:   result.Warnings,
+	}
+	for i, p := range result.Points {
+		if !math.IsNaN(p.Value) {
+			p.Value *= factor
+		}
+		converted.Points[i] = p
+	}
+	return converted
+}
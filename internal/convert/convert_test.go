@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   backend.ConvertConfig
+		expected float64
+	}{
+		{"bytes to GiB", backend.ConvertConfig{From: "bytes", To: "GiB"}, 1.0 / (1 << 30)},
+		{"MB to kB", backend.ConvertConfig{From: "MB", To: "kB"}, 1000},
+		{"rates", backend.ConvertConfig{From: "bytes/s", To: "Mbit/s"}, 8e-6},
+		{"seconds to hours", backend.ConvertConfig{From: "seconds", To: "h"}, 1.0 / 3600},
+		{"ratio to percent", backend.ConvertConfig{From: "ratio", To: "percent"}, 100},
+		{"price", backend.ConvertConfig{From: "bytes", To: "GB", Multiply: 0.02, Unit: "USD"}, 0.02e-9},
+		{"divide", backend.ConvertConfig{Multiply: 3, Divide: 4}, 0.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(&tt.config); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := Factor(&tt.config); math.Abs(got-tt.expected) > 1e-12*math.Abs(tt.expected) {
+				t.Errorf("Expected %g, got %g", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   backend.ConvertConfig
+		expected string
+	}{
+		{"from only", backend.ConvertConfig{From: "bytes"}, "from and to must be set together"},
+		{"unknown unit", backend.ConvertConfig{From: "bytes", To: "GBs"}, `unknown unit "GBs"`},
+		{"unlike units", backend.ConvertConfig{From: "ms", To: "MB"}, "cannot convert ms to MB"},
+		{"rate to total", backend.ConvertConfig{From: "bytes/s", To: "GB"}, "only one is per second"},
+		{"negative", backend.ConvertConfig{Divide: -1}, "must not be negative"},
+		{"empty", backend.ConvertConfig{Unit: "USD"}, "from and to, multiply or divide is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	result := &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now, Value: 2 << 30, Series: "a"},
+		{Timestamp: now, Value: math.NaN(), Series: "b"},
+	}}
+
+	if Apply(nil, result) != result {
+		t.Error("Without a conversion the result should be returned as is")
+	}
+	converted := Apply(&backend.ConvertConfig{From: "bytes", To: "GiB"}, result)
+	if converted.Points[0].Value != 2 || converted.Points[0].Series != "a" {
+		t.Errorf("Expected 2 GiB, got %v", converted.Points[0])
+	}
+	if !math.IsNaN(converted.Points[1].Value) {
+		t.Errorf("NaN should stay NaN, got %v", converted.Points[1].Value)
+	}
+	if result.Points[0].Value != 2<<30 {
+		t.Error("The original result must not be modified")
+	}
+}
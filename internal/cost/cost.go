@@ -0,0 +1,237 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/config"
+	"promviz/internal/numfmt"
+)
+
+// seriesOverhead is the estimated response size of a series besides its
+// samples, mostly its labels
+const seriesOverhead = 150
+
+// bytesPerSample is the estimated uncompressed response size of one sample
+// by backend: a [time, "value"] pair in Prometheus JSON, an annotated CSV
+// row repeating the tags in InfluxDB v2, a [time, value] row in InfluxDB v1
+// JSON. The mock backend doesn't go over the network.
+var bytesPerSample = map[string]int64{
+	"prometheus": 28,
+	"influxdb":   100,
+	"influxdb1":  32,
+	"mock":       0,
+}
+
+// SeriesCounter runs a query once and returns the number of series it
+// returned, for estimates based on real data
+type SeriesCounter func(ctx context.Context, backendName, expr string, tr backend.TimeRange) (int, error)
+
+// Panel is the estimated load one panel puts on its backends per refresh
+type Panel struct {
+	Name       string `json:"name"`
+	Backend    string `json:"backend"`
+	Requests   int    `json:"requests"`
+	Series     int    `json:"series"`
+	Samples    int    `json:"samples"`
+	Bytes      int64  `json:"bytes"`
+	SharedWith string `json:"shared_with,omitempty"` // earlier panel whose identical query it reuses
+	Probed     bool   `json:"probed"`                // series counted by running the queries
+	Health     bool   `json:"health,omitempty"`      // health panel, whose requests return no samples
+	Error      string `json:"error,omitempty"`       // why probing failed
+}
+
+// Report is the estimated load of a dashboard
+type Report struct {
+	Refresh  time.Duration `json:"refresh"`
+	Panels   []Panel       `json:"panels"`
+	Requests int           `json:"requests"` // per refresh
+	Samples  int           `json:"samples"`  // per refresh
+	Bytes    int64         `json:"bytes"`    // per refresh
+}
+
+// request is one backend query of a panel
+type request struct {
+	backend string
+	expr    string
+	tr      backend.TimeRange
+}
+
+// requests returns the backend queries a panel makes per refresh, as the
+// dashboard would at now
+func requests(cfg *config.Config, q backend.Query, now time.Time) ([]request, error) {
+	switch q.PanelType() {
+	case backend.PanelSLO:
+		window, err := backend.ParseDuration(q.SLO.WindowOrDefault())
+		if err != nil {
+			return nil, fmt.Errorf("invalid slo.window: %w", err)
+		}
+		tr := backend.RangeEndingAt(window, now.Add(-q.Shift()))
+		b := cfg.BackendFor(q)
+		return []request{{b, q.SLO.Good, tr}, {b, q.SLO.Total, tr}}, nil
+	case backend.PanelBurnRate:
+		tr := q.TimeRangeAt(now)
+		var reqs []request
+		for _, window := range backend.BurnRateWindows() {
+			reqs = append(reqs, request{cfg.BackendFor(q), q.BurnRate.Expr(window), tr})
+		}
+		return reqs, nil
+	case backend.PanelStatus:
+		tr := q.TimeRangeAt(now)
+		var reqs []request
+		for _, check := range q.Status.Checks {
+			reqs = append(reqs, request{cfg.CheckBackend(q, check), check.Expr, tr})
+		}
+		return reqs, nil
+	case backend.PanelJoin:
+		left, right := cfg.JoinBackends(q)
+		tr := q.TimeRangeAt(now)
+		return []request{{left, q.Join.Left.Expr, tr}, {right, q.Join.Right.Expr, tr}}, nil
+	default:
+		tr := q.TimeRangeAt(now)
+		if q.Evaluation == backend.EvaluationInstantOverRange {
+			tr.Start = tr.End
+		}
+		return []request{{cfg.BackendFor(q), q.Expr, tr}}, nil
+	}
+}
+
+// healthRequests returns the requests a health panel makes per refresh:
+// the health check, a cardinality query per bucket and the task list
+func healthRequests(q backend.Query) int {
+	buckets := 1 // the configured bucket
+	if q.Health != nil && len(q.Health.Buckets) > 0 {
+		buckets = len(q.Health.Buckets)
+	}
+	return buckets + 2
+}
+
+// Estimate computes the load of every panel of cfg when refreshed every
+// refresh. Series counts come from count if given, and are assumed to be
+// series per query otherwise. Panels with the same graph query share one
+// request, as they do in the dashboard.
+func Estimate(ctx context.Context, cfg *config.Config, refresh time.Duration, series int, count SeriesCounter) (*Report, error) {
+	report := &Report{Refresh: refresh}
+	now := time.Now()
+	first := make(map[string]string) // shared query key to panel name
+
+	for _, q := range cfg.Queries {
+		panel := Panel{Name: q.Name, Backend: cfg.BackendFor(q)}
+
+		if q.PanelType() == backend.PanelHealth {
+			panel.Health = true
+			panel.Requests = healthRequests(q)
+			report.Requests += panel.Requests
+			report.Panels = append(report.Panels, panel)
+			continue
+		}
+
+		// Same key as the dashboard's shared fetches
+		if t := q.PanelType(); t != backend.PanelSLO && t != backend.PanelJoin && t != backend.PanelBurnRate && t != backend.PanelStatus {
+			key := cfg.BackendFor(q) + "\x00" + q.Range + "\x00" + q.Offset + "\x00" + q.Evaluation + "\x00" + strconv.FormatBool(cfg.RawFor(q)) + "\x00" + q.Expr
+			if name, ok := first[key]; ok {
+				panel.SharedWith = name
+				report.Panels = append(report.Panels, panel)
+				continue
+			}
+			first[key] = q.Name
+		}
+
+		reqs, err := requests(cfg, q, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", q.Name, err)
+		}
+		for _, r := range reqs {
+			n := series
+			if count != nil {
+				probed, err := count(backend.WithRaw(ctx, cfg.RawFor(q)), r.backend, r.expr, r.tr)
+				if err != nil {
+					panel.Error = err.Error()
+				} else {
+					n = probed
+					panel.Probed = true
+				}
+			}
+
+			points := int(r.tr.End.Sub(r.tr.Start)/r.tr.Step) + 1
+			panel.Requests++
+			panel.Series += n
+			panel.Samples += n * points
+			if perSample, ok := bytesPerSample[r.backend]; !ok || perSample > 0 {
+				panel.Bytes += int64(n)*seriesOverhead + int64(n*points)*perSample
+			}
+		}
+
+		report.Requests += panel.Requests
+		report.Samples += panel.Samples
+		report.Bytes += panel.Bytes
+		report.Panels = append(report.Panels, panel)
+	}
+
+	return report, nil
+}
+
+// perHour scales a per-refresh amount to an hour
+func (r *Report) perHour(n float64) float64 {
+	return n * float64(time.Hour) / float64(r.Refresh)
+}
+
+// WriteTable prints the estimate per panel followed by the totals per
+// refresh, hour and day
+func WriteTable(w io.Writer, r *Report, f numfmt.Format) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PANEL\tBACKEND\tREQUESTS\tSERIES\tSAMPLES\tBYTES\tNOTE\n")
+	for _, p := range r.Panels {
+		note := ""
+		switch {
+		case p.SharedWith != "":
+			note = "shares the query of " + p.SharedWith
+		case p.Health:
+			note = "health checks"
+		case p.Error != "":
+			note = "probe failed: " + p.Error
+		case !p.Probed:
+			note = "assumed series"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", p.Name, p.Backend, p.Requests,
+			f.Float(float64(p.Series), 0), f.Float(float64(p.Samples), 0), f.Bytes(float64(p.Bytes)), note)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nRefreshing every %s:\n", r.Refresh)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\trequests\tsamples\tbytes\t\n")
+	for _, row := range []struct {
+		name  string
+		scale float64
+	}{
+		{"per refresh", 1},
+		{"per hour", r.perHour(1)},
+		{"per day", r.perHour(24)},
+	} {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", row.name,
+			f.Float(float64(r.Requests)*row.scale, 0), f.Float(float64(r.Samples)*row.scale, 0),
+			f.Bytes(float64(r.Bytes)*row.scale))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\nBytes are uncompressed response sizes; gzip typically shrinks them 5-10x.\n")
+	return err
+}
+
+// WriteJSON prints the estimate as JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,200 @@
+package cost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/config"
+	"promviz/internal/numfmt"
+)
+
+func costConfig() *config.Config {
+	return &config.Config{
+		Backend: "prometheus",
+		Queries: []backend.Query{
+			{Name: "CPU", Expr: "cpu", Range: "1h"},
+			{Name: "CPU again", Expr: "cpu", Range: "1h"},
+			{Name: "Disk", Expr: "disk", Backend: "influxdb1"},
+			{Name: "Availability", Type: backend.PanelSLO, SLO: &backend.SLOConfig{Good: "good", Total: "total", Window: "1d"}},
+		},
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	report, err := Estimate(context.Background(), costConfig(), 10*time.Second, 2, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Panels) != 4 {
+		t.Fatalf("Expected 4 panels, got %d", len(report.Panels))
+	}
+
+	// 1h at a 1m step is 61 points for each of the 2 assumed series
+	cpu := report.Panels[0]
+	if cpu.Requests != 1 || cpu.Series != 2 || cpu.Samples != 122 || cpu.Bytes != 2*seriesOverhead+122*28 {
+		t.Errorf("Unexpected CPU estimate %+v", cpu)
+	}
+
+	if again := report.Panels[1]; again.SharedWith != "CPU" || again.Requests != 0 || again.Bytes != 0 {
+		t.Errorf("Identical query should be shared, got %+v", again)
+	}
+
+	// 5m at the 1m minimum step is 6 points
+	if disk := report.Panels[2]; disk.Backend != "influxdb1" || disk.Samples != 12 || disk.Bytes != 2*seriesOverhead+12*32 {
+		t.Errorf("Unexpected Disk estimate %+v", disk)
+	}
+
+	// Good and total over a day at a 24m step
+	if slo := report.Panels[3]; slo.Requests != 2 || slo.Samples != 2*2*61 {
+		t.Errorf("Unexpected SLO estimate %+v", slo)
+	}
+
+	if report.Requests != 4 || report.Samples != 122+12+244 {
+		t.Errorf("Unexpected totals %d requests, %d samples", report.Requests, report.Samples)
+	}
+}
+
+func TestEstimateBurnRate(t *testing.T) {
+	cfg := &config.Config{
+		Backend: "prometheus",
+		Queries: []backend.Query{
+			{Name: "Burn", Type: backend.PanelBurnRate, Range: "1h", BurnRate: &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}},
+			{Name: "Burn again", Type: backend.PanelBurnRate, Range: "1h", BurnRate: &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}},
+		},
+	}
+	report, err := Estimate(context.Background(), cfg, 10*time.Second, 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The error ratio over each of the four windows, at 61 points each
+	for _, panel := range report.Panels {
+		if panel.Requests != 4 || panel.Samples != 4*61 || panel.SharedWith != "" {
+			t.Errorf("Unexpected burn rate estimate %+v", panel)
+		}
+	}
+}
+
+func TestEstimateStatus(t *testing.T) {
+	cfg := &config.Config{
+		Backend: "prometheus",
+		Queries: []backend.Query{
+			{Name: "Services", Type: backend.PanelStatus, Status: &backend.StatusConfig{
+				By:     "service",
+				Checks: []backend.StatusCheck{{Name: "up", Expr: "up"}, {Name: "disk", Expr: "disk", Backend: "influxdb1"}},
+			}},
+		},
+	}
+	report, err := Estimate(context.Background(), cfg, 10*time.Second, 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if panel := report.Panels[0]; panel.Requests != 2 || panel.Samples != 2*6 {
+		t.Errorf("Expected a request per check, got %+v", panel)
+	}
+}
+
+func TestEstimateProbe(t *testing.T) {
+	cfg := &config.Config{
+		Backend: "prometheus",
+		Queries: []backend.Query{
+			{Name: "CPU", Expr: "cpu"},
+			{Name: "Broken", Expr: "broken("},
+		},
+	}
+	count := func(ctx context.Context, name, expr string, tr backend.TimeRange) (int, error) {
+		if expr == "broken(" {
+			return 0, errors.New("parse error")
+		}
+		return 8, nil
+	}
+
+	report, err := Estimate(context.Background(), cfg, 5*time.Second, 1, count)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cpu := report.Panels[0]; !cpu.Probed || cpu.Series != 8 {
+		t.Errorf("Expected 8 probed series, got %+v", cpu)
+	}
+	if broken := report.Panels[1]; broken.Probed || broken.Series != 1 || broken.Error != "parse error" {
+		t.Errorf("Expected the assumed series count after a failed probe, got %+v", broken)
+	}
+}
+
+func TestEstimateHealth(t *testing.T) {
+	cfg := &config.Config{
+		Backend: "influxdb",
+		Queries: []backend.Query{
+			{Name: "Influx", Type: backend.PanelHealth},
+			{Name: "Buckets", Type: backend.PanelHealth, Health: &backend.HealthConfig{Buckets: []string{"metrics", "logs"}}},
+		},
+	}
+	count := func(ctx context.Context, name, expr string, tr backend.TimeRange) (int, error) {
+		t.Errorf("Health panels should not be probed, got %q", expr)
+		return 0, nil
+	}
+
+	report, err := Estimate(context.Background(), cfg, 10*time.Second, 2, count)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if p := report.Panels[0]; !p.Health || p.Requests != 3 || p.Samples != 0 || p.SharedWith != "" {
+		t.Errorf("Unexpected estimate %+v", p)
+	}
+	if p := report.Panels[1]; p.Requests != 4 || p.SharedWith != "" {
+		t.Errorf("Expected a request per bucket, got %+v", p)
+	}
+	if report.Requests != 7 || report.Samples != 0 {
+		t.Errorf("Unexpected totals %d requests, %d samples", report.Requests, report.Samples)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, report, numfmt.Format{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "health checks") {
+		t.Errorf("Expected health panels to be noted, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	report, _ := Estimate(context.Background(), costConfig(), 10*time.Second, 1, nil)
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, report, numfmt.Format{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"PANEL", "shares the query of CPU", "assumed series",
+		"Refreshing every 10s:", "per refresh", "per hour", "per day",
+		// 4 requests every 10s
+		"1440",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	report, _ := Estimate(context.Background(), costConfig(), 10*time.Second, 1, nil)
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded.Requests != report.Requests || len(decoded.Panels) != 4 {
+		t.Errorf("Unexpected decoded report %+v", decoded)
+	}
+}
@@ -0,0 +1,112 @@
+package expand
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"promviz/internal/backend"
+)
+
+// Fetch runs the expression of a query once
+type Fetch func(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error)
+
+// Queries replaces every query with expand_by by one query per value of its
+// label, in sorted order and capped at the query's expand_limit. Queries
+// that can't be expanded are kept as they are, with a warning.
+func Queries(ctx context.Context, queries []backend.Query, fetch Fetch) ([]backend.Query, []string) {
+	var result []backend.Query
+	var warnings []string
+
+	// Expanded IDs must not take the ID of another query
+	used := make(map[string]bool)
+	for _, q := range queries {
+		if q.ID != "" {
+			used[q.ID] = true
+		}
+	}
+
+	for _, q := range queries {
+		if q.ExpandBy == "" {
+			result = append(result, q)
+			continue
+		}
+
+		ts, err := fetch(ctx, q)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: cannot expand by %s: %v", q.Name, q.ExpandBy, err))
+			result = append(result, q)
+			continue
+		}
+
+		values := Values(ts.Points, q.ExpandBy)
+		if len(values) == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: no series with label %s to expand by", q.Name, q.ExpandBy))
+			result = append(result, q)
+			continue
+		}
+
+		limit := q.ExpandLimit
+		if limit <= 0 {
+			limit = backend.DefaultExpandLimit
+		}
+		if len(values) > limit {
+			warnings = append(warnings, fmt.Sprintf("%s: %s has %d values, showing the first %d (raise expand_limit to see more)",
+				q.Name, q.ExpandBy, len(values), limit))
+			values = values[:limit]
+		}
+
+		// IDs already taken, e.g. by values differing only in characters
+		// IDs can't hold, get a suffix
+		for _, v := range values {
+			e := expanded(q, v)
+			for id, n := e.ID, 2; used[e.ID]; n++ {
+				e.ID = fmt.Sprintf("%s-%d", id, n)
+			}
+			used[e.ID] = true
+			result = append(result, e)
+		}
+	}
+
+	return result, warnings
+}
+
+// Values returns the distinct values of a label across the series of the
+// points, sorted
+func Values(points []backend.DataPoint, label string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, p := range points {
+		if seen[p.Series] {
+			continue
+		}
+		seen[p.Series] = true
+
+		if v, ok := backend.ParseSeriesName(p.Series)[label]; ok && !seen["\x00"+v] {
+			seen["\x00"+v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// unsafeID matches characters not allowed in query IDs
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// expanded returns the panel of q for one label value
+func expanded(q backend.Query, value string) backend.Query {
+	match := map[string]string{q.ExpandBy: value}
+	for k, v := range q.Match {
+		match[k] = v
+	}
+
+	q.Name = fmt.Sprintf("%s (%s)", q.Name, value)
+	if q.ID != "" {
+		q.ID += "-" + unsafeID.ReplaceAllString(value, "-")
+	}
+	q.Match = match
+	q.ExpandBy = ""
+	return q
+}
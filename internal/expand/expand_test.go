@@ -0,0 +1,114 @@
+package expand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+// result returns a fetch serving one point for each of the series
+func result(series ...string) Fetch {
+	return func(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+		var points []backend.DataPoint
+		for _, s := range series {
+			points = append(points, backend.DataPoint{Value: 1, Series: s})
+		}
+		return &backend.TimeSeriesResult{Points: points}, nil
+	}
+}
+
+func TestQueries(t *testing.T) {
+	queries := []backend.Query{
+		{ID: "cpu", Name: "CPU", Expr: "cpu", ExpandBy: "instance"},
+		{ID: "mem", Name: "Memory", Expr: "mem"},
+	}
+	fetch := result(`cpu{instance="web-2:9100",mode="user"}`, `cpu{instance="web-1:9100",mode="user"}`, `cpu{instance="web-1:9100",mode="system"}`)
+
+	got, warnings := Queries(context.Background(), queries, fetch)
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 2 expanded panels and the plain query, got %d", len(got))
+	}
+
+	first := got[0]
+	if first.Name != "CPU (web-1:9100)" || first.ID != "cpu-web-1-9100" {
+		t.Errorf("Unexpected expanded panel %q (%s)", first.Name, first.ID)
+	}
+	if first.Match["instance"] != "web-1:9100" || first.ExpandBy != "" || first.Expr != "cpu" {
+		t.Errorf("Expanded panel should match its instance with the same expression, got %+v", first)
+	}
+	if got[1].Name != "CPU (web-2:9100)" || got[2].Name != "Memory" {
+		t.Errorf("Unexpected panel order %q, %q", got[1].Name, got[2].Name)
+	}
+
+	// IDs stay unique when values only differ in characters IDs can't hold
+	got, _ = Queries(context.Background(), queries[:1], result(`cpu{instance="a:1"}`, `cpu{instance="a.1"}`))
+	if got[0].ID != "cpu-a-1" || got[1].ID != "cpu-a-1-2" {
+		t.Errorf("Expected unique IDs, got %s and %s", got[0].ID, got[1].ID)
+	}
+}
+
+func TestQueriesIDCollision(t *testing.T) {
+	queries := []backend.Query{
+		{ID: "api", Name: "API", Expr: "up", ExpandBy: "env"},
+		{ID: "api-prod", Name: "API Prod", Expr: "up"},
+		{ID: "api-prod-2", Name: "API Prod Old", Expr: "up"},
+	}
+
+	got, _ := Queries(context.Background(), queries, result(`up{env="prod"}`, `up{env="dev"}`))
+	ids := make(map[string]bool)
+	for _, q := range got {
+		if ids[q.ID] {
+			t.Errorf("Duplicate ID %s", q.ID)
+		}
+		ids[q.ID] = true
+	}
+	if got[0].ID != "api-dev" || got[1].ID != "api-prod-3" {
+		t.Errorf("Expected the expanded ID to skip the explicit ones, got %s and %s", got[0].ID, got[1].ID)
+	}
+}
+
+func TestQueriesLimit(t *testing.T) {
+	var series []string
+	for i := 0; i < 30; i++ {
+		series = append(series, fmt.Sprintf(`up{pod="pod-%02d"}`, i))
+	}
+	queries := []backend.Query{{Name: "Up", Expr: "up", ExpandBy: "pod", ExpandLimit: 5}}
+
+	got, warnings := Queries(context.Background(), queries, result(series...))
+	if len(got) != 5 || got[4].Name != "Up (pod-04)" {
+		t.Errorf("Expected the first 5 pods, got %d panels", len(got))
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "pod has 30 values, showing the first 5") {
+		t.Errorf("Expected a warning about the cap, got %v", warnings)
+	}
+
+	queries[0].ExpandLimit = 0
+	got, _ = Queries(context.Background(), queries, result(series...))
+	if len(got) != backend.DefaultExpandLimit {
+		t.Errorf("Expected the default limit, got %d panels", len(got))
+	}
+}
+
+func TestQueriesFallback(t *testing.T) {
+	queries := []backend.Query{{Name: "Up", Expr: "up", ExpandBy: "pod"}}
+
+	failing := func(ctx context.Context, q backend.Query) (*backend.TimeSeriesResult, error) {
+		return nil, errors.New("connection refused")
+	}
+	got, warnings := Queries(context.Background(), queries, failing)
+	if len(got) != 1 || got[0].ExpandBy != "pod" || len(warnings) != 1 || !strings.Contains(warnings[0], "connection refused") {
+		t.Errorf("Failed expansion should keep the query with a warning, got %v %v", got, warnings)
+	}
+
+	got, warnings = Queries(context.Background(), queries, result(`up{job="node"}`))
+	if len(got) != 1 || len(warnings) != 1 || !strings.Contains(warnings[0], "no series with label pod") {
+		t.Errorf("Expansion without the label should keep the query with a warning, got %v %v", got, warnings)
+	}
+}
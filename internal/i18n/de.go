@@ -0,0 +1,78 @@
+package i18n
+
+// german translates the dashboard into German
+var german = map[string]string{
+	// Help line
+	"Navigation: ← → Arrow keys or Tab/Shift+Tab to switch panels | i details | o runbook | b breaches | q/Q to quit": "Navigation: ← → Pfeiltasten oder Tab/Shift+Tab wechseln das Panel | i Details | o Runbook | b Verstöße | q/Q beendet",
+	" | [yellow]Maximized[white] (z or Esc to restore)":                                                               " | [yellow]Maximiert[white] (z oder Esc stellt wieder her)",
+	" | Range: [yellow]%s, refresh paused[white] (t)":                                                                 " | Zeitraum: [yellow]%s, Aktualisierung pausiert[white] (t)",
+	" | Range: [yellow]%s[white] (t)":                                                                                 " | Zeitraum: [yellow]%s[white] (t)",
+	"last %s":                                                                                                         "letzte %s",
+	"mixed":                                                                                                           "gemischt",
+	" | Source: [yellow]%s[white] (s)":                                                                                " | Quelle: [yellow]%s[white] (s)",
+	" | [yellow]%d panels hidden[white] (H to show)":                                                                  " | [yellow]%d Panels ausgeblendet[white] (H zeigt sie)",
+	" | [yellow]%d tags muted[white] (g)":                                                                             " | [yellow]%d Tags stummgeschaltet[white] (g)",
+	" | [yellow]Rotation paused[white] (p to resume)":                                                                 " | [yellow]Rotation pausiert[white] (p setzt fort)",
+	" | p to pause rotation":                                                                                          " | p pausiert die Rotation",
+	" | [red]%d backends unreachable (d)[white]":                                                                      " | [red]%d Backends nicht erreichbar (d)[white]",
+	" | [yellow]%d config warnings (d)[white]":                                                                        " | [yellow]%d Konfigurationswarnungen (d)[white]",
+	" | [yellow]%d panels throttled (r to retry)[white]":                                                              " | [yellow]%d Panels gedrosselt (r für neuen Versuch)[white]",
+	" | [red]Memory %s, close to the limit (d)[white]":                                                                " | [red]Speicher %s, kurz vor dem Limit (d)[white]",
+	" | [yellow]Memory %s, panels downsampled (d)[white]":                                                             " | [yellow]Speicher %s, Panels ausgedünnt (d)[white]",
+	"%s of %s": "%s von %s",
+
+	// Help line messages
+	"[yellow]%s has no runbook_url[white]":       "[yellow]%s hat keine runbook_url[white]",
+	"[red]Could not open runbook: %v[white]":     "[red]Runbook konnte nicht geöffnet werden: %v[white]",
+	"[red]Export failed: %s[white]":              "[red]Export fehlgeschlagen: %s[white]",
+	"[green]Exported to %s[white]":               "[green]Exportiert nach %s[white]",
+	"[green]Exported %d panels tagged %s[white]": "[green]%d Panels mit Tag %s exportiert[white]",
+	"[red]Export of %s failed: %s[white]":        "[red]Export von %s fehlgeschlagen: %s[white]",
+	" [yellow](%d skipped: %s)[white]":           " [yellow](%d übersprungen: %s)[white]",
+	"[green]Refreshing every %s[white]":          "[green]Aktualisierung alle %s[white]",
+	"[yellow]Switching to %s...[white]":          "[yellow]Wechsle zu %s...[white]",
+	"[red]Switching to %s failed: %s[white]":     "[red]Wechsel zu %s fehlgeschlagen: %s[white]",
+
+	// Status bar and header
+	"[yellow]Time Range:[white] %s [gray]to[white] %s": "[yellow]Zeitraum:[white] %s [gray]bis[white] %s",
+	"[gray]Time Range: Waiting for data...[white]":     "[gray]Zeitraum: Warte auf Daten...[white]",
+	"[yellow]%s[white]  │  %s  │  up %s  │  ":          "[yellow]%s[white]  │  %s  │  läuft seit %s  │  ",
+	"[gray]waiting for data[white]":                    "[gray]warte auf Daten[white]",
+	"[red]data %s old, %d stale panels[white]":         "[red]Daten %s alt, %d veraltete Panels[white]",
+	"[green]data %s old[white]":                        "[green]Daten %s alt[white]",
+
+	// Panels
+	"Initializing...":   "Initialisiere...",
+	"No data available": "Keine Daten verfügbar",
+	"Retrying...":       "Neuer Versuch...",
+	"[red]%s old[-] ":   "[red]%s alt[-] ",
+	"%s Time Series":    "%s Zeitreihe",
+	"%s to %s":          "%s bis %s",
+	"[%s]Current: %s[%s]\n[gray]Time Range: %s[%s]\n":  "[%s]Aktuell: %s[%s]\n[gray]Zeitraum: %s[%s]\n",
+	"[%s]Current: %s[%s] (%s per %s)\n":                "[%s]Aktuell: %s[%s] (%s pro %s)\n",
+	"[gray]Time Range: %s to %s[%s]\n\n":               "[gray]Zeitraum: %s bis %s[%s]\n\n",
+	"Less":                                             "Weniger",
+	"[gray] More  %s to %s[%s]\n":                      "[gray] Mehr  %s bis %s[%s]\n",
+	"Peak: %s on %s":                                   "Spitze: %s am %s",
+	"[gray]Objective: %s%% (error budget %s%%)[%s]\n":  "[gray]Ziel: %s%% (Fehlerbudget %s%%)[%s]\n",
+	"[%s]%s: %s[%s] [gray](%s and %s above %sx)[%s]\n": "[%s]%s: %s[%s] [gray](%s und %s über %sx)[%s]\n",
+	"Fast burn":                "Schneller Verbrauch",
+	"Slow burn":                "Langsamer Verbrauch",
+	"firing":                   "ausgelöst",
+	"ok":                       "in Ordnung",
+	"n/a":                      "k. A.",
+	"[gray]idle until %s[-] ":  "[gray]pausiert bis %s[-] ",
+	"[yellow]partial (%d)[-] ": "[yellow]unvollständig (%d)[-] ",
+	"[gray]Scheduled idle[white] until %s (active %s)": "[gray]Geplante Pause[white] bis %s (aktiv %s)",
+
+	// Errors
+	"[red]Query syntax error[white]\n%s\n\n[yellow]%s[white]\nFix the expr of this panel in the config": "[red]Syntaxfehler in der Abfrage[white]\n%s\n\n[yellow]%s[white]\nKorrigiere expr dieses Panels in der Konfiguration",
+	"[red]Authentication failed[white]\n%s\n\nCheck the credentials of this backend in the config":      "[red]Authentifizierung fehlgeschlagen[white]\n%s\n\nPrüfe die Zugangsdaten dieses Backends in der Konfiguration",
+	"[red]Not found[white]\n%s\n\nCheck the URL, database or bucket of this backend in the config":      "[red]Nicht gefunden[white]\n%s\n\nPrüfe URL, Datenbank oder Bucket dieses Backends in der Konfiguration",
+	"The backend is refusing queries for now":                                                           "Das Backend lehnt Abfragen derzeit ab",
+	"The backend asks to wait %s":                                                     "Das Backend bittet, %s zu warten",
+	"[yellow]Rate limited[white]\n%s\n\n%s":                                           "[yellow]Anfragelimit erreicht[white]\n%s\n\n%s",
+	"[red]Timed out[white]\n%s\n\nTry a shorter range or a coarser step (r to retry)": "[red]Zeitüberschreitung[white]\n%s\n\nVersuche einen kürzeren Zeitraum oder eine gröbere Auflösung (r für neuen Versuch)",
+	"[red]Error: %v[white]":                                                           "[red]Fehler: %v[white]",
+	"[yellow]Throttled[white] after %d failures, next try at %s (r to retry)\n\n":     "[yellow]Gedrosselt[white] nach %d Fehlern, nächster Versuch um %s (r für neuen Versuch)\n\n",
+}
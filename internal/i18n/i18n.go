@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Catalog translates the messages of the dashboard into one language.
+// Messages are looked up by their English text, so anything without a
+// translation stays English. The zero value is English.
+type Catalog struct {
+	messages map[string]string
+}
+
+// catalogs maps language tags to their translations
+var catalogs = map[string]map[string]string{
+	"de": german,
+}
+
+// Language returns the catalog of a language such as "de", "de-AT" or
+// "de_DE", falling back from the region to the language. "en" is English.
+func Language(name string) (Catalog, bool) {
+	tag := strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		tag = tag[:i]
+	}
+	if tag == "en" {
+		return Catalog{}, true
+	}
+	messages, ok := catalogs[tag]
+	return Catalog{messages: messages}, ok
+}
+
+// Languages returns the supported language tags, sorted
+func Languages() []string {
+	tags := []string{"en"}
+	for tag := range catalogs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// T returns the translation of msg, or msg if there is none
+func (c Catalog) T(msg string) string {
+	if translated, ok := c.messages[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf translates the format and formats like fmt.Sprintf
+func (c Catalog) Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(c.T(format), args...)
+}
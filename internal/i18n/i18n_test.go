@@ -0,0 +1,69 @@
+package i18n
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+		want string // translation of "No data available"
+	}{
+		{"en", true, "No data available"},
+		{"en-GB", true, "No data available"},
+		{"de", true, "Keine Daten verfügbar"},
+		{"de-AT", true, "Keine Daten verfügbar"},
+		{"DE_de", true, "Keine Daten verfügbar"},
+		{"xx", false, "No data available"},
+		{"", false, "No data available"},
+	}
+
+	for _, tt := range tests {
+		c, ok := Language(tt.name)
+		if ok != tt.ok {
+			t.Errorf("Language(%q) ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if got := c.T("No data available"); got != tt.want {
+			t.Errorf("Language(%q).T() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	if got, want := Languages(), []string{"de", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages() = %v, want %v", got, want)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	de, _ := Language("de")
+	if got := de.Sprintf(" | [yellow]%d panels hidden[white] (H to show)", 2); got != " | [yellow]2 Panels ausgeblendet[white] (H zeigt sie)" {
+		t.Errorf("Unexpected translation %q", got)
+	}
+	if got := de.Sprintf("untranslated %d", 3); got != "untranslated 3" {
+		t.Errorf("Expected the English message, got %q", got)
+	}
+	if got := (Catalog{}).Sprintf("last %s", "1h"); got != "last 1h" {
+		t.Errorf("Expected English from the zero catalog, got %q", got)
+	}
+}
+
+// TestCatalogsConsistent checks every translation keeps the verbs and
+// color tags of its message, so arguments and colors line up
+func TestCatalogsConsistent(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	tags := regexp.MustCompile(`\[[a-z-]*\]`)
+	for lang, messages := range catalogs {
+		for msg, translated := range messages {
+			if got, want := verbs.FindAllString(translated, -1), verbs.FindAllString(msg, -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, translated, got, want)
+			}
+			if got, want := tags.FindAllString(translated, -1), tags.FindAllString(msg, -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %q has color tags %v, want %v", lang, translated, got, want)
+			}
+		}
+	}
+}
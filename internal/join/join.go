@@ -0,0 +1,139 @@
+package join
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Operators combining the left and right value of a join
+const (
+	OpAdd = "add"
+	OpSub = "sub"
+	OpMul = "mul"
+	OpDiv = "div"
+)
+
+// Interpolation strategies for right values between right samples
+const (
+	Linear   = "linear"   // interpolate between the neighbouring samples
+	Previous = "previous" // hold the latest sample at or before the timestamp
+	None     = "none"     // only use samples within half a step of the timestamp
+)
+
+// Validate checks the operator and interpolation of a join
+func Validate(op, interpolation string) error {
+	switch op {
+	case OpAdd, OpSub, OpMul, OpDiv:
+	default:
+		return fmt.Errorf("unsupported op: %s (supported: add, sub, mul, div)", op)
+	}
+	switch interpolation {
+	case "", Linear, Previous, None:
+	default:
+		return fmt.Errorf("unsupported interpolation: %s (supported: linear, previous, none)", interpolation)
+	}
+	return nil
+}
+
+// Join combines two series on the timestamps of left. The right value at
+// each timestamp is found with the given interpolation; timestamps without
+// a right value are dropped. step is the resolution of the query and bounds
+// how far apart samples may be for the "none" strategy. Division by zero
+// yields NaN, which renders as a gap.
+func Join(left, right []backend.DataPoint, op, interpolation string, step time.Duration) []backend.DataPoint {
+	left, right = sorted(left), sorted(right)
+
+	var result []backend.DataPoint
+	for _, p := range left {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		r, ok := valueAt(right, p.Timestamp, interpolation, step)
+		if !ok {
+			continue
+		}
+		result = append(result, backend.DataPoint{Timestamp: p.Timestamp, Value: apply(op, p.Value, r)})
+	}
+	return result
+}
+
+// valueAt returns the value of the sorted series at ts
+func valueAt(points []backend.DataPoint, ts time.Time, interpolation string, step time.Duration) (float64, bool) {
+	// Index of the first point after ts
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Timestamp.After(ts)
+	})
+
+	switch interpolation {
+	case Previous:
+		if i == 0 {
+			return 0, false
+		}
+		return points[i-1].Value, !math.IsNaN(points[i-1].Value)
+
+	case None:
+		best, found := 0.0, false
+		nearest := step / 2
+		for _, j := range []int{i - 1, i} {
+			if j < 0 || j >= len(points) || math.IsNaN(points[j].Value) {
+				continue
+			}
+			if d := absDuration(points[j].Timestamp.Sub(ts)); d <= nearest {
+				best, found, nearest = points[j].Value, true, d
+			}
+		}
+		return best, found
+
+	default: // Linear
+		if i > 0 && points[i-1].Timestamp.Equal(ts) {
+			return points[i-1].Value, !math.IsNaN(points[i-1].Value)
+		}
+		if i == 0 || i == len(points) {
+			return 0, false // no extrapolation beyond the right series
+		}
+		before, after := points[i-1], points[i]
+		if math.IsNaN(before.Value) || math.IsNaN(after.Value) {
+			return 0, false
+		}
+		frac := float64(ts.Sub(before.Timestamp)) / float64(after.Timestamp.Sub(before.Timestamp))
+		return before.Value + frac*(after.Value-before.Value), true
+	}
+}
+
+// apply combines two values with the operator
+func apply(op string, l, r float64) float64 {
+	switch op {
+	case OpAdd:
+		return l + r
+	case OpSub:
+		return l - r
+	case OpMul:
+		return l * r
+	default: // OpDiv
+		if r == 0 {
+			return math.NaN()
+		}
+		return l / r
+	}
+}
+
+// sorted returns the points ordered by timestamp
+func sorted(points []backend.DataPoint) []backend.DataPoint {
+	out := make([]backend.DataPoint, len(points))
+	copy(out, points)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,102 @@
+package join
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+var base = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// at builds points at the given minute offsets from base
+func at(pairs ...float64) []backend.DataPoint {
+	var points []backend.DataPoint
+	for i := 0; i+1 < len(pairs); i += 2 {
+		points = append(points, backend.DataPoint{
+			Timestamp: base.Add(time.Duration(pairs[i] * float64(time.Minute))),
+			Value:     pairs[i+1],
+		})
+	}
+	return points
+}
+
+func TestJoinInterpolation(t *testing.T) {
+	left := at(0, 10, 1, 20, 2, 30, 3, 40)
+	right := at(0, 1, 2, 3, 2.4, 5) // no sample at minute 1 or 3
+
+	tests := []struct {
+		interpolation string
+		expected      []backend.DataPoint
+	}{
+		{Linear, at(0, 10, 1, 40, 2, 90)},
+		{Previous, at(0, 10, 1, 20, 2, 90, 3, 200)},
+		{None, at(0, 10, 2, 90)},
+		{"", at(0, 10, 1, 40, 2, 90)}, // linear is the default
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interpolation, func(t *testing.T) {
+			got := Join(left, right, OpMul, tt.interpolation, time.Minute)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d points, got %d: %v", len(tt.expected), len(got), got)
+			}
+			for i := range got {
+				if !got[i].Timestamp.Equal(tt.expected[i].Timestamp) || math.Abs(got[i].Value-tt.expected[i].Value) > 1e-9 {
+					t.Errorf("Point %d: expected %v, got %v", i, tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJoinNoneTolerance(t *testing.T) {
+	left := at(0, 10, 1, 10)
+	right := at(0.4, 2, 1.6, 5) // 24s after the first, 36s after the second
+
+	got := Join(left, right, OpAdd, None, time.Minute)
+	if len(got) != 1 || got[0].Value != 12 {
+		t.Errorf("Expected only the sample within half a step to match, got %v", got)
+	}
+}
+
+func TestJoinOperators(t *testing.T) {
+	left := at(0, 6)
+	right := at(0, 3)
+
+	expected := map[string]float64{OpAdd: 9, OpSub: 3, OpMul: 18, OpDiv: 2}
+	for op, want := range expected {
+		got := Join(left, right, op, Linear, time.Minute)
+		if len(got) != 1 || got[0].Value != want {
+			t.Errorf("%s: expected %v, got %v", op, want, got)
+		}
+	}
+
+	got := Join(left, at(0, 0), OpDiv, Linear, time.Minute)
+	if len(got) != 1 || !math.IsNaN(got[0].Value) {
+		t.Errorf("Division by zero should yield NaN, got %v", got)
+	}
+}
+
+func TestJoinUnsortedAndNaN(t *testing.T) {
+	left := at(2, 30, 0, 10, 1, math.NaN())
+	right := at(2, 1, 0, 1)
+
+	got := Join(left, right, OpAdd, Linear, time.Minute)
+	if len(got) != 2 || got[0].Value != 11 || got[1].Value != 31 {
+		t.Errorf("Expected sorted join without the NaN point, got %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(OpDiv, ""); err != nil {
+		t.Errorf("Expected valid join, got %v", err)
+	}
+	if err := Validate("pow", Linear); err == nil {
+		t.Error("Expected error for unknown op")
+	}
+	if err := Validate(OpAdd, "cubic"); err == nil {
+		t.Error("Expected error for unknown interpolation")
+	}
+}
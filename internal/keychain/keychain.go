@@ -0,0 +1,51 @@
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Service names the entries promviz keeps in the OS keychain
+const Service = "promviz"
+
+// ErrNotFound is returned for entries the keychain doesn't hold
+var ErrNotFound = errors.New("no such keychain entry")
+
+// entryPattern restricts entry names to what every keychain tool passes on
+// unquoted, and never takes for an option
+var entryPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Get returns the secret stored under entry
+func Get(entry string) (string, error) {
+	if err := checkEntry(entry); err != nil {
+		return "", err
+	}
+	secret, err := get(entry)
+	if err != nil {
+		return "", fmt.Errorf("keychain entry %q: %w", entry, err)
+	}
+	return secret, nil
+}
+
+// Set stores secret under entry, replacing the previous one
+func Set(entry, secret string) error {
+	if err := checkEntry(entry); err != nil {
+		return err
+	}
+	if secret == "" {
+		return errors.New("secret is empty")
+	}
+	if err := set(entry, secret); err != nil {
+		return fmt.Errorf("keychain entry %q: %w", entry, err)
+	}
+	return nil
+}
+
+// checkEntry rejects entry names the keychain tools can't take
+func checkEntry(entry string) error {
+	if !entryPattern.MatchString(entry) {
+		return fmt.Errorf("invalid keychain entry %q: use letters, digits, '.', '_' and '-', starting with a letter or digit", entry)
+	}
+	return nil
+}
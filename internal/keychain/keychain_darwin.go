@@ -0,0 +1,40 @@
+package keychain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// notFoundStatus is the exit status of security for a missing item
+const notFoundStatus = 44
+
+// get reads a generic password of the macOS Keychain
+func get(entry string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", entry, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == notFoundStatus {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("security: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// set adds or updates a generic password of the macOS Keychain. The
+// command is passed on stdin so the secret doesn't show in the process
+// list.
+func set(entry, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", Service, entry, hex.EncodeToString([]byte(secret))))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("security: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretTool is the Secret Service client of libsecret, provided by GNOME
+// Keyring and KWallet
+const secretTool = "secret-tool"
+
+// get looks up a secret of the Secret Service
+func get(entry string) (string, error) {
+	cmd := exec.Command(secretTool, "lookup", "service", Service, "entry", entry)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("%s not found, install libsecret (e.g. libsecret-tools)", secretTool)
+	}
+	if err != nil {
+		if stderr.Len() == 0 {
+			// secret-tool fails silently for missing secrets
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%s: %w: %s", secretTool, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// set stores a secret in the Secret Service, read from stdin so it
+// doesn't show in the process list
+func set(entry, secret string) error {
+	cmd := exec.Command(secretTool, "store", "--label", Service+" "+entry, "service", Service, "entry", entry)
+	cmd.Stdin = strings.NewReader(secret)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s not found, install libsecret (e.g. libsecret-tools)", secretTool)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", secretTool, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on PATH that keeps secrets as files
+// named after their entry
+func fakeSecretTool(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+store="` + dir + `"
+case "$1" in
+store) cat > "$store/$7.secret" ;;
+lookup) [ -f "$store/$5.secret" ] || exit 1; cat "$store/$5.secret" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, secretTool), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake %s: %v", secretTool, err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSecretService(t *testing.T) {
+	fakeSecretTool(t)
+
+	if _, err := Get("influxdb"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before storing, got %v", err)
+	}
+	if err := Set("influxdb", "s3cr3t token"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	secret, err := Get("influxdb")
+	if err != nil || secret != "s3cr3t token" {
+		t.Errorf("Expected the stored secret, got %q, %v", secret, err)
+	}
+}
+
+func TestSecretServiceMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Get("influxdb"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an error naming the missing %s, got %v", secretTool, err)
+	}
+}
@@ -0,0 +1,22 @@
+package keychain
+
+import "testing"
+
+func TestCheckEntry(t *testing.T) {
+	for _, entry := range []string{"influxdb", "influx-prod.eu_1"} {
+		if err := checkEntry(entry); err != nil {
+			t.Errorf("%q: unexpected error %v", entry, err)
+		}
+	}
+	for _, entry := range []string{"", "prod token", "a;rm -rf", "-s"} {
+		if err := checkEntry(entry); err == nil {
+			t.Errorf("%q: expected an error", entry)
+		}
+	}
+	if _, err := Get("bad entry"); err == nil {
+		t.Error("Get should reject invalid entries")
+	}
+	if err := Set("influxdb", ""); err == nil {
+		t.Error("Set should reject empty secrets")
+	}
+}
@@ -0,0 +1,138 @@
+package numfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format describes how numbers are written: the decimal separator and the
+// separator between groups of three integer digits. The zero value writes
+// numbers the way Go does, e.g. "1234.56".
+type Format struct {
+	Decimal   string // defaults to "."
+	Thousands string // no grouping if empty
+}
+
+// locales maps language and region tags to their conventions. Where digits
+// are grouped by spaces, a no-break space keeps numbers in one piece.
+var locales = map[string]Format{
+	"en":    {Decimal: ".", Thousands: ","},
+	"ja":    {Decimal: ".", Thousands: ","},
+	"zh":    {Decimal: ".", Thousands: ","},
+	"de":    {Decimal: ",", Thousands: "."},
+	"es":    {Decimal: ",", Thousands: "."},
+	"it":    {Decimal: ",", Thousands: "."},
+	"nl":    {Decimal: ",", Thousands: "."},
+	"pt":    {Decimal: ",", Thousands: "."},
+	"da":    {Decimal: ",", Thousands: "."},
+	"tr":    {Decimal: ",", Thousands: "."},
+	"id":    {Decimal: ",", Thousands: "."},
+	"fr":    {Decimal: ",", Thousands: "\u00a0"},
+	"pl":    {Decimal: ",", Thousands: "\u00a0"},
+	"cs":    {Decimal: ",", Thousands: "\u00a0"},
+	"sv":    {Decimal: ",", Thousands: "\u00a0"},
+	"fi":    {Decimal: ",", Thousands: "\u00a0"},
+	"nb":    {Decimal: ",", Thousands: "\u00a0"},
+	"ru":    {Decimal: ",", Thousands: "\u00a0"},
+	"uk":    {Decimal: ",", Thousands: "\u00a0"},
+	"de-ch": {Decimal: ".", Thousands: "'"},
+	"it-ch": {Decimal: ".", Thousands: "'"},
+	"fr-ch": {Decimal: ",", Thousands: "\u00a0"},
+	"pt-pt": {Decimal: ",", Thousands: "\u00a0"},
+}
+
+// Locale returns the format of a locale such as "de", "de-CH" or "fr_FR",
+// falling back from the region to the language. "none" is the plain format.
+func Locale(name string) (Format, bool) {
+	tag := strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	if tag == "none" {
+		return Format{}, true
+	}
+	if f, ok := locales[tag]; ok {
+		return f, true
+	}
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		f, ok := locales[tag[:i]]
+		return f, ok
+	}
+	return Format{}, false
+}
+
+// Plain reports whether the format writes numbers unchanged
+func (f Format) Plain() bool {
+	return (f.Decimal == "" || f.Decimal == ".") && f.Thousands == ""
+}
+
+// Float formats v with prec decimals, like fmt's %.*f
+func (f Format) Float(v float64, prec int) string {
+	return f.Localize(strconv.FormatFloat(v, 'f', prec, 64))
+}
+
+// Sprintf formats like fmt.Sprintf with a single number verb, e.g. "%+.4g",
+// and localizes the result
+func (f Format) Sprintf(verb string, v float64) string {
+	return f.Localize(fmt.Sprintf(verb, v))
+}
+
+// Bytes formats a size with a binary unit, e.g. 1.5 KiB
+func (f Format) Bytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return f.Float(n, 0) + " B"
+	}
+	return f.Float(n, 1) + " " + units[i]
+}
+
+// Localize rewrites a number written by fmt or strconv, such as "-1234.5",
+// "+12" or "1.5e+06", in the format. Digits are only grouped without an
+// exponent. Anything else, like "NaN", is returned unchanged.
+func (f Format) Localize(s string) string {
+	if f.Plain() {
+		return s
+	}
+
+	// Split into sign, integer digits, fraction and exponent
+	rest := s
+	sign := ""
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		sign, rest = rest[:1], rest[1:]
+	}
+	exp := ""
+	if i := strings.IndexAny(rest, "eE"); i >= 0 {
+		rest, exp = rest[:i], rest[i:]
+	}
+	intPart, frac := rest, ""
+	if i := strings.IndexByte(rest, '.'); i >= 0 {
+		intPart, frac = rest[:i], rest[i+1:]
+	}
+	if intPart == "" || strings.Trim(intPart, "0123456789") != "" {
+		return s
+	}
+
+	if exp == "" && f.Thousands != "" {
+		var b strings.Builder
+		for i, d := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				b.WriteString(f.Thousands)
+			}
+			b.WriteRune(d)
+		}
+		intPart = b.String()
+	}
+
+	decimal := f.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	out := sign + intPart
+	if strings.Contains(rest, ".") {
+		out += decimal + frac
+	}
+	return out + exp
+}
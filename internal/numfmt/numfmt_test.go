@@ -0,0 +1,78 @@
+package numfmt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat(t *testing.T) {
+	de, _ := Locale("de")
+	en, _ := Locale("en-US")
+	ch, _ := Locale("de_CH")
+
+	tests := []struct {
+		f        Format
+		v        float64
+		prec     int
+		expected string
+	}{
+		{Format{}, 1234567.891, 2, "1234567.89"},
+		{de, 1234.56, 2, "1.234,56"},
+		{de, -1234567, 0, "-1.234.567"},
+		{de, 999.5, 1, "999,5"},
+		{en, 1234567.891, 2, "1,234,567.89"},
+		{en, 0.5, 3, "0.500"},
+		{ch, 12345.6, 1, "12'345.6"},
+		{Format{Decimal: ","}, 1234.5, 1, "1234,5"},
+		{de, math.NaN(), 2, "NaN"},
+		{de, math.Inf(-1), 2, "-Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.f.Float(tt.v, tt.prec); got != tt.expected {
+			t.Errorf("%+v.Float(%v, %d) = %q, expected %q", tt.f, tt.v, tt.prec, got, tt.expected)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	de, _ := Locale("de")
+	tests := []struct {
+		f    Format
+		n    float64
+		want string
+	}{
+		{Format{}, 512, "512 B"},
+		{Format{}, 1536, "1.5 KiB"},
+		{Format{}, 3 * 1024 * 1024 * 1024, "3.0 GiB"},
+		{de, 1536, "1,5 KiB"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Bytes(tt.n); got != tt.want {
+			t.Errorf("%+v.Bytes(%v) = %q, want %q", tt.f, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	de, _ := Locale("de")
+	if got := de.Sprintf("%+.4g", 12345.678); got != "+1,235e+04" {
+		t.Errorf("Expected exponent form without grouping, got %q", got)
+	}
+	if got := de.Sprintf("%+.1f%%", 1234.56); got != "+1.234,6%" {
+		t.Errorf("Expected suffix kept, got %q", got)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	fr, ok := Locale("fr-FR")
+	if !ok || fr.Decimal != "," || fr.Thousands != "\u00a0" {
+		t.Errorf("Expected French format from the language, got %+v, %v", fr, ok)
+	}
+	if f, ok := Locale("none"); !ok || !f.Plain() {
+		t.Errorf("Expected none to be plain, got %+v", f)
+	}
+	if _, ok := Locale("xx"); ok {
+		t.Error("Unknown locale should not be found")
+	}
+}
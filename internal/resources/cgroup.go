@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CgroupRoot is where the cgroup filesystem is mounted. Inside a container
+// it shows the container's own cgroup.
+const CgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedV1 is the smallest memory limit cgroup v1 reports for no limit,
+// the largest multiple of the page size
+const unlimitedV1 = 1 << 62
+
+// Detect reads the memory and CPU limits of the cgroup mounted at root,
+// trying the unified cgroup v2 files before cgroup v1. Limits that aren't
+// set or can't be read are zero.
+func Detect(root string) Budget {
+	var b Budget
+
+	if v, ok := readFields(filepath.Join(root, "memory.max")); ok {
+		b.Memory = parseMemory(v[0])
+	} else if v, ok := readFields(filepath.Join(root, "memory", "memory.limit_in_bytes")); ok {
+		b.Memory = parseMemory(v[0])
+	}
+
+	if v, ok := readFields(filepath.Join(root, "cpu.max")); ok && len(v) == 2 {
+		b.CPU = parseQuota(v[0], v[1])
+	} else {
+		quota, ok1 := readFields(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+		period, ok2 := readFields(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+		if ok1 && ok2 {
+			b.CPU = parseQuota(quota[0], period[0])
+		}
+	}
+	return b
+}
+
+// readFields returns the whitespace separated fields of a file
+func readFields(path string) ([]string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	fields := strings.Fields(string(data))
+	return fields, len(fields) > 0
+}
+
+// parseMemory parses a memory limit, "max" or a v1 near-maximum meaning none
+func parseMemory(s string) uint64 {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || v >= unlimitedV1 {
+		return 0
+	}
+	return v
+}
+
+// parseQuota returns the cores a CPU quota per period allows; "max" and -1
+// mean no limit
+func parseQuota(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
@@ -0,0 +1,53 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCgroup creates the files of a cgroup under a temporary root
+func writeCgroup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Budget
+	}{
+		{"none", nil, Budget{}},
+		{"v2", map[string]string{"memory.max": "268435456\n", "cpu.max": "50000 100000\n"}, Budget{Memory: 256 << 20, CPU: 0.5}},
+		{"v2 unlimited", map[string]string{"memory.max": "max\n", "cpu.max": "max 100000\n"}, Budget{}},
+		{"v1", map[string]string{
+			"memory/memory.limit_in_bytes": "536870912\n",
+			"cpu/cpu.cfs_quota_us":         "200000\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+		}, Budget{Memory: 512 << 20, CPU: 2}},
+		{"v1 unlimited", map[string]string{
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+		}, Budget{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(writeCgroup(t, tt.files)); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Limits caps the memory and CPU promviz uses, e.g. on a small jump host
+// or in a container. Container limits found in the cgroup apply as well;
+// the smaller one wins.
+type Limits struct {
+	Memory string  `yaml:"memory,omitempty"` // e.g. 256Mi or 1G
+	CPU    float64 `yaml:"cpu,omitempty"`    // cores, e.g. 0.5
+}
+
+// Budget is the memory and CPU promviz may use; zero means unlimited
+type Budget struct {
+	Memory uint64  // bytes
+	CPU    float64 // cores
+}
+
+// Pressure levels of the memory in use relative to the budget
+const (
+	// DownsampleAt is the share of the memory budget from which panel
+	// data is thinned out
+	DownsampleAt = 0.75
+	// RecoverAt is the share below which panels get all their points again
+	RecoverAt = 0.6
+	// WarnAt is the share from which the dashboard warns of running out
+	WarnAt = 0.9
+)
+
+// memoryLimitShare is the part of the memory budget given to the Go
+// runtime as its soft limit, leaving room for the stacks and buffers it
+// doesn't count
+const memoryLimitShare = 0.9
+
+// byteUnits are the suffixes ParseBytes accepts, binary and decimal
+var byteUnits = []struct {
+	suffix string
+	size   float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// ParseBytes parses a size such as 256Mi, 1.5G or 1048576
+func ParseBytes(s string) (uint64, error) {
+	number, size := strings.TrimSpace(s), 1.0
+	for _, u := range byteUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number, size = strings.TrimSuffix(number, u.suffix), u.size
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v <= 0 || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 256Mi or 1G", s)
+	}
+	return uint64(v * size), nil
+}
+
+// Resolve combines the configured limits with those of the container,
+// keeping the smaller of each
+func (l Limits) Resolve(container Budget) Budget {
+	b := container
+	if m, err := ParseBytes(l.Memory); err == nil && (b.Memory == 0 || m < b.Memory) {
+		b.Memory = m
+	}
+	if l.CPU > 0 && (b.CPU == 0 || l.CPU < b.CPU) {
+		b.CPU = l.CPU
+	}
+	return b
+}
+
+// Applied describes the runtime settings made by Apply
+type Applied struct {
+	Procs       int    // GOMAXPROCS
+	MemoryLimit uint64 // GOMEMLIMIT in bytes, zero if unset
+}
+
+// Apply tunes the Go runtime to the budget: GOMAXPROCS to the CPU cores,
+// rounded up, and the soft memory limit to part of the memory. Settings
+// made with the GOMAXPROCS and GOMEMLIMIT environment variables are kept.
+func Apply(b Budget) Applied {
+	if b.CPU > 0 && os.Getenv("GOMAXPROCS") == "" {
+		if procs := int(math.Ceil(b.CPU)); procs < runtime.GOMAXPROCS(0) {
+			runtime.GOMAXPROCS(procs)
+		}
+	}
+	if b.Memory > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(int64(float64(b.Memory) * memoryLimitShare))
+	}
+
+	applied := Applied{Procs: runtime.GOMAXPROCS(0)}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		applied.MemoryLimit = uint64(limit)
+	}
+	return applied
+}
+
+// Usage returns the memory the process holds from the operating system
+func Usage() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Sys - ms.HeapReleased
+}
+
+// Share returns used as a share of the memory budget, or 0 without one
+func (b Budget) Share(used uint64) float64 {
+	if b.Memory == 0 {
+		return 0
+	}
+	return float64(used) / float64(b.Memory)
+}
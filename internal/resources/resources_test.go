@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    uint64
+		wantErr bool
+	}{
+		{"256Mi", 256 << 20, false},
+		{"1.5Gi", 3 << 29, false},
+		{"512k", 512000, false},
+		{"2G", 2e9, false},
+		{"1048576", 1 << 20, false},
+		{" 64Mi ", 64 << 20, false},
+		{"", 0, true},
+		{"Mi", 0, true},
+		{"-1Gi", 0, true},
+		{"0", 0, true},
+		{"256MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBytes(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBytes(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		limits    Limits
+		container Budget
+		want      Budget
+	}{
+		{Limits{}, Budget{}, Budget{}},
+		{Limits{Memory: "256Mi", CPU: 0.5}, Budget{}, Budget{Memory: 256 << 20, CPU: 0.5}},
+		{Limits{}, Budget{Memory: 1 << 30, CPU: 2}, Budget{Memory: 1 << 30, CPU: 2}},
+		{Limits{Memory: "256Mi", CPU: 4}, Budget{Memory: 128 << 20, CPU: 2}, Budget{Memory: 128 << 20, CPU: 2}},
+		{Limits{Memory: "256Mi", CPU: 0.5}, Budget{Memory: 1 << 30, CPU: 2}, Budget{Memory: 256 << 20, CPU: 0.5}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limits.Resolve(tt.container); got != tt.want {
+			t.Errorf("%+v.Resolve(%+v) = %+v, want %+v", tt.limits, tt.container, got, tt.want)
+		}
+	}
+}
+
+func TestShare(t *testing.T) {
+	if got := (Budget{}).Share(1 << 30); got != 0 {
+		t.Errorf("Expected no share without a memory budget, got %v", got)
+	}
+	if got := (Budget{Memory: 200}).Share(150); got != 0.75 {
+		t.Errorf("Expected 0.75, got %v", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Setenv("GOMAXPROCS", "")
+	t.Setenv("GOMEMLIMIT", "")
+	procs := runtime.GOMAXPROCS(0)
+	limit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetMemoryLimit(limit)
+	})
+
+	applied := Apply(Budget{Memory: 100 << 20, CPU: 0.5})
+	if applied.Procs != 1 || runtime.GOMAXPROCS(0) != 1 {
+		t.Errorf("Expected GOMAXPROCS 1 for half a core, got %d", applied.Procs)
+	}
+	if want := uint64(90 << 20); applied.MemoryLimit != want || debug.SetMemoryLimit(-1) != int64(want) {
+		t.Errorf("Expected a soft memory limit of %d, got %d", want, applied.MemoryLimit)
+	}
+
+	// Without a budget nothing changes
+	runtime.GOMAXPROCS(procs)
+	debug.SetMemoryLimit(math.MaxInt64)
+	applied = Apply(Budget{})
+	if applied.Procs != procs || applied.MemoryLimit != 0 {
+		t.Errorf("Expected the runtime untouched, got %+v", applied)
+	}
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	t.Setenv("GOMAXPROCS", "3")
+	t.Setenv("GOMEMLIMIT", "1GiB")
+	procs := runtime.GOMAXPROCS(0)
+	limit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() { debug.SetMemoryLimit(limit) })
+
+	Apply(Budget{Memory: 100 << 20, CPU: 0.5})
+	if runtime.GOMAXPROCS(0) != procs || debug.SetMemoryLimit(-1) != limit {
+		t.Error("Settings made in the environment should be kept")
+	}
+}
@@ -0,0 +1,108 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"promviz/internal/backend"
+)
+
+// File is a Prometheus rule file
+type File struct {
+	Groups []Group `yaml:"groups"`
+}
+
+// Group is a named group of alerting rules
+type Group struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a single Prometheus alerting rule
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Build converts the thresholds of the given queries into a single rule group.
+// Queries without thresholds are skipped; forDuration is copied to every rule.
+func Build(group string, queries []backend.Query, forDuration string) File {
+	g := Group{Name: group, Rules: []Rule{}}
+
+	for _, q := range queries {
+		th := q.Thresholds
+		if th == nil {
+			continue
+		}
+		if th.Warn != nil {
+			g.Rules = append(g.Rules, buildRule(q, "warning", *th.Warn, forDuration))
+		}
+		if th.Crit != nil {
+			g.Rules = append(g.Rules, buildRule(q, "critical", *th.Crit, forDuration))
+		}
+	}
+
+	return File{Groups: []Group{g}}
+}
+
+// buildRule creates the rule firing when q crosses limit
+func buildRule(q backend.Query, severity string, limit float64, forDuration string) Rule {
+	op, direction := ">", "above"
+	if q.Thresholds.Below {
+		op, direction = "<", "below"
+	}
+
+	value := formatValue(limit)
+	return Rule{
+		Alert:  AlertName(q.Name, severity),
+		Expr:   fmt.Sprintf("(%s) %s %s", q.Expr, op, value),
+		For:    forDuration,
+		Labels: map[string]string{"severity": severity},
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("%s is %s %s", q.Name, direction, value),
+			"description": fmt.Sprintf("%s is {{ $value }} (%s threshold %s %s)", q.Name, severity, op, value),
+		},
+	}
+}
+
+// formatValue prints a threshold without trailing zeros
+func formatValue(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
+
+// AlertName turns a panel name and severity into a CamelCase alert name,
+// e.g. "CPU usage (%)" and "critical" become "CPUUsageCritical"
+func AlertName(name, severity string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name+" "+severity, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	alert := b.String()
+	// Alert names must not start with a digit
+	if alert != "" && unicode.IsDigit([]rune(alert)[0]) {
+		alert = "Query" + alert
+	}
+	return alert
+}
+
+// Write encodes the rule file as YAML, indented like Prometheus' examples
+func Write(w io.Writer, f File) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode rules: %w", err)
+	}
+	return encoder.Close()
+}
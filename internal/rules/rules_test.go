@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"promviz/internal/backend"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestBuild(t *testing.T) {
+	queries := []backend.Query{
+		{Name: "CPU Usage", Expr: "avg(rate(cpu[5m])) * 100", Thresholds: &backend.Thresholds{Warn: float(80), Crit: float(90.5)}},
+		{Name: "Memory", Expr: "mem_used"},
+		{Name: "free disk", Expr: "disk_free", Thresholds: &backend.Thresholds{Crit: float(10), Below: true}},
+	}
+
+	f := Build("promviz", queries, "5m")
+
+	if len(f.Groups) != 1 || f.Groups[0].Name != "promviz" {
+		t.Fatalf("Expected a single promviz group, got %+v", f.Groups)
+	}
+
+	rules := f.Groups[0].Rules
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules))
+	}
+
+	warn := rules[0]
+	if warn.Alert != "CPUUsageWarning" {
+		t.Errorf("Expected alert name CPUUsageWarning, got %s", warn.Alert)
+	}
+	if warn.Expr != "(avg(rate(cpu[5m])) * 100) > 80" {
+		t.Errorf("Unexpected expr: %s", warn.Expr)
+	}
+	if warn.For != "5m" || warn.Labels["severity"] != "warning" {
+		t.Errorf("Unexpected for/labels: %s %v", warn.For, warn.Labels)
+	}
+
+	if rules[1].Expr != "(avg(rate(cpu[5m])) * 100) > 90.5" {
+		t.Errorf("Unexpected critical expr: %s", rules[1].Expr)
+	}
+
+	below := rules[2]
+	if below.Alert != "FreeDiskCritical" || below.Expr != "(disk_free) < 10" {
+		t.Errorf("Unexpected below rule: %+v", below)
+	}
+	if !strings.Contains(below.Annotations["summary"], "below 10") {
+		t.Errorf("Summary should describe the direction, got %q", below.Annotations["summary"])
+	}
+}
+
+func TestAlertName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"CPU usage (%)", "CPUUsageCritical"},
+		{"http_requests_total", "HttpRequestsTotalCritical"},
+		{"5xx rate", "Query5xxRateCritical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AlertName(tt.name, "critical"); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	f := Build("promviz", []backend.Query{
+		{Name: "CPU", Expr: "cpu", Thresholds: &backend.Thresholds{Crit: float(90)}},
+	}, "")
+
+	var buf bytes.Buffer
+	if err := Write(&buf, f); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "for:") {
+		t.Errorf("Empty for should be omitted, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(buf.String(), "groups:\n  - name: promviz\n    rules:\n      - alert: CPUCritical\n") {
+		t.Errorf("Expected rules indented by two spaces, got:\n%s", buf.String())
+	}
+
+	var decoded File
+	decoder := yaml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&decoded); err != nil {
+		t.Fatalf("Output should be valid YAML: %v", err)
+	}
+	if decoded.Groups[0].Rules[0].Alert != "CPUCritical" {
+		t.Errorf("Unexpected decoded rule: %+v", decoded.Groups[0].Rules[0])
+	}
+}
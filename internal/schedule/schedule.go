@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockLayout is how the ends of a window are written
+const clockLayout = "15:04"
+
+// dayNames are the weekdays Days may list, indexed by time.Weekday
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Hours is a daily window of local time in which panels are refreshed,
+// such as business hours. A window ending before it starts runs past
+// midnight, into the next day; one ending when it starts lasts all day.
+type Hours struct {
+	From string   `yaml:"from"`           // e.g. "06:00"
+	To   string   `yaml:"to"`             // e.g. "20:00"
+	Days []string `yaml:"days,omitempty"` // "mon" to "sun" the window starts on, every day if empty
+}
+
+// Validate checks the times and days of the window
+func (h *Hours) Validate() error {
+	if _, err := time.Parse(clockLayout, h.From); err != nil {
+		return fmt.Errorf("from must be a time such as 06:00, got %q", h.From)
+	}
+	if _, err := time.Parse(clockLayout, h.To); err != nil {
+		return fmt.Errorf("to must be a time such as 20:00, got %q", h.To)
+	}
+	for _, day := range h.Days {
+		if weekday(day) < 0 {
+			return fmt.Errorf("days must be mon, tue, wed, thu, fri, sat or sun, got %q", day)
+		}
+	}
+	return nil
+}
+
+// weekday returns the index of a day name in dayNames, or -1
+func weekday(name string) int {
+	for i, day := range dayNames {
+		if strings.EqualFold(name, day) {
+			return i
+		}
+	}
+	return -1
+}
+
+// minutes returns the minutes since midnight of a time of day written as
+// in From and To, or 0 if malformed
+func minutes(clock string) int {
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// startsOn reports whether the window opens on the weekday
+func (h *Hours) startsOn(day time.Weekday) bool {
+	if len(h.Days) == 0 {
+		return true
+	}
+	for _, name := range h.Days {
+		if weekday(name) == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether t lies within the window. A nil window is always
+// active.
+func (h *Hours) Active(t time.Time) bool {
+	if h == nil {
+		return true
+	}
+	from, to := minutes(h.From), minutes(h.To)
+	now := t.Hour()*60 + t.Minute()
+	today, yesterday := h.startsOn(t.Weekday()), h.startsOn(t.AddDate(0, 0, -1).Weekday())
+	switch {
+	case from < to:
+		return today && now >= from && now < to
+	case from > to:
+		return (today && now >= from) || (yesterday && now < to)
+	default:
+		return today
+	}
+}
+
+// Next returns when the window opens next after t, or the zero time for a
+// nil window
+func (h *Hours) Next(t time.Time) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	from := minutes(h.From)
+	for i := 0; i <= 7; i++ {
+		day := t.AddDate(0, 0, i)
+		start := time.Date(day.Year(), day.Month(), day.Day(), from/60, from%60, 0, 0, t.Location())
+		if start.After(t) && h.startsOn(start.Weekday()) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// String describes the window, e.g. "06:00-20:00 mon,tue,wed,thu,fri"
+func (h *Hours) String() string {
+	s := h.From + "-" + h.To
+	if len(h.Days) > 0 {
+		s += " " + strings.ToLower(strings.Join(h.Days, ","))
+	}
+	return s
+}
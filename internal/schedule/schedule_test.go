@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time of day on the given day of the week starting Monday
+// 2024-05-06, in UTC
+func at(day int, clock string) time.Time {
+	t, _ := time.Parse(clockLayout, clock)
+	return time.Date(2024, 5, 6+day, t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		hours Hours
+		err   string
+	}{
+		{Hours{From: "06:00", To: "20:00"}, ""},
+		{Hours{From: "22:00", To: "06:00", Days: []string{"Mon", "fri"}}, ""},
+		{Hours{From: "6am", To: "20:00"}, "from must be a time such as 06:00"},
+		{Hours{From: "06:00", To: "24:00"}, "to must be a time such as 20:00"},
+		{Hours{From: "06:00", To: "20:00", Days: []string{"weekdays"}}, `days must be mon, tue, wed, thu, fri, sat or sun, got "weekdays"`},
+	}
+	for _, tt := range tests {
+		err := tt.hours.Validate()
+		if tt.err == "" && err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", tt.hours, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Expected an error containing %q for %+v, got %v", tt.err, tt.hours, err)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	business := &Hours{From: "06:00", To: "20:00", Days: []string{"mon", "tue", "wed", "thu", "fri"}}
+	overnight := &Hours{From: "22:00", To: "06:00", Days: []string{"fri"}}
+	allDay := &Hours{From: "00:00", To: "00:00", Days: []string{"sat", "sun"}}
+
+	tests := []struct {
+		hours *Hours
+		at    time.Time
+		want  bool
+	}{
+		{nil, at(0, "03:00"), true},
+		{business, at(0, "06:00"), true},
+		{business, at(0, "19:59"), true},
+		{business, at(0, "20:00"), false},
+		{business, at(0, "05:59"), false},
+		{business, at(5, "12:00"), false},
+		{overnight, at(4, "23:00"), true},
+		{overnight, at(5, "05:00"), true},
+		{overnight, at(5, "23:00"), false},
+		{overnight, at(4, "05:00"), false},
+		{allDay, at(5, "00:00"), true},
+		{allDay, at(6, "23:59"), true},
+		{allDay, at(0, "12:00"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.hours.Active(tt.at); got != tt.want {
+			t.Errorf("%v.Active(%s) = %v, want %v", tt.hours, tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	business := &Hours{From: "06:00", To: "20:00", Days: []string{"mon", "tue", "wed", "thu", "fri"}}
+	if got := business.Next(at(0, "21:00")); !got.Equal(at(1, "06:00")) {
+		t.Errorf("Expected Tuesday 06:00, got %v", got)
+	}
+	if got := business.Next(at(4, "21:00")); !got.Equal(at(7, "06:00")) {
+		t.Errorf("Expected Monday 06:00 after the weekend, got %v", got)
+	}
+	if got := business.Next(at(0, "05:00")); !got.Equal(at(0, "06:00")) {
+		t.Errorf("Expected 06:00 the same day, got %v", got)
+	}
+	if got := (*Hours)(nil).Next(at(0, "05:00")); !got.IsZero() {
+		t.Errorf("Expected no next opening without a window, got %v", got)
+	}
+	if s := business.String(); s != "06:00-20:00 mon,tue,wed,thu,fri" {
+		t.Errorf("Unexpected description %q", s)
+	}
+}
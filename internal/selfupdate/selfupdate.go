@@ -0,0 +1,368 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultRepo is the GitHub repository releases are published to
+const DefaultRepo = "hyperbyte-cloud/hyperbyte-plot"
+
+// checksumsAsset lists the SHA-256 of every release binary in sha256sum format;
+// signatureAsset holds its base64 Ed25519 signature
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sig"
+)
+
+// maxAssetSize bounds downloads so a bad release can't fill the disk
+const maxAssetSize = 200 << 20
+
+// Release is a published GitHub release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Updater fetches and verifies release binaries
+type Updater struct {
+	Repo      string
+	APIURL    string // GitHub API base URL
+	Client    *http.Client
+	PublicKey ed25519.PublicKey // checksums must carry a valid signature by this key
+	Insecure  bool              // trust unsigned checksums when no key is set
+}
+
+// NewUpdater creates an updater for the default repository. publicKey is the
+// base64 Ed25519 key release checksums are signed with, or empty.
+func NewUpdater(publicKey string) (*Updater, error) {
+	u := &Updater{
+		Repo:   DefaultRepo,
+		APIURL: "https://api.github.com",
+		Client: http.DefaultClient,
+	}
+	if publicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid release signing key")
+		}
+		u.PublicKey = key
+	}
+	return u, nil
+}
+
+// AssetName returns the name of the release binary for a platform, e.g.
+// "promviz-linux-amd64"
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("promviz-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the most recent release
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	data, err := u.get(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", u.APIURL, u.Repo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for releases: %w", err)
+	}
+
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("failed to parse release: missing tag")
+	}
+	return &release, nil
+}
+
+// IsNewer reports whether the release is a later version than the running
+// one. Versions are compared as semantic versions, with or without the
+// leading "v"; builds that aren't of a release, such as "dev", never are.
+func (r *Release) IsNewer(current string) bool {
+	latest, ok := parseVersion(r.Tag)
+	if !ok {
+		return false
+	}
+	running, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	return latest.compare(running) > 0
+}
+
+// IsRelease reports whether version is of a release build
+func IsRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// semver is a parsed semantic version
+type semver struct {
+	core  [3]int
+	pre   []string // prerelease identifiers
+	ahead bool     // commits after the tag, as git describe reports them
+}
+
+// describeSuffix matches what git describe appends to the tag of a build
+// after it, e.g. "-3-g1a2b3c4"
+var describeSuffix = regexp.MustCompile(`-[0-9]+-g[0-9a-f]+$`)
+
+// parseVersion parses a version such as "v1.2.0", "1.3.0-rc.1" or the git
+// describe output "v1.2.0-3-g1a2b3c4-dirty"
+func parseVersion(version string) (semver, bool) {
+	var v semver
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "+")
+	version = strings.TrimSuffix(version, "-dirty")
+	if loc := describeSuffix.FindStringIndex(version); loc != nil {
+		version, v.ahead = version[:loc[0]], true
+	}
+
+	core, pre, found := strings.Cut(version, "-")
+	if found {
+		v.pre = strings.Split(pre, ".")
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1 as v is older than, the same as or newer
+// than o, ordering prereleases as semantic versioning does
+func (v semver) compare(o semver) int {
+	for i := range v.core {
+		if c := compareInts(v.core[i], o.core[i]); c != 0 {
+			return c
+		}
+	}
+	// A build after a tag is newer than the tag, a prerelease older
+	switch {
+	case v.ahead != o.ahead && v.ahead:
+		return 1
+	case v.ahead != o.ahead:
+		return -1
+	case len(v.pre) == 0 && len(o.pre) > 0:
+		return 1
+	case len(v.pre) > 0 && len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := compareIdentifiers(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.pre), len(o.pre))
+}
+
+// compareIdentifiers orders prerelease identifiers: numbers numerically and
+// before words, words alphabetically
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts returns -1, 0 or 1 as a is less than, equal to or greater than b
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Download fetches the binary for the current platform and verifies it
+// against the release checksums and their signature. Without a key, it
+// refuses unless Insecure is set, as the checksums alone come from the same
+// server as the binary.
+func (u *Updater) Download(ctx context.Context, release *Release) ([]byte, error) {
+	if u.PublicKey == nil && !u.Insecure {
+		return nil, fmt.Errorf("this build has no release signing key to verify %s with; rebuild with -X main.releaseKey=... or pass --insecure to trust the checksums alone", release.Tag)
+	}
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+
+	binaryAsset, ok := release.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s", release.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums, ok := release.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.Tag, checksumsAsset)
+	}
+
+	sums, err := u.get(ctx, checksums.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums: %w", err)
+	}
+
+	if u.PublicKey != nil {
+		sigAsset, ok := release.asset(signatureAsset)
+		if !ok {
+			return nil, fmt.Errorf("release %s is not signed", release.Tag)
+		}
+		sig, err := u.get(ctx, sigAsset.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download signature: %w", err)
+		}
+		if err := verifySignature(u.PublicKey, sums, sig); err != nil {
+			return nil, err
+		}
+	}
+
+	expected, err := findChecksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := u.get(ctx, binaryAsset.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+
+	sum := sha256.Sum256(binary)
+	if hex.EncodeToString(sum[:]) != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s", name)
+	}
+	return binary, nil
+}
+
+// asset returns the asset with the given name
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// findChecksum returns the hex SHA-256 listed for name in sha256sum output
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sha256sum marks binary mode with a '*' before the file name
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s does not list %s", checksumsAsset, name)
+}
+
+// verifySignature checks a base64 Ed25519 signature of the checksums file
+func verifySignature(key ed25519.PublicKey, sums, sig []byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(key, sums, decoded) {
+		return fmt.Errorf("invalid signature on %s", checksumsAsset)
+	}
+	return nil
+}
+
+// get downloads a URL into memory
+func (u *Updater) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "promviz-self-update")
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("GET %s: response larger than %d bytes", url, maxAssetSize)
+	}
+	return data, nil
+}
+
+// Replace atomically swaps the binary at path for data, keeping its mode.
+// The new file is written next to it so the final rename stays on one
+// filesystem.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".promviz-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// Windows cannot replace a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
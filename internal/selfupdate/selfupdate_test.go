@@ -0,0 +1,190 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// releaseServer serves a GitHub-like latest release with the given assets
+func releaseServer(t *testing.T, assets map[string][]byte) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/"+DefaultRepo+"/releases/latest" {
+			var list []string
+			for name := range assets {
+				list = append(list, fmt.Sprintf(`{"name": %q, "browser_download_url": %q}`, name, server.URL+"/download/"+name))
+			}
+			fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [%s]}`, strings.Join(list, ","))
+			return
+		}
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// checksums renders sha256sum output for the given files
+func checksums(files map[string][]byte) []byte {
+	var b strings.Builder
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	return []byte(b.String())
+}
+
+func TestDownload(t *testing.T) {
+	binary := []byte("new promviz binary")
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	sums := checksums(map[string][]byte{name: binary, "promviz-plan9-386": []byte("other")})
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sums)))
+
+	tests := []struct {
+		name     string
+		assets   map[string][]byte
+		key      ed25519.PublicKey
+		insecure bool
+		expected string // error substring, empty for success
+	}{
+		{"checksum only", map[string][]byte{name: binary, checksumsAsset: sums}, nil, true, ""},
+		{"no key", map[string][]byte{name: binary, checksumsAsset: sums, signatureAsset: sig}, nil, false, "no release signing key"},
+		{"signed", map[string][]byte{name: binary, checksumsAsset: sums, signatureAsset: sig}, pub, false, ""},
+		{"tampered binary", map[string][]byte{name: []byte("evil"), checksumsAsset: sums}, nil, true, "checksum mismatch"},
+		{"missing checksums", map[string][]byte{name: binary}, nil, true, "unverified binary"},
+		{"missing signature", map[string][]byte{name: binary, checksumsAsset: sums}, pub, false, "not signed"},
+		{"bad signature", map[string][]byte{name: binary, checksumsAsset: sums, signatureAsset: []byte("AAAA")}, pub, false, "invalid signature"},
+		{"no binary for platform", map[string][]byte{checksumsAsset: sums}, nil, true, "has no binary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := releaseServer(t, tt.assets)
+			u := &Updater{Repo: DefaultRepo, APIURL: server.URL, Client: server.Client(), PublicKey: tt.key, Insecure: tt.insecure}
+
+			release, err := u.Latest(context.Background())
+			if err != nil {
+				t.Fatalf("Latest failed: %v", err)
+			}
+			if release.Tag != "v1.2.0" {
+				t.Errorf("Expected tag v1.2.0, got %s", release.Tag)
+			}
+
+			data, err := u.Download(context.Background(), release)
+			if tt.expected != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expected) {
+					t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download failed: %v", err)
+			}
+			if string(data) != string(binary) {
+				t.Errorf("Unexpected binary %q", data)
+			}
+		})
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	r := &Release{Tag: "v1.2.0"}
+	if r.IsNewer("1.2.0") || r.IsNewer("v1.2.0") {
+		t.Error("Same version should not be newer")
+	}
+	if !r.IsNewer("v1.1.0") || !r.IsNewer("v1.1.9") {
+		t.Error("Later version should be newer")
+	}
+
+	tests := []struct {
+		current string
+		newer   bool
+	}{
+		{"v1.10.0", false}, // not a downgrade, despite sorting first as text
+		{"v2.0.0", false},
+		{"v1.2.0-rc.1", true},
+		{"v1.1.0-3-g1a2b3c4", true},
+		{"v1.2.0-3-g1a2b3c4-dirty", false},
+		{"dev", false},
+		{"1a2b3c4", false},
+	}
+	for _, tt := range tests {
+		if got := r.IsNewer(tt.current); got != tt.newer {
+			t.Errorf("IsNewer(%q) = %v, want %v", tt.current, got, tt.newer)
+		}
+	}
+	if IsRelease("dev") || !IsRelease("v1.2.0") {
+		t.Error("Only versions should be releases")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// In the order of the semantic versioning spec
+	versions := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+	for i := 1; i < len(versions); i++ {
+		a, _ := parseVersion(versions[i-1])
+		b, _ := parseVersion(versions[i])
+		if a.compare(b) != -1 || b.compare(a) != 1 {
+			t.Errorf("Expected %s < %s", versions[i-1], versions[i])
+		}
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	sums := []byte("ABC123  promviz-linux-amd64\ndef456 *promviz-darwin-arm64\n")
+	if got, _ := findChecksum(sums, "promviz-linux-amd64"); got != "abc123" {
+		t.Errorf("Expected lowercased checksum, got %q", got)
+	}
+	if got, _ := findChecksum(sums, "promviz-darwin-arm64"); got != "def456" {
+		t.Errorf("Expected binary-mode entry to match, got %q", got)
+	}
+	if _, err := findChecksum(sums, "promviz-linux-arm64"); err == nil {
+		t.Error("Expected error for missing entry")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "promviz")
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("Expected new content, got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Temporary file should be cleaned up, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// csvHeader is the first row of every CSV file
+var csvHeader = []string{"timestamp", "backend", "expr", "series", "value"}
+
+// csvSink appends samples to a CSV file per day, named after the UTC date
+// of the samples, e.g. promviz-2024-05-02.csv
+type csvSink struct {
+	dir string
+}
+
+// newCSV creates the directory of a CSV sink
+func newCSV(dir string) (*csvSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return &csvSink{dir: dir}, nil
+}
+
+// write implements sink
+func (s *csvSink) write(ctx context.Context, samples []Sample) error {
+	byDay := make(map[string][]Sample)
+	var days []string
+	for _, sample := range samples {
+		day := sample.Time.UTC().Format("2006-01-02")
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], sample)
+	}
+	for _, day := range days {
+		if err := s.append(filepath.Join(s.dir, "promviz-"+day+".csv"), byDay[day]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append writes samples to the end of a file, starting it with the header
+func (s *csvSink) append(path string, samples []Sample) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		w.Write(csvHeader)
+	}
+	for _, sample := range samples {
+		w.Write([]string{
+			sample.Time.UTC().Format(time.RFC3339Nano),
+			sample.Backend,
+			sample.Expr,
+			sample.Series,
+			strconv.FormatFloat(sample.Value, 'g', -1, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// close implements sink
+func (s *csvSink) close() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package sink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCSVSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "series")
+	s, err := newCSV(dir)
+	if err != nil {
+		t.Fatalf("newCSV failed: %v", err)
+	}
+
+	samples := []Sample{
+		{Backend: "prometheus", Expr: "up", Series: `up{job="api"}`, Time: base, Value: 1},
+		{Backend: "prometheus", Expr: "up", Series: `up{job="api"}`, Time: base.Add(12 * time.Hour), Value: 0.5},
+	}
+	if err := s.write(context.Background(), samples[:1]); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := s.write(context.Background(), samples); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "promviz-2024-05-02.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "timestamp,backend,expr,series,value\n" +
+		"2024-05-02T13:00:00Z,prometheus,up,\"up{job=\"\"api\"\"}\",1\n" +
+		"2024-05-02T13:00:00Z,prometheus,up,\"up{job=\"\"api\"\"}\",1\n"
+	if string(data) != expected {
+		t.Errorf("Expected the header once and the samples appended, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "promviz-2024-05-03.csv")); err != nil {
+		t.Errorf("Samples of the next day should go to their own file: %v", err)
+	}
+}
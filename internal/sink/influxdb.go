@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// influxDB writes samples in line protocol to the write API of InfluxDB 2.x,
+// or of InfluxDB 1.x if a database rather than a bucket is configured
+type influxDB struct {
+	url      string // write endpoint with its parameters
+	headers  map[string]string
+	token    string
+	username string
+	password string
+	client   *http.Client
+}
+
+// newInfluxDB builds the write endpoint of an influxdb sink
+func newInfluxDB(c Config, client *http.Client) *influxDB {
+	s := &influxDB{headers: c.Headers, token: c.Token, username: c.Username, password: c.Password, client: client}
+	base := strings.TrimSuffix(c.URL, "/")
+	if c.Bucket != "" {
+		s.url = base + "/api/v2/write?" + url.Values{"org": {c.Org}, "bucket": {c.Bucket}, "precision": {"ms"}}.Encode()
+	} else {
+		s.url = base + "/write?" + url.Values{"db": {c.Database}, "precision": {"ms"}}.Encode()
+	}
+	return s
+}
+
+// write implements sink
+func (s *influxDB) write(ctx context.Context, samples []Sample) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(lineProtocol(samples)))
+	if err != nil {
+		return fmt.Errorf("failed to create write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Token "+s.token)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to write to InfluxDB: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// close implements sink
+func (s *influxDB) close() error {
+	return nil
+}
+
+// lineProtocol formats samples as InfluxDB line protocol with millisecond
+// timestamps: the metric name is the measurement, the other labels are tags
+// and the value is the field "value". Infinite values are skipped.
+func lineProtocol(samples []Sample) []byte {
+	var b bytes.Buffer
+	for _, s := range samples {
+		// InfluxDB has no infinite floats
+		if math.IsInf(s.Value, 0) {
+			continue
+		}
+		labels := s.Labels()
+		b.WriteString(lineEscaper.Replace(labels[backend.NameLabel]))
+		for _, name := range sortedNames(labels) {
+			if name == backend.NameLabel || labels[name] == "" {
+				continue
+			}
+			b.WriteString("," + tagEscaper.Replace(name) + "=" + tagEscaper.Replace(labels[name]))
+		}
+		fmt.Fprintf(&b, " value=%s %d\n", strconv.FormatFloat(s.Value, 'g', -1, 64), s.Time.UnixMilli())
+	}
+	return b.Bytes()
+}
+
+// Line protocol escapes commas and spaces in measurements, and equal signs
+// too in tag keys and values
+var (
+	lineEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	tagEscaper  = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`, "\n", `\n`)
+)
@@ -0,0 +1,69 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLineProtocol(t *testing.T) {
+	samples := []Sample{
+		{Expr: "up", Series: `up{job="api server",zone="a=b"}`, Time: base, Value: 1},
+		{Expr: "sum(up)", Series: "{}", Time: base, Value: 2.5},
+		{Expr: "ratio", Series: "ratio", Time: base, Value: math.Inf(1)},
+	}
+	expected := `up,job=api\ server,zone=a\=b value=1 1714654800000` + "\n" +
+		`promviz_query,expr=sum(up) value=2.5 1714654800000` + "\n"
+	if got := string(lineProtocol(samples)); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestInfluxDBSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		path     string
+		query    string
+		authz    string
+		username string
+	}{
+		{"v2", Config{Org: "ops", Bucket: "metrics", Token: "secret"}, "/api/v2/write", "bucket=metrics&org=ops&precision=ms", "Token secret", ""},
+		{"v1", Config{Database: "telegraf", Username: "promviz", Password: "secret"}, "/write", "db=telegraf&precision=ms", "", "promviz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = r
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			tt.config.URL = server.URL + "/"
+			s := newInfluxDB(tt.config, server.Client())
+			if err := s.write(context.Background(), []Sample{{Expr: "up", Series: "up", Time: base, Value: 1}}); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if req.URL.Path != tt.path || req.URL.RawQuery != tt.query {
+				t.Errorf("Expected %s?%s, got %s", tt.path, tt.query, req.URL)
+			}
+			if got := req.Header.Get("Authorization"); tt.authz != "" && got != tt.authz {
+				t.Errorf("Expected authorization %q, got %q", tt.authz, got)
+			}
+			if user, _, _ := req.BasicAuth(); user != tt.username {
+				t.Errorf("Expected basic auth user %q, got %q", tt.username, user)
+			}
+			if body != "up value=1 1714654800000\n" {
+				t.Errorf("Unexpected body %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+
+	"promviz/internal/backend"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWrite sends samples to a Prometheus remote_write endpoint, such as
+// Prometheus with --web.enable-remote-write-receiver, Mimir or Thanos
+// Receive
+type remoteWrite struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// write implements sink
+func (s *remoteWrite) write(ctx context.Context, samples []Sample) error {
+	body := snappyEncode(writeRequest(samples))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create remote write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remote write: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to remote write: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// close implements sink
+func (s *remoteWrite) close() error {
+	return nil
+}
+
+// writeRequest encodes samples as a remote write WriteRequest protobuf
+// message, with a time series per label set in time order:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func writeRequest(samples []Sample) []byte {
+	bySeries := make(map[string][]Sample)
+	labelSets := make(map[string]map[string]string)
+	var keys []string
+	for _, s := range samples {
+		labels := s.Labels()
+		key := backend.SeriesName("", labels)
+		if _, ok := bySeries[key]; !ok {
+			keys = append(keys, key)
+			labelSets[key] = labels
+		}
+		bySeries[key] = append(bySeries[key], s)
+	}
+
+	var req []byte
+	for _, key := range keys {
+		series := bySeries[key]
+		sort.SliceStable(series, func(i, j int) bool { return series[i].Time.Before(series[j].Time) })
+
+		var ts []byte
+		labels := labelSets[key]
+		for _, name := range sortedNames(labels) {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, labels[name])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		for _, s := range series {
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(s.Time.UnixMilli()))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+// snappyMaxLiteral is the longest literal snappyEncode writes at once
+const snappyMaxLiteral = 1 << 16
+
+// snappyEncode frames data in the snappy block format remote write expects.
+// The data is stored as literals rather than compressed, which every snappy
+// decoder reads; write requests are small and sent to nearby receivers.
+func snappyEncode(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > snappyMaxLiteral {
+			n = snappyMaxLiteral
+		}
+		switch {
+		case n <= 60:
+			out = append(out, byte(n-1)<<2)
+		case n <= 1<<8:
+			out = append(out, 60<<2, byte(n-1))
+		default:
+			out = append(out, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// snappyDecode reads the literals snappyEncode writes
+func snappyDecode(t *testing.T, data []byte) []byte {
+	t.Helper()
+	n, k := binary.Uvarint(data)
+	data = data[k:]
+	var out []byte
+	for len(data) > 0 {
+		tag := data[0]
+		if tag&3 != 0 {
+			t.Fatalf("Expected only literals, got tag %x", tag)
+		}
+		length := int(tag>>2) + 1
+		data = data[1:]
+		switch tag >> 2 {
+		case 60:
+			length = int(data[0]) + 1
+			data = data[1:]
+		case 61:
+			length = int(data[0]) | int(data[1])<<8 + 1
+			data = data[2:]
+		}
+		out = append(out, data[:length]...)
+		data = data[length:]
+	}
+	if uint64(len(out)) != n {
+		t.Fatalf("Expected %d bytes, got %d", n, len(out))
+	}
+	return out
+}
+
+// fields splits a protobuf message into its fields, as raw bytes for
+// messages and strings and as numbers otherwise
+func fields(t *testing.T, msg []byte) map[protowire.Number][][]byte {
+	t.Helper()
+	out := make(map[protowire.Number][][]byte)
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		msg = msg[n:]
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(msg)
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(msg)
+			value = binary.LittleEndian.AppendUint64(nil, v)
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(msg)
+			value = binary.AppendUvarint(nil, v)
+		}
+		if n < 0 {
+			t.Fatalf("Malformed message: %v", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		out[num] = append(out[num], value)
+	}
+	return out
+}
+
+func TestSnappyEncode(t *testing.T) {
+	for _, size := range []int{0, 1, 60, 61, 256, 257, 70000} {
+		data := bytes.Repeat([]byte("ab"), size)[:size]
+		if got := snappyDecode(t, snappyEncode(data)); !bytes.Equal(got, data) {
+			t.Errorf("%d bytes did not round trip", size)
+		}
+	}
+}
+
+func TestRemoteWrite(t *testing.T) {
+	var body []byte
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	s := &remoteWrite{url: server.URL, headers: map[string]string{"X-Scope-OrgID": "ops"}, client: server.Client()}
+	samples := []Sample{
+		{Expr: "up", Series: `up{job="api"}`, Time: base.Add(time.Minute), Value: 0},
+		{Expr: "up", Series: `up{job="api"}`, Time: base, Value: 1},
+		{Expr: "sum(up)", Series: "{}", Time: base, Value: 3},
+	}
+	if err := s.write(context.Background(), samples); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if headers.Get("Content-Encoding") != "snappy" || headers.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" || headers.Get("X-Scope-OrgID") != "ops" {
+		t.Errorf("Unexpected headers %v", headers)
+	}
+
+	series := fields(t, snappyDecode(t, body))[1]
+	if len(series) != 2 {
+		t.Fatalf("Expected 2 time series, got %d", len(series))
+	}
+	var labels []string
+	for _, label := range fields(t, series[0])[1] {
+		f := fields(t, label)
+		labels = append(labels, string(f[1][0])+"="+string(f[2][0]))
+	}
+	if got := strings.Join(labels, ","); got != "__name__=up,job=api" {
+		t.Errorf("Expected sorted labels, got %s", got)
+	}
+	points := fields(t, series[0])[2]
+	first := fields(t, points[0])
+	value := math.Float64frombits(binary.LittleEndian.Uint64(first[1][0]))
+	ts, _ := binary.Uvarint(first[2][0])
+	if len(points) != 2 || value != 1 || int64(ts) != base.UnixMilli() {
+		t.Errorf("Expected the samples in time order, got %d samples starting with %v at %d", len(points), value, ts)
+	}
+
+	var nameless []string
+	for _, label := range fields(t, series[1])[1] {
+		nameless = append(nameless, string(fields(t, label)[2][0]))
+	}
+	if got := strings.Join(nameless, ","); got != QueryMetric+",sum(up)" {
+		t.Errorf("Expected a series without a name to be named after its query, got %s", got)
+	}
+}
+
+func TestRemoteWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	s := &remoteWrite{url: server.URL, client: server.Client()}
+	err := s.write(context.Background(), []Sample{{Expr: "up", Series: "up", Time: base, Value: 1}})
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request: out of order sample") {
+		t.Errorf("Expected the status and message of the receiver, got %v", err)
+	}
+}
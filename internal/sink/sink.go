@@ -0,0 +1,382 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Sink types
+const (
+	TypeCSV         = "csv"
+	TypeSQLite      = "sqlite"
+	TypeRemoteWrite = "remote_write"
+	TypeInfluxDB    = "influxdb"
+)
+
+// Series a sink is written: fetched series are the results of backend
+// queries, derived series are computed by promviz, such as the series of
+// panels with record set and the alert levels of panels with thresholds
+const (
+	SeriesFetched = "fetched"
+	SeriesDerived = "derived"
+	SeriesAll     = "all"
+)
+
+// QueryMetric names series without a metric name, such as the results of
+// aggregations, in sinks that need one. The expression is kept in ExprLabel.
+const (
+	QueryMetric = "promviz_query"
+	ExprLabel   = "expr"
+)
+
+// AlertMetric is the derived series of the alert level of a panel with
+// thresholds, 0 for ok, 1 for warning and 2 for critical. PanelLabel holds
+// the ID of the panel.
+const (
+	AlertMetric = "promviz_alert_level"
+	PanelLabel  = "panel"
+)
+
+// maxBuffered bounds the points kept between writes; newer points are
+// dropped once it is reached, e.g. while a sink is unreachable
+const maxBuffered = 100000
+
+// writeTimeout bounds one write to a sink
+const writeTimeout = 10 * time.Second
+
+// Config is one destination series are written to
+type Config struct {
+	Type   string `yaml:"type"`             // csv, sqlite, remote_write or influxdb
+	Name   string `yaml:"name,omitempty"`   // shown in errors, defaults to the type
+	Series string `yaml:"series,omitempty"` // fetched (default), derived or all
+
+	Dir  string `yaml:"dir,omitempty"`  // csv: directory of the daily files
+	Path string `yaml:"path,omitempty"` // sqlite: database file
+
+	URL      string            `yaml:"url,omitempty"`      // remote_write and influxdb
+	Headers  map[string]string `yaml:"headers,omitempty"`  // sent with every write, e.g. for authentication
+	Org      string            `yaml:"org,omitempty"`      // influxdb 2.x
+	Bucket   string            `yaml:"bucket,omitempty"`   // influxdb 2.x
+	Token    string            `yaml:"token,omitempty"`    // influxdb 2.x
+	Database string            `yaml:"database,omitempty"` // influxdb 1.x
+	Username string            `yaml:"username,omitempty"` // influxdb 1.x
+	Password string            `yaml:"password,omitempty"` // influxdb 1.x
+}
+
+// DisplayName returns the name of the sink in errors
+func (c Config) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+// Validate checks that the sink has what its type needs
+func (c Config) Validate() error {
+	switch c.Type {
+	case TypeCSV:
+		if c.Dir == "" {
+			return fmt.Errorf("dir is required for csv sinks")
+		}
+	case TypeSQLite:
+		if c.Path == "" {
+			return fmt.Errorf("path is required for sqlite sinks")
+		}
+	case TypeRemoteWrite, TypeInfluxDB:
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http(s) URL")
+		}
+		if c.Type == TypeInfluxDB && c.Bucket == "" && c.Database == "" {
+			return fmt.Errorf("bucket (InfluxDB 2.x) or database (InfluxDB 1.x) is required for influxdb sinks")
+		}
+		if c.Type == TypeInfluxDB && c.Bucket != "" && c.Org == "" {
+			return fmt.Errorf("org is required with bucket")
+		}
+	case "":
+		return fmt.Errorf("type is required")
+	default:
+		return fmt.Errorf("unknown type %q (supported: csv, sqlite, remote_write, influxdb)", c.Type)
+	}
+
+	switch c.Series {
+	case "", SeriesFetched:
+	case SeriesDerived, SeriesAll:
+		if c.Type != TypeRemoteWrite {
+			return fmt.Errorf("series %q is only supported by remote_write sinks", c.Series)
+		}
+	default:
+		return fmt.Errorf("unknown series %q (supported: fetched, derived, all)", c.Series)
+	}
+	return nil
+}
+
+// Derived reports whether the sink is written derived series
+func (c Config) Derived() bool {
+	return c.Series == SeriesDerived || c.Series == SeriesAll
+}
+
+// Fetched reports whether the sink is written fetched series
+func (c Config) Fetched() bool {
+	return c.Series != SeriesDerived
+}
+
+// Sample is one point with where it came from
+type Sample struct {
+	Backend string // name of the backend queried, empty for derived samples
+	Expr    string // ID of the panel for derived samples
+	Series  string
+	Time    time.Time
+	Value   float64
+	Derived bool
+}
+
+// key identifies the series of the sample among the ones recorded
+func (s Sample) key() string {
+	key := s.Backend + "\x00" + s.Expr + "\x00" + s.Series
+	if s.Derived {
+		return "derived\x00" + key
+	}
+	return key
+}
+
+// Labels returns the labels of the sample's series. Series without a
+// metric name are named QueryMetric and labeled with their expression.
+func (s Sample) Labels() map[string]string {
+	labels := backend.ParseSeriesName(s.Series)
+	if labels[backend.NameLabel] == "" {
+		labels[backend.NameLabel] = QueryMetric
+		labels[ExprLabel] = s.Expr
+	}
+	return labels
+}
+
+// sortedNames returns the names of labels in order
+func sortedNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sink writes samples to one destination
+type sink interface {
+	write(ctx context.Context, samples []Sample) error
+	close() error
+}
+
+// named is a sink with the name it is reported as and the series it takes
+type named struct {
+	name   string
+	sink   sink
+	series string
+}
+
+// takes reports whether the sink is written the sample
+func (n named) takes(s Sample) bool {
+	c := Config{Series: n.series}
+	if s.Derived {
+		return c.Derived()
+	}
+	return c.Fetched()
+}
+
+// Writer buffers the points of fetched and derived series and writes them
+// to the sinks that take them. Points are written once: series recorded
+// again over an overlapping range only pass on their newer points. A nil
+// writer is valid and writes nothing.
+type Writer struct {
+	sinks []named
+
+	mu      sync.Mutex
+	pending []Sample
+	newest  map[string]time.Time // newest point written per series, see Sample.key
+	dropped int                  // points dropped since the last write
+}
+
+// New creates the sinks of configs
+func New(configs []Config) (*Writer, error) {
+	w := &Writer{newest: make(map[string]time.Time)}
+	client := &http.Client{Timeout: writeTimeout}
+	for _, c := range configs {
+		var s sink
+		var err error
+		switch c.Type {
+		case TypeCSV:
+			s, err = newCSV(c.Dir)
+		case TypeSQLite:
+			s, err = newSQLite(c.Path)
+		case TypeRemoteWrite:
+			s = &remoteWrite{url: c.URL, headers: c.Headers, client: client}
+		case TypeInfluxDB:
+			s = newInfluxDB(c, client)
+		default:
+			err = fmt.Errorf("unknown type %q", c.Type)
+		}
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("sink %s: %w", c.DisplayName(), err)
+		}
+		w.sinks = append(w.sinks, named{name: c.DisplayName(), sink: s, series: c.Series})
+	}
+	return w, nil
+}
+
+// takes reports whether any sink is written derived or fetched series
+func (w *Writer) takes(derived bool) bool {
+	for _, s := range w.sinks {
+		if s.takes(Sample{Derived: derived}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record buffers the points of a result fetched from the named backend that
+// are newer than the ones recorded before. NaN values are skipped.
+func (w *Writer) Record(backendName, expr string, result *backend.TimeSeriesResult) {
+	if w == nil || result == nil || !w.takes(false) {
+		return
+	}
+	w.record(result.Points, func(p backend.DataPoint) Sample {
+		return Sample{Backend: backendName, Expr: expr, Series: p.Series}
+	})
+}
+
+// RecordDerived buffers the points of a series computed for a panel, named
+// metric and keeping their other labels, for the sinks that take derived
+// series
+func (w *Writer) RecordDerived(panel, metric string, result *backend.TimeSeriesResult) {
+	if w == nil || result == nil || !w.takes(true) {
+		return
+	}
+	w.record(result.Points, func(p backend.DataPoint) Sample {
+		labels := backend.ParseSeriesName(p.Series)
+		delete(labels, backend.NameLabel)
+		return Sample{Expr: panel, Series: backend.SeriesName(metric, labels), Derived: true}
+	})
+}
+
+// record buffers the points newer than the ones recorded before for their
+// series, as the samples sample returns
+func (w *Writer) record(points []backend.DataPoint, sample func(backend.DataPoint) Sample) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	newest := make(map[string]time.Time)
+	for _, p := range points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		s := sample(p)
+		s.Time, s.Value = p.Timestamp, p.Value
+		key := s.key()
+		if last, ok := w.newest[key]; ok && !p.Timestamp.After(last) {
+			continue
+		}
+		if len(w.pending) >= maxBuffered {
+			w.dropped++
+			continue
+		}
+		w.pending = append(w.pending, s)
+		if p.Timestamp.After(newest[key]) {
+			newest[key] = p.Timestamp
+		}
+	}
+	for key, ts := range newest {
+		w.newest[key] = ts
+	}
+}
+
+// Run writes the buffered points every interval until ctx is done, then
+// writes what is left. report, if set, gets the result of every write.
+func (w *Writer) Run(ctx context.Context, interval time.Duration, report func(error)) {
+	if w == nil || len(w.sinks) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+			_ = w.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			if err := w.Flush(ctx); report != nil {
+				report(err)
+			}
+		}
+	}
+}
+
+// Flush writes the buffered points to the sinks that take them. A sink that
+// fails misses these points; the others still get them.
+func (w *Writer) Flush(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	samples, dropped := w.pending, w.dropped
+	w.pending, w.dropped = nil, 0
+	w.mu.Unlock()
+
+	var errs []error
+	if dropped > 0 {
+		errs = append(errs, fmt.Errorf("dropped %d points over the buffer limit", dropped))
+	}
+	for _, s := range w.sinks {
+		var batch []Sample
+		for _, sample := range samples {
+			if s.takes(sample) {
+				batch = append(batch, sample)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := s.sink.write(ctx, batch); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", s.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Names returns the names of the sinks
+func (w *Writer) Names() []string {
+	if w == nil {
+		return nil
+	}
+	names := make([]string, len(w.sinks))
+	for i, s := range w.sinks {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Close releases the sinks. Call after Run has returned.
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range w.sinks {
+		if err := s.sink.close(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
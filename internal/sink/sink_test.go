@@ -0,0 +1,200 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+var base = time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+
+// result returns a result of one series with a point per minute offset
+func result(series string, minutes ...int) *backend.TimeSeriesResult {
+	r := &backend.TimeSeriesResult{}
+	for _, m := range minutes {
+		r.Points = append(r.Points, backend.DataPoint{Timestamp: base.Add(time.Duration(m) * time.Minute), Value: float64(m), Series: series})
+	}
+	return r
+}
+
+// recorder is a sink keeping what it was written
+type recorder struct {
+	samples []Sample
+	err     error
+}
+
+func (r *recorder) write(ctx context.Context, samples []Sample) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.samples = append(r.samples, samples...)
+	return nil
+}
+
+func (r *recorder) close() error {
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config   Config
+		expected string
+	}{
+		{Config{Type: TypeCSV, Dir: "out"}, ""},
+		{Config{Type: TypeSQLite, Path: "promviz.db"}, ""},
+		{Config{Type: TypeRemoteWrite, URL: "http://localhost:9090/api/v1/write"}, ""},
+		{Config{Type: TypeInfluxDB, URL: "http://localhost:8086", Org: "ops", Bucket: "metrics"}, ""},
+		{Config{Type: TypeInfluxDB, URL: "http://localhost:8086", Database: "telegraf"}, ""},
+		{Config{}, "type is required"},
+		{Config{Type: "kafka"}, `unknown type "kafka"`},
+		{Config{Type: TypeCSV}, "dir is required"},
+		{Config{Type: TypeSQLite}, "path is required"},
+		{Config{Type: TypeRemoteWrite, URL: "localhost:9090"}, "url must be an http(s) URL"},
+		{Config{Type: TypeInfluxDB, URL: "http://localhost:8086"}, "bucket (InfluxDB 2.x) or database (InfluxDB 1.x) is required"},
+		{Config{Type: TypeInfluxDB, URL: "http://localhost:8086", Bucket: "metrics"}, "org is required with bucket"},
+		{Config{Type: TypeRemoteWrite, URL: "http://localhost:9090/api/v1/write", Series: SeriesDerived}, ""},
+		{Config{Type: TypeCSV, Dir: "out", Series: SeriesFetched}, ""},
+		{Config{Type: TypeCSV, Dir: "out", Series: SeriesAll}, `series "all" is only supported by remote_write sinks`},
+		{Config{Type: TypeRemoteWrite, URL: "http://localhost:9090/api/v1/write", Series: "computed"}, `unknown series "computed"`},
+	}
+
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error: %v", tt.config, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.config, tt.expected, err)
+		}
+	}
+}
+
+func TestSampleLabels(t *testing.T) {
+	named := Sample{Expr: `up{job="api"}`, Series: `up{job="api"}`}
+	if labels := named.Labels(); labels[backend.NameLabel] != "up" || labels["job"] != "api" || labels[ExprLabel] != "" {
+		t.Errorf("Expected the labels of the series, got %v", labels)
+	}
+	nameless := Sample{Expr: "sum(rate(http_requests_total[5m]))", Series: "{}"}
+	if labels := nameless.Labels(); labels[backend.NameLabel] != QueryMetric || labels[ExprLabel] != nameless.Expr {
+		t.Errorf("Expected series without a name to be named after the query, got %v", labels)
+	}
+}
+
+func TestWriterRecordsNewPoints(t *testing.T) {
+	rec := &recorder{}
+	w := &Writer{sinks: []named{{name: "test", sink: rec}}, newest: make(map[string]time.Time)}
+
+	w.Record("prometheus", "up", result(`up{job="api"}`, 0, 1, 2))
+	// A refresh over an overlapping range only adds the newer point
+	w.Record("prometheus", "up", result(`up{job="api"}`, 1, 2, 3))
+	// The same series from another query is written on its own
+	w.Record("prometheus", `up{job="api"}`, result(`up{job="api"}`, 2))
+	w.Record("prometheus", "up", &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: base.Add(time.Hour), Value: math.NaN(), Series: `up{job="api"}`}}})
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(rec.samples) != 5 {
+		t.Fatalf("Expected 5 samples, got %d: %v", len(rec.samples), rec.samples)
+	}
+	if last := rec.samples[3]; last.Value != 3 || last.Backend != "prometheus" || last.Expr != "up" {
+		t.Errorf("Expected the newer point of the refresh, got %+v", last)
+	}
+
+	// Written points are not written again
+	if err := w.Flush(context.Background()); err != nil || len(rec.samples) != 5 {
+		t.Errorf("Expected nothing left to write, got %d samples and %v", len(rec.samples), err)
+	}
+}
+
+func TestWriterDerivedSeries(t *testing.T) {
+	fetched := &recorder{}
+	derived := &recorder{}
+	all := &recorder{}
+	w := &Writer{sinks: []named{
+		{name: "csv", sink: fetched},
+		{name: "mimir", sink: derived, series: SeriesDerived},
+		{name: "thanos", sink: all, series: SeriesAll},
+	}, newest: make(map[string]time.Time)}
+
+	w.Record("prometheus", "up", result(`up{job="api"}`, 0))
+	w.RecordDerived("errors", "job:errors:ratio", result(`{job="api"}`, 0, 1))
+	w.RecordDerived("errors", "job:errors:ratio", result(`{job="api"}`, 1, 2))
+	// Derived series are kept apart from fetched series of the same name
+	w.Record("prometheus", "up", result(`up{job="api"}`, 1))
+	w.RecordDerived("up", "up", result(`up{job="api"}`, 1))
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(fetched.samples) != 2 || len(derived.samples) != 4 || len(all.samples) != 6 {
+		t.Fatalf("Expected 2 fetched, 4 derived and 6 samples in all, got %d, %d and %d", len(fetched.samples), len(derived.samples), len(all.samples))
+	}
+	for _, s := range fetched.samples {
+		if s.Derived {
+			t.Errorf("Expected only fetched samples, got %+v", s)
+		}
+	}
+	got := derived.samples[0]
+	if !got.Derived || got.Expr != "errors" || got.Backend != "" || got.Series != `job:errors:ratio{job="api"}` {
+		t.Errorf("Expected the series renamed after the metric, got %+v", got)
+	}
+	if labels := got.Labels(); labels[ExprLabel] != "" {
+		t.Errorf("Derived series should not be labeled with an expression, got %v", labels)
+	}
+}
+
+func TestWriterSkipsSeriesWithoutSinks(t *testing.T) {
+	w := &Writer{sinks: []named{{name: "csv", sink: &recorder{}}}, newest: make(map[string]time.Time)}
+	w.RecordDerived("errors", "job:errors:ratio", result("", 0))
+	if len(w.pending) != 0 {
+		t.Errorf("Derived series should not be buffered without a sink taking them, got %v", w.pending)
+	}
+
+	w = &Writer{sinks: []named{{name: "mimir", sink: &recorder{}, series: SeriesDerived}}, newest: make(map[string]time.Time)}
+	w.Record("prometheus", "up", result("up", 0))
+	if len(w.pending) != 0 {
+		t.Errorf("Fetched series should not be buffered without a sink taking them, got %v", w.pending)
+	}
+}
+
+func TestWriterFailingSink(t *testing.T) {
+	failing := &recorder{err: errors.New("connection refused")}
+	working := &recorder{}
+	w := &Writer{sinks: []named{{name: "remote", sink: failing}, {name: "csv", sink: working}}, newest: make(map[string]time.Time)}
+
+	w.Record("prometheus", "up", result("up", 0, 1))
+	err := w.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "sink remote: connection refused") {
+		t.Errorf("Expected the error of the failing sink, got %v", err)
+	}
+	if len(working.samples) != 2 {
+		t.Errorf("The other sinks should still be written, got %d samples", len(working.samples))
+	}
+}
+
+func TestWriterBufferLimit(t *testing.T) {
+	w := &Writer{sinks: []named{{name: "test", sink: &recorder{}}}, newest: make(map[string]time.Time)}
+	w.pending = make([]Sample, maxBuffered)
+
+	w.Record("prometheus", "up", result("up", 0, 1))
+	if err := w.Flush(context.Background()); err == nil || !strings.Contains(err.Error(), "dropped 2 points") {
+		t.Errorf("Expected the dropped points to be reported, got %v", err)
+	}
+}
+
+func TestNilWriter(t *testing.T) {
+	var w *Writer
+	w.Record("prometheus", "up", result("up", 0))
+	if err := w.Flush(context.Background()); err != nil || w.Names() != nil || w.Close() != nil {
+		t.Error("A nil writer should do nothing")
+	}
+}
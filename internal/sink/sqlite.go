@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sqliteCommand is the sqlite3 shell; the database is written through it so
+// promviz needs no C toolchain or database driver
+var sqliteCommand = "sqlite3"
+
+// sqliteSchema creates the samples table. Points fetched again replace the
+// ones written before.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS samples (
+	timestamp INTEGER NOT NULL, -- milliseconds since the epoch
+	backend TEXT NOT NULL,
+	expr TEXT NOT NULL,
+	series TEXT NOT NULL,
+	value REAL,
+	PRIMARY KEY (backend, expr, series, timestamp)
+);
+`
+
+// sqliteSink inserts samples into the samples table of a SQLite database
+type sqliteSink struct {
+	path string
+}
+
+// newSQLite checks that the sqlite3 shell is available and creates the
+// samples table
+func newSQLite(path string) (*sqliteSink, error) {
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		return nil, fmt.Errorf("sqlite sinks need the %s command: %w", sqliteCommand, err)
+	}
+	s := &sqliteSink{path: path}
+	if err := s.exec(context.Background(), sqliteSchema); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// write implements sink
+func (s *sqliteSink) write(ctx context.Context, samples []Sample) error {
+	var b strings.Builder
+	b.WriteString(sqliteSchema)
+	b.WriteString("BEGIN;\n")
+	for _, sample := range samples {
+		fmt.Fprintf(&b, "INSERT OR REPLACE INTO samples VALUES (%d, %s, %s, %s, %s);\n",
+			sample.Time.UnixMilli(), sqlString(sample.Backend), sqlString(sample.Expr), sqlString(sample.Series),
+			sqlFloat(sample.Value))
+	}
+	b.WriteString("COMMIT;\n")
+	return s.exec(ctx, b.String())
+}
+
+// exec runs SQL statements against the database
+func (s *sqliteSink) exec(ctx context.Context, sql string) error {
+	cmd := exec.CommandContext(ctx, sqliteCommand, "-bail", s.path)
+	cmd.Stdin = strings.NewReader(sql)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to write %s: %s", s.path, msg)
+		}
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// sqlString quotes s as an SQL string literal
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlFloat formats v as an SQL number; SQLite reads values out of range
+// as infinity
+func sqlFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "9e999"
+	case math.IsInf(v, -1):
+		return "-9e999"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// close implements sink
+func (s *sqliteSink) close() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"context"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLiteSink(t *testing.T) {
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	path := filepath.Join(t.TempDir(), "promviz.db")
+	s, err := newSQLite(path)
+	if err != nil {
+		t.Fatalf("newSQLite failed: %v", err)
+	}
+
+	samples := []Sample{
+		{Backend: "prometheus", Expr: "up", Series: `up{job="it's"}`, Time: base, Value: 1},
+		{Backend: "prometheus", Expr: "up", Series: `up{job="it's"}`, Time: base.Add(time.Minute), Value: math.Inf(1)},
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.write(context.Background(), samples); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	out, err := exec.Command(sqliteCommand, path, "SELECT count(*), max(value), min(series) FROM samples").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != `2|Inf|up{job="it's"}` {
+		t.Errorf("Expected each sample once, got %q", got)
+	}
+}
+
+func TestSQLiteMissing(t *testing.T) {
+	defer func(command string) { sqliteCommand = command }(sqliteCommand)
+	sqliteCommand = "promviz-no-such-sqlite3"
+	if _, err := newSQLite(filepath.Join(t.TempDir(), "promviz.db")); err == nil || !strings.Contains(err.Error(), "need the promviz-no-such-sqlite3 command") {
+		t.Errorf("Expected an error naming the missing command, got %v", err)
+	}
+}
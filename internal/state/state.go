@@ -0,0 +1,78 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State holds the runtime customizations of a dashboard, kept per user so
+// the shared config file is never rewritten
+type State struct {
+	Hidden       []string             `json:"hidden,omitempty"`       // query IDs of hidden panels
+	Order        []string             `json:"order,omitempty"`        // query IDs in display order
+	Refresh      time.Duration        `json:"refresh,omitempty"`      // refresh interval, the default if zero
+	Acknowledged map[string]time.Time `json:"acknowledged,omitempty"` // newest acknowledged transition per query ID
+	Muted        []string             `json:"muted,omitempty"`        // tags whose panels raise no alerts
+}
+
+// Path returns the state file of the config with the given contents. The
+// file is named after a hash of the config, so each dashboard keeps its own
+// state and a changed config starts afresh.
+func Path(config []byte) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory for state file: %w", err)
+	}
+	sum := sha256.Sum256(config)
+	return filepath.Join(dir, "promviz", "state", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// Load reads a state file. A missing file yields an empty state.
+func Load(path string) (State, error) {
+	var s State
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return State{}, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes a state file, creating its directory if needed. The file is
+// replaced in one step so an interrupted save leaves the old state intact.
+func Save(path string, s State) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
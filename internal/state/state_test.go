@@ -0,0 +1,80 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	acked := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := State{
+		Hidden:       []string{"disk"},
+		Order:        []string{"mem", "cpu", "disk"},
+		Refresh:      10 * time.Second,
+		Acknowledged: map[string]time.Time{"cpu": acked},
+		Muted:        []string{"db"},
+	}
+
+	if err := Save(path, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, s) {
+		t.Errorf("Expected %+v, got %+v", s, loaded)
+	}
+
+	// Saving again replaces the file without leaving temporary files
+	if err := Save(path, State{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file, got %d entries", len(entries))
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(s, State{}) {
+		t.Errorf("Expected an empty state, got %+v", s)
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{"), 0644)
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "failed to parse state file") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	a, err := Path([]byte("queries: []"))
+	if err != nil {
+		t.Skipf("No config directory: %v", err)
+	}
+	b, _ := Path([]byte("queries: []"))
+	c, _ := Path([]byte("queries: [{}]"))
+
+	if a != b {
+		t.Errorf("Same config should map to the same file, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Error("Different configs should map to different files")
+	}
+	if filepath.Base(filepath.Dir(a)) != "state" || filepath.Ext(a) != ".json" {
+		t.Errorf("Unexpected path %q", a)
+	}
+}
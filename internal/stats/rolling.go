@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"math"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Rolling returns, for every point of the sorted series, the p-th percentile
+// of the values in the window ending at that point. Points with a NaN value
+// stay NaN so gaps remain visible.
+func Rolling(points []backend.DataPoint, p float64, window time.Duration) []float64 {
+	result := make([]float64, len(points))
+	start := 0
+	for i, point := range points {
+		if math.IsNaN(point.Value) {
+			result[i] = math.NaN()
+			continue
+		}
+
+		// The window covers (point - window, point]
+		for start < i && !points[start].Timestamp.Add(window).After(point.Timestamp) {
+			start++
+		}
+
+		values := make([]float64, 0, i-start+1)
+		for _, q := range points[start : i+1] {
+			if !math.IsNaN(q.Value) {
+				values = append(values, q.Value)
+			}
+		}
+		result[i] = Percentile(values, p)
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestRolling(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	var points []backend.DataPoint
+	for i, v := range []float64{1, 2, 3, math.NaN(), 10, 20} {
+		points = append(points, backend.DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: v})
+	}
+
+	// A 3 minute window holds the current point and the two before it
+	tests := []struct {
+		p        float64
+		expected []float64
+	}{
+		{100, []float64{1, 2, 3, math.NaN(), 10, 20}},
+		{0, []float64{1, 1, 1, math.NaN(), 3, 10}},
+		{50, []float64{1, 1.5, 2, math.NaN(), 6.5, 15}},
+	}
+
+	for _, tt := range tests {
+		got := Rolling(points, tt.p, 3*time.Minute)
+		for i, want := range tt.expected {
+			if math.IsNaN(want) != math.IsNaN(got[i]) || !math.IsNaN(want) && math.Abs(got[i]-want) > 1e-9 {
+				t.Errorf("p%g at %d: expected %v, got %v", tt.p, i, want, got[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"promviz/internal/backend"
+)
+
+// Summary holds descriptive statistics for a series of points
+type Summary struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Last  float64 `json:"last"`
+}
+
+// Summarize computes statistics over the points, ignoring NaN values.
+// Last is the value of the most recent point.
+func Summarize(points []backend.DataPoint) Summary {
+	values := make([]float64, 0, len(points))
+	var latest *backend.DataPoint
+	for i, p := range points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		values = append(values, p.Value)
+		if latest == nil || p.Timestamp.After(latest.Timestamp) {
+			latest = &points[i]
+		}
+	}
+
+	if len(values) == 0 {
+		return Summary{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return Summary{
+		Count: len(values),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Avg:   sum / float64(len(values)),
+		P50:   percentileSorted(sorted, 50),
+		P90:   percentileSorted(sorted, 90),
+		P99:   percentileSorted(sorted, 99),
+		Last:  latest.Value,
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of the values using linear
+// interpolation between closest ranks. It returns NaN for an empty slice.
+func Percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return percentileSorted(sorted, p)
+}
+
+// percentileSorted is Percentile for already sorted values
+func percentileSorted(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
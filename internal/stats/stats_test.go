@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	points := []backend.DataPoint{
+		{Timestamp: start.Add(2 * time.Minute), Value: 30},
+		{Timestamp: start, Value: 10},
+		{Timestamp: start.Add(time.Minute), Value: math.NaN()},
+		{Timestamp: start.Add(3 * time.Minute), Value: 20},
+		{Timestamp: start.Add(4 * time.Minute), Value: 40},
+	}
+
+	summary := Summarize(points)
+
+	if summary.Count != 4 {
+		t.Errorf("Expected 4 values (NaN ignored), got %d", summary.Count)
+	}
+	if summary.Min != 10 || summary.Max != 40 {
+		t.Errorf("Expected min 10 and max 40, got %f and %f", summary.Min, summary.Max)
+	}
+	if summary.Avg != 25 {
+		t.Errorf("Expected avg 25, got %f", summary.Avg)
+	}
+	if summary.P50 != 25 {
+		t.Errorf("Expected p50 25, got %f", summary.P50)
+	}
+	if summary.Last != 40 {
+		t.Errorf("Expected last value from the latest timestamp (40), got %f", summary.Last)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	summary := Summarize(nil)
+	if summary.Count != 0 {
+		t.Errorf("Expected empty summary, got %+v", summary)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{0, 1},
+		{50, 3},
+		{90, 4.6},
+		{100, 5},
+	}
+
+	for _, tt := range tests {
+		got := Percentile(values, tt.p)
+		if math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("Percentile(%v) = %f, expected %f", tt.p, got, tt.expected)
+		}
+	}
+
+	if !math.IsNaN(Percentile(nil, 50)) {
+		t.Error("Percentile of no values should be NaN")
+	}
+
+	// Input must not be reordered
+	if values[0] != 5 {
+		t.Error("Percentile should not modify its input")
+	}
+}
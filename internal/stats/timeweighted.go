@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// TimeWeighted holds statistics of one series that weigh every value by the
+// time it covers, which unlike point averages stay right for unevenly
+// spaced samples
+type TimeWeighted struct {
+	Mean     float64       // integral divided by the covered duration
+	Integral float64       // area under the series, in value × seconds
+	Covered  time.Duration // time between samples with values on both ends
+}
+
+// Weigh computes the time-weighted statistics of the points of one series
+// with the trapezoidal rule. Intervals next to a NaN value are left out, so
+// gaps don't count. A single value is its own mean; NaN is returned without
+// any value.
+func Weigh(points []backend.DataPoint) TimeWeighted {
+	sorted := make([]backend.DataPoint, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var tw TimeWeighted
+	last := math.NaN()
+	for i, p := range sorted {
+		if !math.IsNaN(p.Value) {
+			last = p.Value
+		}
+		if i == 0 || math.IsNaN(p.Value) || math.IsNaN(sorted[i-1].Value) {
+			continue
+		}
+		dt := p.Timestamp.Sub(sorted[i-1].Timestamp)
+		tw.Integral += (p.Value + sorted[i-1].Value) / 2 * dt.Seconds()
+		tw.Covered += dt
+	}
+
+	switch {
+	case tw.Covered > 0:
+		tw.Mean = tw.Integral / tw.Covered.Seconds()
+	default:
+		tw.Mean = last
+	}
+	return tw
+}
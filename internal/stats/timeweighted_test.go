@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestWeigh(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int, v float64) backend.DataPoint {
+		return backend.DataPoint{Timestamp: start.Add(time.Duration(minutes) * time.Minute), Value: v}
+	}
+
+	// 2 kW for an hour, then 10 kW sampled densely for six minutes: the
+	// point average is dominated by the dense samples
+	points := []backend.DataPoint{at(0, 2), at(60, 2), at(61, 10), at(62, 10), at(63, 10), at(64, 10), at(65, 10), at(66, 10)}
+	tw := Weigh(points)
+	if tw.Covered != 66*time.Minute {
+		t.Errorf("Expected 66m covered, got %v", tw.Covered)
+	}
+	// 2 × 60m + 6 × 1m (ramp) + 10 × 5m, in kW × seconds
+	want := (2*60 + 6 + 10*5) * 60.0
+	if math.Abs(tw.Integral-want) > 1e-9 {
+		t.Errorf("Expected integral %v, got %v", want, tw.Integral)
+	}
+	if math.Abs(tw.Mean-want/(66*60)) > 1e-9 {
+		t.Errorf("Expected mean %v, got %v", want/(66*60), tw.Mean)
+	}
+	if s := Summarize(points); s.Avg <= tw.Mean {
+		t.Errorf("The point average %v should overstate the time-weighted mean %v", s.Avg, tw.Mean)
+	}
+
+	// Out of order points are sorted, and intervals next to a gap skipped
+	gapped := Weigh([]backend.DataPoint{at(2, 4), at(0, 4), at(1, math.NaN()), at(3, 6)})
+	if gapped.Covered != time.Minute || gapped.Integral != 5*60 || gapped.Mean != 5 {
+		t.Errorf("Expected only the last minute counted, got %+v", gapped)
+	}
+
+	if single := Weigh([]backend.DataPoint{at(0, 7)}); single.Mean != 7 || single.Integral != 0 {
+		t.Errorf("Expected a single value as its own mean, got %+v", single)
+	}
+	if empty := Weigh(nil); !math.IsNaN(empty.Mean) {
+		t.Errorf("Expected NaN without values, got %+v", empty)
+	}
+}
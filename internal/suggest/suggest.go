@@ -0,0 +1,217 @@
+// Package suggest recognizes well-known exporters among the metrics of a
+// backend and proposes dashboard panels for them
+package suggest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"promviz/internal/backend"
+	"promviz/internal/templates"
+)
+
+// Panel is a proposed query, kept as YAML so it is written out unchanged
+type Panel struct {
+	Name     string
+	Template string // template the panel comes from
+	node     *yaml.Node
+}
+
+// Suggestion groups the panels proposed for one detected exporter
+type Suggestion struct {
+	Template templates.Template
+	Jobs     []string // jobs exposing the exporter; panels select the first
+	Panels   []Panel
+}
+
+// Detect returns the templates whose signature metric is among names
+func Detect(names []string) []templates.Template {
+	available := make(map[string]bool, len(names))
+	for _, name := range names {
+		available[name] = true
+	}
+
+	var found []templates.Template
+	for _, t := range templates.Catalog {
+		if available[t.Signature] {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// Suggest inspects the metrics of a backend and renders the panels of every
+// recognized exporter for the job label it is scraped under
+func Suggest(ctx context.Context, ex backend.Explorer, url string) ([]Suggestion, error) {
+	names, err := ex.MetricNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []Suggestion
+	for _, t := range Detect(names) {
+		jobs, err := ex.LabelValues(ctx, "job", t.Signature)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(jobs)
+
+		var job string
+		if len(jobs) > 0 {
+			job = jobs[0]
+		}
+
+		panels, err := renderPanels(t.Name, templates.Params{URL: url, Job: job})
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, Suggestion{Template: t, Jobs: jobs, Panels: panels})
+	}
+	return suggestions, nil
+}
+
+// renderPanels renders a template and splits it into its queries
+func renderPanels(name string, p templates.Params) ([]Panel, error) {
+	data, err := templates.Render(name, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Queries []yaml.Node `yaml:"queries"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	panels := make([]Panel, len(doc.Queries))
+	for i := range doc.Queries {
+		node := &doc.Queries[i]
+		panels[i] = Panel{Name: scalar(node, "name"), Template: name, node: node}
+	}
+	return panels, nil
+}
+
+// Review asks whether to keep each panel, reading one answer per line from
+// in: y or an empty line keeps it, n drops it, a keeps all remaining panels
+// and q drops them. Running out of input drops the remaining panels.
+func Review(in io.Reader, out io.Writer, suggestions []Suggestion) []Panel {
+	scanner := bufio.NewScanner(in)
+	var accepted []Panel
+	all, none := false, false
+
+	for _, s := range suggestions {
+		fmt.Fprintf(out, "\n%s (job %q)\n", s.Template.Description, jobOf(s))
+		for _, panel := range s.Panels {
+			if all {
+				accepted = append(accepted, panel)
+				continue
+			}
+			if none {
+				continue
+			}
+
+			fmt.Fprintf(out, "  Add %q? [Y/n/a/q] ", panel.Name)
+			if !scanner.Scan() {
+				fmt.Fprintln(out)
+				none = true
+				continue
+			}
+
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "", "y", "yes":
+				accepted = append(accepted, panel)
+			case "a", "all":
+				all = true
+				accepted = append(accepted, panel)
+			case "q", "quit":
+				none = true
+			}
+		}
+	}
+	return accepted
+}
+
+// All returns every suggested panel
+func All(suggestions []Suggestion) []Panel {
+	var panels []Panel
+	for _, s := range suggestions {
+		panels = append(panels, s.Panels...)
+	}
+	return panels
+}
+
+// Build generates a Prometheus config containing the given panels. Panels
+// with the same name from different templates get the template as suffix.
+func Build(url string, panels []Panel) ([]byte, error) {
+	count := make(map[string]int)
+	for _, p := range panels {
+		count[p.Name]++
+	}
+
+	queries := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, p := range panels {
+		if count[p.Name] > 1 {
+			setScalar(p.node, "name", fmt.Sprintf("%s (%s)", p.Name, p.Template))
+		}
+		queries.Content = append(queries.Content, p.node)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		str("prometheus"),
+		{Kind: yaml.MappingNode, Content: []*yaml.Node{str("url"), str(url)}},
+		str("queries"),
+		queries,
+	}}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jobOf returns the job label the panels of a suggestion select
+func jobOf(s Suggestion) string {
+	if len(s.Jobs) > 0 {
+		return s.Jobs[0]
+	}
+	return s.Template.Job
+}
+
+// str creates a scalar string node
+func str(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// scalar returns the value of a scalar field of a mapping node
+func scalar(node *yaml.Node, key string) string {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// setScalar replaces the value of a scalar field of a mapping node
+func setScalar(node *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1].Value = value
+			return
+		}
+	}
+}
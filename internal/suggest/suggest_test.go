@@ -0,0 +1,125 @@
+package suggest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"promviz/internal/config"
+)
+
+// fakeExplorer serves a fixed set of metrics and job labels
+type fakeExplorer struct {
+	names []string
+	jobs  map[string][]string // metric -> jobs
+}
+
+func (f *fakeExplorer) MetricNames(ctx context.Context) ([]string, error) {
+	return f.names, nil
+}
+
+func (f *fakeExplorer) LabelValues(ctx context.Context, label, metric string) ([]string, error) {
+	return f.jobs[metric], nil
+}
+
+func TestDetect(t *testing.T) {
+	found := Detect([]string{"up", "redis_connected_clients", "node_cpu_seconds_total", "go_goroutines"})
+	if len(found) != 2 || found[0].Name != "node" || found[1].Name != "redis" {
+		t.Errorf("Expected node and redis in catalog order, got %+v", found)
+	}
+
+	if found := Detect([]string{"up"}); len(found) != 0 {
+		t.Errorf("Expected no exporters, got %+v", found)
+	}
+}
+
+func TestSuggestAndBuild(t *testing.T) {
+	ex := &fakeExplorer{
+		names: []string{"node_cpu_seconds_total", "redis_connected_clients"},
+		jobs: map[string][]string{
+			"node_cpu_seconds_total": {"nodes-edge", "nodes"},
+		},
+	}
+
+	suggestions, err := Suggest(context.Background(), ex, "http://prometheus:9090")
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(suggestions) != 2 {
+		t.Fatalf("Expected 2 suggestions, got %d", len(suggestions))
+	}
+	if jobOf(suggestions[0]) != "nodes" {
+		t.Errorf("Expected the first job in sorted order, got %q", jobOf(suggestions[0]))
+	}
+
+	data, err := Build("http://prometheus:9090", All(suggestions))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(string(data), `job="nodes"`) || !strings.Contains(string(data), `job="redis"`) {
+		t.Errorf("Expected detected and default jobs in config:\n%s", data)
+	}
+
+	path := filepath.Join(t.TempDir(), "queries.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfigStrict(path)
+	if err != nil {
+		t.Fatalf("Generated config is invalid: %v\n%s", err, data)
+	}
+
+	// Both templates have a "Memory Usage (%)" panel
+	var names []string
+	for _, q := range cfg.Queries {
+		names = append(names, q.Name)
+	}
+	joined := strings.Join(names, "|")
+	if !strings.Contains(joined, "Memory Usage (%) (node)") || !strings.Contains(joined, "Memory Usage (%) (redis)") {
+		t.Errorf("Expected duplicate names to be qualified, got %v", names)
+	}
+}
+
+func TestReview(t *testing.T) {
+	ex := &fakeExplorer{names: []string{"node_cpu_seconds_total", "jvm_memory_bytes_used"}}
+	suggestions, err := Suggest(context.Background(), ex, "http://prometheus:9090")
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"accept and reject", "y\nn\n\n", []string{"CPU Usage (%)", "Load Average (1m)"}},
+		{"accept all", "n\na\n", nil}, // checked by count below
+		{"quit", "y\nq\n", []string{"CPU Usage (%)"}},
+		{"end of input", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			accepted := Review(strings.NewReader(tt.input), &out, suggestions)
+
+			if tt.name == "accept all" {
+				if want := len(All(suggestions)) - 1; len(accepted) != want {
+					t.Errorf("Expected %d panels, got %d", want, len(accepted))
+				}
+				return
+			}
+
+			var names []string
+			for _, p := range accepted {
+				names = append(names, p.Name)
+			}
+			if strings.Join(names, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package templates ships ready-made dashboards for common exporters that
+// `promviz init` renders into a config file
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed *.yaml
+var files embed.FS
+
+// Template describes a built-in dashboard
+type Template struct {
+	Name        string // name passed to --template
+	Description string
+	Job         string // default value of the job label
+	Signature   string // metric that identifies the exporter
+}
+
+// Catalog lists the built-in templates
+var Catalog = []Template{
+	{Name: "node", Description: "Host CPU, memory, disk and network from node_exporter", Job: "node", Signature: "node_cpu_seconds_total"},
+	{Name: "kube", Description: "Pod, deployment and node state from kube-state-metrics", Job: "kube-state-metrics", Signature: "kube_pod_status_phase"},
+	{Name: "postgres", Description: "Connections, transactions and cache hits from postgres_exporter", Job: "postgres", Signature: "pg_stat_database_xact_commit"},
+	{Name: "redis", Description: "Clients, memory and hit ratio from redis_exporter", Job: "redis", Signature: "redis_connected_clients"},
+	{Name: "jvm", Description: "Heap, GC and threads from the Prometheus Java client", Job: "jvm", Signature: "jvm_memory_bytes_used"},
+}
+
+// Params are the values substituted into a template
+type Params struct {
+	URL string // Prometheus server URL
+	Job string // value of the job label selecting the exporter, defaults to the template's
+}
+
+// Lookup returns the template with the given name
+func Lookup(name string) (Template, error) {
+	for _, t := range Catalog {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	names := make([]string, len(Catalog))
+	for i, t := range Catalog {
+		names[i] = t.Name
+	}
+	return Template{}, fmt.Errorf("unknown template: %s (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Render generates the config of the named template
+func Render(name string, p Params) ([]byte, error) {
+	t, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.Job == "" {
+		p.Job = t.Job
+	}
+
+	// Values end up inside quoted YAML and PromQL strings
+	if strings.ContainsAny(p.URL, `"'\`) {
+		return nil, fmt.Errorf("url must not contain quotes or backslashes")
+	}
+	if strings.ContainsAny(p.Job, `"'\`) {
+		return nil, fmt.Errorf("job must not contain quotes or backslashes")
+	}
+
+	source, err := files.ReadFile(name + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"promviz/internal/config"
+)
+
+func TestRenderAllTemplates(t *testing.T) {
+	for _, tmpl := range Catalog {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			data, err := Render(tmpl.Name, Params{URL: "http://prometheus:9090"})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !strings.Contains(string(data), `job="`+tmpl.Job+`"`) {
+				t.Errorf("Expected default job %q in rendered config", tmpl.Job)
+			}
+
+			// Every template must produce a config that loads without warnings
+			path := filepath.Join(t.TempDir(), "queries.yaml")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.LoadConfigStrict(path)
+			if err != nil {
+				t.Fatalf("Rendered config is invalid: %v", err)
+			}
+			if cfg.Prometheus.URL != "http://prometheus:9090" {
+				t.Errorf("Expected URL to be substituted, got %q", cfg.Prometheus.URL)
+			}
+		})
+	}
+}
+
+func TestRenderJob(t *testing.T) {
+	data, err := Render("node", Params{URL: "http://localhost:9090", Job: "hosts"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(string(data), `job="node"`) || !strings.Contains(string(data), `job="hosts"`) {
+		t.Errorf("Expected job label to be overridden:\n%s", data)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Render("mysql", Params{URL: "http://localhost:9090"}); err == nil || !strings.Contains(err.Error(), "available: node") {
+		t.Errorf("Expected unknown template error listing templates, got %v", err)
+	}
+	if _, err := Render("node", Params{URL: "http://localhost:9090", Job: `a"b`}); err == nil {
+		t.Error("Expected error for job containing a quote")
+	}
+}
@@ -0,0 +1,79 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"promviz/internal/backend"
+)
+
+// windowName is the tmux window holding the panes
+const windowName = "promviz"
+
+// BuildCommands returns the tmux invocations that create a session with one
+// pane per query, each running a single-panel promviz instance
+func BuildCommands(session, executable, configPath string, queries []backend.Query) [][]string {
+	target := session + ":" + windowName
+
+	var commands [][]string
+	for i, query := range queries {
+		// IDs are unique where names may repeat
+		paneCmd := shellJoin(executable, "--config", configPath, "--panel", query.ID)
+		if i == 0 {
+			commands = append(commands, []string{"new-session", "-d", "-s", session, "-n", windowName, paneCmd})
+			continue
+		}
+		commands = append(commands,
+			[]string{"split-window", "-t", target, paneCmd},
+			// Re-tile after every split so tmux never runs out of room for the next pane
+			[]string{"select-layout", "-t", target, "tiled"},
+		)
+	}
+
+	return commands
+}
+
+// Launch creates the tmux session for the given queries and attaches to it.
+// Inside an existing tmux client the client is switched to the new session.
+func Launch(session, configPath string, queries []backend.Query) error {
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries to display")
+	}
+
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux not found in PATH: %w", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate promviz executable: %w", err)
+	}
+
+	for _, args := range BuildCommands(session, executable, configPath, queries) {
+		if output, err := exec.Command("tmux", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux %s failed: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	attach := "attach-session"
+	if os.Getenv("TMUX") != "" {
+		attach = "switch-client"
+	}
+
+	cmd := exec.Command("tmux", attach, "-t", session)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// shellJoin quotes each argument for the shell tmux uses to run pane commands
+func shellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
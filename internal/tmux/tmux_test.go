@@ -0,0 +1,76 @@
+package tmux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestBuildCommands(t *testing.T) {
+	queries := []backend.Query{
+		{ID: "cpu-usage", Name: "CPU Usage", Expr: "cpu_usage"},
+		{ID: "memory-usage", Name: "Memory Usage", Expr: "memory_usage"},
+	}
+
+	commands := BuildCommands("metrics", "/usr/bin/promviz", "/etc/promviz.yaml", queries)
+
+	expected := [][]string{
+		{"new-session", "-d", "-s", "metrics", "-n", "promviz",
+			`'/usr/bin/promviz' '--config' '/etc/promviz.yaml' '--panel' 'cpu-usage'`},
+		{"split-window", "-t", "metrics:promviz",
+			`'/usr/bin/promviz' '--config' '/etc/promviz.yaml' '--panel' 'memory-usage'`},
+		{"select-layout", "-t", "metrics:promviz", "tiled"},
+	}
+
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected tmux commands:\ngot:  %q\nwant: %q", commands, expected)
+	}
+}
+
+func TestBuildCommandsDuplicateNames(t *testing.T) {
+	queries := []backend.Query{
+		{ID: "cpu-usage", Name: "CPU Usage", Expr: "cpu_usage"},
+		{ID: "cpu-usage-2", Name: "CPU Usage", Expr: "cpu_by_host"},
+	}
+
+	commands := BuildCommands("metrics", "promviz", "queries.yaml", queries)
+	if len(commands) != 3 {
+		t.Fatalf("Expected 3 commands, got %q", commands)
+	}
+	first, second := commands[0][len(commands[0])-1], commands[1][len(commands[1])-1]
+	if !strings.HasSuffix(first, `'--panel' 'cpu-usage'`) || !strings.HasSuffix(second, `'--panel' 'cpu-usage-2'`) {
+		t.Errorf("Expected each pane to select its query by ID, got %q and %q", first, second)
+	}
+}
+
+func TestBuildCommandsEmpty(t *testing.T) {
+	commands := BuildCommands("metrics", "promviz", "queries.yaml", nil)
+	if len(commands) != 0 {
+		t.Errorf("Expected no commands for empty query list, got %d", len(commands))
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"promviz"}, `'promviz'`},
+		{[]string{"--panel", "Disk Usage %"}, `'--panel' 'Disk Usage %'`},
+		{[]string{"--panel", "Bob's metric"}, `'--panel' 'Bob'\''s metric'`},
+	}
+
+	for _, tt := range tests {
+		if got := shellJoin(tt.args...); got != tt.expected {
+			t.Errorf("shellJoin(%q) = %s, expected %s", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestLaunchNoQueries(t *testing.T) {
+	if err := Launch("metrics", "queries.yaml", nil); err == nil {
+		t.Error("Launch should return error when there are no queries")
+	}
+}
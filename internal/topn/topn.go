@@ -0,0 +1,68 @@
+package topn
+
+import (
+	"math"
+	"sort"
+
+	"promviz/internal/backend"
+)
+
+// MaxN is the largest supported top_n, so every series can get its own color
+const MaxN = 8
+
+// Series is one series of a multi-series result
+type Series struct {
+	Name   string
+	Points []backend.DataPoint // sorted by timestamp
+	Latest float64             // value of the newest non-NaN point, NaN if there is none
+}
+
+// Split groups points by their series label, in order of first appearance.
+// The points of each series are sorted by timestamp.
+func Split(points []backend.DataPoint) []Series {
+	var result []Series
+	index := make(map[string]int)
+	for _, p := range points {
+		i, ok := index[p.Series]
+		if !ok {
+			i = len(result)
+			index[p.Series] = i
+			result = append(result, Series{Name: p.Series})
+		}
+		result[i].Points = append(result[i].Points, p)
+	}
+
+	for i := range result {
+		s := &result[i]
+		sort.SliceStable(s.Points, func(a, b int) bool {
+			return s.Points[a].Timestamp.Before(s.Points[b].Timestamp)
+		})
+		s.Latest = math.NaN()
+		for j := len(s.Points) - 1; j >= 0; j-- {
+			if !math.IsNaN(s.Points[j].Value) {
+				s.Latest = s.Points[j].Value
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Select returns the n series with the highest latest value, highest first,
+// and the number of series left out. Series without any value rank last;
+// ties keep the order of the result.
+func Select(points []backend.DataPoint, n int) (top []Series, hidden int) {
+	series := Split(points)
+	sort.SliceStable(series, func(i, j int) bool {
+		a, b := series[i].Latest, series[j].Latest
+		if math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		return a > b
+	})
+
+	if len(series) <= n {
+		return series, 0
+	}
+	return series[:n], len(series) - n
+}
@@ -0,0 +1,57 @@
+package topn
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestSelect(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	point := func(series string, minute int, value float64) backend.DataPoint {
+		return backend.DataPoint{Timestamp: start.Add(time.Duration(minute) * time.Minute), Value: value, Series: series}
+	}
+
+	points := []backend.DataPoint{
+		// Highest at first, but lowest now
+		point("a", 1, 1), point("a", 0, 100),
+		point("b", 0, 5), point("b", 1, 50),
+		point("c", 0, 10), point("c", 1, 20), point("c", 2, math.NaN()),
+		point("d", 0, math.NaN()),
+		point("e", 0, 30), point("e", 1, 20),
+	}
+
+	top, hidden := Select(points, 3)
+	if hidden != 2 {
+		t.Errorf("Expected 2 hidden series, got %d", hidden)
+	}
+	var names []string
+	for _, s := range top {
+		names = append(names, s.Name)
+	}
+	if len(names) != 3 || names[0] != "b" || names[1] != "c" || names[2] != "e" {
+		t.Errorf("Expected b, c, e by latest value with ties in result order, got %v", names)
+	}
+	if top[1].Latest != 20 {
+		t.Errorf("Latest should skip NaN points, got %v", top[1].Latest)
+	}
+	if !top[0].Points[0].Timestamp.Before(top[0].Points[1].Timestamp) {
+		t.Error("Series points should be sorted by timestamp")
+	}
+
+	// Series without a value rank last
+	top, hidden = Select(points, 10)
+	if hidden != 0 || len(top) != 5 || top[4].Name != "d" {
+		t.Errorf("Expected all series with d last, got %d hidden and %v", hidden, top)
+	}
+}
+
+func TestSplitSingleSeries(t *testing.T) {
+	points := []backend.DataPoint{{Value: 1}, {Value: 2}}
+	series := Split(points)
+	if len(series) != 1 || series[0].Name != "" || len(series[0].Points) != 2 {
+		t.Errorf("Unlabeled points should form one series, got %v", series)
+	}
+}
@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportTimeout bounds one export request to the collector
+const exportTimeout = 10 * time.Second
+
+// OTLP span kinds and status codes used in exports
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusError      = 2
+)
+
+// finishedSpan is a span waiting for export
+type finishedSpan struct {
+	span *Span
+	end  time.Time
+	err  error
+}
+
+// record queues a finished span for export. Without an endpoint spans are
+// only used for propagation and not kept.
+func (t *Tracer) record(s *Span, end time.Time, err error) {
+	if t.config.Endpoint == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= maxBuffered {
+		return
+	}
+	t.spans = append(t.spans, finishedSpan{span: s, end: end, err: err})
+}
+
+// Run exports finished spans every interval until ctx is done, then exports
+// what is left. Export failures are dropped so tracing never gets in the
+// way of the dashboard.
+func (t *Tracer) Run(ctx context.Context, interval time.Duration) {
+	if t == nil || t.config.Endpoint == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+			_ = t.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			_ = t.Flush(ctx)
+		}
+	}
+}
+
+// Flush sends the finished spans to the collector's OTLP/HTTP traces
+// endpoint as JSON
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.exportRequest(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	url := strings.TrimSuffix(t.config.Endpoint, "/") + "/v1/traces"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export spans: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP/JSON request structure, limited to the fields promviz sets
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// exportRequest builds the OTLP request for spans
+func (t *Tracer) exportRequest(spans []finishedSpan) otlpRequest {
+	encoded := make([]otlpSpan, len(spans))
+	for i, f := range spans {
+		s := f.span
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(f.end.UnixNano(), 10),
+			Attributes:        attributes(s.attrs),
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		if s.client {
+			span.Kind = spanKindClient
+		}
+		if f.err != nil {
+			span.Status = &otlpStatus{Code: statusError, Message: f.err.Error()}
+		}
+		encoded[i] = span
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: attributes(t.resource)},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "promviz"},
+			Spans: encoded,
+		}},
+	}}}
+}
+
+// attributes converts a map to OTLP attributes, sorted by key
+func attributes(m map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otlpAttribute, len(keys))
+	for i, k := range keys {
+		attrs[i] = otlpAttribute{Key: k, Value: otlpValue{StringValue: m[k]}}
+	}
+	return attrs
+}
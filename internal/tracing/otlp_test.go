@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	var got otlpRequest
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("Invalid export body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tracer := New(Config{Endpoint: server.URL + "/", Headers: map[string]string{"Authorization": "Bearer secret"}},
+		map[string]string{"promviz.dashboard": "prod"})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracer.now = func() time.Time { return start }
+
+	ctx, parent := tracer.Start(context.Background(), "refresh cpu", map[string]string{"promviz.panel": "CPU"})
+	_, child := tracer.StartClient(ctx, "query prometheus", map[string]string{"db.statement": "up"})
+	child.End(errors.New("timeout"))
+	parent.End(nil)
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != "/v1/traces" || auth != "Bearer secret" {
+		t.Errorf("Unexpected request to %q with authorization %q", path, auth)
+	}
+
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Unexpected export %+v", got)
+	}
+	resource := got.ResourceSpans[0].Resource.Attributes
+	if len(resource) != 2 || resource[0].Key != "promviz.dashboard" || resource[1].Value.StringValue != DefaultServiceName {
+		t.Errorf("Unexpected resource attributes %+v", resource)
+	}
+
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	query, refresh := spans[0], spans[1]
+	if query.Kind != spanKindClient || query.Status == nil || query.Status.Message != "timeout" {
+		t.Errorf("Unexpected query span %+v", query)
+	}
+	if query.TraceID != refresh.TraceID || query.ParentSpanID != refresh.SpanID || refresh.ParentSpanID != "" {
+		t.Errorf("Query span should be a child of the refresh span: %+v, %+v", query, refresh)
+	}
+	if refresh.Kind != spanKindInternal || refresh.Status != nil || refresh.StartTimeUnixNano != "1704110400000000000" {
+		t.Errorf("Unexpected refresh span %+v", refresh)
+	}
+
+	// Exported spans are not sent again
+	path = ""
+	if err := tracer.Flush(context.Background()); err != nil || path != "" {
+		t.Errorf("Expected nothing to export, got %q, %v", path, err)
+	}
+}
+
+func TestFlushCollectorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tracer := New(Config{Endpoint: server.URL}, nil)
+	_, span := tracer.Start(context.Background(), "refresh", nil)
+	span.End(nil)
+
+	if err := tracer.Flush(context.Background()); err == nil {
+		t.Error("Expected an error when the collector fails")
+	}
+}
+
+func TestRecordWithoutEndpoint(t *testing.T) {
+	tracer := New(Config{}, nil)
+	_, span := tracer.Start(context.Background(), "refresh", nil)
+	span.End(nil)
+
+	if len(tracer.spans) != 0 {
+		t.Errorf("Spans should not be kept without an endpoint, got %d", len(tracer.spans))
+	}
+}
+
+func TestRecordBounded(t *testing.T) {
+	tracer := New(Config{Endpoint: "http://localhost:4318"}, nil)
+	for i := 0; i < maxBuffered+10; i++ {
+		_, span := tracer.Start(context.Background(), "refresh", nil)
+		span.End(nil)
+	}
+
+	if len(tracer.spans) != maxBuffered {
+		t.Errorf("Expected %d buffered spans, got %d", maxBuffered, len(tracer.spans))
+	}
+}
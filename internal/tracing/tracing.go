@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServiceName is reported as service.name unless configured
+const DefaultServiceName = "promviz"
+
+// maxBuffered bounds the spans kept between exports; newer spans are
+// dropped once it is reached, e.g. while the collector is unreachable
+const maxBuffered = 2048
+
+// Config enables tracing of promviz's own queries
+type Config struct {
+	Endpoint    string            `yaml:"endpoint,omitempty"`     // OTLP/HTTP collector, e.g. http://localhost:4318; spans are not exported if unset
+	ServiceName string            `yaml:"service_name,omitempty"` // defaults to DefaultServiceName
+	Headers     map[string]string `yaml:"headers,omitempty"`      // sent with every export, e.g. for authentication
+}
+
+// SpanContext identifies a span across process boundaries
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// Traceparent formats the span context as a W3C traceparent header value
+// of a sampled span
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// parseTraceparent reads a W3C traceparent header value
+func parseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("malformed trace ID in traceparent: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("malformed span ID in traceparent: %w", err)
+	}
+	return sc, nil
+}
+
+// Span is an operation being traced. A nil span is valid and does nothing.
+type Span struct {
+	tracer *Tracer
+	name   string
+	sc     SpanContext
+	parent [8]byte // zero for root spans
+	client bool    // a request to another service
+	start  time.Time
+	attrs  map[string]string
+	once   sync.Once
+}
+
+// Tracer records spans and exports them to the configured collector. A nil
+// tracer is valid and records nothing, so tracing can be left off by
+// passing nil around.
+type Tracer struct {
+	config   Config
+	resource map[string]string
+	client   *http.Client
+	now      func() time.Time
+
+	mu    sync.Mutex
+	spans []finishedSpan // waiting for export
+}
+
+// New returns a tracer for cfg. resource attributes such as the dashboard
+// name are attached to every exported span.
+func New(cfg Config, resource map[string]string) *Tracer {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	attrs := map[string]string{"service.name": cfg.ServiceName}
+	for k, v := range resource {
+		attrs[k] = v
+	}
+	return &Tracer{config: cfg, resource: attrs, client: &http.Client{Timeout: exportTimeout}, now: time.Now}
+}
+
+type spanKey struct{}
+
+// FromContext returns the span context of the span ctx carries
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	span, ok := ctx.Value(spanKey{}).(*Span)
+	if !ok || span == nil {
+		return SpanContext{}, false
+	}
+	return span.sc, true
+}
+
+// Start begins a span named name as a child of the span ctx carries, if
+// any, and returns a context carrying the new span
+func (t *Tracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, *Span) {
+	return t.start(ctx, name, attrs, false)
+}
+
+// StartClient is Start for a span covering a request to another service
+func (t *Tracer) StartClient(ctx context.Context, name string, attrs map[string]string) (context.Context, *Span) {
+	return t.start(ctx, name, attrs, true)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, attrs map[string]string, client bool) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, client: client, start: t.now(), attrs: attrs}
+	if parent, ok := FromContext(ctx); ok {
+		span.sc.TraceID = parent.TraceID
+		span.parent = parent.SpanID
+	} else {
+		randomID(span.sc.TraceID[:])
+	}
+	randomID(span.sc.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// End finishes the span, marking it failed if err is not nil. Only the
+// first call has an effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		end := s.tracer.now()
+		s.tracer.record(s, end, err)
+	})
+}
+
+// randomID fills id with random bytes, retrying the unlikely all-zero ID
+// which W3C trace context treats as invalid. crypto/rand doesn't fail on
+// supported platforms.
+func randomID(id []byte) {
+	for {
+		rand.Read(id)
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	sc := SpanContext{
+		TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}
+
+	header := sc.Traceparent()
+	if header != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Unexpected traceparent %q", header)
+	}
+
+	parsed, err := parseTraceparent(header)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parsed != sc {
+		t.Errorf("Expected %+v, got %+v", sc, parsed)
+	}
+}
+
+func TestParseTraceparentMalformed(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := parseTraceparent(header); err == nil {
+			t.Errorf("%q: expected an error", header)
+		}
+	}
+}
+
+func TestStartChildSpan(t *testing.T) {
+	tracer := New(Config{}, nil)
+
+	ctx, parent := tracer.Start(context.Background(), "refresh", nil)
+	childCtx, child := tracer.StartClient(ctx, "query", nil)
+
+	if parent.sc.TraceID == [16]byte{} || parent.parent != [8]byte{} {
+		t.Errorf("Root span should have a trace ID and no parent, got %+v", parent)
+	}
+	if child.sc.TraceID != parent.sc.TraceID {
+		t.Error("Child span should share the trace of its parent")
+	}
+	if child.parent != parent.sc.SpanID || child.sc.SpanID == parent.sc.SpanID {
+		t.Error("Child span should have its own ID and point at its parent")
+	}
+
+	sc, ok := FromContext(childCtx)
+	if !ok || sc != child.sc {
+		t.Errorf("Context should carry the child span, got %+v, %v", sc, ok)
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Empty context should carry no span")
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "refresh", nil)
+	if span != nil {
+		t.Error("Nil tracer should not start spans")
+	}
+	if _, ok := FromContext(ctx); ok {
+		t.Error("Nil tracer should leave the context alone")
+	}
+	span.End(nil)
+}
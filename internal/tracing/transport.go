@@ -0,0 +1,28 @@
+package tracing
+
+import "net/http"
+
+// Transport adds a W3C traceparent header to requests whose context carries
+// a span, so backend operators can tie expensive queries to the promviz
+// dashboard and panel that sent them
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	sc, ok := FromContext(req.Context())
+	if !ok {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set("traceparent", sc.Traceparent())
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	tracer := New(Config{}, nil)
+	ctx, span := tracer.StartClient(context.Background(), "query", nil)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if header != span.sc.Traceparent() {
+		t.Errorf("Expected traceparent %q, got %q", span.sc.Traceparent(), header)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("Transport should not modify the original request")
+	}
+
+	// Untraced requests go out unchanged
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if header != "" {
+		t.Errorf("Expected no traceparent, got %q", header)
+	}
+}
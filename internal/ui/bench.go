@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+// SetScreen makes the TUI draw to screen instead of the terminal. Call
+// before Run or DrawFrame.
+func (t *TUI) SetScreen(screen tcell.Screen) {
+	t.app.SetScreen(screen)
+}
+
+// DrawFrame gives every panel new data, renders it and draws the screen
+// right away, as a refresh of the running dashboard would. It bypasses the
+// event loop for benchmarks and must not be called while the TUI runs.
+func (t *TUI) DrawFrame(results []*backend.TimeSeriesResult) {
+	for i, result := range results {
+		if i >= len(t.histories) {
+			break
+		}
+		t.histories[i].TimeSeries = result
+		t.histories[i].LastError = nil
+		t.renderTimeSeriesGraph(i)
+	}
+	t.updateTimeRange()
+	t.updateInstructions()
+	t.app.ForceDraw()
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+func TestDrawFrame(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	tui := NewTUI([]backend.Query{{Name: "CPU", Expr: "cpu"}, {Name: "Memory", Expr: "mem"}}, nil)
+	tui.SetScreen(screen)
+	screen.SetSize(120, 30)
+	defer screen.Fini()
+
+	now := time.Now()
+	result := &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now.Add(-time.Minute), Value: 1},
+		{Timestamp: now, Value: 42},
+	}}
+	tui.DrawFrame([]*backend.TimeSeriesResult{result, result})
+	tui.DrawFrame([]*backend.TimeSeriesResult{result, result})
+
+	cells, width, _ := screen.GetContents()
+	var text strings.Builder
+	for i, cell := range cells {
+		if i > 0 && i%width == 0 {
+			text.WriteByte('\n')
+		}
+		if len(cell.Runes) > 0 {
+			text.WriteRune(cell.Runes[0])
+		}
+	}
+	if !strings.Contains(text.String(), "Current: 42.00") {
+		t.Errorf("Expected the panels drawn without the event loop, got:\n%s", text.String())
+	}
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+	"promviz/internal/topn"
+)
+
+// boolState is what a column of a bool panel's bar shows
+type boolState int
+
+const (
+	boolMissing boolState = iota
+	boolUp
+	boolDown
+)
+
+// boolCells are the tview-tagged cells of a bar, by state
+var boolCells = map[boolState]string{
+	boolMissing: "[gray]" + string(hatchRune),
+	boolUp:      "[green]█",
+	boolDown:    "[red]█",
+}
+
+// boolLabelWidth caps the series name column of a bool panel
+const boolLabelWidth = 24
+
+// boolColumns resamples values to width columns. A column is down if any of
+// its values is zero, so short failures stay visible, up if it has other
+// values and missing otherwise.
+func boolColumns(values []float64, width int) []boolState {
+	columns := make([]boolState, width)
+	if len(values) == 0 {
+		return columns
+	}
+
+	for x := range columns {
+		from := x * len(values) / width
+		to := (x + 1) * len(values) / width
+		if to <= from {
+			to = from + 1
+		}
+		for _, v := range values[from:to] {
+			switch {
+			case math.IsNaN(v):
+			case v == 0:
+				columns[x] = boolDown
+			case columns[x] == boolMissing:
+				columns[x] = boolUp
+			}
+		}
+	}
+	return columns
+}
+
+// boolSummary counts the share of up values and the changes between up and
+// down of a series, skipping missing values. last is the time of the latest
+// change, zero if there was none.
+func boolSummary(values []float64, grid []backend.DataPoint) (up, present, changes int, last time.Time) {
+	prev := math.NaN()
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		present++
+		if v != 0 {
+			up++
+		}
+		if !math.IsNaN(prev) && (prev == 0) != (v == 0) {
+			changes++
+			last = grid[i].Timestamp
+		}
+		prev = v
+	}
+	return up, present, changes, last
+}
+
+// renderBool renders a panel of 0/1 values as one segmented bar per series:
+// green while up, red while down and hatched where data is missing
+func (t *TUI) renderBool(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	q := t.queries[index]
+
+	series := topn.Split(history.TimeSeries.Points)
+	grid, gaps := fillGaps(unionTimestamps(series), q.TimeRangeAt(t.now()))
+	values := make([][]float64, len(series))
+	for i, s := range series {
+		values[i] = alignSeries(s.Points, grid)
+	}
+
+	// Current state of each series, and totals over the range
+	var up, present, changes, current, upNow int
+	var lastChange, newest time.Time
+	for i, s := range series {
+		u, p, c, last := boolSummary(values[i], grid)
+		up, present, changes = up+u, present+p, changes+c
+		if last.After(lastChange) {
+			lastChange = last
+		}
+		if math.IsNaN(s.Latest) {
+			continue
+		}
+		current++
+		if s.Latest != 0 {
+			upNow++
+		}
+		if newest.Before(s.Points[len(s.Points)-1].Timestamp) {
+			newest = s.Points[len(s.Points)-1].Timestamp
+		}
+	}
+	if current == 0 {
+		panel.SetText(t.lang.T("No data available"))
+		return
+	}
+
+	textColor := "white"
+	age, stale := staleAge(q, newest, t.now())
+	t.setStale(index, age, stale)
+	if stale {
+		textColor = "gray"
+	}
+
+	var b strings.Builder
+	switch {
+	case stale:
+		fmt.Fprintf(&b, "[gray]Current: %d of %d up[%s]\n", upNow, current, textColor)
+	case len(series) == 1 && upNow == 1:
+		fmt.Fprintf(&b, "[green]Current: UP[%s]\n", textColor)
+	case len(series) == 1:
+		fmt.Fprintf(&b, "[red]Current: DOWN[%s]\n", textColor)
+	default:
+		color := "green"
+		if upNow < current {
+			color = "red"
+		}
+		fmt.Fprintf(&b, "[%s]Current: %d of %d up[%s]\n", color, upNow, current, textColor)
+	}
+	fmt.Fprintf(&b, "[gray]Time Range: %s to %s[%s]\n",
+		grid[0].Timestamp.Format("15:04:05"), grid[len(grid)-1].Timestamp.Format("15:04:05"), textColor)
+	summary := fmt.Sprintf("Up %s%% of the time, %d changes", t.numbers.Float(float64(up)/float64(present)*100, 1), changes)
+	if !lastChange.IsZero() {
+		summary += ", last at " + lastChange.Format("15:04:05")
+	}
+	b.WriteString(summary + "\n\n")
+
+	// One bar per series, named when there are several or they have labels
+	_, _, width, height := panel.GetInnerRect()
+	labelWidth := 0
+	if len(series) > 1 || series[0].Name != "" {
+		for _, s := range series {
+			if n := len([]rune(seriesLabel(s.Name))); n > labelWidth {
+				labelWidth = n
+			}
+		}
+		if labelWidth > boolLabelWidth {
+			labelWidth = boolLabelWidth
+		}
+	}
+	indent := 0
+	if labelWidth > 0 {
+		indent = labelWidth + 1
+	}
+	barWidth := width - indent
+	if barWidth < 10 {
+		barWidth = 10
+	}
+
+	// Leave space for the text above and the time axis below, and list the
+	// series that don't fit
+	reserved := 5
+	if len(gaps) > 0 {
+		reserved++
+	}
+	if history.TimeSeries.Truncation != nil {
+		reserved++
+	}
+	rows := len(series)
+	if rows > height-reserved {
+		rows = height - reserved - 1
+		if rows < 1 {
+			rows = 1
+		}
+	}
+	for i := 0; i < rows; i++ {
+		if labelWidth > 0 {
+			label := truncate(seriesLabel(series[i].Name), labelWidth)
+			b.WriteString(tview.Escape(label) + strings.Repeat(" ", labelWidth-len([]rune(label))+1))
+		}
+		for _, state := range boolColumns(values[i], barWidth) {
+			b.WriteString(boolCells[state])
+		}
+		fmt.Fprintf(&b, "[%s]\n", textColor)
+	}
+	if hidden := len(series) - rows; hidden > 0 {
+		fmt.Fprintf(&b, "[gray]%d more series hidden[%s]\n", hidden, textColor)
+	}
+
+	// Start and end of the range under the bars
+	start, end := grid[0].Timestamp.Format("15:04"), grid[len(grid)-1].Timestamp.Format("15:04")
+	spacing := barWidth - len(start) - len(end)
+	if spacing < 1 {
+		spacing = 1
+	}
+	fmt.Fprintf(&b, "[gray]%s%s%s%s[%s]", strings.Repeat(" ", indent), start, strings.Repeat(" ", spacing), end, textColor)
+	if len(gaps) > 0 {
+		fmt.Fprintf(&b, "\n[red]%s[%s]", formatGaps(gaps), textColor)
+	}
+	b.WriteString(truncationNote(textColor, history.TimeSeries))
+
+	panel.SetText(b.String())
+}
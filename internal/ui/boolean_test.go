@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+func TestBoolColumns(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		values   []float64
+		width    int
+		expected []boolState
+	}{
+		{"one value per column", []float64{1, 0, nan}, 3, []boolState{boolUp, boolDown, boolMissing}},
+		{"down wins within a column", []float64{1, 1, 0, 1, nan, nan}, 3, []boolState{boolUp, boolDown, boolMissing}},
+		{"present wins over missing", []float64{nan, 1, nan, nan}, 2, []boolState{boolUp, boolMissing}},
+		{"stretched", []float64{1, 0}, 4, []boolState{boolUp, boolUp, boolDown, boolDown}},
+		{"empty", nil, 2, []boolState{boolMissing, boolMissing}},
+	}
+
+	for _, tt := range tests {
+		if got := boolColumns(tt.values, tt.width); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestBoolSummary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var grid []backend.DataPoint
+	for i := 0; i < 7; i++ {
+		grid = append(grid, backend.DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	// A gap between two up values is not a change
+	values := []float64{1, 0, 0, 1, math.NaN(), 1, 2}
+	up, present, changes, last := boolSummary(values, grid)
+	if up != 4 || present != 6 || changes != 2 {
+		t.Errorf("Expected 4 of 6 up with 2 changes, got %d of %d with %d", up, present, changes)
+	}
+	if !last.Equal(grid[3].Timestamp) {
+		t.Errorf("Expected the last change at %v, got %v", grid[3].Timestamp, last)
+	}
+}
+
+func TestBoolPanelColors(t *testing.T) {
+	query := backend.Query{Name: "Probe", Expr: "probe_success", Range: "1h", Type: backend.PanelBool}
+	h := newHarness(t, []backend.Query{query}, 80, 12)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: series(func(i int) (float64, bool) {
+		return float64(i / 30 % 2), true
+	})}, nil)
+	h.sync()
+
+	h.assertContains("Current: DOWN")
+	x, y, ok := h.find("█")
+	if !ok {
+		t.Fatalf("Bar not rendered:\n%s", h.text())
+	}
+	if fg, _, _ := h.style(x, y).Decompose(); fg != tcell.ColorRed {
+		t.Errorf("Expected the start of the range down in red, got %v", fg)
+	}
+	if fg, _, _ := h.style(x+50, y).Decompose(); fg != tcell.ColorGreen {
+		t.Errorf("Expected the second half up in green, got %v", fg)
+	}
+}
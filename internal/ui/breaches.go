@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/alert"
+	"promviz/internal/numfmt"
+)
+
+const (
+	mainPage     = "main"
+	breachesPage = "breaches"
+
+	maxBreaches = 100 // transitions kept for the breach view
+)
+
+// modalOpen reports whether an overlay is covering the panels
+func (t *TUI) modalOpen() bool {
+	name, _ := t.pages.GetFrontPage()
+	return name != mainPage
+}
+
+// closeModal removes an overlay and restores panel focus
+func (t *TUI) closeModal(name string) {
+	t.pages.RemovePage(name)
+	t.updateFocus()
+}
+
+// SetBreaches seeds the breach view with previously logged transitions.
+// Call before Run.
+func (t *TUI) SetBreaches(transitions []alert.Transition) {
+	t.breaches = append([]alert.Transition(nil), transitions...)
+	t.trimBreaches()
+}
+
+// AddBreach records a new threshold transition for the breach view
+func (t *TUI) AddBreach(tr alert.Transition) {
+	t.queueUpdateDraw(func() {
+		t.breaches = append(t.breaches, tr)
+		t.trimBreaches()
+		t.refreshInspect(t.focusIndex)
+	})
+}
+
+// trimBreaches drops the oldest transitions beyond maxBreaches
+func (t *TUI) trimBreaches() {
+	if len(t.breaches) > maxBreaches {
+		t.breaches = t.breaches[len(t.breaches)-maxBreaches:]
+	}
+}
+
+// formatBreach renders a transition as a single list line
+func formatBreach(tr alert.Transition, f numfmt.Format) string {
+	return fmt.Sprintf("%s  %-20s %s → [%s]%s[white]  %s (threshold %s)",
+		tr.Time.Local().Format("01-02 15:04:05"),
+		tr.Query,
+		tr.From,
+		levelColor(tr.To),
+		tr.To,
+		f.Float(tr.Value, 2),
+		f.Float(tr.Threshold, 2))
+}
+
+// levelColor maps a logged level name to its display color
+func levelColor(level string) string {
+	switch level {
+	case alert.LevelCritical.String():
+		return alert.LevelCritical.Color()
+	case alert.LevelWarning.String():
+		return alert.LevelWarning.Color()
+	default:
+		return alert.LevelOK.Color()
+	}
+}
+
+// showBreaches opens a modal listing recent transitions, newest first.
+// Selecting an entry jumps to its panel, and a acknowledges it along with
+// the earlier transitions of its query.
+func (t *TUI) showBreaches() {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle(" Threshold breaches (Enter to jump, a to acknowledge, Esc to close) ")
+
+	if len(t.breaches) == 0 {
+		list.AddItem("No threshold transitions recorded", "", 0, nil)
+	}
+
+	// List items run newest first
+	transition := func(item int) alert.Transition {
+		return t.breaches[len(t.breaches)-1-item]
+	}
+	for i := len(t.breaches) - 1; i >= 0; i-- {
+		tr := t.breaches[i]
+		list.AddItem(t.breachItem(tr), "", 0, func() {
+			t.closeModal(breachesPage)
+			t.jumpToPanel(t.panelIndex(tr.ID))
+		})
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'b' || event.Rune() == 'B' {
+			t.closeModal(breachesPage)
+			return nil
+		}
+		if (event.Rune() == 'a' || event.Rune() == 'A') && len(t.breaches) > 0 {
+			tr := transition(list.GetCurrentItem())
+			t.acknowledge(tr.ID, tr.Time)
+			for item := 0; item < list.GetItemCount(); item++ {
+				list.SetItemText(item, t.breachItem(transition(item)), "")
+			}
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(breachesPage, modal(list, 100, 20), true, true)
+	t.app.SetFocus(list)
+}
+
+// breachItem is the list line of a transition in the breach view
+func (t *TUI) breachItem(tr alert.Transition) string {
+	item := formatBreach(tr, t.numbers)
+	if t.isAcked(tr.ID, tr.Time) {
+		item += "  [gray]acknowledged[white]"
+	}
+	return item
+}
+
+// panelIndex returns the index of the panel showing the query with the given
+// ID, or -1 if no such panel exists
+func (t *TUI) panelIndex(id string) int {
+	for i, q := range t.queries {
+		if q.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// jumpToPanel focuses the panel at index, scrolling it into view and
+// showing it again if it was hidden
+func (t *TUI) jumpToPanel(index int) {
+	if index < 0 || index >= len(t.panels) {
+		return
+	}
+	if t.hidden[index] {
+		delete(t.hidden, index)
+		t.rebuildShown()
+		t.updateInstructions()
+		t.stateChanged()
+	}
+	t.focusIndex = index
+	t.scrollToShowFocus()
+	t.updateFocus()
+}
+
+// modal centers a primitive with the given size over the main layout
+func modal(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+)
+
+func TestShowBreachesJumpToPanel(t *testing.T) {
+	queries := []backend.Query{
+		{ID: "query-1", Name: "Query 1", Expr: "metric1"},
+		{ID: "query-2", Name: "Query 2", Expr: "metric2"},
+		{ID: "query-3", Name: "Query 3", Expr: "metric3"},
+		{ID: "query-4", Name: "Query 4", Expr: "metric4"},
+	}
+
+	tui := NewTUI(queries, nil)
+	tui.SetBreaches([]alert.Transition{
+		{ID: "query-4", Query: "Query 4", From: "ok", To: "critical", Value: 95, Threshold: 90, Time: time.Now()},
+	})
+
+	tui.showBreaches()
+	if !tui.modalOpen() {
+		t.Fatal("Breach view should open as a modal")
+	}
+
+	tui.closeModal(breachesPage)
+	tui.jumpToPanel(tui.panelIndex(tui.breaches[0].ID))
+
+	if tui.modalOpen() {
+		t.Error("Breach view should close before jumping")
+	}
+	if tui.focusIndex != 3 {
+		t.Errorf("Expected focus on panel 3, got %d", tui.focusIndex)
+	}
+	if tui.scrollOffset != 1 {
+		t.Errorf("Expected panel 3 scrolled into view, got offset %d", tui.scrollOffset)
+	}
+}
+
+func TestSetBreachesTrims(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Query 1", Expr: "metric1"}}, nil)
+
+	transitions := make([]alert.Transition, maxBreaches+10)
+	for i := range transitions {
+		transitions[i].Value = float64(i)
+	}
+	tui.SetBreaches(transitions)
+
+	if len(tui.breaches) != maxBreaches {
+		t.Fatalf("Expected %d breaches, got %d", maxBreaches, len(tui.breaches))
+	}
+	if tui.breaches[0].Value != 10 {
+		t.Errorf("Expected oldest breaches dropped, first value %v", tui.breaches[0].Value)
+	}
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// burnRateSeries splits the points of a burn rate panel by window, in time
+// order. Where the error ratio has several series, a window takes the
+// highest burn rate among them at each timestamp.
+func burnRateSeries(points []backend.DataPoint) map[string][]backend.DataPoint {
+	highest := make(map[string]map[int64]backend.DataPoint)
+	for _, p := range points {
+		window := backend.ParseSeriesName(p.Series)[backend.BurnRateLabel]
+		byTime := highest[window]
+		if byTime == nil {
+			byTime = make(map[int64]backend.DataPoint)
+			highest[window] = byTime
+		}
+		key := p.Timestamp.UnixNano()
+		if prev, ok := byTime[key]; !ok || p.Value > prev.Value {
+			byTime[key] = p
+		}
+	}
+
+	series := make(map[string][]backend.DataPoint, len(highest))
+	for window, byTime := range highest {
+		s := make([]backend.DataPoint, 0, len(byTime))
+		for _, p := range byTime {
+			s = append(s, p)
+		}
+		sort.Slice(s, func(i, j int) bool { return s[i].Timestamp.Before(s[j].Timestamp) })
+		series[window] = s
+	}
+	return series
+}
+
+// renderBurnRate renders the burn rate over every window of the alerts
+// against their factor, and whether each alert fires
+func (t *TUI) renderBurnRate(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	q := t.queries[index]
+	series := burnRateSeries(history.TimeSeries.Points)
+
+	var newest time.Time
+	for _, s := range series {
+		if len(s) > 0 && s[len(s)-1].Timestamp.After(newest) {
+			newest = s[len(s)-1].Timestamp
+		}
+	}
+	age, stale := staleAge(q, newest, t.now())
+	textColor := "white"
+	if stale {
+		textColor = "gray"
+	}
+	color := func(c string) string {
+		if stale {
+			return "gray"
+		}
+		return c
+	}
+
+	n := t.numbers
+	var b strings.Builder
+	b.WriteString(t.lang.Sprintf("[gray]Objective: %s%% (error budget %s%%)[%s]\n",
+		n.Float(q.BurnRate.Objective, 3), n.Float(100-q.BurnRate.Objective, 3), textColor))
+
+	for _, a := range backend.BurnRateAlerts {
+		long, short := series[a.Long], series[a.Short]
+		firing := len(long) > 0 && len(short) > 0 &&
+			long[len(long)-1].Value >= a.Factor && short[len(short)-1].Value >= a.Factor
+		status, statusColor := t.lang.T("ok"), "green"
+		if firing {
+			status, statusColor = t.lang.T("firing"), "red"
+		}
+
+		b.WriteString("\n")
+		b.WriteString(t.lang.Sprintf("[%s]%s: %s[%s] [gray](%s and %s above %sx)[%s]\n",
+			color(statusColor), t.lang.T(a.Name), status, textColor, a.Long, a.Short, n.Float(a.Factor, 1), textColor))
+		for _, window := range []string{a.Long, a.Short} {
+			s := series[window]
+			if len(s) == 0 {
+				fmt.Fprintf(&b, "  %-4s [gray]%s[%s]\n", window, t.lang.T("n/a"), textColor)
+				continue
+			}
+			values := make([]float64, len(s))
+			for i, p := range s {
+				values[i] = p.Value
+			}
+			rate := s[len(s)-1].Value
+			rateColor := "green"
+			if rate >= a.Factor {
+				rateColor = "red"
+			}
+			fmt.Fprintf(&b, "  %-4s %s [%s]%sx[%s]\n", window, sparkline(values), color(rateColor), n.Float(rate, 2), textColor)
+		}
+	}
+	b.WriteString(truncationNote(textColor, history.TimeSeries))
+
+	// The title follows the long window of the fastest alert
+	history.Sparkline = ""
+	if s := series[backend.BurnRateAlerts[0].Long]; len(s) > 0 {
+		values := make([]float64, len(s))
+		for i, p := range s {
+			values[i] = p.Value
+		}
+		history.Sparkline = sparkline(values)
+	}
+	t.setStale(index, age, stale)
+
+	panel.SetText(b.String())
+}
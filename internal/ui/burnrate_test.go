@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/i18n"
+)
+
+// burnRatePoints returns points of the burn rate over window, one minute
+// apart and ending at end
+func burnRatePoints(window string, end time.Time, values ...float64) []backend.DataPoint {
+	series := backend.SeriesName("", map[string]string{backend.BurnRateLabel: window})
+	points := make([]backend.DataPoint, len(values))
+	for i, v := range values {
+		points[i] = backend.DataPoint{Timestamp: end.Add(-time.Duration(len(values)-1-i) * time.Minute), Value: v, Series: series}
+	}
+	return points
+}
+
+func TestBurnRateSeries(t *testing.T) {
+	points := append(burnRatePoints("5m", goldenNow, 3, 1), burnRatePoints("1h", goldenNow, 2)...)
+	// A second series of the 5m window, higher at the newest timestamp
+	points = append(points, backend.DataPoint{Timestamp: goldenNow, Value: 4, Series: `{job="web",window="5m"}`})
+
+	series := burnRateSeries(points)
+	if len(series) != 2 {
+		t.Fatalf("Expected 2 windows, got %v", series)
+	}
+	short := series["5m"]
+	if len(short) != 2 || short[0].Value != 3 || short[1].Value != 4 {
+		t.Errorf("Expected the highest burn rate per timestamp in time order, got %v", short)
+	}
+	if long := series["1h"]; len(long) != 1 || long[0].Value != 2 {
+		t.Errorf("Unexpected 1h window %v", long)
+	}
+}
+
+func TestBurnRatePanel(t *testing.T) {
+	var points []backend.DataPoint
+	points = append(points, burnRatePoints("1h", goldenNow, 10, 16.2)...)
+	points = append(points, burnRatePoints("5m", goldenNow, 12, 20.1)...)
+	points = append(points, burnRatePoints("6h", goldenNow, 2, 2.5)...)
+
+	query := backend.Query{Name: "API Burn Rate", Type: backend.PanelBurnRate, Range: "1h",
+		BurnRate: &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: points}, nil)
+	h.sync()
+
+	h.assertContains("Objective: 99.900% (error budget 0.100%)")
+	h.assertContains("Fast burn: firing (1h and 5m above 14.4x)")
+	h.assertContains("16.20x")
+	h.assertContains("20.10x")
+	h.assertContains("Slow burn: ok (6h and 30m above 6.0x)")
+	h.assertContains("2.50x")
+	h.assertContains("30m  n/a")
+}
+
+func TestBurnRatePanelGerman(t *testing.T) {
+	query := backend.Query{Name: "API Burn Rate", Type: backend.PanelBurnRate, Range: "1h",
+		BurnRate: &backend.BurnRateConfig{ErrorRatio: "errors[$window]", Objective: 99.9}}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+	de, _ := i18n.Language("de")
+	h.tui.SetLanguage(de)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: burnRatePoints("1h", goldenNow, 2)}, nil)
+	h.sync()
+
+	h.assertContains("Schneller Verbrauch: in Ordnung")
+	h.assertContains("Langsamer Verbrauch: in Ordnung")
+}
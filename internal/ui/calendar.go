@@ -0,0 +1,265 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+)
+
+// calendarColors shade the cells of a calendar panel, from the buckets with
+// the lowest values to the ones with the highest
+var calendarColors = []string{"#0e4429", "#006d32", "#26a641", "#39d353"}
+
+// calendarEmpty marks a bucket of the range without data
+const calendarEmpty = "·"
+
+// calendarBuckets combines points into buckets of a day or an hour in loc,
+// keyed by the start of the bucket. NaN values are skipped.
+func calendarBuckets(points []backend.DataPoint, bucket, combine string, loc *time.Location) map[time.Time]float64 {
+	type accumulator struct {
+		sum, min, max float64
+		n             int
+	}
+	accumulators := make(map[time.Time]*accumulator)
+	for _, p := range points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		start := bucketStart(p.Timestamp, bucket, loc)
+		a := accumulators[start]
+		if a == nil {
+			a = &accumulator{min: p.Value, max: p.Value}
+			accumulators[start] = a
+		}
+		a.sum += p.Value
+		a.n++
+		if p.Value < a.min {
+			a.min = p.Value
+		}
+		if p.Value > a.max {
+			a.max = p.Value
+		}
+	}
+
+	values := make(map[time.Time]float64, len(accumulators))
+	for start, a := range accumulators {
+		switch combine {
+		case backend.AggregateSum:
+			values[start] = a.sum
+		case backend.AggregateMin:
+			values[start] = a.min
+		case backend.AggregateMax:
+			values[start] = a.max
+		default:
+			values[start] = a.sum / float64(a.n)
+		}
+	}
+	return values
+}
+
+// bucketStart returns the start of the day or hour in loc containing ts
+func bucketStart(ts time.Time, bucket string, loc *time.Location) time.Time {
+	t := ts.In(loc)
+	if bucket == backend.BucketHour {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// calendarCell renders a bucket as width cells, shaded by where its value
+// lies between lo and hi
+func calendarCell(value float64, ok bool, lo, hi float64, width int) string {
+	if !ok {
+		return "[gray]" + strings.Repeat(calendarEmpty, width)
+	}
+	level := len(calendarColors) - 1
+	if hi > lo {
+		level = int((value - lo) / (hi - lo) * float64(len(calendarColors)))
+		if level >= len(calendarColors) {
+			level = len(calendarColors) - 1
+		}
+	}
+	return "[" + calendarColors[level] + "]" + strings.Repeat("█", width)
+}
+
+// renderCalendar renders a panel as a heat grid of its metric per day, with
+// a row per weekday and a column per week, or per hour, with a row per day
+// and a column per hour of the day
+func (t *TUI) renderCalendar(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	q := t.queries[index]
+	bucket, combine := q.Calendar.BucketSize(), q.Calendar.Combine()
+
+	// Points left over from before a range change are not shown
+	tr := q.TimeRangeAt(t.now())
+	var points []backend.DataPoint
+	for _, p := range history.TimeSeries.Points {
+		if !p.Timestamp.Before(tr.Start) && !p.Timestamp.After(tr.End) {
+			points = append(points, p)
+		}
+	}
+	loc := t.now().Location()
+	values := calendarBuckets(points, bucket, combine, loc)
+	if len(values) == 0 {
+		panel.SetText(t.lang.T("No data available"))
+		return
+	}
+
+	// Buckets in time order, for the current value, peak, scale and trend
+	starts := make([]time.Time, 0, len(values))
+	for start := range values {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	lo, hi := math.Inf(1), math.Inf(-1)
+	peak := starts[0]
+	trend := make([]float64, len(starts))
+	for i, start := range starts {
+		v := values[start]
+		trend[i] = v
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+			peak = start
+		}
+	}
+	current := starts[len(starts)-1]
+
+	var newest time.Time
+	for _, p := range points {
+		if p.Timestamp.After(newest) {
+			newest = p.Timestamp
+		}
+	}
+	valueColor, textColor := "yellow", "white"
+	if th := q.Thresholds; th != nil {
+		valueColor = alert.Evaluate(th, values[current]).Color()
+	}
+	value, valueColor := t.currentValue(index, values[current], valueColor)
+	history.Sparkline = sparkline(trend)
+	age, stale := staleAge(q, newest, t.now())
+	t.setStale(index, age, stale)
+	if stale {
+		valueColor, textColor = "gray", "gray"
+	}
+
+	first := bucketStart(tr.Start, backend.BucketDay, loc)
+	last := bucketStart(tr.End, backend.BucketDay, loc)
+
+	unit := t.unit(index)
+	var b strings.Builder
+	b.WriteString(t.lang.Sprintf("[%s]Current: %s[%s] (%s per %s)\n", valueColor, value, textColor, combine, bucket))
+	b.WriteString(t.lang.Sprintf("[gray]Time Range: %s to %s[%s]\n\n", first.Format("2006-01-02"), last.Format("2006-01-02"), textColor))
+
+	_, _, width, height := panel.GetInnerRect()
+	cell := func(start time.Time, w int) string {
+		if start.Before(bucketStart(tr.Start, bucket, loc)) || start.After(tr.End) {
+			return strings.Repeat(" ", w)
+		}
+		v, ok := values[start]
+		return calendarCell(v, ok, lo, hi, w)
+	}
+
+	peakLayout := "Mon 01-02"
+	if bucket == backend.BucketHour {
+		peakLayout = "Mon 01-02 15:00"
+		writeHourGrid(&b, first, last, width, height-7, textColor, cell)
+	} else {
+		writeDayGrid(&b, first, last, width, textColor, cell)
+	}
+
+	// Scale and peak under the grid
+	b.WriteString("[gray]" + t.lang.T("Less") + " ")
+	for _, color := range calendarColors {
+		b.WriteString("[" + color + "]█")
+	}
+	b.WriteString(t.lang.Sprintf("[gray] More  %s to %s[%s]\n", formatValue(lo, unit, t.numbers), formatValue(hi, unit, t.numbers), textColor))
+	b.WriteString(t.lang.Sprintf("Peak: %s on %s", formatValue(hi, unit, t.numbers), peak.Format(peakLayout)))
+	b.WriteString(truncationNote(textColor, history.TimeSeries))
+
+	panel.SetText(b.String())
+}
+
+// writeDayGrid writes a row per weekday and a column per week from the
+// week of first to the week of last, keeping the newest weeks that fit the
+// width. Months are named above the week they start in.
+func writeDayGrid(b *strings.Builder, first, last time.Time, width int, textColor string, cell func(time.Time, int) string) {
+	const labelWidth, columnWidth = 4, 3
+
+	// Weeks start on Monday
+	monday := first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+	var weeks []time.Time
+	for week := monday; !week.After(last); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, week)
+	}
+	if fit := (width - labelWidth) / columnWidth; fit > 0 && len(weeks) > fit {
+		weeks = weeks[len(weeks)-fit:]
+	}
+
+	b.WriteString("[gray]" + strings.Repeat(" ", labelWidth))
+	for i, week := range weeks {
+		label := ""
+		switch end := week.AddDate(0, 0, 6); {
+		case i == 0 || week.Day() == 1:
+			label = week.Format("Jan")
+		case end.Month() != week.Month():
+			label = end.Format("Jan")
+		}
+		b.WriteString(label + strings.Repeat(" ", columnWidth-len(label)))
+	}
+	fmt.Fprintf(b, "[%s]\n", textColor)
+
+	for day := 0; day < 7; day++ {
+		fmt.Fprintf(b, "[gray]%s ", weeks[0].AddDate(0, 0, day).Format("Mon"))
+		for _, week := range weeks {
+			b.WriteString(cell(week.AddDate(0, 0, day), columnWidth-1) + " ")
+		}
+		fmt.Fprintf(b, "[%s]\n", textColor)
+	}
+}
+
+// writeHourGrid writes a row per day from first to last, keeping the newest
+// that fit in rows, and a column per hour of the day
+func writeHourGrid(b *strings.Builder, first, last time.Time, width, rows int, textColor string, cell func(time.Time, int) string) {
+	const labelWidth = 10
+
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if len(days) > rows {
+		days = days[len(days)-rows:]
+	}
+
+	// Two columns per hour if they fit
+	columnWidth := 2
+	if width-labelWidth < 24*columnWidth {
+		columnWidth = 1
+	}
+
+	b.WriteString("[gray]" + strings.Repeat(" ", labelWidth))
+	for hour := 0; hour < 24; hour += 6 {
+		label := fmt.Sprintf("%02d", hour)
+		b.WriteString(label + strings.Repeat(" ", 6*columnWidth-len(label)))
+	}
+	fmt.Fprintf(b, "[%s]\n", textColor)
+
+	for _, day := range days {
+		fmt.Fprintf(b, "[gray]%s ", day.Format("Mon 01-02"))
+		for hour := 0; hour < 24; hour++ {
+			b.WriteString(cell(time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, day.Location()), columnWidth))
+		}
+		fmt.Fprintf(b, "[%s]\n", textColor)
+	}
+}
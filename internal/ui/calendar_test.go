@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestCalendarBuckets(t *testing.T) {
+	day := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	points := []backend.DataPoint{
+		{Timestamp: day.Add(1 * time.Hour), Value: 1},
+		{Timestamp: day.Add(1*time.Hour + 30*time.Minute), Value: 3},
+		{Timestamp: day.Add(5 * time.Hour), Value: 8},
+		{Timestamp: day.Add(6 * time.Hour), Value: math.NaN()},
+		{Timestamp: day.Add(25 * time.Hour), Value: 2},
+	}
+
+	tests := []struct {
+		bucket, combine string
+		want            map[time.Time]float64
+	}{
+		{backend.BucketDay, backend.AggregateAvg, map[time.Time]float64{day: 4, day.AddDate(0, 0, 1): 2}},
+		{backend.BucketDay, backend.AggregateSum, map[time.Time]float64{day: 12, day.AddDate(0, 0, 1): 2}},
+		{backend.BucketDay, backend.AggregateMin, map[time.Time]float64{day: 1, day.AddDate(0, 0, 1): 2}},
+		{backend.BucketDay, backend.AggregateMax, map[time.Time]float64{day: 8, day.AddDate(0, 0, 1): 2}},
+		{backend.BucketHour, backend.AggregateAvg, map[time.Time]float64{
+			day.Add(time.Hour): 2, day.Add(5 * time.Hour): 8, day.Add(25 * time.Hour): 2,
+		}},
+	}
+	for _, tt := range tests {
+		got := calendarBuckets(points, tt.bucket, tt.combine, time.UTC)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s per %s: expected %v, got %v", tt.combine, tt.bucket, tt.want, got)
+			continue
+		}
+		for start, v := range tt.want {
+			if got[start] != v {
+				t.Errorf("%s per %s at %v: expected %v, got %v", tt.combine, tt.bucket, start, v, got[start])
+			}
+		}
+	}
+
+	// Buckets follow the days of the given location
+	east := time.FixedZone("UTC+3", 3*60*60)
+	got := calendarBuckets(points[4:], backend.BucketDay, backend.AggregateAvg, east)
+	if _, ok := got[time.Date(2024, 5, 3, 0, 0, 0, 0, east)]; !ok {
+		t.Errorf("Expected a bucket for May 3 in UTC+3, got %v", got)
+	}
+}
+
+func TestCalendarCell(t *testing.T) {
+	tests := []struct {
+		value float64
+		ok    bool
+		want  string
+	}{
+		{0, true, "[#0e4429]██"},
+		{4.9, true, "[#006d32]██"},
+		{7.5, true, "[#39d353]██"},
+		{10, true, "[#39d353]██"},
+		{0, false, "[gray]··"},
+	}
+	for _, tt := range tests {
+		if got := calendarCell(tt.value, tt.ok, 0, 10, 2); got != tt.want {
+			t.Errorf("calendarCell(%v, %v) = %q, want %q", tt.value, tt.ok, got, tt.want)
+		}
+	}
+	if got := calendarCell(3, true, 3, 3, 1); got != "[#39d353]█" {
+		t.Errorf("Expected a flat calendar in the top shade, got %q", got)
+	}
+}
+
+func TestCalendarPanel(t *testing.T) {
+	// Hourly points over two weeks, with a busy Wednesday
+	var points []backend.DataPoint
+	for ts := goldenNow.Add(-14 * 24 * time.Hour); !ts.After(goldenNow); ts = ts.Add(time.Hour) {
+		value := 1.0
+		if ts.Month() == time.December && ts.Day() == 27 {
+			value = 5
+		}
+		points = append(points, backend.DataPoint{Timestamp: ts, Value: value})
+	}
+
+	query := backend.Query{Name: "Requests", Expr: "sum(rate(requests_total[1h]))", Type: backend.PanelCalendar, Range: "14d"}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: points}, nil)
+	h.sync()
+
+	h.assertContains("Current: 1.00 (avg per day)")
+	h.assertContains("Time Range: 2023-12-18 to 2024-01-01")
+	h.assertContains("Dec")
+	h.assertContains("Jan")
+	h.assertContains("Mon")
+	h.assertContains("Sun")
+	h.assertContains("Peak: 5.00 on Wed 12-27")
+
+	query.Calendar = &backend.CalendarConfig{Bucket: backend.BucketHour, Aggregate: backend.AggregateMax}
+	query.Range = "2d"
+	h = newHarness(t, []backend.Query{query}, 80, 20)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: points}, nil)
+	h.sync()
+
+	h.assertContains("(max per hour)")
+	h.assertContains("Sun 12-31")
+	h.assertContains("Mon 01-01")
+	h.assertContains("00          06")
+	h.assertContains("Peak: 1.00 on Sat 12-30 12:00")
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const credentialPage = "credential"
+
+// credentialRequest is a backend waiting for a new credential
+type credentialRequest struct {
+	backend    string
+	credential string // what to ask for, e.g. "token"
+}
+
+// SetCredentialHandler sets the function called with a backend and the
+// credential entered for it. It runs outside the event loop; an error
+// keeps the prompt open to try again. Call before Run.
+func (t *TUI) SetCredentialHandler(onCredential func(backendName, secret string) error) {
+	t.onCredential = onCredential
+}
+
+// PromptCredential asks for a new credential of a backend that rejected
+// the current one. Prompts for further backends wait for the open one.
+func (t *TUI) PromptCredential(backendName, credential string) {
+	t.queueUpdateDraw(func() {
+		t.credentialQueue = append(t.credentialQueue, credentialRequest{backend: backendName, credential: credential})
+		if !t.pages.HasPage(credentialPage) {
+			t.showCredentialPrompt()
+		}
+	})
+}
+
+// showCredentialPrompt opens a modal asking for the credential of the
+// first queued backend
+func (t *TUI) showCredentialPrompt() {
+	if len(t.credentialQueue) == 0 || t.onCredential == nil {
+		return
+	}
+	req := t.credentialQueue[0]
+
+	message := tview.NewTextView().SetDynamicColors(true).SetWordWrap(true)
+	message.SetText(fmt.Sprintf("[red]%s rejected its %s.[white] Paste a new one to use it right away, Esc to leave the panels failing.",
+		tview.Escape(req.backend), tview.Escape(req.credential)))
+	status := tview.NewTextView().SetDynamicColors(true)
+	input := tview.NewInputField().SetLabel("New " + req.credential + ": ").SetMaskCharacter('*')
+
+	closed := false
+	next := func() {
+		if closed {
+			// Dismissed while the credential was checked
+			return
+		}
+		closed = true
+		t.credentialQueue = t.credentialQueue[1:]
+		t.closeModal(credentialPage)
+		t.showCredentialPrompt()
+	}
+	checking := false
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch {
+		case key == tcell.KeyEscape:
+			next()
+		case key == tcell.KeyEnter && input.GetText() != "" && !checking:
+			checking = true
+			status.SetText("[yellow]Checking...[white]")
+			secret := input.GetText()
+			go func() {
+				err := t.onCredential(req.backend, secret)
+				t.queueUpdateDraw(func() {
+					checking = false
+					if err != nil {
+						status.SetText(fmt.Sprintf("[red]%s[white]", tview.Escape(err.Error())))
+						return
+					}
+					next()
+				})
+			}()
+		}
+	})
+
+	form := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(message, 2, 0, false).
+		AddItem(input, 1, 0, true).
+		AddItem(status, 0, 1, false)
+	form.SetBorder(true)
+	form.SetTitle(" Authentication failed (Enter to apply, Esc to dismiss) ")
+
+	t.pages.AddPage(credentialPage, modal(form, 70, 7), true, true)
+	t.app.SetFocus(input)
+}
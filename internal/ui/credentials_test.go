@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+// waitForText syncs with the event loop until s shows up on screen
+func waitForText(h *harness, s string) {
+	h.t.Helper()
+	deadline := time.Now().Add(harnessTimeout)
+	for time.Now().Before(deadline) {
+		h.sync()
+		if strings.Contains(h.text(), s) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("Screen never contained %q:\n%s", s, h.text())
+}
+
+func TestCredentialPrompt(t *testing.T) {
+	h := newHarness(t, []backend.Query{{ID: "cpu", Name: "CPU", Expr: "cpu"}}, 140, 30)
+	entered := make(chan string)
+	results := make(chan error)
+	h.tui.SetCredentialHandler(func(backendName, secret string) error {
+		entered <- backendName + "=" + secret
+		return <-results
+	})
+	enter := func(want string, result error) {
+		t.Helper()
+		h.press(tcell.KeyEnter, 0)
+		select {
+		case got := <-entered:
+			if got != want {
+				t.Errorf("Expected %s to be entered, got %s", want, got)
+			}
+		case <-time.After(harnessTimeout):
+			t.Fatal("Credential handler was not called")
+		}
+		results <- result
+	}
+
+	h.tui.PromptCredential("influxdb", "token")
+	h.tui.PromptCredential("influxdb1", "password")
+	h.sync()
+	h.assertContains("influxdb rejected its token")
+	h.assertContains("New token:")
+	h.assertNotContains("influxdb1 rejected")
+
+	// The secret is masked and a rejected one keeps the prompt open
+	for _, r := range "bad" {
+		h.typeRune(r)
+	}
+	h.assertContains("New token: ***")
+	h.assertNotContains("bad")
+	enter("influxdb=bad", errors.New("authorization failed"))
+	waitForText(h, "authorization failed")
+
+	h.typeRune('!')
+	enter("influxdb=bad!", nil)
+	waitForText(h, "influxdb1 rejected its password")
+	h.assertNotContains("New token:")
+
+	h.press(tcell.KeyEscape, 0)
+	h.assertNotContains("Authentication failed")
+}
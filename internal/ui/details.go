@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+const detailsPage = "details"
+
+// openURL opens a URL in the user's browser; replaced in tests
+var openURL = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// panelDetails describes the query behind a panel for the details view
+func (t *TUI) panelDetails(index int) string {
+	q := t.queries[index]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow]%s[white]\n\n", tview.Escape(q.Name))
+	md := t.metadata[index]
+	if q.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", tview.Escape(q.Description))
+	} else if md.Help != "" {
+		fmt.Fprintf(&b, "%s [gray](from metadata)[white]\n\n", tview.Escape(md.Help))
+	}
+
+	// The parts a partial result is missing, as the backend reported them
+	if warnings := panelWarnings(t.histories[index]); len(warnings) > 0 {
+		b.WriteString("[yellow]Partial result:[white]\n")
+		for _, w := range warnings {
+			fmt.Fprintf(&b, "  • %s\n", tview.Escape(w))
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "[gray]Type:[white]  %s\n", q.PanelType())
+	if q.Expr != "" {
+		fmt.Fprintf(&b, "[gray]Expr:[white]  %s\n", tview.Escape(q.Expr))
+	}
+	if j := q.Join; j != nil {
+		fmt.Fprintf(&b, "[gray]Left:[white]  %s\n", joinSide(j.Left))
+		fmt.Fprintf(&b, "[gray]Right:[white] %s\n", joinSide(j.Right))
+		interpolation := j.Interpolation
+		if interpolation == "" {
+			interpolation = "linear"
+		}
+		fmt.Fprintf(&b, "[gray]Op:[white]    %s (%s interpolation)\n", j.Op, interpolation)
+	}
+	if st := q.Status; st != nil {
+		fmt.Fprintf(&b, "[gray]Rows:[white]  by %s\n", tview.Escape(st.By))
+		for _, check := range st.Checks {
+			fmt.Fprintf(&b, "[gray]Check:[white] %s: %s\n", tview.Escape(check.Name), joinSide(backend.JoinSide{Expr: check.Expr, Backend: check.Backend}))
+		}
+	}
+	if br := q.BurnRate; br != nil {
+		fmt.Fprintf(&b, "[gray]Error ratio:[white] %s\n", tview.Escape(br.ErrorRatio))
+		fmt.Fprintf(&b, "[gray]Objective:[white] %v%%\n", br.Objective)
+	}
+	if q.Window != nil {
+		fmt.Fprintf(&b, "[gray]Range:[white] %s\n", formatWindow(*q.Window))
+	} else if q.Range != "" {
+		fmt.Fprintf(&b, "[gray]Range:[white] %s\n", q.Range)
+	}
+	if q.Evaluation == backend.EvaluationInstantOverRange {
+		b.WriteString("[gray]Evaluation:[white] once at the end of the range\n")
+	}
+	if q.Offset != "" && q.Window == nil {
+		fmt.Fprintf(&b, "[gray]Offset:[white] %s behind\n", q.Offset)
+	}
+	if len(q.Match) > 0 {
+		var labels []string
+		for k, v := range q.Match {
+			labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+		}
+		sort.Strings(labels)
+		fmt.Fprintf(&b, "[gray]Series:[white] %s\n", tview.Escape(strings.Join(labels, ", ")))
+	}
+	if q.TopN > 0 {
+		fmt.Fprintf(&b, "[gray]Top N:[white] %d series\n", q.TopN)
+	}
+	if p := q.Percentiles; p != nil {
+		var levels []string
+		for _, level := range p.Levels() {
+			levels = append(levels, fmt.Sprintf("p%g", level))
+		}
+		over := "whole range"
+		if p.Window != "" {
+			over = "rolling " + p.Window
+		}
+		fmt.Fprintf(&b, "[gray]Stats:[white] %s (%s)\n", strings.Join(levels, ", "), over)
+	}
+	if st := q.Stats; st != nil {
+		var names []string
+		for _, name := range st.Show {
+			names = append(names, statLabels[name])
+		}
+		fmt.Fprintf(&b, "[gray]Stats row:[white] %s\n", strings.Join(names, ", "))
+	}
+	if q.Unit != "" {
+		fmt.Fprintf(&b, "[gray]Unit:[white]  %s\n", tview.Escape(q.Unit))
+	} else if md.Unit != "" {
+		fmt.Fprintf(&b, "[gray]Unit:[white]  %s (detected)\n", tview.Escape(md.Unit))
+	}
+	if c := q.Convert; c != nil {
+		var steps []string
+		if c.From != "" {
+			steps = append(steps, c.From+" → "+c.To)
+		}
+		if c.Multiply != 0 {
+			steps = append(steps, "× "+t.numbers.Float(c.Multiply, -1))
+		}
+		if c.Divide != 0 {
+			steps = append(steps, "÷ "+t.numbers.Float(c.Divide, -1))
+		}
+		if c.Unit != "" {
+			steps = append(steps, "in "+c.Unit)
+		}
+		fmt.Fprintf(&b, "[gray]Convert:[white] %s\n", tview.Escape(strings.Join(steps, ", ")))
+	}
+	if q.Record != "" {
+		fmt.Fprintf(&b, "[gray]Recorded as:[white] %s\n", tview.Escape(q.Record))
+	}
+	if md.Type != "" {
+		fmt.Fprintf(&b, "[gray]Metric type:[white] %s\n", tview.Escape(md.Type))
+	}
+	if len(q.ValueMappings) > 0 {
+		var mappings []string
+		for _, m := range q.ValueMappings {
+			mappings = append(mappings, fmt.Sprintf("%s → %s", t.numbers.Float(m.Value, -1), m.Text))
+		}
+		fmt.Fprintf(&b, "[gray]Mappings:[white] %s\n", tview.Escape(strings.Join(mappings, ", ")))
+	}
+	if th := q.Thresholds; th != nil {
+		writeThresholds(&b, th, t.numbers)
+	}
+	if len(q.Tags) > 0 {
+		fmt.Fprintf(&b, "[gray]Tags:[white]  %s\n", tview.Escape(strings.Join(q.Tags, ", ")))
+	}
+
+	if q.RunbookURL != "" {
+		fmt.Fprintf(&b, "\n[gray]Runbook:[white] %s\n[gray](press o to open)[white]", tview.Escape(q.RunbookURL))
+	} else {
+		b.WriteString("\n[gray]No runbook configured[white]")
+	}
+
+	return b.String()
+}
+
+// showDetails opens a modal describing the focused panel
+func (t *TUI) showDetails() {
+	if len(t.panels) == 0 {
+		return
+	}
+	index := t.focusIndex
+
+	view := tview.NewTextView()
+	view.SetDynamicColors(true)
+	view.SetWordWrap(true)
+	view.SetBorder(true)
+	view.SetTitle(" Details (Esc to close) ")
+	view.SetText(t.panelDetails(index))
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape, event.Rune() == 'i', event.Rune() == 'I':
+			t.closeModal(detailsPage)
+			return nil
+		case event.Rune() == 'o', event.Rune() == 'O':
+			t.openRunbook(index)
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(detailsPage, modal(view, 80, 16), true, true)
+	t.app.SetFocus(view)
+}
+
+// openRunbook opens the runbook of a panel, reporting failures in the footer
+func (t *TUI) openRunbook(index int) {
+	url := t.queries[index].RunbookURL
+	if url == "" {
+		t.instructions.SetText(t.lang.Sprintf("[yellow]%s has no runbook_url[white]", tview.Escape(t.queries[index].Name)))
+		return
+	}
+	if err := openURL(url); err != nil {
+		t.instructions.SetText(t.lang.Sprintf("[red]Could not open runbook: %v[white]", err))
+		return
+	}
+	t.updateInstructions()
+}
+
+// joinSide describes one operand of a join panel
+func joinSide(side backend.JoinSide) string {
+	if side.Backend == "" {
+		return tview.Escape(side.Expr)
+	}
+	return fmt.Sprintf("%s [gray](%s)[white]", tview.Escape(side.Expr), side.Backend)
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestPanelDetails(t *testing.T) {
+	warn := 80.0
+	queries := []backend.Query{
+		{
+			Name:        "CPU Usage",
+			Expr:        `avg(rate(cpu{mode!="idle"}[5m]))`,
+			Description: "Fleet-wide CPU; sustained spikes usually mean a runaway batch job.",
+			RunbookURL:  "https://wiki.example.com/runbooks/cpu",
+			Thresholds:  &backend.Thresholds{Warn: &warn},
+		},
+		{Name: "Memory", Expr: "mem", Offset: "1h", Percentiles: &backend.Percentiles{Window: "10m"}, Match: map[string]string{"instance": "web-1"}},
+	}
+
+	tui := NewTUI(queries, nil)
+
+	details := tui.panelDetails(0)
+	for _, want := range []string{"runaway batch job", "https://wiki.example.com/runbooks/cpu", "above 80", "press o to open"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("Details should contain %q, got:\n%s", want, details)
+		}
+	}
+
+	if !strings.Contains(tui.panelDetails(1), "Offset:[white] 1h behind") {
+		t.Errorf("Details should show the offset, got:\n%s", tui.panelDetails(1))
+	}
+	if !strings.Contains(tui.panelDetails(1), `Series:[white] instance="web-1"`) {
+		t.Errorf("Details should show the series of an expanded panel, got:\n%s", tui.panelDetails(1))
+	}
+	if !strings.Contains(tui.panelDetails(1), "p50, p90, p99 (rolling 10m)") {
+		t.Errorf("Details should list the percentiles, got:\n%s", tui.panelDetails(1))
+	}
+	if !strings.Contains(tui.panelDetails(1), "No runbook configured") {
+		t.Error("Details without runbook should say so")
+	}
+}
+
+func TestPanelDetailsJoin(t *testing.T) {
+	queries := []backend.Query{{
+		Name: "Requests per Order",
+		Type: backend.PanelJoin,
+		Join: &backend.JoinConfig{
+			Left:  backend.JoinSide{Expr: "sum(rate(http_requests_total[5m]))"},
+			Right: backend.JoinSide{Expr: "orders", Backend: "influxdb"},
+			Op:    "div",
+		},
+		Record: "job:requests_per_order:ratio",
+	}}
+
+	details := NewTUI(queries, nil).panelDetails(0)
+	for _, want := range []string{"Type:[white]  join", "sum(rate(http_requests_total", "orders [gray](influxdb)", "div (linear interpolation)", "Recorded as:[white] job:requests_per_order:ratio"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("Details should contain %q, got:\n%s", want, details)
+		}
+	}
+}
+
+func TestOpenRunbook(t *testing.T) {
+	var opened string
+	original := openURL
+	openURL = func(url string) error {
+		opened = url
+		return nil
+	}
+	defer func() { openURL = original }()
+
+	queries := []backend.Query{
+		{Name: "CPU Usage", Expr: "cpu", RunbookURL: "https://wiki.example.com/runbooks/cpu"},
+		{Name: "Memory", Expr: "mem"},
+	}
+	tui := NewTUI(queries, nil)
+
+	tui.openRunbook(0)
+	if opened != "https://wiki.example.com/runbooks/cpu" {
+		t.Errorf("Expected runbook to be opened, got %q", opened)
+	}
+
+	opened = ""
+	tui.openRunbook(1)
+	if opened != "" {
+		t.Error("Panels without runbook should not open anything")
+	}
+	if !strings.Contains(tui.instructions.GetText(false), "has no runbook_url") {
+		t.Errorf("Expected a hint in the footer, got %q", tui.instructions.GetText(false))
+	}
+
+	openURL = func(string) error { return errors.New("no browser") }
+	tui.openRunbook(0)
+	if !strings.Contains(tui.instructions.GetText(false), "no browser") {
+		t.Errorf("Expected failure in the footer, got %q", tui.instructions.GetText(false))
+	}
+}
+
+func TestShowDetails(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "CPU Usage", Expr: "cpu"}}, nil)
+
+	tui.showDetails()
+	if !tui.modalOpen() {
+		t.Fatal("Details view should open as a modal")
+	}
+
+	tui.closeModal(detailsPage)
+	if tui.modalOpen() {
+		t.Error("Details view should close")
+	}
+}
+
+func TestInstantOverRangePanel(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Errors", Expr: "increase(errors_total[1h])", Range: "1h", Evaluation: backend.EvaluationInstantOverRange}}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: goldenNow, Value: 42}}}
+	tui.renderTimeSeriesGraph(0)
+
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "Current: 42.00") {
+		t.Errorf("Expected the single value shown, got %q", text)
+	}
+	if details := tui.panelDetails(0); !strings.Contains(details, "Evaluation:[white] once at the end of the range") {
+		t.Errorf("Details should show the evaluation, got:\n%s", details)
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const diagnosticsPage = "diagnostics"
+
+// SetDiagnostics sets the warnings listed in the diagnostics view.
+// Call before Run.
+func (t *TUI) SetDiagnostics(warnings []string) {
+	t.warnings = warnings
+	t.updateInstructions()
+}
+
+// SetBackendStatus sets the per-backend connection results listed in the
+// diagnostics view. Call before Run.
+func (t *TUI) SetBackendStatus(lines []string, failed int) {
+	t.backendStatus = lines
+	t.backendsDown = failed
+	t.updateInstructions()
+}
+
+// SetSinks sets the names of the sinks listed in the diagnostics view.
+// Call before Run.
+func (t *TUI) SetSinks(names []string) {
+	t.sinks = names
+}
+
+// SetSinkError reports the result of the last write to the sinks, nil if it
+// succeeded
+func (t *TUI) SetSinkError(err error) {
+	t.queueUpdateDraw(func() {
+		t.sinkError = err
+	})
+}
+
+// diagnosticsText renders the diagnostics view content
+func (t *TUI) diagnosticsText() string {
+	var b strings.Builder
+
+	if len(t.backendStatus) > 0 {
+		b.WriteString("[yellow]Backends[white]\n\n")
+		for _, s := range t.backendStatus {
+			fmt.Fprintf(&b, "• %s\n", tview.Escape(s))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.sinks) > 0 {
+		b.WriteString("[yellow]Sinks[white]\n\n")
+		fmt.Fprintf(&b, "• Writing to %s\n", tview.Escape(strings.Join(t.sinks, ", ")))
+		if t.sinkError != nil {
+			fmt.Fprintf(&b, "• [red]Last write failed:[white] %s\n", tview.Escape(t.sinkError.Error()))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("[yellow]Configuration warnings[white]\n\n")
+	if len(t.warnings) == 0 {
+		b.WriteString("[gray]None[white]\n")
+	}
+	for _, w := range t.warnings {
+		fmt.Fprintf(&b, "• %s\n", tview.Escape(w))
+	}
+	if len(t.warnings) > 0 {
+		b.WriteString("\n[gray]Run with --strict to turn these into errors.[white]\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(t.memoryText(t.memoryUsage()))
+	return b.String()
+}
+
+// showDiagnostics opens a modal with configuration warnings and memory
+// usage; c compacts the panel histories
+func (t *TUI) showDiagnostics() {
+	view := tview.NewTextView()
+	view.SetDynamicColors(true)
+	view.SetWordWrap(true)
+	view.SetBorder(true)
+	view.SetTitle(" Diagnostics (c to compact memory, Esc to close) ")
+	view.SetText(t.diagnosticsText())
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'd' || event.Rune() == 'D':
+			t.closeModal(diagnosticsPage)
+			return nil
+		case event.Rune() == 'c' || event.Rune() == 'C':
+			before := t.memoryUsage()
+			dropped := t.compactHistories(0)
+			after := t.memoryUsage()
+			t.redrawPanels()
+			view.SetText(t.diagnosticsText() + fmt.Sprintf("\n[green]Compacted: dropped %s points, heap %s → %s[white]\n",
+				t.numbers.Float(float64(dropped), 0), t.numbers.Bytes(float64(before.heapInUse)), t.numbers.Bytes(float64(after.heapInUse))))
+			view.ScrollToEnd()
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(diagnosticsPage, modal(view, 100, 20), true, true)
+	t.app.SetFocus(view)
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+)
+
+func TestSetDiagnostics(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Query 1", Expr: "metric1"}}, nil)
+
+	tui.SetDiagnostics([]string{"line 2: field refresh not found in type config.Config"})
+
+	if !strings.Contains(tui.instructions.GetText(false), "1 config warnings (d)") {
+		t.Errorf("Footer should announce warnings, got %q", tui.instructions.GetText(false))
+	}
+	if !strings.Contains(tui.diagnosticsText(), "field refresh not found") {
+		t.Errorf("Diagnostics should list the warning, got %q", tui.diagnosticsText())
+	}
+
+	tui.showDiagnostics()
+	if !tui.modalOpen() {
+		t.Error("Diagnostics should open as a modal")
+	}
+}
+
+func TestDiagnosticsEmpty(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Query 1", Expr: "metric1"}}, nil)
+
+	if strings.Contains(tui.instructions.GetText(false), "config warnings") {
+		t.Error("Footer should not mention warnings when there are none")
+	}
+	if !strings.Contains(tui.diagnosticsText(), "None") {
+		t.Errorf("Diagnostics should say there is nothing to report, got %q", tui.diagnosticsText())
+	}
+}
+
+func TestDiagnosticsSinks(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Query 1", Expr: "metric1"}}, nil)
+	if strings.Contains(tui.diagnosticsText(), "Sinks") {
+		t.Error("Diagnostics should not list sinks without any")
+	}
+
+	tui.SetSinks([]string{"csv", "mimir"})
+	tui.sinkError = errors.New("sink mimir: failed to remote write: 503 Service Unavailable")
+	text := tui.diagnosticsText()
+	for _, want := range []string{"Writing to csv, mimir", "Last write failed:[white] sink mimir"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in the diagnostics, got %q", want, text)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+	"promviz/internal/i18n"
+)
+
+// describeError renders the error of a panel's query, with a heading and a
+// hint on what to do for the kinds of errors backends tell apart. The
+// message of the backend itself is not translated.
+func describeError(err error, query backend.Query, c i18n.Catalog) string {
+	var (
+		auth        *backend.AuthError
+		notFound    *backend.NotFoundError
+		timeout     *backend.TimeoutError
+		rateLimited *backend.RateLimitedError
+		syntax      *backend.SyntaxError
+	)
+	message := tview.Escape(err.Error())
+
+	switch {
+	case errors.As(err, &syntax):
+		return c.Sprintf("[red]Query syntax error[white]\n%s\n\n[yellow]%s[white]\nFix the expr of this panel in the config",
+			message, tview.Escape(query.Expr))
+	case errors.As(err, &auth):
+		return c.Sprintf("[red]Authentication failed[white]\n%s\n\nCheck the credentials of this backend in the config", message)
+	case errors.As(err, &notFound):
+		return c.Sprintf("[red]Not found[white]\n%s\n\nCheck the URL, database or bucket of this backend in the config", message)
+	case errors.As(err, &rateLimited):
+		hint := c.T("The backend is refusing queries for now")
+		if rateLimited.RetryAfter > 0 {
+			hint = c.Sprintf("The backend asks to wait %s", rateLimited.RetryAfter)
+		}
+		return c.Sprintf("[yellow]Rate limited[white]\n%s\n\n%s", message, hint)
+	case errors.As(err, &timeout), errors.Is(err, context.DeadlineExceeded):
+		return c.Sprintf("[red]Timed out[white]\n%s\n\nTry a shorter range or a coarser step (r to retry)", message)
+	}
+	return c.Sprintf("[red]Error: %v[white]", err)
+}
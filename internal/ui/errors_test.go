@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/i18n"
+)
+
+func TestDescribeError(t *testing.T) {
+	query := backend.Query{Name: "Rate", Expr: `rate(http_requests_total[5m]`}
+	parseErr := errors.New(`1:29: parse error: unclosed left parenthesis`)
+
+	tests := []struct {
+		err      error
+		expected []string
+	}{
+		{&backend.SyntaxError{Err: parseErr}, []string{"Query syntax error", "unclosed left parenthesis", "rate(http_requests_total[5m[]", "Fix the expr"}},
+		{fmt.Errorf("query failed: %w", &backend.AuthError{Err: errors.New("server returned HTTP 401 Unauthorized")}), []string{"Authentication failed", "Check the credentials"}},
+		{&backend.NotFoundError{Err: errors.New("database not found")}, []string{"Not found", "database or bucket"}},
+		{&backend.RateLimitedError{Err: errors.New("server returned HTTP 429"), RetryAfter: 30 * time.Second}, []string{"Rate limited", "wait 30s"}},
+		{&backend.RateLimitedError{Err: errors.New("server returned HTTP 429")}, []string{"Rate limited", "refusing queries"}},
+		{&backend.TimeoutError{Err: errors.New("query timed out")}, []string{"Timed out", "shorter range"}},
+		{fmt.Errorf("query failed: %w", context.DeadlineExceeded), []string{"Timed out"}},
+		{errors.New("connection refused"), []string{"[red]Error: connection refused[white]"}},
+	}
+	for _, tt := range tests {
+		got := describeError(tt.err, query, i18n.Catalog{})
+		for _, want := range tt.expected {
+			if !strings.Contains(got, want) {
+				t.Errorf("%v: expected %q in %q", tt.err, want, got)
+			}
+		}
+	}
+}
+
+func TestSyntaxErrorPanel(t *testing.T) {
+	h := newHarness(t, screenQueries[:1], 120, 24)
+	h.tui.UpdateTimeSeries(0, nil, &backend.SyntaxError{Err: errors.New("parse error: unexpected end of input")})
+	h.sync()
+
+	h.assertContains("Query syntax error")
+	h.assertContains("parse error: unexpected end of input")
+	h.assertContains(screenQueries[0].Expr)
+}
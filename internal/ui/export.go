@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+	"promviz/internal/topn"
+)
+
+// panelExport is the JSON written for a panel by exportPanel
+type panelExport struct {
+	Panel      string         `json:"panel"`
+	ID         string         `json:"id"`
+	Expr       string         `json:"expr,omitempty"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Series     []seriesExport `json:"series"`
+	Warnings   []string       `json:"warnings,omitempty"` // set when the backend returned part of the data
+
+	IntegralUnit string `json:"integral_unit,omitempty"` // time the integral stats are counted in
+}
+
+// seriesExport is one series of a panel export
+type seriesExport struct {
+	Name   string             `json:"name"`
+	Labels map[string]string  `json:"labels,omitempty"`
+	Stats  map[string]float64 `json:"stats,omitempty"` // the statistics selected by the panel's stats
+	Points []pointExport      `json:"points"`
+}
+
+// pointExport is one sample of an exported series
+type pointExport struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// unsafeFileChars matches characters kept out of export file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// exportPanel writes the series the panel at index shows to a timestamped
+// JSON file in exportDir and returns its path
+func (t *TUI) exportPanel(index int) (string, error) {
+	q := t.queries[index]
+	history := t.histories[index]
+
+	var series []seriesExport
+	switch {
+	case q.PanelType() == backend.PanelSLO:
+		for _, side := range []struct {
+			name   string
+			result *backend.TimeSeriesResult
+		}{{"good", history.Good}, {"total", history.Total}} {
+			if side.result != nil {
+				series = append(series, exportSeries(side.name, side.result.Points))
+			}
+		}
+	case history.TimeSeries != nil:
+		split := topn.Split(history.TimeSeries.Points)
+		if q.TopN > 0 {
+			split, _ = topn.Select(history.TimeSeries.Points, q.TopN)
+		}
+		for _, s := range split {
+			e := exportSeries(s.Name, s.Points)
+			if s.Name != "" {
+				e.Labels = backend.ParseSeriesName(s.Name)
+			}
+			if q.Stats != nil {
+				e.Stats = make(map[string]float64)
+				for name, v := range seriesStats(q.Stats, s.Points) {
+					if !math.IsNaN(v) && !math.IsInf(v, 0) {
+						e.Stats[name] = v
+					}
+				}
+			}
+			series = append(series, e)
+		}
+	}
+	if len(series) == 0 {
+		return "", fmt.Errorf("%s has no data to export", q.Name)
+	}
+
+	now := t.now()
+	export := panelExport{
+		Panel:      q.Name,
+		ID:         q.ID,
+		Expr:       q.Expr,
+		ExportedAt: now,
+		Series:     series,
+		Warnings:   panelWarnings(history),
+	}
+	if st := q.Stats; st != nil {
+		for _, name := range st.Show {
+			if name == backend.StatIntegral {
+				export.IntegralUnit = model.Duration(st.Per()).String()
+			}
+		}
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode export: %w", err)
+	}
+
+	name := fmt.Sprintf("promviz-%s-%s.json", unsafeFileChars.ReplaceAllString(q.ID, "-"), now.Format("20060102-150405"))
+	path := filepath.Join(t.exportDir, name)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+	return path, nil
+}
+
+// exportSeries converts the points of a series, sorted by time. Missing
+// values are left out since JSON has no NaN.
+func exportSeries(name string, points []backend.DataPoint) seriesExport {
+	s := seriesExport{Name: name, Points: []pointExport{}}
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		s.Points = append(s.Points, pointExport{Timestamp: p.Timestamp, Value: p.Value})
+	}
+	sort.SliceStable(s.Points, func(i, j int) bool {
+		return s.Points[i].Timestamp.Before(s.Points[j].Timestamp)
+	})
+	return s
+}
+
+// exportFocused exports the focused panel and reports the result in the
+// help line
+func (t *TUI) exportFocused() {
+	if len(t.panels) == 0 {
+		return
+	}
+	path, err := t.exportPanel(t.focusIndex)
+	if err != nil {
+		t.instructions.SetText(t.lang.Sprintf("[red]Export failed: %s[white]", tview.Escape(err.Error())))
+		return
+	}
+	t.instructions.SetText(t.lang.Sprintf("[green]Exported to %s[white]", tview.Escape(path)))
+}
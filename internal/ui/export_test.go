@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestExportPanel(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	query := backend.Query{ID: "cpu/usage", Name: "CPU Usage", Expr: "cpu", TopN: 1}
+	tui := NewTUI([]backend.Query{query}, nil)
+	tui.now = func() time.Time { return now }
+	tui.exportDir = t.TempDir()
+
+	if _, err := tui.exportPanel(0); err == nil {
+		t.Error("Expected an error for a panel without data")
+	}
+
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now, Value: 3, Series: `cpu{host="a"}`},
+		{Timestamp: now.Add(-time.Minute), Value: 2, Series: `cpu{host="a"}`},
+		{Timestamp: now.Add(-2 * time.Minute), Value: math.NaN(), Series: `cpu{host="a"}`},
+		{Timestamp: now, Value: 1, Series: `cpu{host="b"}`},
+	}}
+
+	path, err := tui.exportPanel(0)
+	if err != nil {
+		t.Fatalf("exportPanel should not return error, got %v", err)
+	}
+	if want := filepath.Join(tui.exportDir, "promviz-cpu-usage-20240101-120000.json"); path != want {
+		t.Errorf("Expected export at %s, got %s", want, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var export panelExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("Export should be valid JSON, got %v", err)
+	}
+
+	// Only the top series is visible, sorted and without missing values
+	if export.Panel != "CPU Usage" || len(export.Series) != 1 {
+		t.Fatalf("Expected the one visible series of CPU Usage, got %+v", export)
+	}
+	s := export.Series[0]
+	if s.Labels["host"] != "a" || s.Labels[backend.NameLabel] != "cpu" {
+		t.Errorf("Expected the labels of the series, got %v", s.Labels)
+	}
+	if len(s.Points) != 2 || s.Points[0].Value != 2 || !s.Points[1].Timestamp.Equal(now) {
+		t.Errorf("Expected two sorted points, got %+v", s.Points)
+	}
+}
+
+func TestExportKey(t *testing.T) {
+	h := newHarness(t, []backend.Query{{ID: "cpu", Name: "CPU", Expr: "cpu"}}, 120, 30)
+	h.tui.exportDir = t.TempDir()
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: time.Now(), Value: 1}}}, nil)
+	h.sync()
+
+	h.typeRune('e')
+	h.assertContains("Exported to " + h.tui.exportDir)
+
+	matches, _ := filepath.Glob(filepath.Join(h.tui.exportDir, "promviz-cpu-*.json"))
+	if len(matches) != 1 {
+		t.Errorf("Expected one export file, got %v", matches)
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"promviz/internal/backend"
+)
+
+// maxGridSteps bounds the number of steps a panel is drawn with. Data far
+// outside the panel's range, such as from a Flux query with its own time
+// bounds, coarsens the grid instead of growing it without limit.
+const maxGridSteps = 5000
+
+// hatchRune marks graph columns where the backend returned no data
+const hatchRune = '░'
+
+// Gap is an interval of expected steps for which the backend returned no points
+type Gap struct {
+	Start time.Time // first missing step
+	End   time.Time // last missing step
+}
+
+// timeGrid is a uniform grid of steps that points are placed on, so their
+// position on the x-axis reflects their time rather than their index
+type timeGrid struct {
+	start time.Time
+	step  time.Duration
+	steps int // number of grid points
+}
+
+// newTimeGrid returns the grid of tr's steps, ending at tr.End and extended
+// to cover points from first to last
+func newTimeGrid(tr backend.TimeRange, first, last time.Time) timeGrid {
+	from, to := tr.Start, tr.End
+	if first.Before(from) {
+		from = first
+	}
+	if last.After(to) {
+		to = last
+	}
+
+	step := tr.Step
+	if span := to.Sub(from); span/step >= maxGridSteps {
+		step = span/(maxGridSteps-1) + 1
+	}
+
+	// Anchor the grid at the end of the range so that points of a range
+	// query land on grid points
+	before := int(math.Floor(float64(tr.End.Sub(from))/float64(step) + 0.5))
+	after := int(math.Floor(float64(to.Sub(tr.End))/float64(step) + 0.5))
+	return timeGrid{
+		start: tr.End.Add(-time.Duration(before) * step),
+		step:  step,
+		steps: before + after + 1,
+	}
+}
+
+// index returns the grid point nearest to ts
+func (g timeGrid) index(ts time.Time) int {
+	i := int(math.Floor(float64(ts.Sub(g.start))/float64(g.step) + 0.5))
+	if i < 0 {
+		return 0
+	}
+	if i >= g.steps {
+		return g.steps - 1
+	}
+	return i
+}
+
+// at returns the time of grid point i
+func (g timeGrid) at(i int) time.Time {
+	return g.start.Add(time.Duration(i) * g.step)
+}
+
+// fillGaps places the sorted points on a uniform grid of tr's steps, so that
+// irregularly spaced samples are drawn at their time and outages render as
+// holes rather than being interpolated over. Each step keeps the points of
+// the latest timestamp that falls on it, which are several only when
+// series share timestamps. Steps without points get a NaN placeholder and
+// are reported as missing intervals.
+func fillGaps(points []backend.DataPoint, tr backend.TimeRange) ([]backend.DataPoint, []Gap) {
+	if tr.Step <= 0 || len(points) == 0 {
+		return points, nil
+	}
+
+	g := newTimeGrid(tr, points[0].Timestamp, points[len(points)-1].Timestamp)
+	buckets := make([][]backend.DataPoint, g.steps)
+	for _, p := range points {
+		i := g.index(p.Timestamp)
+		switch {
+		case len(buckets[i]) == 0 || p.Timestamp.After(buckets[i][0].Timestamp):
+			buckets[i] = []backend.DataPoint{p}
+		case p.Timestamp.Equal(buckets[i][0].Timestamp):
+			buckets[i] = append(buckets[i], p)
+		}
+	}
+
+	filled := make([]backend.DataPoint, 0, g.steps)
+	var gaps []Gap
+	var gap *Gap
+	for i, bucket := range buckets {
+		if len(bucket) > 0 {
+			filled = append(filled, bucket...)
+			gap = nil
+			continue
+		}
+
+		ts := g.at(i)
+		filled = append(filled, backend.DataPoint{Timestamp: ts, Value: math.NaN()})
+		if gap == nil {
+			gaps = append(gaps, Gap{Start: ts})
+			gap = &gaps[len(gaps)-1]
+		}
+		gap.End = ts
+	}
+
+	return filled, gaps
+}
+
+// gapColumns reports which of width graph columns fall on a missing value,
+// mirroring how asciigraph resamples values to the requested width
+func gapColumns(values []float64, width int) []bool {
+	columns := make([]bool, width)
+	if len(values) == 0 || width < 2 {
+		return columns
+	}
+
+	spring := float64(len(values)-1) / float64(width-1)
+	for x := 0; x < width; x++ {
+		pos := float64(x) * spring
+		before := int(math.Floor(pos))
+		after := int(math.Ceil(pos))
+		if after >= len(values) {
+			after = len(values) - 1
+		}
+		columns[x] = math.IsNaN(values[before]) || math.IsNaN(values[after])
+	}
+	return columns
+}
+
+// hatchGaps fills the blank cells of gap columns in an asciigraph plot with
+// hatchRune. The caption line, if any, is left untouched. Plots with colored
+// series are supported; escape sequences don't count as columns.
+func hatchGaps(graph string, columns []bool, caption bool) string {
+	lines := strings.Split(graph, "\n")
+	plotLines := len(lines)
+	if caption {
+		plotLines--
+	}
+
+	// The plot area starts right after the y-axis on the first line
+	axis := -1
+	for i, c := range cells(lines[0]) {
+		if strings.HasSuffix(c, "┤") || strings.HasSuffix(c, "┼") {
+			axis = i
+			break
+		}
+	}
+	if axis < 0 {
+		return graph
+	}
+	start := axis + 1
+
+	for i := 0; i < plotLines; i++ {
+		row := cells(lines[i])
+		for len(row) < start+len(columns) {
+			row = append(row, " ")
+		}
+		for x, gap := range columns {
+			if c := row[start+x]; gap && strings.HasSuffix(c, " ") {
+				row[start+x] = strings.TrimSuffix(c, " ") + string(hatchRune)
+			}
+		}
+		lines[i] = strings.Join(row, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// cells splits a line into one string per screen column. ANSI escape
+// sequences are kept with the character that follows them; trailing ones
+// are kept with the last character.
+func cells(line string) []string {
+	var result []string
+	var pending strings.Builder
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			end := strings.IndexByte(line[i:], 'm')
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			pending.WriteString(line[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		result = append(result, pending.String()+line[i:i+size])
+		pending.Reset()
+		i += size
+	}
+	if pending.Len() > 0 {
+		if len(result) == 0 {
+			return []string{pending.String()}
+		}
+		result[len(result)-1] += pending.String()
+	}
+	return result
+}
+
+// formatGaps renders the footer note listing missing intervals
+func formatGaps(gaps []Gap) string {
+	const maxListed = 3
+
+	var parts []string
+	for i, gap := range gaps {
+		if i == maxListed {
+			parts = append(parts, fmt.Sprintf("+%d more", len(gaps)-maxListed))
+			break
+		}
+		if gap.Start.Equal(gap.End) {
+			parts = append(parts, gap.Start.Format("15:04"))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s–%s", gap.Start.Format("15:04"), gap.End.Format("15:04")))
+		}
+	}
+	return "no data " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestFillGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{Start: start, End: start.Add(9 * time.Minute), Step: time.Minute}
+
+	at := func(minutes int) backend.DataPoint {
+		return backend.DataPoint{Timestamp: start.Add(time.Duration(minutes) * time.Minute), Value: float64(minutes)}
+	}
+	// 12:03-12:05 and 12:08-12:09 are missing
+	points := []backend.DataPoint{at(0), at(1), at(2), at(6), at(7)}
+
+	filled, gaps := fillGaps(points, tr)
+
+	if len(filled) != 10 {
+		t.Fatalf("Expected one point per step (10), got %d", len(filled))
+	}
+	for _, i := range []int{3, 4, 5, 8, 9} {
+		if !math.IsNaN(filled[i].Value) {
+			t.Errorf("Expected NaN placeholder at step %d, got %v", i, filled[i].Value)
+		}
+	}
+	if filled[6].Value != 6 {
+		t.Errorf("Expected real point at step 6, got %v", filled[6].Value)
+	}
+
+	if len(gaps) != 2 {
+		t.Fatalf("Expected 2 gaps, got %d", len(gaps))
+	}
+	if !gaps[0].Start.Equal(start.Add(3*time.Minute)) || !gaps[0].End.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("Unexpected first gap: %v", gaps[0])
+	}
+	if !gaps[1].Start.Equal(start.Add(8*time.Minute)) || !gaps[1].End.Equal(start.Add(9*time.Minute)) {
+		t.Errorf("Unexpected trailing gap: %v", gaps[1])
+	}
+}
+
+func TestFillGapsLeading(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{Start: start, End: start.Add(4 * time.Minute), Step: time.Minute}
+	points := []backend.DataPoint{
+		{Timestamp: start.Add(3 * time.Minute), Value: 1},
+		{Timestamp: start.Add(4 * time.Minute), Value: 2},
+	}
+
+	filled, gaps := fillGaps(points, tr)
+	if len(filled) != 5 || len(gaps) != 1 {
+		t.Fatalf("Expected 5 points and 1 gap, got %d and %d", len(filled), len(gaps))
+	}
+	if !gaps[0].Start.Equal(start) || !gaps[0].End.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Unexpected leading gap: %v", gaps[0])
+	}
+}
+
+func TestFillGapsComplete(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{Start: start, End: start.Add(2 * time.Minute), Step: time.Minute}
+	points := []backend.DataPoint{
+		{Timestamp: start, Value: 0},
+		{Timestamp: start.Add(time.Minute), Value: 0},
+		{Timestamp: start.Add(2 * time.Minute), Value: 0},
+	}
+
+	filled, gaps := fillGaps(points, tr)
+	if len(filled) != 3 || len(gaps) != 0 {
+		t.Errorf("Zero values are data, not gaps: got %d points and %d gaps", len(filled), len(gaps))
+	}
+}
+
+func TestFillGapsIrregular(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{Start: start, End: start.Add(4 * time.Minute), Step: time.Minute}
+	at := func(d time.Duration, v float64) backend.DataPoint {
+		return backend.DataPoint{Timestamp: start.Add(d), Value: v}
+	}
+
+	// A burst around 12:01, a point just before 12:02 and nothing at 12:03
+	points := []backend.DataPoint{
+		at(0, 0),
+		at(50*time.Second, 1), at(time.Minute, 2), at(70*time.Second, 3),
+		at(115*time.Second, 4),
+		at(4*time.Minute, 5),
+	}
+
+	filled, gaps := fillGaps(points, tr)
+	if len(filled) != 5 {
+		t.Fatalf("Expected one point per step (5), got %d", len(filled))
+	}
+	if filled[1].Value != 3 {
+		t.Errorf("Expected the latest point of the burst at step 1, got %v", filled[1].Value)
+	}
+	if filled[2].Value != 4 || !filled[2].Timestamp.Equal(start.Add(115*time.Second)) {
+		t.Errorf("Expected the off-grid point at its nearest step 2, got %+v", filled[2])
+	}
+	if !math.IsNaN(filled[3].Value) || len(gaps) != 1 || !gaps[0].Start.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("Expected a gap at 12:03 only, got %v", gaps)
+	}
+}
+
+func TestFillGapsOutsideRange(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{Start: end.Add(-5 * time.Minute), End: end, Step: time.Minute}
+
+	// Data with its own time bounds, e.g. a full Flux query over a day
+	points := []backend.DataPoint{
+		{Timestamp: end.Add(-24 * time.Hour), Value: 1},
+		{Timestamp: end, Value: 2},
+	}
+
+	filled, _ := fillGaps(points, tr)
+	if len(filled) > maxGridSteps {
+		t.Errorf("Expected at most %d steps, got %d", maxGridSteps, len(filled))
+	}
+	if filled[0].Value != 1 || filled[len(filled)-1].Value != 2 {
+		t.Errorf("Expected the grid to cover both points, got %v and %v", filled[0].Value, filled[len(filled)-1].Value)
+	}
+}
+
+func TestHatchGaps(t *testing.T) {
+	nan := math.NaN()
+	values := []float64{1, 2, nan, nan, 3, 4}
+	columns := gapColumns(values, len(values))
+
+	expected := []bool{false, false, true, true, false, false}
+	for i := range expected {
+		if columns[i] != expected[i] {
+			t.Errorf("Column %d: expected gap %v, got %v", i, expected[i], columns[i])
+		}
+	}
+
+	graph := " 4.00 ┤    ╭\n 1.00 ┼─╴ ╶╯\n caption"
+	hatched := hatchGaps(graph, columns, true)
+	lines := strings.Split(hatched, "\n")
+
+	for _, line := range lines[:2] {
+		plot := []rune(line)[7:]
+		if plot[2] != hatchRune {
+			t.Errorf("Expected gap column hatched, got %q", line)
+		}
+		if plot[0] == hatchRune || plot[5] == hatchRune {
+			t.Errorf("Data columns should not be hatched, got %q", line)
+		}
+	}
+	if []rune(lines[1])[7+3] != '╶' {
+		t.Errorf("Drawn cells in gap columns should be kept, got %q", lines[1])
+	}
+	if lines[2] != " caption" {
+		t.Errorf("Caption should be untouched, got %q", lines[2])
+	}
+}
+
+func TestHatchGapsColored(t *testing.T) {
+	blue, reset := "\x1b[94m", "\x1b[0m"
+	columns := []bool{false, true, true, false}
+
+	// Escape sequences don't take up a column
+	graph := " 2.00 ┤" + blue + "─" + reset + "  ╭\n 1.00 ┼───╯"
+	hatched := hatchGaps(graph, columns, false)
+
+	expected := " 2.00 ┤" + blue + "─" + reset + "░░╭\n 1.00 ┼───╯"
+	if hatched != expected {
+		t.Errorf("Expected %q, got %q", expected, hatched)
+	}
+
+	if got := cells("a" + blue + "b" + reset); len(got) != 2 || got[1] != blue+"b"+reset {
+		t.Errorf("Expected escapes to stay with their cell, got %q", got)
+	}
+}
+
+func TestFormatGaps(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2024, 1, 1, h, m, 0, 0, time.Local)
+	}
+
+	gaps := []Gap{
+		{Start: at(12, 3), End: at(12, 7)},
+		{Start: at(12, 10), End: at(12, 10)},
+	}
+	if got := formatGaps(gaps); got != "no data 12:03–12:07, 12:10" {
+		t.Errorf("Unexpected footer: %q", got)
+	}
+
+	many := []Gap{gaps[1], gaps[1], gaps[1], gaps[1], gaps[1]}
+	if got := formatGaps(many); !strings.HasSuffix(got, "+2 more") {
+		t.Errorf("Expected overflow note, got %q", got)
+	}
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenNow is the fixed clock golden renders are made at
+var goldenNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// series returns one point per minute over the hour up to goldenNow, with
+// values from f. Points for which f returns ok=false are left out.
+func series(f func(i int) (float64, bool)) []backend.DataPoint {
+	var points []backend.DataPoint
+	for i := 0; i <= 60; i++ {
+		v, ok := f(i)
+		if !ok {
+			continue
+		}
+		points = append(points, backend.DataPoint{
+			Timestamp: goldenNow.Add(time.Duration(i-60) * time.Minute),
+			Value:     v,
+		})
+	}
+	return points
+}
+
+// multiSeries returns n series labeled by instance, with values from f
+// for series s at minute i
+func multiSeries(n int, f func(s, i int) (float64, bool)) []backend.DataPoint {
+	var points []backend.DataPoint
+	for s := 0; s < n; s++ {
+		for _, p := range series(func(i int) (float64, bool) { return f(s, i) }) {
+			p.Series = fmt.Sprintf(`up{instance="host-%d"}`, s)
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// irregular returns a ramp sampled every 10s for the first 20 minutes of
+// the hour and every minute after that, with 5 silent minutes
+func irregular() []backend.DataPoint {
+	var points []backend.DataPoint
+	for offset := 0 * time.Second; offset <= time.Hour; {
+		if offset < 35*time.Minute || offset > 40*time.Minute {
+			points = append(points, backend.DataPoint{
+				Timestamp: goldenNow.Add(offset - time.Hour),
+				Value:     offset.Minutes(),
+			})
+		}
+		if offset < 20*time.Minute {
+			offset += 10 * time.Second
+		} else {
+			offset += time.Minute
+		}
+	}
+	return points
+}
+
+func TestGoldenGraphs(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		points        []backend.DataPoint
+		percentiles   *backend.Percentiles
+		topN          int
+		panelType     string
+	}{
+		{
+			name: "flat", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) { return 50, true }),
+		},
+		{
+			name: "spike", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) {
+				if i == 30 {
+					return 100, true
+				}
+				return 2, true
+			}),
+		},
+		{
+			name: "spike_narrow", width: 44, height: 14,
+			points: series(func(i int) (float64, bool) {
+				if i == 30 {
+					return 100, true
+				}
+				return 2, true
+			}),
+		},
+		{
+			name: "nan_gaps", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) {
+				switch {
+				case i >= 20 && i < 30:
+					return 0, false // missing steps
+				case i == 45 || i == 46:
+					return math.NaN(), true // NaN returned by the backend
+				}
+				return 10 + float64(i%10), true
+			}),
+		},
+		{
+			name: "negative", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) {
+				return -40 * math.Sin(float64(i)/60*2*math.Pi+0.5), true
+			}),
+		},
+		{
+			// Series of several label sets share timestamps and are drawn interleaved
+			name: "multi_series", width: 80, height: 20,
+			points: append(
+				series(func(i int) (float64, bool) { return 20 + float64(i)/6, true }),
+				series(func(i int) (float64, bool) { return 80 - float64(i)/6, true })...,
+			),
+		},
+		{
+			// The 10s samples take as much width as the minutely ones, rather
+			// than most of the graph as when drawn by index
+			name: "irregular", width: 80, height: 20,
+			points: irregular(),
+		},
+		{
+			name: "percentiles", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) {
+				return 20 + float64(i*37%23), true
+			}),
+			percentiles: &backend.Percentiles{Quantiles: []float64{50, 90}, Overlay: true},
+		},
+		{
+			// Rolling lines break at gaps like the series itself
+			name: "percentiles_rolling", width: 80, height: 20,
+			points: series(func(i int) (float64, bool) {
+				if i >= 20 && i < 30 {
+					return 0, false
+				}
+				return 20 + float64(i*37%23), true
+			}),
+			percentiles: &backend.Percentiles{Quantiles: []float64{90}, Window: "10m", Overlay: true},
+		},
+		{
+			// Five instances, of which the three highest now are drawn
+			name: "top_n", width: 80, height: 24,
+			points: multiSeries(5, func(s, i int) (float64, bool) {
+				if s == 1 && i > 40 {
+					return 0, false // instance stopped reporting
+				}
+				return float64(10*s) + float64(i)*float64(s%3)/3, true
+			}),
+			topN: 3,
+		},
+		{
+			// A probe that failed for a few minutes and stopped reporting
+			// for a while
+			name: "bool", width: 80, height: 12,
+			points: series(func(i int) (float64, bool) {
+				switch {
+				case i >= 40 && i < 45:
+					return 0, false
+				case i >= 20 && i < 24:
+					return 0, true
+				}
+				return 1, true
+			}),
+			panelType: backend.PanelBool,
+		},
+		{
+			name: "bool_multi", width: 80, height: 12,
+			points: multiSeries(3, func(s, i int) (float64, bool) {
+				if s == 2 && i%15 == 0 {
+					return 0, true
+				}
+				return 1, true
+			}),
+			panelType: backend.PanelBool,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := backend.Query{Name: "Requests", Expr: "requests", Range: "1h", Type: tt.panelType, Percentiles: tt.percentiles, TopN: tt.topN}
+			h := newHarness(t, []backend.Query{query}, tt.width, tt.height)
+			h.tui.now = func() time.Time { return goldenNow }
+
+			h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: tt.points}, nil)
+			h.sync()
+
+			assertGolden(t, tt.name, h.text()+"\n")
+		})
+	}
+}
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the file
+// instead when the test runs with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Render differs from %s (run with -update to accept):\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
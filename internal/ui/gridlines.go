@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/guptarohit/asciigraph"
+)
+
+// gridRowsPerTick is the spacing aimed for between labeled rows, so taller
+// panels get more grid lines
+const gridRowsPerTick = 4
+
+// gridColor is the color of grid lines
+var gridColor = plotColor{asciigraph.Gray, "gray"}
+
+// gridCell is drawn in the blank cells of a labeled row
+var gridCell = gridColor.ansi.String() + "┈" + asciigraph.Default.String()
+
+// axisValues returns the y-axis label value of each line of an asciigraph
+// plot, NaN for lines without one such as the caption
+func axisValues(graph string) []float64 {
+	lines := strings.Split(graph, "\n")
+	values := make([]float64, len(lines))
+	for i, line := range lines {
+		values[i] = math.NaN()
+		axis := strings.IndexAny(line, "┤┼")
+		if axis < 0 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(line[:axis]), 64); err == nil {
+			values[i] = v
+		}
+	}
+	return values
+}
+
+// niceStep returns the smallest of 1, 2, 2.5 and 5 times a power of ten that
+// is at least raw, along with the decimals needed to write its multiples
+func niceStep(raw float64) (step float64, decimals int) {
+	exp := math.Floor(math.Log10(raw))
+	base := math.Pow(10, exp)
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if m*base >= raw*(1-1e-9) {
+			step = m * base
+			decimals = int(-exp)
+			if m == 2.5 {
+				decimals++
+			}
+			if m == 10 {
+				decimals--
+			}
+			break
+		}
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+	return step, decimals
+}
+
+// gridLines labels the rows of an asciigraph plot nearest to round values
+// and draws a dotted line through their blank cells; the other rows lose
+// their labels. rows holds the axis value of each line as returned by
+// axisValues, and width is the number of plot columns. Plots with fewer than
+// three rows are returned unchanged.
+func gridLines(graph string, rows []float64, width int) string {
+	var plotRows []int
+	for i, v := range rows {
+		if !math.IsNaN(v) {
+			plotRows = append(plotRows, i)
+		}
+	}
+	if len(plotRows) < 3 {
+		return graph
+	}
+
+	top, bottom := rows[plotRows[0]], rows[plotRows[len(plotRows)-1]]
+	rowStep := (top - bottom) / float64(len(plotRows)-1)
+	if rowStep <= 0 {
+		return graph
+	}
+
+	ticks := (len(plotRows) - 2 + gridRowsPerTick) / gridRowsPerTick
+	step, decimals := niceStep((top - bottom) / float64(ticks))
+	if step < rowStep {
+		step, decimals = niceStep(rowStep)
+	}
+
+	// Place each multiple of step on the row whose band it falls in, halving
+	// the step while that labels fewer than two rows
+	var labels map[int]string
+	for {
+		labels = make(map[int]string)
+		first := math.Ceil((bottom - rowStep/2) / step)
+		last := math.Floor((top + rowStep/2) / step)
+		for k := first; k <= last; k++ {
+			v := k * step
+			row := int(math.Round((top - v) / rowStep))
+			if row < 0 || row >= len(plotRows) {
+				continue
+			}
+			if v == 0 {
+				v = 0 // no "-0"
+			}
+			labels[plotRows[row]] = strconv.FormatFloat(v, 'f', decimals, 64)
+		}
+		if len(labels) >= 2 || step/2 < rowStep {
+			break
+		}
+		step, decimals = niceStep(step / 2)
+	}
+	if len(labels) == 0 {
+		return graph
+	}
+	labelWidth := 0
+	for _, label := range labels {
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+
+	lines := strings.Split(graph, "\n")
+	oldWidth := strings.IndexAny(lines[plotRows[0]], "┤┼")
+	newWidth := labelWidth + 1
+	if oldWidth > newWidth {
+		newWidth = oldWidth
+	}
+
+	for i, line := range lines {
+		axis := strings.IndexAny(line, "┤┼")
+		if math.IsNaN(rows[i]) || axis < 0 {
+			if line != "" {
+				lines[i] = strings.Repeat(" ", newWidth-oldWidth) + line
+			}
+			continue
+		}
+
+		label, ok := labels[i]
+		if !ok {
+			rest := line[axis:]
+			if strings.HasPrefix(rest, "┤") {
+				rest = "│" + strings.TrimPrefix(rest, "┤")
+			}
+			lines[i] = strings.Repeat(" ", newWidth) + rest
+			continue
+		}
+
+		plot := cells(line[axis:])
+		for len(plot) < width+1 {
+			plot = append(plot, " ")
+		}
+		for x := 1; x < len(plot); x++ {
+			if c := plot[x]; strings.HasSuffix(c, " ") {
+				plot[x] = strings.TrimSuffix(c, " ") + gridCell
+			}
+		}
+		lines[i] = strings.Repeat(" ", newWidth-len(label)-1) + label + " " + strings.Join(plot, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/guptarohit/asciigraph"
+)
+
+func TestNiceStep(t *testing.T) {
+	tests := []struct {
+		raw      float64
+		step     float64
+		decimals int
+	}{
+		{1, 1, 0},
+		{1.5, 2, 0},
+		{2.2, 2.5, 1},
+		{3, 5, 0},
+		{7, 10, 0},
+		{18, 20, 0},
+		{0.03, 0.05, 2},
+		{0.21, 0.25, 2},
+		{400, 500, 0},
+	}
+
+	for _, tt := range tests {
+		step, decimals := niceStep(tt.raw)
+		if math.Abs(step-tt.step) > 1e-12 || decimals != tt.decimals {
+			t.Errorf("niceStep(%v): expected %v with %d decimals, got %v with %d", tt.raw, tt.step, tt.decimals, step, decimals)
+		}
+	}
+}
+
+func TestAxisValues(t *testing.T) {
+	graph := asciigraph.Plot([]float64{0, 5, 10}, asciigraph.Height(2), asciigraph.Caption("load"))
+	values := axisValues(graph)
+	if len(values) != 4 || values[0] != 10 || values[1] != 5 || values[2] != 0 || !math.IsNaN(values[3]) {
+		t.Errorf("Expected 10, 5, 0 and no value for the caption, got %v", values)
+	}
+}
+
+func TestGridLines(t *testing.T) {
+	data := make([]float64, 20)
+	for i := range data {
+		data[i] = float64(i) * 3.1
+	}
+	graph := asciigraph.Plot(data, asciigraph.Height(12), asciigraph.Width(20), asciigraph.Caption("load"))
+	got := gridLines(graph, axisValues(graph), 20)
+	lines := strings.Split(got, "\n")
+
+	var labels []string
+	for _, line := range lines[:len(lines)-1] {
+		axis := strings.IndexAny(line, "┤┼│")
+		if axis < 0 {
+			t.Fatalf("Every plot row should keep the axis, got:\n%s", got)
+		}
+		if label := strings.TrimSpace(line[:axis]); label != "" {
+			labels = append(labels, label)
+			if !strings.Contains(line, gridCell) {
+				t.Errorf("Labeled row should have a grid line, got %q", line)
+			}
+		} else if strings.Contains(line, gridCell) {
+			t.Errorf("Unlabeled row should not have a grid line, got %q", line)
+		}
+	}
+	if strings.Join(labels, " ") != "60 40 20 0" {
+		t.Errorf("Expected labels at multiples of 20, got %v:\n%s", labels, got)
+	}
+
+	// The caption keeps its position relative to the axis
+	origLines := strings.Split(graph, "\n")
+	shift := strings.IndexAny(lines[0], "┤┼│") - strings.IndexAny(origLines[0], "┤┼")
+	if caption := lines[len(lines)-1]; caption != strings.Repeat(" ", shift)+origLines[len(origLines)-1] {
+		t.Errorf("Caption moved relative to the axis: %q", caption)
+	}
+}
+
+func TestGridLinesDensity(t *testing.T) {
+	data := []float64{0, 100}
+	count := func(height int) int {
+		graph := asciigraph.Plot(data, asciigraph.Height(height))
+		return strings.Count(gridLines(graph, axisValues(graph), 2), gridCell)
+	}
+
+	if short, tall := count(6), count(40); tall <= short {
+		t.Errorf("Taller graphs should get more labeled rows, got %d for 6 rows and %d for 40", short, tall)
+	}
+}
+
+func TestGridLinesShortPlot(t *testing.T) {
+	graph := asciigraph.Plot([]float64{1, 2}, asciigraph.Height(1))
+	if got := gridLines(graph, axisValues(graph), 2); got != graph {
+		t.Errorf("Plots with fewer than three rows should be unchanged, got:\n%s", got)
+	}
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+// harnessTimeout bounds every wait on the UI event loop so a deadlock fails
+// the test instead of hanging it
+const harnessTimeout = 5 * time.Second
+
+// harness runs a TUI on a headless simulation screen so tests can send keys
+// and inspect what was actually drawn
+type harness struct {
+	t      *testing.T
+	tui    *TUI
+	screen tcell.SimulationScreen
+	keys   chan struct{} // signalled when a key reaches the input capture
+	done   chan error    // receives the result of Run
+	quit   bool          // set when the quit handler was called
+}
+
+// newHarness starts a TUI for the queries on a width x height screen. It is
+// stopped when the test ends.
+func newHarness(t *testing.T, queries []backend.Query, width, height int) *harness {
+	t.Helper()
+
+	h := &harness{
+		t:      t,
+		screen: tcell.NewSimulationScreen("UTF-8"),
+		keys:   make(chan struct{}, 16),
+		done:   make(chan error, 1),
+	}
+	h.tui = NewTUI(queries, func() { h.quit = true })
+
+	// Run initializes the screen at its default size
+	h.tui.app.SetScreen(h.screen)
+	h.screen.SetSize(width, height)
+
+	capture := h.tui.app.GetInputCapture()
+	h.tui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		defer func() { h.keys <- struct{}{} }()
+		return capture(event)
+	})
+
+	go func() {
+		h.done <- h.tui.Run()
+	}()
+	t.Cleanup(h.stop)
+
+	h.sync()
+	return h
+}
+
+// sync waits until the event loop has processed everything queued so far
+// and redrawn the screen
+func (h *harness) sync() {
+	h.t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		h.tui.app.QueueUpdateDraw(func() {})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(harnessTimeout):
+		h.t.Fatal("UI event loop did not respond")
+	}
+}
+
+// press sends a key to the application and waits until it was handled
+func (h *harness) press(key tcell.Key, r rune) {
+	h.t.Helper()
+
+	h.screen.InjectKey(key, r, tcell.ModNone)
+	select {
+	case <-h.keys:
+	case <-time.After(harnessTimeout):
+		h.t.Fatalf("Key %v %q was not delivered", key, r)
+	}
+
+	// The event loop finishes handling the key before it runs queued updates
+	h.sync()
+}
+
+// typeRune presses a printable key
+func (h *harness) typeRune(r rune) {
+	h.t.Helper()
+	h.press(tcell.KeyRune, r)
+}
+
+// resize changes the screen size and redraws
+func (h *harness) resize(width, height int) {
+	h.t.Helper()
+	h.screen.SetSize(width, height)
+	h.sync()
+}
+
+// lines returns the screen content row by row, without trailing spaces
+func (h *harness) lines() []string {
+	cells, width, height := h.screen.GetContents()
+
+	lines := make([]string, height)
+	for y := 0; y < height; y++ {
+		var b strings.Builder
+		for x := 0; x < width; x++ {
+			runes := cells[y*width+x].Runes
+			if len(runes) == 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteString(string(runes))
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+// text returns the whole screen content
+func (h *harness) text() string {
+	return strings.Join(h.lines(), "\n")
+}
+
+// find returns the position of the first occurrence of s on screen
+func (h *harness) find(s string) (x, y int, ok bool) {
+	for y, line := range h.lines() {
+		if i := strings.Index(line, s); i >= 0 {
+			return len([]rune(line[:i])), y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// style returns the style of the cell at x, y
+func (h *harness) style(x, y int) tcell.Style {
+	cells, width, _ := h.screen.GetContents()
+	return cells[y*width+x].Style
+}
+
+// assertContains fails the test if s is not on screen
+func (h *harness) assertContains(s string) {
+	h.t.Helper()
+	if !strings.Contains(h.text(), s) {
+		h.t.Errorf("Expected screen to contain %q, got:\n%s", s, h.text())
+	}
+}
+
+// assertNotContains fails the test if s is on screen
+func (h *harness) assertNotContains(s string) {
+	h.t.Helper()
+	if strings.Contains(h.text(), s) {
+		h.t.Errorf("Expected screen not to contain %q, got:\n%s", s, h.text())
+	}
+}
+
+// stop shuts the application down and checks that Run returned cleanly
+func (h *harness) stop() {
+	h.tui.Stop()
+	select {
+	case err := <-h.done:
+		if err != nil {
+			h.t.Errorf("Run returned error: %v", err)
+		}
+		h.done <- err // allow stop to be called again
+	case <-time.After(harnessTimeout):
+		h.t.Error("Run did not return after Stop")
+	}
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+// EnableHeader adds a row above the panels showing the dashboard title, the
+// clock, the uptime since started and how fresh the data is. Call before Run.
+func (t *TUI) EnableHeader(title string, started time.Time) {
+	t.header = tview.NewTextView()
+	t.header.SetDynamicColors(true)
+	t.header.SetTextAlign(tview.AlignCenter)
+	t.headerTitle = title
+	t.started = started
+
+	t.flex.Clear()
+	t.flex.AddItem(t.header, 1, 0, false)
+	t.flex.AddItem(t.scrollView, 0, 1, true)
+	t.flex.AddItem(t.timeRange, 1, 0, false)
+	t.flex.AddItem(t.instructions, 1, 0, false)
+
+	t.updateHeader()
+}
+
+// RefreshHeader updates the clock, uptime and data freshness in the header
+func (t *TUI) RefreshHeader() {
+	t.queueUpdateDraw(t.updateHeader)
+}
+
+// updateHeader redraws the header row, if enabled
+func (t *TUI) updateHeader() {
+	if t.header == nil {
+		return
+	}
+	t.header.SetText(t.headerText(t.now()))
+}
+
+// headerText renders the header row at now
+func (t *TUI) headerText(now time.Time) string {
+	text := t.lang.Sprintf("[yellow]%s[white]  │  %s  │  up %s  │  ",
+		tview.Escape(t.headerTitle), now.Format("15:04:05"), formatUptime(now.Sub(t.started)))
+
+	age, stale, ok := t.freshness(now)
+	switch {
+	case !ok:
+		text += t.lang.T("[gray]waiting for data[white]")
+	case stale > 0:
+		text += t.lang.Sprintf("[red]data %s old, %d stale panels[white]", formatAge(age), stale)
+	default:
+		text += t.lang.Sprintf("[green]data %s old[white]", formatAge(age))
+	}
+	return text
+}
+
+// freshness returns the age of the oldest latest point across panels, i.e.
+// how far behind the most lagging panel is, and how many panels exceed
+// their max_age. ok is false until some panel has data.
+func (t *TUI) freshness(now time.Time) (age time.Duration, stale int, ok bool) {
+	for i, history := range t.histories {
+		var newest time.Time
+		for _, ts := range []*backend.TimeSeriesResult{history.TimeSeries, history.Good, history.Total} {
+			if ts == nil {
+				continue
+			}
+			for _, p := range ts.Points {
+				if p.Timestamp.After(newest) {
+					newest = p.Timestamp
+				}
+			}
+		}
+		if newest.IsZero() {
+			continue
+		}
+
+		q := t.queries[i]
+		if a := q.TimeRangeAt(now).End.Sub(newest); !ok || a > age {
+			age = a
+		}
+		ok = true
+		if _, isStale := staleAge(q, newest, now); isStale {
+			stale++
+		}
+	}
+	if age < 0 {
+		age = 0
+	}
+	return age, stale, ok
+}
+
+// formatUptime renders a duration in its two largest units, e.g. "3h05m"
+func formatUptime(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%02dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d/time.Minute), int(d%time.Minute/time.Second))
+	default:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+}
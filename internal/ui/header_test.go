@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestHeader(t *testing.T) {
+	queries := []backend.Query{
+		{Name: "Requests", Expr: "requests"},
+		{Name: "Heartbeat", Expr: "heartbeat", MaxAge: "5m"},
+		{Name: "Billing", Expr: "cost", Offset: "1h"},
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	h := newHarness(t, queries, 160, 30)
+	h.tui.now = func() time.Time { return now }
+	h.tui.queueUpdateDraw(func() { h.tui.EnableHeader("Payments on-call", now.Add(-(2*time.Hour + 5*time.Minute))) })
+	h.sync()
+
+	h.assertContains("Payments on-call  │  12:00:00  │  up 2h05m  │  waiting for data")
+	lines := h.lines()
+	if lines[0] == "" || !strings.HasPrefix(lines[1], "╔") {
+		t.Errorf("Expected the header above the panels, got:\n%s", h.text())
+	}
+
+	point := func(age time.Duration) *backend.TimeSeriesResult {
+		return &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: now.Add(-age), Value: 1}}}
+	}
+	h.tui.UpdateTimeSeries(0, point(20*time.Second), nil)
+	h.tui.UpdateTimeSeries(1, point(90*time.Second), nil)
+	// Lags by its offset, which doesn't count as old
+	h.tui.UpdateTimeSeries(2, point(time.Hour+30*time.Second), nil)
+	h.tui.RefreshHeader()
+	h.sync()
+	h.assertContains("data 1m old")
+
+	h.tui.UpdateTimeSeries(1, point(10*time.Minute), nil)
+	h.tui.RefreshHeader()
+	h.sync()
+	h.assertContains("data 10m old, 1 stale panels")
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := map[time.Duration]string{
+		42 * time.Second:              "42s",
+		3*time.Minute + 7*time.Second: "3m07s",
+		5*time.Hour + 30*time.Minute:  "5h30m",
+		50*time.Hour + 59*time.Minute: "2d02h",
+	}
+	for d, want := range tests {
+		if got := formatUptime(d); got != want {
+			t.Errorf("formatUptime(%v) = %q, expected %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+// renderHealth renders the health report of a backend for the given panel
+func (t *TUI) renderHealth(index int) {
+	report := t.histories[index].Health
+	panel := t.panels[index]
+
+	var b strings.Builder
+	if report.Status == backend.HealthPass {
+		b.WriteString("[green]Status: pass[white]")
+	} else {
+		b.WriteString("[red]Status: fail[white]")
+	}
+	if report.Version != "" {
+		fmt.Fprintf(&b, " [gray]%s[white]", tview.Escape(report.Version))
+	}
+	if report.Message != "" {
+		fmt.Fprintf(&b, "\n[gray]%s[white]", tview.Escape(report.Message))
+	}
+
+	b.WriteString("\n\nSeries per bucket:")
+	for _, bucket := range report.Buckets {
+		if bucket.Err != "" {
+			fmt.Fprintf(&b, "\n  %s  [red]%s[white]", tview.Escape(bucket.Bucket), tview.Escape(bucket.Err))
+			continue
+		}
+		fmt.Fprintf(&b, "\n  %s  %s", tview.Escape(bucket.Bucket), t.numbers.Float(float64(bucket.Series), 0))
+	}
+
+	switch {
+	case report.TasksErr != "":
+		fmt.Fprintf(&b, "\n\n[yellow]Tasks: %s[white]", tview.Escape(report.TasksErr))
+	case len(report.FailingTasks) == 0:
+		b.WriteString("\n\n[green]No failing tasks[white]")
+	default:
+		fmt.Fprintf(&b, "\n\n[red]Failing tasks: %d[white]", len(report.FailingTasks))
+		for _, task := range report.FailingTasks {
+			fmt.Fprintf(&b, "\n  %s", tview.Escape(task.Name))
+			if !task.LastRun.IsZero() {
+				fmt.Fprintf(&b, " [gray](%s ago)[white]", formatAge(t.now().Sub(task.LastRun)))
+			}
+			if task.Error != "" {
+				fmt.Fprintf(&b, "\n    [gray]%s[white]", tview.Escape(task.Error))
+			}
+		}
+	}
+
+	panel.SetText(b.String())
+}
+
+// UpdateHealth updates a health panel with a new report
+func (t *TUI) UpdateHealth(index int, report *backend.HealthReport, err error) {
+	if index < 0 || index >= len(t.histories) {
+		return
+	}
+
+	if err != nil {
+		t.histories[index].LastError = err
+	} else {
+		t.histories[index].Health = report
+		t.histories[index].LastError = nil
+		t.clearThrottled(index)
+	}
+
+	if t.app != nil && len(t.panels) > index {
+		t.queueUpdateDraw(func() {
+			if err != nil {
+				t.panels[index].SetText(t.errorText(index, err))
+			} else {
+				t.renderHealth(index)
+			}
+			t.updateInstructions()
+			t.refreshInspect(index)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestUpdateHealth(t *testing.T) {
+	h := newHarness(t, []backend.Query{{ID: "influx", Name: "Influx", Type: backend.PanelHealth}}, 140, 30)
+	h.tui.now = func() time.Time { return time.Date(2023, 1, 1, 0, 30, 0, 0, time.UTC) }
+
+	h.tui.UpdateHealth(0, &backend.HealthReport{
+		Status:  backend.HealthFail,
+		Version: "v2.7.1",
+		Message: "storage engine unavailable",
+		Buckets: []backend.BucketCardinality{
+			{Bucket: "metrics", Series: 1234},
+			{Bucket: "logs", Err: "bucket not found"},
+		},
+		FailingTasks: []backend.FailingTask{
+			{Name: "downsample", Error: "could not write", LastRun: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}, nil)
+	waitForText(h, "Status: fail")
+	for _, want := range []string{"v2.7.1", "storage engine unavailable", "metrics  1234", "logs  bucket not found",
+		"Failing tasks: 1", "downsample (30m ago)", "could not write"} {
+		if !strings.Contains(h.text(), want) {
+			t.Errorf("Expected %q on screen:\n%s", want, h.text())
+		}
+	}
+
+	h.tui.UpdateHealth(0, &backend.HealthReport{
+		Status:   backend.HealthPass,
+		Buckets:  []backend.BucketCardinality{{Bucket: "metrics", Series: 1234}},
+		TasksErr: "unauthorized access",
+	}, nil)
+	waitForText(h, "Status: pass")
+	if !strings.Contains(h.text(), "Tasks: unauthorized access") {
+		t.Errorf("Expected the tasks error on screen:\n%s", h.text())
+	}
+
+	h.tui.UpdateHealth(0, nil, &backend.AuthError{Err: errors.New("unauthorized")})
+	waitForText(h, "Authentication failed")
+	if h.tui.histories[0].Health == nil {
+		t.Error("An error should keep the last report")
+	}
+}
+
+func TestRenderHealthNoFailingTasks(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Influx", Type: backend.PanelHealth}}, nil)
+	tui.histories[0].Health = &backend.HealthReport{Status: backend.HealthPass, Buckets: []backend.BucketCardinality{{Bucket: "metrics", Series: 1234567}}}
+	tui.numbers.Thousands = ","
+
+	tui.renderHealth(0)
+	text := tui.panels[0].GetText(true)
+	if !strings.Contains(text, "metrics  1,234,567") || !strings.Contains(text, "No failing tasks") {
+		t.Errorf("Unexpected health panel:\n%s", text)
+	}
+}
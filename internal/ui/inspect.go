@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/stats"
+	"promviz/internal/topn"
+)
+
+const (
+	// inspectMinWidth is the terminal width from which a maximized panel
+	// gets the inspect column next to it
+	inspectMinWidth = 160
+
+	inspectWidth  = 48 // columns taken by the inspect column
+	inspectAlerts = 5  // recent transitions listed
+	inspectPoints = 10 // latest raw points listed
+	inspectSeries = 8  // series listed in the legend
+)
+
+// setupInspect creates the side column shown next to a maximized panel and
+// tracks whether the terminal is wide enough for it
+func (t *TUI) setupInspect() {
+	t.inspect = tview.NewTextView()
+	t.inspect.SetDynamicColors(true)
+	t.inspect.SetWordWrap(false)
+	t.inspect.SetBorder(true)
+	t.inspect.SetTitle(" Inspect ")
+
+	t.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		width, _ := screen.Size()
+		if wide := width >= inspectMinWidth; wide != t.wide {
+			t.wide = wide
+			if t.maximized {
+				t.updateScrollView()
+			}
+		}
+		return false
+	})
+}
+
+// toggleMaximized switches between showing the focused panel alone and the
+// normal layout
+func (t *TUI) toggleMaximized() {
+	if len(t.panels) == 0 {
+		return
+	}
+	t.maximized = !t.maximized
+	t.updateScrollView()
+	t.updateFocus()
+	t.updateInstructions()
+
+	// Graphs are sized to their panel, which only changes once drawn
+	go t.queueUpdateDraw(t.redrawPanels)
+}
+
+// redrawPanels renders the data of every panel again at its current size
+func (t *TUI) redrawPanels() {
+	for i, history := range t.histories {
+		switch {
+		case history.LastError != nil:
+			continue
+		case t.queries[i].PanelType() == backend.PanelSLO:
+			if history.Good != nil {
+				t.renderSLO(i)
+			}
+		case t.queries[i].PanelType() == backend.PanelHealth:
+			if history.Health != nil {
+				t.renderHealth(i)
+			}
+		case len(history.TimeSeries.Points) > 0:
+			t.renderTimeSeriesGraph(i)
+		}
+	}
+}
+
+// refreshInspect updates the inspect column if it shows the panel at index
+func (t *TUI) refreshInspect(index int) {
+	if t.maximized && t.wide && index == t.focusIndex {
+		t.inspect.SetText(t.inspectText(index))
+	}
+}
+
+// inspectText renders the statistics, legend, thresholds, recent alerts and
+// latest raw points of a panel
+func (t *TUI) inspectText(index int) string {
+	q := t.queries[index]
+	history := t.histories[index]
+
+	var points []backend.DataPoint
+	if history.TimeSeries != nil {
+		points = make([]backend.DataPoint, len(history.TimeSeries.Points))
+		copy(points, history.TimeSeries.Points)
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow]%s[white]\n", tview.Escape(q.Name))
+	if history.LastError != nil {
+		fmt.Fprintf(&b, "[red]%s[white]\n", tview.Escape(truncate(history.LastError.Error(), inspectWidth-4)))
+	}
+	if history.TimeSeries != nil && history.TimeSeries.Truncation != nil {
+		fmt.Fprintf(&b, "[yellow]%s[white]\n", history.TimeSeries.Truncation)
+	}
+	if warnings := panelWarnings(history); len(warnings) > 0 {
+		fmt.Fprintf(&b, "[yellow]Partial result, %d warnings (i)[white]\n", len(warnings))
+	}
+
+	b.WriteString("\n[yellow]Statistics[white]\n")
+	if s := stats.Summarize(points); s.Count > 0 {
+		unit := t.unit(index)
+		n := func(v float64) string { return formatValue(v, unit, t.numbers) }
+		fmt.Fprintf(&b, "[gray]Last[white] %-10s [gray]Avg[white] %s\n", n(s.Last), n(s.Avg))
+		fmt.Fprintf(&b, "[gray]Min[white]  %-10s [gray]Max[white] %s\n", n(s.Min), n(s.Max))
+		fmt.Fprintf(&b, "[gray]p50[white]  %-10s [gray]p90[white] %s\n", n(s.P50), n(s.P90))
+		fmt.Fprintf(&b, "[gray]p99[white]  %-10s [gray]Points[white] %d\n", n(s.P99), s.Count)
+	} else {
+		b.WriteString("[gray]No data[white]\n")
+	}
+
+	if legend := t.inspectLegend(q, points); len(legend) > 0 {
+		b.WriteString("\n[yellow]Legend[white]\n")
+		for _, line := range legend {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if th := q.Thresholds; th != nil {
+		b.WriteString("\n[yellow]Thresholds[white]\n")
+		writeThresholds(&b, th, t.numbers)
+		if latest, ok := alert.Latest(&backend.TimeSeriesResult{Points: points}); ok {
+			level := alert.Evaluate(th, latest.Value)
+			fmt.Fprintf(&b, "[gray]Now:[white]   [%s]%s[white]\n", level.Color(), level)
+		}
+	}
+
+	b.WriteString("\n[yellow]Recent alerts[white]\n")
+	listed := 0
+	for i := len(t.breaches) - 1; i >= 0 && listed < inspectAlerts; i-- {
+		tr := t.breaches[i]
+		if tr.ID != q.ID {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s → [%s]%s[white] %s\n",
+			tr.Time.Local().Format("01-02 15:04"), tr.From, levelColor(tr.To), tr.To, t.numbers.Float(tr.Value, 2))
+		listed++
+	}
+	if listed == 0 {
+		b.WriteString("[gray]None[white]\n")
+	}
+
+	b.WriteString("\n[yellow]Latest points[white]\n")
+	if len(points) == 0 {
+		b.WriteString("[gray]None[white]\n")
+	}
+	for i := len(points) - 1; i >= 0 && i >= len(points)-inspectPoints; i-- {
+		p := points[i]
+		line := fmt.Sprintf("%s %10s", p.Timestamp.Local().Format("15:04:05"), t.numbers.Float(p.Value, 2))
+		if p.Series != "" {
+			line += " " + truncate(p.Series, inspectWidth-4-len([]rune(line))-1)
+		}
+		b.WriteString(tview.Escape(line) + "\n")
+	}
+
+	return b.String()
+}
+
+// inspectLegend lists the lines plotted in a panel with their colors
+func (t *TUI) inspectLegend(q backend.Query, points []backend.DataPoint) []string {
+	var lines []string
+	entry := func(color, name string) {
+		lines = append(lines, fmt.Sprintf("[%s]━━[white] %s", color, tview.Escape(truncate(name, inspectWidth-7))))
+	}
+
+	if q.TopN > 0 {
+		top, hidden := topn.Select(points, q.TopN)
+		for i, s := range top {
+			entry(seriesPalette[i%len(seriesPalette)].tag, seriesLabel(s.Name))
+		}
+		if hidden > 0 {
+			lines = append(lines, fmt.Sprintf("[gray]%d more series hidden[white]", hidden))
+		}
+		return lines
+	}
+
+	series := topn.Split(points)
+	for i, s := range series {
+		if i == inspectSeries {
+			lines = append(lines, fmt.Sprintf("[gray]+%d more series[white]", len(series)-inspectSeries))
+			break
+		}
+		if s.Name != "" {
+			entry("white", s.Name)
+		}
+	}
+	if p := q.Percentiles; p != nil && p.Overlay {
+		for i, level := range p.Levels() {
+			entry(overlayColors[i%len(overlayColors)].tag, fmt.Sprintf("p%g", level))
+		}
+	}
+	return lines
+}
+
+// writeThresholds lists the warn and crit levels of a query
+func writeThresholds(b *strings.Builder, th *backend.Thresholds, f numfmt.Format) {
+	direction := "above"
+	if th.Below {
+		direction = "below"
+	}
+	if th.Warn != nil {
+		fmt.Fprintf(b, "[gray]Warn:[white]  %s %s\n", direction, f.Sprintf("%g", *th.Warn))
+	}
+	if th.Crit != nil {
+		fmt.Fprintf(b, "[gray]Crit:[white]  %s %s\n", direction, f.Sprintf("%g", *th.Crit))
+	}
+}
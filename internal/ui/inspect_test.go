@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+)
+
+func TestMaximizeWithInspectColumn(t *testing.T) {
+	warn := 40.0
+	queries := []backend.Query{
+		{ID: "latency", Name: "Latency", Expr: "latency", Thresholds: &backend.Thresholds{Warn: &warn}},
+		{ID: "errors", Name: "Errors", Expr: "errors"},
+	}
+	h := newHarness(t, queries, 200, 40)
+
+	now := time.Now()
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now.Add(-2 * time.Minute), Value: 10, Series: `latency{pod="api-1"}`},
+		{Timestamp: now.Add(-time.Minute), Value: 30, Series: `latency{pod="api-1"}`},
+		{Timestamp: now, Value: 50, Series: `latency{pod="api-1"}`},
+	}}, nil)
+	h.tui.AddBreach(alert.Transition{ID: "latency", Query: "Latency", From: "ok", To: "warning", Value: 50, Threshold: 40, Time: now})
+	h.tui.AddBreach(alert.Transition{ID: "errors", Query: "Errors", From: "ok", To: "critical", Value: 9, Threshold: 5, Time: now})
+	h.sync()
+
+	h.typeRune('z')
+	h.assertNotContains(" Errors ")
+	h.assertContains("Maximized")
+	for _, want := range []string{
+		" Inspect ",
+		"Last 50.00",
+		"Max 50.00",
+		`━━ latency{pod="api-1"}`,
+		"Warn:  above 40",
+		"Now:   warning",
+		"ok → warning 50.00",
+		`30.00 latency{pod="api-1"}`,
+	} {
+		h.assertContains(want)
+	}
+	h.assertNotContains("critical")
+
+	// The next panel is shown maximized in its place
+	h.press(tcell.KeyTab, 0)
+	h.assertContains(" Errors ")
+	h.assertNotContains(" Latency ")
+
+	h.press(tcell.KeyEscape, 0)
+	h.assertContains(" Latency ")
+	h.assertNotContains(" Inspect ")
+}
+
+func TestMaximizeNarrowTerminal(t *testing.T) {
+	h := newHarness(t, screenQueries, 120, 30)
+
+	h.typeRune('z')
+	h.assertContains(" Query 1 ")
+	h.assertNotContains(" Query 2 ")
+	h.assertNotContains(" Inspect ")
+
+	// Widening the terminal brings the column in
+	h.resize(180, 30)
+	h.sync()
+	h.assertContains(" Inspect ")
+
+	h.typeRune('z')
+	if !strings.Contains(h.text(), " Query 3 ") {
+		t.Errorf("Restoring should show the page of panels again, got:\n%s", h.text())
+	}
+}
@@ -0,0 +1,15 @@
+package ui
+
+import "promviz/internal/i18n"
+
+// SetLanguage sets the language of the help line, status bar, panel
+// messages and errors. Call before Run.
+func (t *TUI) SetLanguage(c i18n.Catalog) {
+	t.lang = c
+	for _, panel := range t.panels {
+		panel.SetText(c.T("Initializing..."))
+	}
+	t.updateTimeRange()
+	t.updateInstructions()
+	t.updateHeader()
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"promviz/internal/backend"
+	"promviz/internal/i18n"
+)
+
+func TestSetLanguage(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "API", Expr: "up"}, {Name: "Web", Expr: "web"}}, nil)
+	de, _ := i18n.Language("de")
+	tui.SetLanguage(de)
+
+	if got := tui.panels[0].GetText(false); got != "Initialisiere..." {
+		t.Errorf("Expected the panels to initialize in German, got %q", got)
+	}
+	if got := tui.instructions.GetText(false); !strings.Contains(got, "Pfeiltasten") {
+		t.Errorf("Expected the help line in German, got %q", got)
+	}
+	if got := tui.timeRange.GetText(false); !strings.Contains(got, "Warte auf Daten") {
+		t.Errorf("Expected the status bar in German, got %q", got)
+	}
+
+	tui.SetDiagnostics([]string{"unknown field"})
+	if got := tui.instructions.GetText(false); !strings.Contains(got, "1 Konfigurationswarnungen (d)") {
+		t.Errorf("Expected the warning count in German, got %q", got)
+	}
+
+	tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: pointsEvery(3, goldenNow)}, nil)
+	tui.renderTimeSeriesGraph(0)
+	if got := tui.panels[0].GetText(false); !strings.Contains(got, "Aktuell: 0.00") || !strings.Contains(got, "API Zeitreihe") {
+		t.Errorf("Expected the panel in German, got %q", got)
+	}
+
+	auth := &backend.AuthError{Err: errors.New("401 Unauthorized")}
+	if got := tui.errorText(1, auth); !strings.Contains(got, "Authentifizierung fehlgeschlagen") || !strings.Contains(got, "401 Unauthorized") {
+		t.Errorf("Expected the error heading in German with the backend's message, got %q", got)
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"time"
+
+	"promviz/internal/state"
+)
+
+// refreshSteps are the refresh intervals + and - step through
+var refreshSteps = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second,
+	30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute,
+}
+
+// SetState restores the customizations of an earlier session: the panel
+// order, hidden panels, refresh interval, acknowledged alerts and muted
+// tags. Panels missing from the saved order follow in config order. Call
+// before Run.
+func (t *TUI) SetState(s state.State) {
+	placed := make(map[int]bool)
+	var layout []int
+	for _, id := range s.Order {
+		if i := t.panelIndex(id); i >= 0 && !placed[i] {
+			placed[i] = true
+			layout = append(layout, i)
+		}
+	}
+	for i := range t.queries {
+		if !placed[i] {
+			layout = append(layout, i)
+		}
+	}
+	t.layout = layout
+
+	t.hidden = make(map[int]bool)
+	for _, id := range s.Hidden {
+		if i := t.panelIndex(id); i >= 0 {
+			t.hidden[i] = true
+		}
+	}
+	if len(t.hidden) == len(t.queries) {
+		// Never start with an empty screen
+		t.hidden = make(map[int]bool)
+	}
+
+	t.refresh = s.Refresh
+	t.acked = make(map[string]time.Time)
+	for id, at := range s.Acknowledged {
+		t.acked[id] = at
+	}
+	t.muted = make(map[string]bool)
+	for _, tag := range s.Muted {
+		t.muted[tag] = true
+	}
+
+	t.rebuildShown()
+	if len(t.shown) > 0 {
+		t.focusIndex = t.shown[0]
+	}
+	t.scrollOffset = 0
+	t.updateScrollView()
+	t.updateFocus()
+	t.updateInstructions()
+}
+
+// SetStateHandler sets the function called with the customizations whenever
+// the user changes them. Call before Run.
+func (t *TUI) SetStateHandler(onState func(state.State)) {
+	t.onState = onState
+}
+
+// currentState collects the customizations for the state handler
+func (t *TUI) currentState() state.State {
+	s := state.State{Refresh: t.refresh}
+	for _, i := range t.layout {
+		s.Order = append(s.Order, t.queries[i].ID)
+	}
+	for i, q := range t.queries {
+		if t.hidden[i] {
+			s.Hidden = append(s.Hidden, q.ID)
+		}
+	}
+	if len(t.acked) > 0 {
+		s.Acknowledged = make(map[string]time.Time, len(t.acked))
+		for id, at := range t.acked {
+			s.Acknowledged[id] = at
+		}
+	}
+	s.Muted = t.mutedTags()
+	return s
+}
+
+// stateChanged passes the customizations to the state handler
+func (t *TUI) stateChanged() {
+	if t.onState != nil {
+		t.onState(t.currentState())
+	}
+}
+
+// rebuildShown lists the panels of the layout that aren't hidden
+func (t *TUI) rebuildShown() {
+	t.shown = t.shown[:0]
+	for _, i := range t.layout {
+		if !t.hidden[i] {
+			t.shown = append(t.shown, i)
+		}
+	}
+}
+
+// position returns where the panel at index is shown, -1 if it is hidden
+func (t *TUI) position(index int) int {
+	for pos, i := range t.shown {
+		if i == index {
+			return pos
+		}
+	}
+	return -1
+}
+
+// hideFocused takes the focused panel off the screen and focuses the next
+// one. The last shown panel can't be hidden.
+func (t *TUI) hideFocused() {
+	if len(t.shown) <= 1 {
+		return
+	}
+
+	pos := t.position(t.focusIndex)
+	t.hidden[t.focusIndex] = true
+	t.rebuildShown()
+	if pos >= len(t.shown) {
+		pos = len(t.shown) - 1
+	}
+	t.focusIndex = t.shown[pos]
+
+	t.scrollToShowFocus()
+	t.updateFocus()
+	t.updateInstructions()
+	t.stateChanged()
+}
+
+// showHidden brings back all hidden panels
+func (t *TUI) showHidden() {
+	if len(t.hidden) == 0 {
+		return
+	}
+
+	t.hidden = make(map[int]bool)
+	t.rebuildShown()
+	t.scrollToShowFocus()
+	t.updateFocus()
+	t.updateInstructions()
+	t.stateChanged()
+
+	// Panels rendered while hidden were sized for no space
+	go t.queueUpdateDraw(t.redrawPanels)
+}
+
+// moveFocused swaps the focused panel with its shown neighbor, delta -1
+// for the left and 1 for the right one
+func (t *TUI) moveFocused(delta int) {
+	pos := t.position(t.focusIndex)
+	if pos < 0 || pos+delta < 0 || pos+delta >= len(t.shown) {
+		return
+	}
+
+	a, b := -1, -1
+	for i, index := range t.layout {
+		switch index {
+		case t.focusIndex:
+			a = i
+		case t.shown[pos+delta]:
+			b = i
+		}
+	}
+	t.layout[a], t.layout[b] = t.layout[b], t.layout[a]
+	t.rebuildShown()
+
+	t.scrollToShowFocus()
+	t.updateFocus()
+	t.stateChanged()
+}
+
+// stepRefresh switches to the next shorter refresh interval for a negative
+// direction and the next longer one otherwise. It does nothing unless the
+// refresh interval was set with SetState.
+func (t *TUI) stepRefresh(direction int) {
+	if t.refresh == 0 {
+		return
+	}
+
+	next := t.refresh
+	if direction < 0 {
+		for i := len(refreshSteps) - 1; i >= 0; i-- {
+			if refreshSteps[i] < t.refresh {
+				next = refreshSteps[i]
+				break
+			}
+		}
+	} else {
+		for _, step := range refreshSteps {
+			if step > t.refresh {
+				next = step
+				break
+			}
+		}
+	}
+	if next == t.refresh {
+		return
+	}
+
+	t.refresh = next
+	t.instructions.SetText(t.lang.Sprintf("[green]Refreshing every %s[white]", formatAge(next)))
+	t.stateChanged()
+}
+
+// acknowledge marks a transition and the earlier ones of its query as
+// acknowledged
+func (t *TUI) acknowledge(id string, at time.Time) {
+	if t.isAcked(id, at) {
+		return
+	}
+	t.acked[id] = at
+	t.stateChanged()
+}
+
+// isAcked reports whether a transition of the query with the given ID at
+// time at has been acknowledged
+func (t *TUI) isAcked(id string, at time.Time) bool {
+	acked, ok := t.acked[id]
+	return ok && !at.After(acked)
+}
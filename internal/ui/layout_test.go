@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+	"promviz/internal/state"
+)
+
+func layoutQueries() []backend.Query {
+	return []backend.Query{
+		{ID: "cpu", Name: "CPU", Expr: "cpu"},
+		{ID: "mem", Name: "Memory", Expr: "mem"},
+		{ID: "disk", Name: "Disk", Expr: "disk"},
+		{ID: "net", Name: "Network", Expr: "net"},
+	}
+}
+
+func TestSetState(t *testing.T) {
+	tui := NewTUI(layoutQueries(), nil)
+	tui.SetState(state.State{
+		Order:   []string{"net", "removed", "cpu"},
+		Hidden:  []string{"net"},
+		Refresh: 10 * time.Second,
+	})
+
+	// Saved order first, then the panels it doesn't mention
+	if want := []int{3, 0, 1, 2}; !reflect.DeepEqual(tui.layout, want) {
+		t.Errorf("Expected layout %v, got %v", want, tui.layout)
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(tui.shown, want) {
+		t.Errorf("Expected shown panels %v, got %v", want, tui.shown)
+	}
+	if tui.focusIndex != 0 || tui.refresh != 10*time.Second {
+		t.Errorf("Expected focus on CPU and a 10s refresh, got %d and %v", tui.focusIndex, tui.refresh)
+	}
+
+	s := tui.currentState()
+	if !reflect.DeepEqual(s.Order, []string{"net", "cpu", "mem", "disk"}) || !reflect.DeepEqual(s.Hidden, []string{"net"}) {
+		t.Errorf("Unexpected state %+v", s)
+	}
+}
+
+func TestSetStateAllHidden(t *testing.T) {
+	tui := NewTUI(layoutQueries()[:2], nil)
+	tui.SetState(state.State{Hidden: []string{"cpu", "mem"}})
+
+	if len(tui.shown) != 2 {
+		t.Errorf("Expected all panels shown rather than none, got %v", tui.shown)
+	}
+}
+
+func TestHideAndMovePanels(t *testing.T) {
+	tui := NewTUI(layoutQueries(), nil)
+	var saved []state.State
+	tui.SetStateHandler(func(s state.State) { saved = append(saved, s) })
+
+	tui.focusNext()
+	tui.hideFocused()
+	if tui.focusIndex != 2 || !reflect.DeepEqual(tui.shown, []int{0, 2, 3}) {
+		t.Errorf("Expected Memory hidden and Disk focused, got focus %d and %v", tui.focusIndex, tui.shown)
+	}
+
+	// Focus skips hidden panels
+	tui.focusPrev()
+	if tui.focusIndex != 0 {
+		t.Errorf("Expected focus on CPU, got %d", tui.focusIndex)
+	}
+
+	// Moving swaps with the shown neighbor, past the hidden panel
+	tui.moveFocused(1)
+	if want := []int{2, 1, 0, 3}; !reflect.DeepEqual(tui.layout, want) {
+		t.Errorf("Expected layout %v, got %v", want, tui.layout)
+	}
+	tui.moveFocused(1)
+	tui.moveFocused(1)
+	if want := []int{2, 1, 3, 0}; !reflect.DeepEqual(tui.layout, want) {
+		t.Errorf("Moving past the end should stop at the last position, got %v", tui.layout)
+	}
+
+	tui.showHidden()
+	if len(tui.hidden) != 0 || len(tui.shown) != 4 {
+		t.Errorf("Expected all panels shown, got %v", tui.shown)
+	}
+
+	if len(saved) != 4 {
+		t.Fatalf("Expected a state change per effective action, got %d", len(saved))
+	}
+	if last := saved[len(saved)-1]; !reflect.DeepEqual(last.Order, []string{"disk", "mem", "net", "cpu"}) || len(last.Hidden) != 0 {
+		t.Errorf("Unexpected final state %+v", last)
+	}
+}
+
+func TestHideLastPanel(t *testing.T) {
+	tui := NewTUI(layoutQueries()[:1], nil)
+	tui.hideFocused()
+	if len(tui.shown) != 1 {
+		t.Error("The last shown panel should not be hidden")
+	}
+}
+
+func TestStepRefresh(t *testing.T) {
+	tui := NewTUI(layoutQueries(), nil)
+	tui.stepRefresh(-1)
+	if tui.refresh != 0 {
+		t.Fatal("Refresh should not change before it is set")
+	}
+
+	tui.SetState(state.State{Refresh: 5 * time.Second})
+	tui.stepRefresh(-1)
+	if tui.refresh != 2*time.Second {
+		t.Errorf("Expected 2s, got %v", tui.refresh)
+	}
+	tui.stepRefresh(1)
+	tui.stepRefresh(1)
+	if tui.refresh != 10*time.Second {
+		t.Errorf("Expected 10s, got %v", tui.refresh)
+	}
+
+	// Intervals between steps move to the neighboring step
+	tui.SetState(state.State{Refresh: 7 * time.Second})
+	tui.stepRefresh(1)
+	if tui.refresh != 10*time.Second {
+		t.Errorf("Expected 10s, got %v", tui.refresh)
+	}
+
+	tui.SetState(state.State{Refresh: 5 * time.Minute})
+	tui.stepRefresh(1)
+	if tui.refresh != 5*time.Minute {
+		t.Errorf("Expected to stay at the longest step, got %v", tui.refresh)
+	}
+}
+
+func TestAcknowledge(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tui := NewTUI(layoutQueries(), nil)
+	tui.SetState(state.State{Acknowledged: map[string]time.Time{"cpu": at}})
+	changes := 0
+	tui.SetStateHandler(func(state.State) { changes++ })
+
+	if !tui.isAcked("cpu", at.Add(-time.Minute)) || tui.isAcked("cpu", at.Add(time.Minute)) || tui.isAcked("mem", at) {
+		t.Error("Only transitions up to the acknowledged one should be acknowledged")
+	}
+
+	tui.acknowledge("cpu", at.Add(-time.Hour))
+	if changes != 0 {
+		t.Error("Acknowledging an older transition should change nothing")
+	}
+	tui.acknowledge("cpu", at.Add(time.Hour))
+	if changes != 1 || !tui.currentState().Acknowledged["cpu"].Equal(at.Add(time.Hour)) {
+		t.Errorf("Expected the newer transition acknowledged, got %v", tui.currentState().Acknowledged)
+	}
+}
+
+func TestLayoutKeys(t *testing.T) {
+	h := newHarness(t, layoutQueries(), 160, 30)
+
+	h.typeRune('h')
+	h.assertNotContains(" CPU ")
+	h.assertContains("1 panels hidden")
+
+	h.typeRune('>')
+	x1, _, _ := h.find(" Disk ")
+	x2, _, _ := h.find(" Memory ")
+	if x1 > x2 {
+		t.Errorf("Expected Memory moved right of Disk, got Memory at %d and Disk at %d", x2, x1)
+	}
+
+	h.typeRune('H')
+	h.assertContains(" CPU ")
+	h.assertNotContains("panels hidden")
+}
+
+func TestBreachAcknowledgeKey(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := newHarness(t, layoutQueries(), 160, 30)
+	h.tui.AddBreach(alert.Transition{ID: "cpu", Query: "CPU", From: "ok", To: "critical", Value: 95, Threshold: 90, Time: at})
+	h.sync()
+
+	h.typeRune('b')
+	h.assertNotContains("acknowledged")
+	h.typeRune('a')
+	h.assertContains("acknowledged")
+	if !h.tui.isAcked("cpu", at) {
+		t.Error("Expected the transition acknowledged")
+	}
+	h.press(tcell.KeyEscape, 0)
+}
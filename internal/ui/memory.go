@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+	"promviz/internal/resources"
+)
+
+// SetResourceLimits sets the description of the memory and CPU limits
+// listed in the diagnostics view. Call before Run.
+func (t *TUI) SetResourceLimits(desc string) {
+	t.resourceLimits = desc
+}
+
+// SetMemoryPressure reports the memory in use against the budget, shown in
+// the help line once panels are downsampled or memory is close to running
+// out
+func (t *TUI) SetMemoryPressure(used, budget uint64, downsampled bool) {
+	t.queueUpdateDraw(func() {
+		t.memoryUsed, t.memoryBudget, t.downsampled = used, budget, downsampled
+		t.updateInstructions()
+	})
+}
+
+// Downsample thins out the stored data of every panel to at most
+// maxPoints points and returns the freed memory to the operating system
+func (t *TUI) Downsample(maxPoints int) {
+	t.queueUpdateDraw(func() {
+		t.compactHistories(maxPoints)
+		t.redrawPanels()
+	})
+}
+
+// memoryNote renders the memory pressure for the help line, or ""
+func (t *TUI) memoryNote() string {
+	if t.memoryBudget == 0 {
+		return ""
+	}
+	usage := t.lang.Sprintf("%s of %s", t.numbers.Bytes(float64(t.memoryUsed)), t.numbers.Bytes(float64(t.memoryBudget)))
+	switch {
+	case float64(t.memoryUsed) >= resources.WarnAt*float64(t.memoryBudget):
+		return t.lang.Sprintf(" | [red]Memory %s, close to the limit (d)[white]", usage)
+	case t.downsampled:
+		return t.lang.Sprintf(" | [yellow]Memory %s, panels downsampled (d)[white]", usage)
+	}
+	return ""
+}
+
+// memoryStats is the memory of the process and of the panel histories
+type memoryStats struct {
+	rss       uint64 // zero if the platform doesn't report it
+	heapInUse uint64
+	heapSys   uint64
+	numGC     uint32
+	lastPause time.Duration
+
+	points []int    // stored points per panel
+	bytes  []uint64 // estimated size of the stored data per panel
+	total  uint64   // estimated size of all stored data, shared results counted once
+}
+
+// readRSS returns the resident set size of the process, read from
+// /proc/self/statm where available
+func readRSS() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
+
+// resultSize estimates the memory held by a result: its point and histogram
+// arrays, buckets and the distinct series names
+func resultSize(ts *backend.TimeSeriesResult) uint64 {
+	if ts == nil {
+		return 0
+	}
+	size := uint64(cap(ts.Points))*uint64(unsafe.Sizeof(backend.DataPoint{})) +
+		uint64(cap(ts.Histograms))*uint64(unsafe.Sizeof(backend.HistogramPoint{}))
+	series := make(map[string]bool)
+	for _, p := range ts.Points {
+		series[p.Series] = true
+	}
+	for _, h := range ts.Histograms {
+		series[h.Series] = true
+		size += uint64(cap(h.Buckets)) * uint64(unsafe.Sizeof(backend.Bucket{}))
+	}
+	for s := range series {
+		size += uint64(len(s))
+	}
+	return size
+}
+
+// resultPoints counts the points and histogram samples of a result
+func resultPoints(ts *backend.TimeSeriesResult) int {
+	if ts == nil {
+		return 0
+	}
+	return len(ts.Points) + len(ts.Histograms)
+}
+
+// memoryUsage measures the process and the panel histories
+func (t *TUI) memoryUsage() memoryStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	stats := memoryStats{
+		heapInUse: ms.HeapInuse,
+		heapSys:   ms.HeapSys,
+		numGC:     ms.NumGC,
+		lastPause: time.Duration(ms.PauseNs[(ms.NumGC+255)%256]),
+		points:    make([]int, len(t.histories)),
+		bytes:     make([]uint64, len(t.histories)),
+	}
+	stats.rss, _ = readRSS()
+
+	// Panels created by expand_by share the result of one query
+	counted := make(map[*backend.TimeSeriesResult]bool)
+	for i, h := range t.histories {
+		for _, ts := range []*backend.TimeSeriesResult{h.TimeSeries, h.Good, h.Total} {
+			stats.points[i] += resultPoints(ts)
+			size := resultSize(ts)
+			stats.bytes[i] += size
+			if ts != nil && !counted[ts] {
+				counted[ts] = true
+				stats.total += size
+			}
+		}
+	}
+	return stats
+}
+
+// memoryText renders the memory section of the diagnostics view
+func (t *TUI) memoryText(stats memoryStats) string {
+	var b strings.Builder
+	b.WriteString("[yellow]Memory[white]\n\n")
+
+	rss := "n/a"
+	if stats.rss > 0 {
+		rss = t.numbers.Bytes(float64(stats.rss))
+	}
+	fmt.Fprintf(&b, "• Process: RSS %s, heap %s in use of %s, %d GC runs, last pause %s\n",
+		rss, t.numbers.Bytes(float64(stats.heapInUse)), t.numbers.Bytes(float64(stats.heapSys)),
+		stats.numGC, stats.lastPause.Round(time.Microsecond))
+	if t.resourceLimits != "" {
+		fmt.Fprintf(&b, "• Limits: %s\n", tview.Escape(t.resourceLimits))
+	}
+
+	total := 0
+	for _, n := range stats.points {
+		total += n
+	}
+	fmt.Fprintf(&b, "• Stored: %s points in %d panels, about %s\n",
+		t.numbers.Float(float64(total), 0), len(stats.points), t.numbers.Bytes(float64(stats.total)))
+	for i, n := range stats.points {
+		fmt.Fprintf(&b, "    %s: %s points, %s\n", tview.Escape(t.histories[i].Name),
+			t.numbers.Float(float64(n), 0), t.numbers.Bytes(float64(stats.bytes[i])))
+	}
+	return b.String()
+}
+
+// compactHistories drops stored points outside the current range of their
+// panel, such as those left over from a longer range, thins out panels with
+// more than maxPoints points unless it is zero, trims the arrays holding the
+// rest to their length and returns the freed memory to the operating
+// system. It returns the number of points dropped.
+func (t *TUI) compactHistories(maxPoints int) int {
+	dropped := 0
+	now := t.now()
+
+	// Results shared between panels stay shared
+	compacted := make(map[*backend.TimeSeriesResult]*backend.TimeSeriesResult)
+	compact := func(ts *backend.TimeSeriesResult, tr *backend.TimeRange) *backend.TimeSeriesResult {
+		if ts == nil {
+			return nil
+		}
+		if c, ok := compacted[ts]; ok {
+			return c
+		}
+		c := &backend.TimeSeriesResult{Truncation: ts.Truncation, Warnings: ts.Warnings}
+		for _, p := range ts.Points {
+			if tr == nil || (!p.Timestamp.Before(tr.Start) && !p.Timestamp.After(tr.End)) {
+				c.Points = append(c.Points, p)
+			}
+		}
+		for _, h := range ts.Histograms {
+			if tr == nil || (!h.Timestamp.Before(tr.Start) && !h.Timestamp.After(tr.End)) {
+				h.Buckets = append([]backend.Bucket(nil), h.Buckets...)
+				c.Histograms = append(c.Histograms, h)
+			}
+		}
+		if maxPoints > 0 {
+			c = backend.Limit(c, backend.Limits{MaxSeries: math.MaxInt, MaxPoints: maxPoints})
+			// Thinning out for memory is not what the backend returned being
+			// truncated; the memory note says it happens
+			c.Truncation = ts.Truncation
+		}
+		dropped += resultPoints(ts) - resultPoints(c)
+
+		// append grows the arrays ahead of need; copy into exact ones
+		c.Points = append(make([]backend.DataPoint, 0, len(c.Points)), c.Points...)
+		c.Histograms = append([]backend.HistogramPoint(nil), c.Histograms...)
+		compacted[ts] = c
+		return c
+	}
+
+	for i, h := range t.histories {
+		tr := t.queries[i].TimeRangeAt(now)
+		h.TimeSeries = compact(h.TimeSeries, &tr)
+		// SLO series cover the objective's window, not the panel range
+		h.Good = compact(h.Good, nil)
+		h.Total = compact(h.Total, nil)
+	}
+	t.breaches = append([]alert.Transition(nil), t.breaches...)
+
+	debug.FreeOSMemory()
+	return dropped
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+// pointsEvery returns n points one minute apart ending at end
+func pointsEvery(n int, end time.Time) []backend.DataPoint {
+	points := make([]backend.DataPoint, 0, 2*n)
+	for i := n - 1; i >= 0; i-- {
+		points = append(points, backend.DataPoint{Timestamp: end.Add(-time.Duration(i) * time.Minute), Value: float64(i), Series: "{job=\"api\"}"})
+	}
+	return points
+}
+
+func TestResultSize(t *testing.T) {
+	if resultSize(nil) != 0 || resultPoints(nil) != 0 {
+		t.Error("A missing result should take no memory")
+	}
+
+	small := &backend.TimeSeriesResult{Points: pointsEvery(2, goldenNow)}
+	large := &backend.TimeSeriesResult{Points: pointsEvery(20, goldenNow)}
+	if resultPoints(large) != 20 {
+		t.Errorf("Expected 20 points, got %d", resultPoints(large))
+	}
+	if resultSize(small) >= resultSize(large) {
+		t.Errorf("More points should take more memory, got %d and %d", resultSize(small), resultSize(large))
+	}
+}
+
+func TestCompactHistories(t *testing.T) {
+	tui := NewTUI([]backend.Query{
+		{Name: "API", Expr: "up", Range: "5m"},
+		{Name: "API copy", Expr: "up", Range: "5m"},
+		{Name: "Web", Expr: "web", Range: "1h"},
+	}, nil)
+	tui.now = func() time.Time { return goldenNow }
+
+	// The first two panels share a result left over from a longer range
+	shared := &backend.TimeSeriesResult{Points: pointsEvery(30, goldenNow)}
+	tui.histories[0].TimeSeries = shared
+	tui.histories[1].TimeSeries = shared
+	tui.histories[2].TimeSeries = &backend.TimeSeriesResult{Points: pointsEvery(30, goldenNow)}
+
+	before := tui.memoryUsage()
+	if dropped := tui.compactHistories(0); dropped != 24 {
+		t.Errorf("Expected the 24 points older than 5m dropped once, got %d", dropped)
+	}
+	after := tui.memoryUsage()
+
+	first := tui.histories[0].TimeSeries
+	if len(first.Points) != 6 || cap(first.Points) != 6 {
+		t.Errorf("Expected 6 points in an exact array, got %d of %d", len(first.Points), cap(first.Points))
+	}
+	if tui.histories[1].TimeSeries != first {
+		t.Error("Panels sharing a result should keep sharing it")
+	}
+	if len(shared.Points) != 30 {
+		t.Error("The original result must not be modified")
+	}
+	if got := len(tui.histories[2].TimeSeries.Points); got != 30 {
+		t.Errorf("Points within the range should be kept, got %d", got)
+	}
+	if after.total >= before.total {
+		t.Errorf("Compaction should shrink the stored data, got %d then %d", before.total, after.total)
+	}
+}
+
+func TestMemoryText(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "API", Expr: "up"}, {Name: "Web", Expr: "web"}}, nil)
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: pointsEvery(3, goldenNow)}
+
+	text := tui.memoryText(tui.memoryUsage())
+	for _, want := range []string{"Memory", "Process: RSS", "GC runs", "Stored: 3 points in 2 panels", "API: 3 points", "Web: 0 points, 0 B"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in the memory section, got %q", want, text)
+		}
+	}
+}
+
+func TestCompactKey(t *testing.T) {
+	h := newHarness(t, []backend.Query{{Name: "API", Expr: "up", Range: "5m"}}, 120, 40)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: pointsEvery(30, goldenNow)}, nil)
+	h.sync()
+
+	h.typeRune('d')
+	h.assertContains("Memory")
+	h.assertContains("c to compact memory")
+
+	h.press(tcell.KeyRune, 'c')
+	h.assertContains("Compacted: dropped 24 points")
+	h.assertContains("Stored: 6 points in 1 panels")
+}
+
+func TestDownsampleHistories(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "API", Expr: "up", Range: "1h"}}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: pointsEvery(60, goldenNow)}
+
+	if dropped := tui.compactHistories(10); dropped != 50 {
+		t.Errorf("Expected 50 points dropped, got %d", dropped)
+	}
+	ts := tui.histories[0].TimeSeries
+	if len(ts.Points) != 10 || !ts.Points[9].Timestamp.Equal(goldenNow) {
+		t.Errorf("Expected 10 points ending at the newest, got %d", len(ts.Points))
+	}
+	if ts.Truncation != nil {
+		t.Errorf("Downsampled panels should not say the query was truncated, got %+v", ts.Truncation)
+	}
+
+	// Truncation by result limits is kept
+	limited := &backend.Truncation{Series: 3, KeptSeries: 1, Points: 180, KeptPoints: 60}
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: pointsEvery(60, goldenNow), Truncation: limited}
+	tui.compactHistories(10)
+	if got := tui.histories[0].TimeSeries.Truncation; got == nil || *got != *limited {
+		t.Errorf("Expected the truncation of the query kept, got %+v", got)
+	}
+}
+
+func TestMemoryNote(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "API", Expr: "up"}}, nil)
+	tui.SetResourceLimits("256.0 MiB memory")
+
+	if tui.memoryNote() != "" {
+		t.Error("Expected no note without a memory budget")
+	}
+	tui.memoryUsed, tui.memoryBudget = 100<<20, 256<<20
+	if tui.memoryNote() != "" {
+		t.Error("Expected no note well within the budget")
+	}
+	tui.downsampled = true
+	if note := tui.memoryNote(); !strings.Contains(note, "Memory 100.0 MiB of 256.0 MiB, panels downsampled") {
+		t.Errorf("Expected a downsampling note, got %q", note)
+	}
+	tui.memoryUsed = 240 << 20
+	if note := tui.memoryNote(); !strings.Contains(note, "[red]Memory 240.0 MiB of 256.0 MiB, close to the limit") {
+		t.Errorf("Expected a warning close to the limit, got %q", note)
+	}
+	if text := tui.memoryText(tui.memoryUsage()); !strings.Contains(text, "Limits: 256.0 MiB memory") {
+		t.Errorf("Expected the limits in the memory section, got %q", text)
+	}
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"promviz/internal/numfmt"
+)
+
+// SetNumberFormat sets how values are written in panels. Call before Run.
+func (t *TUI) SetNumberFormat(f numfmt.Format) {
+	t.numbers = f
+}
+
+// localizeAxis rewrites the y-axis labels of an asciigraph plot in the number
+// format, keeping their precision. Lines without an axis, such as the
+// caption, are shifted along when the labels get wider.
+func localizeAxis(graph string, f numfmt.Format) string {
+	if f.Plain() {
+		return graph
+	}
+
+	lines := strings.Split(graph, "\n")
+	labels := make([]string, len(lines))
+	axes := make([]int, len(lines))
+	oldWidth, newWidth := 0, 0
+	for i, line := range lines {
+		axes[i] = strings.IndexAny(line, "┤┼")
+		if axes[i] < 0 {
+			continue
+		}
+
+		label := strings.TrimSpace(line[:axes[i]])
+		if _, err := strconv.ParseFloat(label, 64); err != nil {
+			axes[i] = -1
+			continue
+		}
+		labels[i] = f.Localize(label)
+		if w := len([]rune(line[:axes[i]])); w > oldWidth {
+			oldWidth = w
+		}
+		if w := len([]rune(labels[i])) + 1; w > newWidth {
+			newWidth = w
+		}
+	}
+	if newWidth <= oldWidth {
+		newWidth = oldWidth
+	}
+
+	for i, line := range lines {
+		if axes[i] < 0 {
+			if newWidth > oldWidth && line != "" {
+				lines[i] = strings.Repeat(" ", newWidth-oldWidth) + line
+			}
+			continue
+		}
+		pad := newWidth - len([]rune(labels[i]))
+		lines[i] = strings.Repeat(" ", pad) + labels[i] + line[axes[i]:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guptarohit/asciigraph"
+
+	"promviz/internal/numfmt"
+)
+
+func TestLocalizeAxis(t *testing.T) {
+	graph := asciigraph.Plot([]float64{1e6, 2.5e6, 1.5e6}, asciigraph.Height(2), asciigraph.Caption("requests"))
+
+	if got := localizeAxis(graph, numfmt.Format{}); got != graph {
+		t.Errorf("Plain format should leave the graph untouched, got:\n%s", got)
+	}
+
+	de, _ := numfmt.Locale("de")
+	got := localizeAxis(graph, de)
+	lines := strings.Split(got, "\n")
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "2.500.000┤") {
+		t.Errorf("Expected top label 2.500.000, got:\n%s", got)
+	}
+
+	// Labels stay right aligned, and the caption moves along with the plot
+	origLines := strings.Split(graph, "\n")
+	shift := strings.IndexAny(lines[0], "┤┼") - strings.IndexAny(origLines[0], "┤┼")
+	if shift <= 0 {
+		t.Errorf("Expected the axis to move right for the added separators, moved by %d:\n%s", shift, got)
+	}
+	for i, line := range lines[:len(lines)-1] {
+		if strings.IndexAny(line, "┤┼") != strings.IndexAny(lines[0], "┤┼") {
+			t.Errorf("Axis of line %d is not aligned:\n%s", i, got)
+		}
+	}
+	caption := lines[len(lines)-1]
+	if want := strings.Repeat(" ", shift) + origLines[len(origLines)-1]; caption != want {
+		t.Errorf("Expected caption %q, got %q", want, caption)
+	}
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/guptarohit/asciigraph"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/stats"
+)
+
+// plotColor pairs the plot color of a line with the tview color of its
+// legend entry
+type plotColor struct {
+	ansi asciigraph.AnsiColor
+	tag  string
+}
+
+// overlayColors are cycled through for percentile lines. They avoid the
+// green/yellow/red used for thresholds.
+var overlayColors = []plotColor{
+	{asciigraph.Blue, "blue"},
+	{asciigraph.Fuchsia, "fuchsia"},
+	{asciigraph.Aqua, "aqua"},
+}
+
+// percentileLines computes the configured quantiles of a panel's sorted raw
+// points. current holds the latest value of each quantile; lines holds one
+// overlay series per quantile aligned with the plotted grid points, or nil
+// when overlays are off. Without a window each quantile covers the whole
+// range and its line is flat.
+func percentileLines(p *backend.Percentiles, raw, grid []backend.DataPoint) (current []float64, lines [][]float64) {
+	window, _ := backend.ParseDuration(p.Window)
+
+	finite := make([]float64, 0, len(raw))
+	for _, point := range raw {
+		if !math.IsNaN(point.Value) {
+			finite = append(finite, point.Value)
+		}
+	}
+
+	for _, level := range p.Levels() {
+		var line []float64
+		latest := math.NaN()
+		if window > 0 {
+			// Evaluate the window at every raw point, then plot the value
+			// of the point each grid step shows
+			rolling := stats.Rolling(raw, level, window)
+			byTime := make(map[int64]float64, len(raw))
+			for i, point := range raw {
+				byTime[point.Timestamp.UnixNano()] = rolling[i]
+				if !math.IsNaN(rolling[i]) {
+					latest = rolling[i]
+				}
+			}
+
+			line = make([]float64, len(grid))
+			for i, point := range grid {
+				v, ok := byTime[point.Timestamp.UnixNano()]
+				if !ok || math.IsNaN(point.Value) {
+					v = math.NaN()
+				}
+				line[i] = v
+			}
+		} else {
+			latest = stats.Percentile(finite, level)
+			line = make([]float64, len(grid))
+			for i := range line {
+				line[i] = latest
+			}
+		}
+		current = append(current, latest)
+
+		if p.Overlay {
+			lines = append(lines, line)
+		}
+	}
+	return current, lines
+}
+
+// formatPercentiles renders the stats line of a panel, e.g.
+// "p50 12.00  p90 40.00  p99 98.00". With overlays the labels double as the
+// legend of the lines.
+func formatPercentiles(p *backend.Percentiles, current []float64, textColor string, f numfmt.Format) string {
+	var parts []string
+	for i, level := range p.Levels() {
+		labelColor := "gray"
+		if p.Overlay {
+			labelColor = overlayColors[i%len(overlayColors)].tag
+		}
+		parts = append(parts, fmt.Sprintf("[%s]p%g[%s] %s", labelColor, level, textColor, f.Float(current[i], 2)))
+	}
+
+	line := strings.Join(parts, "  ")
+	if p.Window != "" {
+		line += fmt.Sprintf(" [gray](rolling %s)[%s]", p.Window, textColor)
+	}
+	return line
+}
+
+// seriesColors returns the plot colors for n overlay lines followed by the
+// panel's own series, which keeps the default color
+func seriesColors(n int) []asciigraph.AnsiColor {
+	var colors []asciigraph.AnsiColor
+	for i := 0; i < n; i++ {
+		colors = append(colors, overlayColors[i%len(overlayColors)].ansi)
+	}
+	return append(colors, asciigraph.Default)
+}
+
+// colorizeGraph translates the escape sequences of a plot drawn with the
+// palette, and of its grid lines, into tview color tags, resetting to
+// textColor
+func colorizeGraph(graph, textColor string, palette []plotColor) string {
+	pairs := []string{asciigraph.Default.String(), "[" + textColor + "]", gridColor.ansi.String(), "[" + gridColor.tag + "]"}
+	for _, c := range palette {
+		pairs = append(pairs, c.ansi.String(), "["+c.tag+"]")
+	}
+	return strings.NewReplacer(pairs...).Replace(graph)
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+)
+
+func TestPercentileLines(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var points []backend.DataPoint
+	for i, v := range []float64{1, 2, 3, 4, math.NaN(), 100} {
+		points = append(points, backend.DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: v})
+	}
+
+	// Over the whole range, NaN ignored
+	current, lines := percentileLines(&backend.Percentiles{Quantiles: []float64{50}}, points, points)
+	if len(current) != 1 || current[0] != 3 {
+		t.Errorf("Expected p50 3, got %v", current)
+	}
+	if lines != nil {
+		t.Errorf("Expected no overlay lines without overlay, got %v", lines)
+	}
+
+	// Rolling over 2 minutes: the latest value only sees itself after the gap
+	current, lines = percentileLines(&backend.Percentiles{Quantiles: []float64{50}, Window: "2m", Overlay: true}, points, points)
+	if current[0] != 100 {
+		t.Errorf("Expected rolling p50 100, got %v", current)
+	}
+	if len(lines) != 1 || lines[0][1] != 1.5 || !math.IsNaN(lines[0][4]) {
+		t.Errorf("Unexpected rolling line %v", lines)
+	}
+
+	// Computed from all raw points, not only those the grid shows
+	burst := append([]backend.DataPoint{{Timestamp: start.Add(-10 * time.Second), Value: 1000}}, points...)
+	current, _ = percentileLines(&backend.Percentiles{Quantiles: []float64{100}}, burst, points)
+	if current[0] != 1000 {
+		t.Errorf("Expected p100 over the raw points 1000, got %v", current)
+	}
+
+	// Defaults to p50, p90 and p99
+	current, _ = percentileLines(&backend.Percentiles{}, points, points)
+	if len(current) != 3 {
+		t.Errorf("Expected default quantiles, got %v", current)
+	}
+}
+
+func TestFormatPercentiles(t *testing.T) {
+	p := &backend.Percentiles{Quantiles: []float64{50, 99.9}, Window: "10m"}
+	got := formatPercentiles(p, []float64{1, 2.5}, "white", numfmt.Format{})
+	expected := "[gray]p50[white] 1.00  [gray]p99.9[white] 2.50 [gray](rolling 10m)[white]"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	p.Overlay = true
+	if got := formatPercentiles(p, []float64{1, 2.5}, "white", numfmt.Format{}); !strings.HasPrefix(got, "[blue]p50[white] 1.00  [fuchsia]p99.9") {
+		t.Errorf("Overlay labels should use the line colors, got %q", got)
+	}
+}
+
+func TestPercentileOverlayColors(t *testing.T) {
+	query := backend.Query{
+		Name:        "Latency",
+		Expr:        "latency",
+		Percentiles: &backend.Percentiles{Quantiles: []float64{50, 90}, Overlay: true},
+	}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+
+	now := time.Now()
+	var points []backend.DataPoint
+	for i := 0; i < 20; i++ {
+		points = append(points, backend.DataPoint{Timestamp: now.Add(time.Duration(i-19) * 15 * time.Second), Value: float64(i % 7)})
+	}
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: points}, nil)
+	h.sync()
+
+	h.assertContains("p50 3.00  p90 5.10")
+	h.assertNotContains("\x1b")
+
+	x, y, ok := h.find("p90")
+	if !ok {
+		t.Fatal("Percentile legend not rendered")
+	}
+	if fg, _, _ := h.style(x, y).Decompose(); fg != tcell.ColorFuchsia {
+		t.Errorf("Expected p90 legend in fuchsia, got %v", fg)
+	}
+}
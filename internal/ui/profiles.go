@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const profilesPage = "profiles"
+
+// SetProfiles lists the backend profiles the switcher offers and marks the
+// active one. Call before Run.
+func (t *TUI) SetProfiles(names []string, active string) {
+	t.profiles = names
+	t.profile = active
+	t.updateInstructions()
+}
+
+// SetProfileHandler sets the function called with the name of the profile
+// picked in the switcher. It runs outside the event loop since connecting
+// takes a while. Call before Run.
+func (t *TUI) SetProfileHandler(onProfile func(name string)) {
+	t.onProfile = onProfile
+}
+
+// SetActiveProfile shows that the panels now run against the named profile,
+// along with the connection results of its backends
+func (t *TUI) SetActiveProfile(name string, status []string, failed int) {
+	t.queueUpdateDraw(func() {
+		t.profile = name
+		t.backendStatus = status
+		t.backendsDown = failed
+		t.updateInstructions()
+	})
+}
+
+// ProfileSwitchFailed reports a switch that left the previous profile active
+func (t *TUI) ProfileSwitchFailed(name string, err error) {
+	t.queueUpdateDraw(func() {
+		t.instructions.SetText(t.lang.Sprintf("[red]Switching to %s failed: %s[white]", tview.Escape(name), tview.Escape(err.Error())))
+	})
+}
+
+// showProfiles opens a modal listing the profiles. Selecting one switches
+// all panels to its backends.
+func (t *TUI) showProfiles() {
+	if len(t.profiles) == 0 {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle(" Data source (Enter to switch, Esc to close) ")
+
+	for _, name := range t.profiles {
+		item := "  " + tview.Escape(name)
+		if name == t.profile {
+			item = "[yellow]● " + tview.Escape(name) + "[white]"
+		}
+		list.AddItem(item, "", 0, func() {
+			t.closeModal(profilesPage)
+			t.switchProfile(name)
+		})
+	}
+	for i, name := range t.profiles {
+		if name == t.profile {
+			list.SetCurrentItem(i)
+		}
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 's' || event.Rune() == 'S' {
+			t.closeModal(profilesPage)
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(profilesPage, modal(list, 50, len(t.profiles)+2), true, true)
+	t.app.SetFocus(list)
+}
+
+// switchProfile asks the handler to repoint the panels at the named profile
+func (t *TUI) switchProfile(name string) {
+	if name == t.profile || t.onProfile == nil {
+		return
+	}
+	t.instructions.SetText(t.lang.Sprintf("[yellow]Switching to %s...[white]", tview.Escape(name)))
+	go t.onProfile(name)
+}
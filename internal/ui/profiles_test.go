@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+func TestProfileSwitcher(t *testing.T) {
+	h := newHarness(t, []backend.Query{{ID: "cpu", Name: "CPU", Expr: "cpu"}}, 140, 30)
+	picked := make(chan string, 1)
+	h.tui.app.QueueUpdateDraw(func() {
+		h.tui.SetProfiles([]string{"default", "staging"}, "default")
+		h.tui.SetProfileHandler(func(name string) { picked <- name })
+	})
+	h.sync()
+	h.assertContains("Source: default (s)")
+
+	h.typeRune('s')
+	h.assertContains("● default")
+	h.assertContains("staging")
+
+	// Picking the active profile changes nothing
+	h.press(tcell.KeyEnter, 0)
+	h.assertNotContains("Data source")
+	select {
+	case name := <-picked:
+		t.Fatalf("Unexpected switch to %s", name)
+	default:
+	}
+
+	h.typeRune('s')
+	h.press(tcell.KeyDown, 0)
+	h.press(tcell.KeyEnter, 0)
+	h.assertContains("Switching to staging...")
+	select {
+	case name := <-picked:
+		if name != "staging" {
+			t.Errorf("Expected a switch to staging, got %s", name)
+		}
+	case <-time.After(harnessTimeout):
+		t.Fatal("Profile handler was not called")
+	}
+
+	h.tui.SetActiveProfile("staging", []string{"prometheus: connected"}, 0)
+	h.sync()
+	h.assertContains("Source: staging (s)")
+
+	h.tui.ProfileSwitchFailed("default", errors.New("connection refused"))
+	h.sync()
+	h.assertContains("Switching to default failed: connection refused")
+}
+
+func TestProfileSwitcherWithoutProfiles(t *testing.T) {
+	h := newHarness(t, []backend.Query{{ID: "cpu", Name: "CPU", Expr: "cpu"}}, 140, 30)
+	h.typeRune('s')
+	h.assertNotContains("Data source")
+	h.assertNotContains("Source:")
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+// SetIdle marks a panel that isn't refreshed outside its active hours,
+// described by hours, until they open again. A zero until marks it active.
+func (t *TUI) SetIdle(index int, until time.Time, hours string) {
+	t.queueUpdateDraw(func() { t.setIdle(index, until, hours) })
+}
+
+// setIdle marks a panel idle or active. Panels keep showing their last
+// data, with the time they are refreshed again in the title; panels
+// without data say they are idle. It runs on the UI event loop.
+func (t *TUI) setIdle(index int, until time.Time, hours string) {
+	if index < 0 || index >= len(t.histories) {
+		return
+	}
+	h := t.histories[index]
+	h.IdleUntil = until
+
+	graph := t.queries[index].PanelType() != backend.PanelSLO && t.queries[index].PanelType() != backend.PanelHealth
+	switch {
+	case h.LastError == nil && graph && h.TimeSeries != nil && len(h.TimeSeries.Points) > 0:
+		t.renderTimeSeriesGraph(index)
+	case h.LastError == nil && h.Good == nil && h.Health == nil && !until.IsZero():
+		t.setStale(index, 0, false)
+		t.panels[index].SetText(t.lang.Sprintf("[gray]Scheduled idle[white] until %s (active %s)",
+			formatUntil(until, t.now()), tview.Escape(hours)))
+	default:
+		t.setStale(index, 0, false)
+	}
+}
+
+// formatUntil writes a time later than now as its time of day, with the
+// weekday unless it is today
+func formatUntil(until, now time.Time) string {
+	y1, m1, d1 := until.Date()
+	y2, m2, d2 := now.Date()
+	if y1 == y2 && m1 == m2 && d1 == d2 {
+		return until.Format("15:04")
+	}
+	return until.Format("Mon 15:04")
+}
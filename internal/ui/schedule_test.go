@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestSetIdle(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Batch", Expr: "batch"}, {Name: "Nightly", Expr: "nightly"}}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: goldenNow, Value: 7}}}
+
+	tomorrow := goldenNow.Add(24 * time.Hour)
+	tui.setIdle(0, tomorrow, "06:00-20:00")
+	tui.setIdle(1, tomorrow, "06:00-20:00")
+
+	want := "idle until " + tomorrow.Format("Mon 15:04")
+	if title := tui.panels[0].GetTitle(); !strings.Contains(title, want) {
+		t.Errorf("Expected %q in the title, got %q", want, title)
+	}
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "Current: 7.00") {
+		t.Errorf("Expected the last data kept, got %q", text)
+	}
+	if text := tui.panels[1].GetText(false); !strings.Contains(text, "Scheduled idle[white] until "+tomorrow.Format("Mon 15:04")+" (active 06:00-20:00)") {
+		t.Errorf("Expected a panel without data to say it is idle, got %q", text)
+	}
+
+	tui.setIdle(0, time.Time{}, "")
+	if title := tui.panels[0].GetTitle(); strings.Contains(title, "idle") {
+		t.Errorf("Expected the badge gone once active, got %q", title)
+	}
+}
+
+func TestFormatUntil(t *testing.T) {
+	now := time.Date(2024, 5, 6, 21, 0, 0, 0, time.UTC)
+	if got := formatUntil(time.Date(2024, 5, 6, 22, 30, 0, 0, time.UTC), now); got != "22:30" {
+		t.Errorf("Expected only the time today, got %q", got)
+	}
+	if got := formatUntil(time.Date(2024, 5, 7, 6, 0, 0, 0, time.UTC), now); got != "Tue 06:00" {
+		t.Errorf("Expected the weekday for tomorrow, got %q", got)
+	}
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+// screenQueries are four mock panels, one more than fits on a page
+var screenQueries = []backend.Query{
+	{Name: "Query 1", Expr: "metric1"},
+	{Name: "Query 2", Expr: "metric2"},
+	{Name: "Query 3", Expr: "metric3"},
+	{Name: "Query 4", Expr: "metric4"},
+}
+
+// titleColor returns the foreground color of a panel title on screen
+func titleColor(t *testing.T, h *harness, title string) tcell.Color {
+	t.Helper()
+	x, y, ok := h.find(title)
+	if !ok {
+		t.Fatalf("Title %q not on screen:\n%s", title, h.text())
+	}
+	fg, _, _ := h.style(x, y).Decompose()
+	return fg
+}
+
+func TestScreenLayout(t *testing.T) {
+	h := newHarness(t, screenQueries, 120, 30)
+
+	h.assertContains(" Query 1 ")
+	h.assertContains(" Query 2 ")
+	h.assertContains(" Query 3 ")
+	h.assertNotContains(" Query 4 ")
+	h.assertContains("Initializing...")
+	h.assertContains("Time Range: Waiting for data...")
+
+	lines := h.lines()
+	if last := lines[len(lines)-1]; !strings.Contains(last, "q/Q to quit") {
+		t.Errorf("Expected key help on the last line, got %q", last)
+	}
+
+	// Panels share the width equally
+	x1, _, _ := h.find(" Query 1 ")
+	x2, _, _ := h.find(" Query 2 ")
+	x3, _, _ := h.find(" Query 3 ")
+	if x2-x1 != x3-x2 {
+		t.Errorf("Expected equal panel widths, titles at %d, %d, %d", x1, x2, x3)
+	}
+}
+
+func TestScreenFocusKeys(t *testing.T) {
+	h := newHarness(t, screenQueries, 120, 30)
+
+	if c := titleColor(t, h, " Query 1 "); c != tcell.ColorYellow {
+		t.Errorf("Expected focused title to be yellow, got %v", c)
+	}
+
+	h.press(tcell.KeyTab, 0)
+	if c := titleColor(t, h, " Query 2 "); c != tcell.ColorYellow {
+		t.Errorf("Expected Tab to focus the second panel, got %v", c)
+	}
+	if c := titleColor(t, h, " Query 1 "); c == tcell.ColorYellow {
+		t.Error("Expected the first panel to lose focus")
+	}
+
+	// Moving past the last visible panel scrolls the page
+	h.press(tcell.KeyRight, 0)
+	h.press(tcell.KeyRight, 0)
+	h.assertContains(" Query 4 ")
+	h.assertNotContains(" Query 1 ")
+
+	// Wrapping around shows the first page again
+	h.press(tcell.KeyTab, 0)
+	h.assertContains(" Query 1 ")
+	h.assertNotContains(" Query 4 ")
+
+	h.press(tcell.KeyBacktab, 0)
+	if c := titleColor(t, h, " Query 4 "); c != tcell.ColorYellow {
+		t.Errorf("Expected Shift+Tab to wrap to the last panel, got %v", c)
+	}
+}
+
+func TestScreenModals(t *testing.T) {
+	h := newHarness(t, screenQueries, 120, 30)
+
+	h.typeRune('d')
+	h.assertContains("Diagnostics (c to compact memory, Esc to close)")
+
+	// Keys go to the modal while it is open
+	h.typeRune('q')
+	if h.quit {
+		t.Error("q should not quit while a modal is open")
+	}
+
+	h.press(tcell.KeyEscape, 0)
+	h.assertNotContains("Diagnostics (Esc to close)")
+
+	h.typeRune('b')
+	h.assertContains("Threshold breaches")
+	h.press(tcell.KeyEscape, 0)
+
+	h.typeRune('i')
+	h.assertContains("Details (Esc to close)")
+	h.press(tcell.KeyEscape, 0)
+
+	h.typeRune('q')
+	if !h.quit {
+		t.Error("q should call the quit handler")
+	}
+}
+
+func TestScreenRendersUpdates(t *testing.T) {
+	h := newHarness(t, screenQueries[:2], 120, 30)
+
+	now := time.Now()
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now.Add(-time.Minute), Value: 42.5},
+		{Timestamp: now, Value: 45},
+	}}, nil)
+	h.tui.UpdateTimeSeries(1, nil, fmt.Errorf("connection refused"))
+	h.sync()
+
+	h.assertContains("Current: 45.00")
+	h.assertContains("Query 1 Time Series")
+	h.assertContains("Error: connection refused")
+	h.assertNotContains("Waiting for data")
+}
+
+func TestScreenResize(t *testing.T) {
+	h := newHarness(t, screenQueries[:1], 120, 30)
+
+	now := time.Now()
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: now.Add(-time.Minute), Value: 1},
+		{Timestamp: now, Value: 2},
+	}}, nil)
+	h.sync()
+
+	h.resize(50, 12)
+	lines := h.lines()
+	if len(lines) != 12 {
+		t.Fatalf("Expected 12 rows, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if n := len([]rune(line)); n > 50 {
+			t.Errorf("Row %d is %d columns wide", i, n)
+		}
+	}
+	h.assertContains(" Query 1 ")
+}
+
+func TestQuitFromKeyHandler(t *testing.T) {
+	h := newHarness(t, screenQueries[:1], 80, 24)
+	h.tui.onQuit = h.tui.Stop
+
+	h.screen.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	select {
+	case err := <-h.done:
+		if err != nil {
+			t.Errorf("Run returned error: %v", err)
+		}
+		h.done <- err
+	case <-time.After(harnessTimeout):
+		t.Fatal("Run did not return after quitting")
+	}
+}
+
+func TestUpdatesOutsideRunDoNotBlock(t *testing.T) {
+	tui := NewTUI(screenQueries[:1], nil)
+	series := &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: time.Now(), Value: 1}}}
+
+	finished := make(chan struct{})
+	go func() {
+		// Before Run
+		tui.UpdateTimeSeries(0, series, nil)
+		tui.AdvancePlaylist()
+
+		// After Stop, which may also be called twice
+		tui.Stop()
+		tui.Stop()
+		tui.UpdateTimeSeries(0, series, nil)
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(harnessTimeout):
+		t.Fatal("UI updates blocked without a running event loop")
+	}
+
+	// Run after Stop returns immediately instead of taking over the terminal
+	if err := tui.Run(); err != nil {
+		t.Errorf("Run after Stop returned error: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+
+	"promviz/internal/backend"
+)
+
+// Burn rate thresholds for a 30-day budget: above 1x the budget runs out
+// before the window ends, above 6x it is gone within five days.
+const (
+	burnRateWarning  = 1.0
+	burnRateCritical = 6.0
+)
+
+// SLOStatus summarizes a service level objective computed from a good/total query pair
+type SLOStatus struct {
+	SLI             float64 // percentage of good events over the window
+	Objective       float64 // target percentage
+	BudgetRemaining float64 // fraction of the error budget left, negative once exhausted
+	BurnRate        float64 // latest error rate relative to the allowed error rate
+}
+
+// ComputeSLO derives the SLI, remaining error budget and burn rate from the
+// good and total series. The SLI covers every returned sample; the burn rate
+// uses the most recent timestamp present in both series.
+func ComputeSLO(good, total *backend.TimeSeriesResult, objective float64) (*SLOStatus, error) {
+	if good == nil || total == nil {
+		return nil, fmt.Errorf("missing good or total series")
+	}
+
+	var goodSum, totalSum float64
+	for _, p := range good.Points {
+		goodSum += p.Value
+	}
+	for _, p := range total.Points {
+		totalSum += p.Value
+	}
+	if totalSum <= 0 {
+		return nil, fmt.Errorf("no events in window")
+	}
+
+	allowed := 1 - objective/100
+	sli := goodSum / totalSum
+	status := &SLOStatus{
+		SLI:             sli * 100,
+		Objective:       objective,
+		BudgetRemaining: 1 - (1-sli)/allowed,
+	}
+
+	// Pair up the latest sample that exists in both series
+	goodByTime := make(map[int64]float64, len(good.Points))
+	for _, p := range good.Points {
+		goodByTime[p.Timestamp.UnixNano()] = p.Value
+	}
+	var latest *backend.DataPoint
+	for i, p := range total.Points {
+		if _, ok := goodByTime[p.Timestamp.UnixNano()]; !ok {
+			continue
+		}
+		if latest == nil || p.Timestamp.After(latest.Timestamp) {
+			latest = &total.Points[i]
+		}
+	}
+	if latest != nil && latest.Value > 0 {
+		errorRate := 1 - goodByTime[latest.Timestamp.UnixNano()]/latest.Value
+		status.BurnRate = errorRate / allowed
+	}
+
+	return status, nil
+}
+
+// renderSLO renders the SLO summary for the given panel
+func (t *TUI) renderSLO(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	slo := t.queries[index].SLO
+
+	status, err := ComputeSLO(history.Good, history.Total, slo.Objective)
+	if err != nil {
+		panel.SetText(fmt.Sprintf("[gray]%v[white]", err))
+		return
+	}
+
+	sliColor := "green"
+	if status.SLI < status.Objective {
+		sliColor = "red"
+	}
+
+	budgetColor := "green"
+	if status.BudgetRemaining <= 0 {
+		budgetColor = "red"
+	} else if status.BudgetRemaining < 0.25 {
+		budgetColor = "yellow"
+	}
+
+	burnColor := "green"
+	if status.BurnRate >= burnRateCritical {
+		burnColor = "red"
+	} else if status.BurnRate >= burnRateWarning {
+		burnColor = "yellow"
+	}
+
+	n := t.numbers
+	content := fmt.Sprintf("[%s]SLI: %s%%[white]\n[gray]Objective: %s%% over %s[white]\n\n[%s]Error budget remaining: %s%%[white]\n[%s]Burn rate: %sx[white]",
+		sliColor, n.Float(status.SLI, 3),
+		n.Float(status.Objective, 3), slo.WindowOrDefault(),
+		budgetColor, n.Float(status.BudgetRemaining*100, 1),
+		burnColor, n.Float(status.BurnRate, 2))
+	content += truncationNote("white", history.Good, history.Total)
+
+	panel.SetText(content)
+}
+
+// UpdateSLO updates an SLO panel with new good/total series
+func (t *TUI) UpdateSLO(index int, good, total *backend.TimeSeriesResult, err error) {
+	if index < 0 || index >= len(t.histories) {
+		return
+	}
+
+	if err != nil {
+		t.histories[index].LastError = err
+	} else {
+		t.histories[index].Good = good
+		t.histories[index].Total = total
+		t.histories[index].LastError = nil
+		t.clearThrottled(index)
+	}
+
+	if t.app != nil && len(t.panels) > index {
+		t.queueUpdateDraw(func() {
+			if err != nil {
+				t.panels[index].SetText(t.errorText(index, err))
+			} else {
+				t.renderSLO(index)
+			}
+			t.updateInstructions()
+			t.refreshInspect(index)
+		})
+	}
+}
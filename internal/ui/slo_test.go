@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func seriesFromValues(start time.Time, values ...float64) *backend.TimeSeriesResult {
+	points := make([]backend.DataPoint, len(values))
+	for i, v := range values {
+		points[i] = backend.DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	return &backend.TimeSeriesResult{Points: points}
+}
+
+func TestComputeSLO(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	good := seriesFromValues(start, 999, 1000, 998)
+	total := seriesFromValues(start, 1000, 1000, 1000)
+
+	status, err := ComputeSLO(good, total, 99.9)
+	if err != nil {
+		t.Fatalf("ComputeSLO should not return error, got %v", err)
+	}
+
+	// 2997 good out of 3000 total
+	if math.Abs(status.SLI-99.9) > 1e-9 {
+		t.Errorf("Expected SLI 99.9, got %f", status.SLI)
+	}
+
+	// Exactly the allowed error rate consumes the whole budget
+	if math.Abs(status.BudgetRemaining) > 1e-9 {
+		t.Errorf("Expected no budget remaining, got %f", status.BudgetRemaining)
+	}
+
+	// Latest sample has a 0.2% error rate against 0.1% allowed
+	if math.Abs(status.BurnRate-2) > 1e-9 {
+		t.Errorf("Expected burn rate 2, got %f", status.BurnRate)
+	}
+}
+
+func TestComputeSLOHealthy(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	good := seriesFromValues(start, 1000, 1000)
+	total := seriesFromValues(start, 1000, 1000)
+
+	status, err := ComputeSLO(good, total, 99.9)
+	if err != nil {
+		t.Fatalf("ComputeSLO should not return error, got %v", err)
+	}
+
+	if status.SLI != 100 {
+		t.Errorf("Expected SLI 100, got %f", status.SLI)
+	}
+	if math.Abs(status.BudgetRemaining-1) > 1e-9 {
+		t.Errorf("Expected full budget remaining, got %f", status.BudgetRemaining)
+	}
+	if status.BurnRate != 0 {
+		t.Errorf("Expected burn rate 0, got %f", status.BurnRate)
+	}
+}
+
+func TestComputeSLONoEvents(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := ComputeSLO(seriesFromValues(start, 0), seriesFromValues(start, 0), 99.9)
+	if err == nil {
+		t.Error("ComputeSLO should return error when there are no events")
+	}
+
+	_, err = ComputeSLO(nil, seriesFromValues(start, 1), 99.9)
+	if err == nil {
+		t.Error("ComputeSLO should return error for missing series")
+	}
+}
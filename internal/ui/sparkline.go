@@ -0,0 +1,56 @@
+package ui
+
+import "math"
+
+// sparkPoints is the number of latest values drawn in a panel's title
+const sparkPoints = 30
+
+// brailleDots holds the dot bits of each braille column, bottom row first
+var brailleDots = [2][4]rune{
+	{0x40, 0x04, 0x02, 0x01},
+	{0x80, 0x20, 0x10, 0x08},
+}
+
+// sparkline renders the last sparkPoints values as bars of braille dots, two
+// values per character. Missing values leave their column blank; a flat
+// series is drawn at half height.
+func sparkline(values []float64) string {
+	if len(values) > sparkPoints {
+		values = values[len(values)-sparkPoints:]
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if min > max {
+		return ""
+	}
+
+	// Each value fills one to four dots from the bottom
+	height := func(v float64) int {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		if max == min {
+			return 2
+		}
+		return 1 + int(math.Round((v-min)/(max-min)*3))
+	}
+
+	runes := make([]rune, 0, (len(values)+1)/2)
+	for i := 0; i < len(values); i += 2 {
+		r := rune(0x2800)
+		for col := 0; col < 2 && i+col < len(values); col++ {
+			for row := 0; row < height(values[i+col]); row++ {
+				r |= brailleDots[col][row]
+			}
+		}
+		runes = append(runes, r)
+	}
+	return string(runes)
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"all missing", []float64{math.NaN(), math.NaN()}, ""},
+		{"rising", []float64{0, 1, 2, 3}, "⣠⣾"},
+		{"falling", []float64{3, 2, 1, 0}, "⣷⣄"},
+		{"flat", []float64{5, 5, 5}, "⣤⡄"},
+		{"gap", []float64{0, math.NaN(), 3, 3}, "⡀⣿"},
+	}
+
+	for _, tt := range tests {
+		if got := sparkline(tt.values); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestSparklineKeepsLatestPoints(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i % 7)
+	}
+
+	got := []rune(sparkline(values))
+	if len(got) != sparkPoints/2 {
+		t.Errorf("Expected %d characters, got %d", sparkPoints/2, len(got))
+	}
+	if want := []rune(sparkline(values[len(values)-sparkPoints:])); string(got) != string(want) {
+		t.Errorf("Expected the sparkline of the latest points %q, got %q", string(want), string(got))
+	}
+}
+
+func TestPanelTitleSparkline(t *testing.T) {
+	h := newHarness(t, []backend.Query{{Name: "CPU", Expr: "cpu"}}, 120, 30)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: series(func(i int) (float64, bool) {
+		return float64(i), true
+	})}, nil)
+	h.sync()
+
+	// The last 30 minutes of a steady ramp
+	h.assertContains(" CPU ⣀⣀⣠⣤⣤⣤⣤⣴⣶⣶⣶⣶⣾⣿⣿ ")
+}
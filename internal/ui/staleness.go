@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+// staleAge returns how old the newest point is and whether that exceeds the
+// query's max_age. Queries without max_age are never stale. The age of a
+// query with an offset or a fixed window counts from the end of its range.
+func staleAge(q backend.Query, newest, now time.Time) (time.Duration, bool) {
+	maxAge := q.Staleness()
+	if maxAge <= 0 {
+		return 0, false
+	}
+	age := q.TimeRangeAt(now).End.Sub(newest)
+	return age, age > maxAge
+}
+
+// formatAge renders a duration in its largest whole unit, e.g. "45s" or "3h"
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+}
+
+// setStale dims a panel and adds an age badge to its title, or restores it.
+// The title also carries the panel's sparkline and says when a panel
+// outside its active hours is refreshed again.
+func (t *TUI) setStale(index int, age time.Duration, stale bool) {
+	panel := t.panels[index]
+	title := fmt.Sprintf(" %s ", t.queries[index].Name)
+	if spark := t.histories[index].Sparkline; spark != "" {
+		title += spark + " "
+	}
+
+	if until := t.histories[index].IdleUntil; !until.IsZero() {
+		title += t.lang.Sprintf("[gray]idle until %s[-] ", formatUntil(until, t.now()))
+	}
+	if warnings := panelWarnings(t.histories[index]); len(warnings) > 0 {
+		title += t.lang.Sprintf("[yellow]partial (%d)[-] ", len(warnings))
+	}
+	if stale {
+		title += t.lang.Sprintf("[red]%s old[-] ", formatAge(age))
+		panel.SetTextColor(tcell.ColorGray)
+	} else {
+		panel.SetTextColor(tview.Styles.PrimaryTextColor)
+	}
+	panel.SetTitle(title)
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestStaleAge(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := backend.Query{Name: "Heartbeat", Expr: "up", MaxAge: "5m"}
+
+	if _, stale := staleAge(q, now.Add(-4*time.Minute), now); stale {
+		t.Error("Point within max_age should not be stale")
+	}
+
+	age, stale := staleAge(q, now.Add(-12*time.Minute), now)
+	if !stale {
+		t.Error("Point older than max_age should be stale")
+	}
+	if age != 12*time.Minute {
+		t.Errorf("Expected age 12m, got %v", age)
+	}
+
+	if _, stale := staleAge(backend.Query{Name: "Any"}, now.Add(-24*time.Hour), now); stale {
+		t.Error("Queries without max_age are never stale")
+	}
+
+	// Data lagging by the configured offset is expected
+	lagged := backend.Query{Name: "Billing", Expr: "cost", MaxAge: "5m", Offset: "1h"}
+	if age, stale := staleAge(lagged, now.Add(-62*time.Minute), now); stale || age != 2*time.Minute {
+		t.Errorf("Expected age 2m past the offset and not stale, got %v, %v", age, stale)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 30*time.Second, "12m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAge(tt.age); got != tt.expected {
+			t.Errorf("formatAge(%v): expected %s, got %s", tt.age, tt.expected, got)
+		}
+	}
+}
+
+func TestSetStale(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Heartbeat", Expr: "up", MaxAge: "5m"}}, nil)
+
+	tui.setStale(0, 12*time.Minute, true)
+	if !strings.Contains(tui.panels[0].GetTitle(), "12m old") {
+		t.Errorf("Stale panel title should carry an age badge, got %q", tui.panels[0].GetTitle())
+	}
+
+	tui.setStale(0, 0, false)
+	if tui.panels[0].GetTitle() != " Heartbeat " {
+		t.Errorf("Fresh panel title should be restored, got %q", tui.panels[0].GetTitle())
+	}
+}
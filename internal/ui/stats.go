@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+	"promviz/internal/stats"
+	"promviz/internal/topn"
+)
+
+// statLabels name the statistics in the stats row
+var statLabels = map[string]string{
+	backend.StatMin:      "min",
+	backend.StatMax:      "max",
+	backend.StatAvg:      "avg",
+	backend.StatLast:     "last",
+	backend.StatTimeAvg:  "time avg",
+	backend.StatIntegral: "integral",
+}
+
+// seriesStats computes the selected statistics of one series. The integral
+// is counted in the configured time unit.
+func seriesStats(st *backend.StatsConfig, points []backend.DataPoint) map[string]float64 {
+	s := stats.Summarize(points)
+	tw := stats.Weigh(points)
+	values := make(map[string]float64, len(st.Show))
+	for _, name := range st.Show {
+		switch name {
+		case backend.StatMin:
+			values[name] = s.Min
+		case backend.StatMax:
+			values[name] = s.Max
+		case backend.StatAvg:
+			values[name] = s.Avg
+		case backend.StatLast:
+			values[name] = s.Last
+		case backend.StatTimeAvg:
+			values[name] = tw.Mean
+		case backend.StatIntegral:
+			values[name] = tw.Integral / st.Per().Seconds()
+		}
+		if s.Count == 0 {
+			values[name] = math.NaN()
+		}
+	}
+	return values
+}
+
+// panelStats computes the selected statistics over every series of a
+// panel: min, max, avg and last over all their points, the mean of their
+// time-weighted averages and the sum of their integrals
+func panelStats(st *backend.StatsConfig, points []backend.DataPoint) map[string]float64 {
+	all := seriesStats(st, points)
+	series := topn.Split(points)
+	if len(series) < 2 {
+		return all
+	}
+
+	var meanSum, integral float64
+	var means int
+	for _, s := range series {
+		values := seriesStats(&backend.StatsConfig{Show: []string{backend.StatTimeAvg, backend.StatIntegral}, IntegralUnit: st.IntegralUnit}, s.Points)
+		if !math.IsNaN(values[backend.StatTimeAvg]) {
+			meanSum += values[backend.StatTimeAvg]
+			integral += values[backend.StatIntegral]
+			means++
+		}
+	}
+	if _, ok := all[backend.StatTimeAvg]; ok && means > 0 {
+		all[backend.StatTimeAvg] = meanSum / float64(means)
+	}
+	if _, ok := all[backend.StatIntegral]; ok && means > 0 {
+		all[backend.StatIntegral] = integral
+	}
+	return all
+}
+
+// integralUnit names the time an integral is counted in, e.g. "h" or "15m"
+func integralUnit(per time.Duration) string {
+	s := model.Duration(per).String()
+	if len(s) == 2 && s[0] == '1' {
+		return s[1:]
+	}
+	return s
+}
+
+// formatStats renders the stats row of a panel, e.g.
+// "time avg 3.20 kW  integral 12.50 kW·h"
+func formatStats(st *backend.StatsConfig, values map[string]float64, unit, textColor string, f numfmt.Format) string {
+	var parts []string
+	for _, name := range st.Show {
+		v := values[name]
+		text := formatValue(v, unit, f)
+		if name == backend.StatIntegral {
+			text = strings.TrimSpace(f.Float(v, 2)+" "+unit) + "·" + integralUnit(st.Per())
+		}
+		parts = append(parts, fmt.Sprintf("[gray]%s[%s] %s", statLabels[name], textColor, text))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+)
+
+// meterPoints returns the readings of a power meter sampling 2 kW every ten
+// minutes for an hour, then 10 kW every minute for five
+func meterPoints(series string, end time.Time) []backend.DataPoint {
+	var points []backend.DataPoint
+	start := end.Add(-65 * time.Minute)
+	for m := 0; m <= 60; m += 10 {
+		points = append(points, backend.DataPoint{Timestamp: start.Add(time.Duration(m) * time.Minute), Value: 2, Series: series})
+	}
+	for m := 61; m <= 65; m++ {
+		points = append(points, backend.DataPoint{Timestamp: start.Add(time.Duration(m) * time.Minute), Value: 10, Series: series})
+	}
+	return points
+}
+
+func TestPanelStats(t *testing.T) {
+	st := &backend.StatsConfig{Show: []string{backend.StatAvg, backend.StatTimeAvg, backend.StatIntegral}, IntegralUnit: "1h"}
+	points := meterPoints(`power{meter="a"}`, goldenNow)
+
+	values := panelStats(st, points)
+	// 2 kW for an hour, a minute ramping up to 10 kW and four minutes at it
+	integral := (2*60 + 6 + 10*4) / 60.0
+	if math.Abs(values[backend.StatIntegral]-integral) > 1e-9 {
+		t.Errorf("Expected %v kWh, got %v", integral, values[backend.StatIntegral])
+	}
+	if math.Abs(values[backend.StatTimeAvg]-integral/65*60) > 1e-9 {
+		t.Errorf("Expected a time-weighted mean of %v, got %v", integral/65*60, values[backend.StatTimeAvg])
+	}
+	if values[backend.StatAvg] != 64.0/12 {
+		t.Errorf("Expected the point average %v, got %v", 64.0/12, values[backend.StatAvg])
+	}
+
+	// The integrals of several series add up
+	both := panelStats(st, append(points, meterPoints(`power{meter="b"}`, goldenNow)...))
+	if math.Abs(both[backend.StatIntegral]-2*integral) > 1e-9 || math.Abs(both[backend.StatTimeAvg]-values[backend.StatTimeAvg]) > 1e-9 {
+		t.Errorf("Expected the integrals summed and the means averaged, got %v", both)
+	}
+
+	if empty := panelStats(st, nil); !math.IsNaN(empty[backend.StatTimeAvg]) {
+		t.Errorf("Expected NaN without data, got %v", empty)
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	st := &backend.StatsConfig{Show: []string{backend.StatTimeAvg, backend.StatIntegral}, IntegralUnit: "1h"}
+	got := formatStats(st, map[string]float64{backend.StatTimeAvg: 3.2, backend.StatIntegral: 12.5}, "kW", "white", numfmt.Format{})
+	if want := "[gray]time avg[white] 3.20 kW  [gray]integral[white] 12.50 kW·h"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := integralUnit(15 * time.Minute); got != "15m" {
+		t.Errorf("Expected 15m, got %q", got)
+	}
+	if got := integralUnit(time.Second); got != "s" {
+		t.Errorf("Expected s, got %q", got)
+	}
+}
+
+func TestStatsRow(t *testing.T) {
+	query := backend.Query{ID: "power", Name: "Power", Expr: "power_kw", Range: "2h", Unit: "kW",
+		Stats: &backend.StatsConfig{Show: []string{backend.StatTimeAvg, backend.StatIntegral}, IntegralUnit: "1h"}}
+	tui := NewTUI([]backend.Query{query}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	tui.exportDir = t.TempDir()
+	tui.histories[0].TimeSeries = &backend.TimeSeriesResult{Points: meterPoints(`power{meter="a"}`, goldenNow)}
+	tui.renderTimeSeriesGraph(0)
+
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "time avg[white] 2.55 kW  [gray]integral[white] 2.77 kW·h") {
+		t.Errorf("Expected the stats row, got %q", text)
+	}
+	if details := tui.panelDetails(0); !strings.Contains(details, "Stats row:[white] time avg, integral") {
+		t.Errorf("Expected the stats in the details, got:\n%s", details)
+	}
+
+	path, err := tui.exportPanel(0)
+	if err != nil {
+		t.Fatalf("exportPanel failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export panelExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("Export should be valid JSON, got %v", err)
+	}
+	if export.IntegralUnit != "1h" || len(export.Series) != 1 || math.Abs(export.Series[0].Stats[backend.StatIntegral]-166.0/60) > 1e-9 {
+		t.Errorf("Expected the stats of the series in the export, got %+v", export)
+	}
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+// statusCells are the symbol and color of a cell of a status matrix, by
+// state
+var statusCells = map[boolState]struct{ symbol, color string }{
+	boolMissing: {"·", "gray"},
+	boolUp:      {"●", "green"},
+	boolDown:    {"●", "red"},
+}
+
+// statusMatrix returns the state of every check per value of the by label,
+// from the latest value of each series: down if any series of the row and
+// check is zero, up if it has others and missing otherwise. Series without
+// the label form a row of their own. Rows are sorted; newest is the time of
+// the latest value.
+func statusMatrix(points []backend.DataPoint, by string) (rows []string, states map[string]map[string]boolState, newest time.Time) {
+	latest := make(map[string]backend.DataPoint)
+	for _, p := range points {
+		if math.IsNaN(p.Value) {
+			continue
+		}
+		if prev, ok := latest[p.Series]; !ok || p.Timestamp.After(prev.Timestamp) {
+			latest[p.Series] = p
+		}
+	}
+
+	states = make(map[string]map[string]boolState)
+	for series, p := range latest {
+		labels := backend.ParseSeriesName(series)
+		row, ok := labels[by]
+		if !ok {
+			row = fmt.Sprintf("(no %s)", by)
+		}
+		if states[row] == nil {
+			states[row] = make(map[string]boolState)
+			rows = append(rows, row)
+		}
+		check := labels[backend.StatusCheckLabel]
+		switch {
+		case p.Value == 0:
+			states[row][check] = boolDown
+		case states[row][check] == boolMissing:
+			states[row][check] = boolUp
+		}
+		if p.Timestamp.After(newest) {
+			newest = p.Timestamp
+		}
+	}
+	sort.Strings(rows)
+	return rows, states, newest
+}
+
+// renderStatus renders a status panel as a matrix of its checks per value
+// of its by label, green while passing and red while failing
+func (t *TUI) renderStatus(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	q := t.queries[index]
+	checks := q.Status.Checks
+
+	rows, states, newest := statusMatrix(history.TimeSeries.Points, q.Status.By)
+	if len(rows) == 0 {
+		panel.SetText(t.lang.T("No data available"))
+		return
+	}
+
+	textColor := "white"
+	age, stale := staleAge(q, newest, t.now())
+	t.setStale(index, age, stale)
+	if stale {
+		textColor = "gray"
+	}
+
+	var passing, failing, missing int
+	for _, row := range rows {
+		for _, check := range checks {
+			switch states[row][check.Name] {
+			case boolUp:
+				passing++
+			case boolDown:
+				failing++
+			default:
+				missing++
+			}
+		}
+	}
+
+	var b strings.Builder
+	switch {
+	case stale:
+		fmt.Fprintf(&b, "[gray]%d of %d checks passing[%s]", passing, passing+failing, textColor)
+	case failing > 0:
+		fmt.Fprintf(&b, "[red]%d of %d checks failing[%s]", failing, passing+failing, textColor)
+	default:
+		fmt.Fprintf(&b, "[green]All %d checks passing[%s]", passing, textColor)
+	}
+	if missing > 0 {
+		fmt.Fprintf(&b, ", [gray]%d without data[%s]", missing, textColor)
+	}
+	b.WriteString("\n\n")
+
+	labelWidth := 0
+	for _, row := range rows {
+		if n := len([]rune(row)); n > labelWidth {
+			labelWidth = n
+		}
+	}
+	if labelWidth > boolLabelWidth {
+		labelWidth = boolLabelWidth
+	}
+
+	// A column per check, as wide as its name
+	b.WriteString("[gray]" + strings.Repeat(" ", labelWidth+1))
+	for _, check := range checks {
+		b.WriteString(tview.Escape(check.Name) + "  ")
+	}
+	fmt.Fprintf(&b, "[%s]\n", textColor)
+	for _, row := range rows {
+		label := truncate(row, labelWidth)
+		b.WriteString(tview.Escape(label) + strings.Repeat(" ", labelWidth-len([]rune(label))+1))
+		for _, check := range checks {
+			cell := statusCells[states[row][check.Name]]
+			color := cell.color
+			if stale {
+				color = "gray"
+			}
+			fmt.Fprintf(&b, "[%s]%s%s", color, cell.symbol, strings.Repeat(" ", len([]rune(check.Name))+1))
+		}
+		fmt.Fprintf(&b, "[%s]\n", textColor)
+	}
+	b.WriteString(truncationNote(textColor, history.TimeSeries))
+
+	panel.SetText(b.String())
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+func TestStatusMatrix(t *testing.T) {
+	earlier := goldenNow.Add(-time.Minute)
+	points := []backend.DataPoint{
+		{Timestamp: earlier, Value: 0, Series: `{check="up",service="api"}`},
+		{Timestamp: goldenNow, Value: 1, Series: `{check="up",service="api"}`},
+		{Timestamp: goldenNow, Value: 1, Series: `{check="up",instance="a",service="web"}`},
+		{Timestamp: goldenNow, Value: 0, Series: `{check="up",instance="b",service="web"}`},
+		{Timestamp: earlier, Value: 1, Series: `{check="errors",service="web"}`},
+		{Timestamp: goldenNow, Value: 1, Series: `{check="errors"}`},
+	}
+
+	rows, states, newest := statusMatrix(points, "service")
+	if len(rows) != 3 || rows[0] != "(no service)" || rows[1] != "api" || rows[2] != "web" {
+		t.Fatalf("Expected sorted rows, got %v", rows)
+	}
+	if states["api"]["up"] != boolUp {
+		t.Error("The latest value of a series should decide its state")
+	}
+	if states["web"]["up"] != boolDown {
+		t.Error("A row should fail a check when any of its series does")
+	}
+	if states["web"]["errors"] != boolUp || states["api"]["errors"] != boolMissing {
+		t.Errorf("Unexpected errors column %v %v", states["web"]["errors"], states["api"]["errors"])
+	}
+	if !newest.Equal(goldenNow) {
+		t.Errorf("Expected the newest value at %v, got %v", goldenNow, newest)
+	}
+}
+
+func TestStatusPanel(t *testing.T) {
+	query := backend.Query{Name: "Services", Type: backend.PanelStatus, Status: &backend.StatusConfig{
+		By: "service",
+		Checks: []backend.StatusCheck{
+			{Name: "up", Expr: "up"},
+			{Name: "errors", Expr: "error_ratio < bool 0.01"},
+		},
+	}}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: goldenNow, Value: 1, Series: `{check="up",service="api"}`},
+		{Timestamp: goldenNow, Value: 1, Series: `{check="up",service="web"}`},
+		{Timestamp: goldenNow, Value: 0, Series: `{check="errors",service="web"}`},
+	}}, nil)
+	h.sync()
+
+	h.assertContains("1 of 3 checks failing, 1 without data")
+	h.assertContains("    up  errors")
+	h.assertContains("api ●   ·")
+	h.assertContains("web ●   ●")
+
+	x, y, ok := h.find("web ●   ●")
+	if !ok {
+		t.Fatal("Expected the web row")
+	}
+	if fg, _, _ := h.style(x+8, y).Decompose(); fg != tcell.ColorRed {
+		t.Errorf("Expected the failing check in red, got %v", fg)
+	}
+
+	details := h.tui.panelDetails(0)
+	for _, want := range []string{"Rows:[white]  by service", "Check:[white] errors: error_ratio < bool 0.01"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("Details should contain %q, got:\n%s", want, details)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+const tagsPage = "tags"
+
+// SetMuteHandler sets the function called with the muted tags whenever
+// they change. Call before Run.
+func (t *TUI) SetMuteHandler(onMute func(tags []string)) {
+	t.onMute = onMute
+}
+
+// mutedTags returns the muted tags, sorted
+func (t *TUI) mutedTags() []string {
+	var tags []string
+	for tag := range t.muted {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// tagged returns the panels carrying the tag, in display order
+func (t *TUI) tagged(tag string) []int {
+	var panels []int
+	for _, i := range t.layout {
+		if t.queries[i].HasTag(tag) {
+			panels = append(panels, i)
+		}
+	}
+	return panels
+}
+
+// showTags opens a modal listing the tags of the panels. Selecting one
+// shows only its panels, m mutes or unmutes their alerts and e exports
+// their data.
+func (t *TUI) showTags() {
+	tags := backend.Tags(t.queries)
+	if len(tags) == 0 {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle(" Tags (Enter to show only, m to mute alerts, e to export, Esc to close) ")
+
+	list.AddItem("  All panels", "", 0, func() {
+		t.closeModal(tagsPage)
+		t.showHidden()
+	})
+	for _, tag := range tags {
+		list.AddItem(t.tagItem(tag), "", 0, func() {
+			t.closeModal(tagsPage)
+			t.showTag(tag)
+		})
+	}
+
+	// The first item shows all panels
+	tagAt := func(item int) (string, bool) {
+		if item == 0 {
+			return "", false
+		}
+		return tags[item-1], true
+	}
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'g' || event.Rune() == 'G' {
+			t.closeModal(tagsPage)
+			return nil
+		}
+		item := list.GetCurrentItem()
+		tag, ok := tagAt(item)
+		switch event.Rune() {
+		case 'm', 'M':
+			if ok {
+				t.toggleMute(tag)
+				list.SetItemText(item, t.tagItem(tag), "")
+			}
+			return nil
+		case 'e', 'E':
+			if ok {
+				t.closeModal(tagsPage)
+				t.exportTag(tag)
+			}
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(tagsPage, modal(list, 80, len(tags)+3), true, true)
+	t.app.SetFocus(list)
+}
+
+// tagItem is the list line of a tag in the tag view
+func (t *TUI) tagItem(tag string) string {
+	item := fmt.Sprintf("  %-20s %d panels", tview.Escape(tag), len(t.tagged(tag)))
+	if t.muted[tag] {
+		item += "  [yellow]alerts muted[white]"
+	}
+	return item
+}
+
+// showTag shows only the panels carrying the tag
+func (t *TUI) showTag(tag string) {
+	panels := t.tagged(tag)
+	if len(panels) == 0 {
+		return
+	}
+
+	t.hidden = make(map[int]bool)
+	for i, q := range t.queries {
+		if !q.HasTag(tag) {
+			t.hidden[i] = true
+		}
+	}
+	t.rebuildShown()
+	if t.hidden[t.focusIndex] {
+		t.focusIndex = panels[0]
+	}
+	t.scrollOffset = 0
+	t.scrollToShowFocus()
+	t.updateFocus()
+	t.updateInstructions()
+	t.stateChanged()
+
+	// Panels rendered while hidden were sized for no space
+	go t.queueUpdateDraw(t.redrawPanels)
+}
+
+// toggleMute mutes or unmutes the alerts of the panels carrying the tag
+func (t *TUI) toggleMute(tag string) {
+	if t.muted[tag] {
+		delete(t.muted, tag)
+	} else {
+		t.muted[tag] = true
+	}
+	if t.onMute != nil {
+		t.onMute(t.mutedTags())
+	}
+	t.updateInstructions()
+	t.stateChanged()
+}
+
+// exportTag exports every panel carrying the tag and reports the result in
+// the help line. Panels without data are skipped.
+func (t *TUI) exportTag(tag string) {
+	var exported int
+	var failed []string
+	for _, i := range t.tagged(tag) {
+		if _, err := t.exportPanel(i); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		exported++
+	}
+
+	text := t.lang.Sprintf("[green]Exported %d panels tagged %s[white]", exported, tview.Escape(tag))
+	if exported == 0 {
+		text = t.lang.Sprintf("[red]Export of %s failed: %s[white]", tview.Escape(tag), tview.Escape(strings.Join(failed, "; ")))
+	} else if len(failed) > 0 {
+		text += t.lang.Sprintf(" [yellow](%d skipped: %s)[white]", len(failed), tview.Escape(strings.Join(failed, "; ")))
+	}
+	t.instructions.SetText(text)
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+	"promviz/internal/state"
+)
+
+func taggedQueries() []backend.Query {
+	return []backend.Query{
+		{ID: "cpu", Name: "CPU", Expr: "cpu", Tags: []string{"host"}},
+		{ID: "pg", Name: "Postgres", Expr: "pg", Tags: []string{"db", "critical"}},
+		{ID: "api", Name: "API", Expr: "api", Tags: []string{"critical"}},
+		{ID: "redis", Name: "Redis", Expr: "redis", Tags: []string{"db"}},
+	}
+}
+
+func TestShowTag(t *testing.T) {
+	tui := NewTUI(taggedQueries(), nil)
+	var saved []state.State
+	tui.SetStateHandler(func(s state.State) { saved = append(saved, s) })
+
+	tui.showTag("critical")
+	if want := []int{1, 2}; !reflect.DeepEqual(tui.shown, want) {
+		t.Errorf("Expected only the critical panels, got %v", tui.shown)
+	}
+	if tui.focusIndex != 1 {
+		t.Errorf("Expected focus on the first critical panel, got %d", tui.focusIndex)
+	}
+	if len(saved) != 1 || !reflect.DeepEqual(saved[0].Hidden, []string{"cpu", "redis"}) {
+		t.Errorf("Expected the other panels saved as hidden, got %+v", saved)
+	}
+
+	// The focused panel stays focused if it carries the tag
+	tui.showTag("db")
+	if want := []int{1, 3}; !reflect.DeepEqual(tui.shown, want) || tui.focusIndex != 1 {
+		t.Errorf("Expected the db panels with focus kept, got %v and %d", tui.shown, tui.focusIndex)
+	}
+
+	tui.showTag("unknown")
+	if len(tui.shown) != 2 {
+		t.Errorf("An unknown tag should leave the panels as they are, got %v", tui.shown)
+	}
+}
+
+func TestToggleMute(t *testing.T) {
+	tui := NewTUI(taggedQueries(), nil)
+	var muted [][]string
+	tui.SetMuteHandler(func(tags []string) { muted = append(muted, tags) })
+
+	tui.toggleMute("db")
+	tui.toggleMute("critical")
+	tui.toggleMute("db")
+	want := [][]string{{"db"}, {"critical", "db"}, {"critical"}}
+	if !reflect.DeepEqual(muted, want) {
+		t.Errorf("Expected muted tags %v, got %v", want, muted)
+	}
+	if s := tui.currentState(); !reflect.DeepEqual(s.Muted, []string{"critical"}) {
+		t.Errorf("Expected the muted tags in the state, got %v", s.Muted)
+	}
+
+	tui.SetState(state.State{Muted: []string{"host"}})
+	if !tui.muted["host"] || tui.muted["critical"] {
+		t.Errorf("Expected the saved muted tags restored, got %v", tui.muted)
+	}
+}
+
+func TestTagsKey(t *testing.T) {
+	h := newHarness(t, taggedQueries(), 160, 30)
+	h.tui.exportDir = t.TempDir()
+	h.tui.UpdateTimeSeries(1, &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: time.Now(), Value: 1}}}, nil)
+	h.tui.UpdateTimeSeries(3, &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: time.Now(), Value: 2}}}, nil)
+	h.sync()
+
+	h.typeRune('g')
+	h.assertContains("All panels")
+	h.assertContains("critical")
+
+	// Items run All panels, critical, db, host
+	h.press(tcell.KeyDown, 0)
+	h.press(tcell.KeyDown, 0)
+	h.typeRune('m')
+	h.assertContains("alerts muted")
+	h.assertContains("1 tags muted")
+	h.typeRune('e')
+	h.assertContains("Exported 2 panels tagged db")
+
+	matches, _ := filepath.Glob(filepath.Join(h.tui.exportDir, "promviz-*.json"))
+	if len(matches) != 2 {
+		t.Errorf("Expected an export file per db panel, got %v", matches)
+	}
+
+	h.typeRune('g')
+	h.press(tcell.KeyDown, 0)
+	h.press(tcell.KeyEnter, 0)
+	h.assertContains("2 panels hidden")
+	h.assertNotContains("Redis")
+}
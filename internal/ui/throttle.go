@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"time"
+)
+
+// SetRetryHandler sets the function called with the index of the focused
+// panel when retry is pressed. Call before Run.
+func (t *TUI) SetRetryHandler(onRetry func(index int)) {
+	t.onRetry = onRetry
+}
+
+// SetThrottled marks a panel whose query failed the given number of times
+// in a row and won't be refreshed again until next
+func (t *TUI) SetThrottled(index int, failures int, next time.Time) {
+	if index < 0 || index >= len(t.histories) {
+		return
+	}
+
+	history := t.histories[index]
+	history.Failures = failures
+	history.ThrottledUntil = next
+
+	t.queueUpdateDraw(func() {
+		if history.LastError != nil {
+			t.panels[index].SetText(t.errorText(index, history.LastError))
+		}
+		t.updateInstructions()
+	})
+}
+
+// throttledPanels counts the panels currently backed off
+func (t *TUI) throttledPanels() int {
+	n := 0
+	for _, h := range t.histories {
+		if !h.ThrottledUntil.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// errorText renders the error shown in place of a panel's graph, noting
+// when the panel is throttled
+func (t *TUI) errorText(index int, err error) string {
+	text := describeError(err, t.queries[index], t.lang)
+	if until := t.histories[index].ThrottledUntil; !until.IsZero() {
+		text = t.lang.Sprintf("[yellow]Throttled[white] after %d failures, next try at %s (r to retry)\n\n",
+			t.histories[index].Failures, until.Format("15:04:05")) + text
+	}
+	return text
+}
+
+// clearThrottled returns a panel to the normal refresh interval after a
+// successful update or a retry
+func (t *TUI) clearThrottled(index int) {
+	t.histories[index].Failures = 0
+	t.histories[index].ThrottledUntil = time.Time{}
+}
+
+// retry refreshes the focused panel right away, lifting any throttling
+func (t *TUI) retry() {
+	if t.onRetry == nil || len(t.panels) == 0 {
+		return
+	}
+
+	index := t.focusIndex
+	if t.histories[index].LastError != nil {
+		t.panels[index].SetText(t.lang.T("Retrying..."))
+	}
+	t.clearThrottled(index)
+	t.updateInstructions()
+
+	// The handler updates the panel through the event loop, which is
+	// running this key handler
+	go t.onRetry(index)
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestThrottledPanel(t *testing.T) {
+	h := newHarness(t, screenQueries[:2], 160, 30)
+
+	retried := make(chan int, 1)
+	h.tui.SetRetryHandler(func(index int) { retried <- index })
+
+	next := time.Date(2023, 1, 1, 12, 5, 0, 0, time.Local)
+	h.tui.UpdateTimeSeries(0, nil, errors.New("connection refused"))
+	h.tui.SetThrottled(0, 3, next)
+	h.sync()
+
+	h.assertContains("Throttled after 3 failures, next try at 12:05:00 (r to retry)")
+	h.assertContains("Error: connection refused")
+	h.assertContains("1 panels throttled (r to retry)")
+
+	h.typeRune('r')
+	select {
+	case index := <-retried:
+		if index != 0 {
+			t.Errorf("Expected retry of the focused panel 0, got %d", index)
+		}
+	case <-time.After(harnessTimeout):
+		t.Fatal("Retry handler was not called")
+	}
+	h.assertContains("Retrying...")
+	h.assertNotContains("panels throttled")
+
+	// A successful update clears the throttled state
+	h.tui.SetThrottled(0, 3, next)
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: []backend.DataPoint{
+		{Timestamp: time.Now(), Value: 7},
+	}}, nil)
+	h.sync()
+	if !h.tui.histories[0].ThrottledUntil.IsZero() {
+		t.Error("Successful update should clear the throttled state")
+	}
+	h.assertNotContains("Throttled")
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+)
+
+const (
+	rangePage       = "range"
+	customRangePage = "custom-range"
+)
+
+// rangePresets are the relative ranges offered by the time range menu
+var rangePresets = []string{"5m", "15m", "1h", "6h", "24h", "7d"}
+
+// SetRangeHandler sets the function called with the queries after the
+// range of a panel changed. Call before Run.
+func (t *TUI) SetRangeHandler(onRange func(queries []backend.Query)) {
+	t.onRange = onRange
+}
+
+// showRanges opens a modal with range presets, applied to every panel or,
+// with f, to the focused one
+func (t *TUI) showRanges() {
+	if len(t.panels) == 0 {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle(" Time Range (Enter for all panels, f for the focused one, Esc to close) ")
+
+	// Each item applies to the given panels
+	actions := make([]func(panels []int), 0, len(rangePresets)+2)
+	for _, preset := range rangePresets {
+		actions = append(actions, func(panels []int) { t.applyRange(panels, preset, nil) })
+		list.AddItem("  Last "+preset, "", 0, nil)
+	}
+	actions = append(actions, t.showCustomRange)
+	list.AddItem("  Custom range...", "", 0, nil)
+	actions = append(actions, func(panels []int) { t.applyRange(panels, "", nil) })
+	list.AddItem("  As configured", "", 0, nil)
+
+	choose := func(panels []int) {
+		t.closeModal(rangePage)
+		actions[list.GetCurrentItem()](panels)
+	}
+	list.SetSelectedFunc(func(int, string, string, rune) { choose(t.layout) })
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 't' || event.Rune() == 'T':
+			t.closeModal(rangePage)
+			return nil
+		case event.Rune() == 'f' || event.Rune() == 'F':
+			choose([]int{t.focusIndex})
+			return nil
+		}
+		return event
+	})
+
+	t.pages.AddPage(rangePage, modal(list, 80, len(actions)+2), true, true)
+	t.app.SetFocus(list)
+}
+
+// showCustomRange opens a form asking for the start and end of a fixed
+// range for the panels, prefilled with the focused panel's current range
+func (t *TUI) showCustomRange(panels []int) {
+	current := t.queries[t.focusIndex].TimeRangeAt(t.now())
+	status := tview.NewTextView().SetDynamicColors(true)
+
+	form := tview.NewForm()
+	form.AddInputField("Start", current.Start.Format(backend.WindowLayout), 20, nil, nil)
+	form.AddInputField("End", current.End.Format(backend.WindowLayout), 20, nil, nil)
+	form.AddButton("Apply", func() {
+		start := form.GetFormItemByLabel("Start").(*tview.InputField).GetText()
+		end := form.GetFormItemByLabel("End").(*tview.InputField).GetText()
+		window, err := backend.ParseWindow(start+" to "+end, time.Local)
+		if err != nil {
+			status.SetText(fmt.Sprintf("[red]%s[white]", tview.Escape(err.Error())))
+			return
+		}
+		t.closeModal(customRangePage)
+		t.applyRange(panels, "", &window)
+	})
+	form.AddButton("Cancel", func() { t.closeModal(customRangePage) })
+	form.SetCancelFunc(func() { t.closeModal(customRangePage) })
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 7, 0, true).
+		AddItem(status, 0, 1, false)
+	layout.SetBorder(true)
+	layout.SetTitle(" Custom range (YYYY-MM-DD HH:MM, the end may be HH:MM, Esc to cancel) ")
+
+	t.pages.AddPage(customRangePage, modal(layout, 76, 10), true, true)
+	t.app.SetFocus(form)
+}
+
+// applyRange sets the range of the panels to a relative range or a fixed
+// window. Without either, the panels return to their configured range.
+func (t *TUI) applyRange(panels []int, rng string, window *backend.TimeRange) {
+	for _, i := range panels {
+		t.queries[i].Range, t.queries[i].Window = rng, window
+		if rng == "" && window == nil {
+			t.queries[i].Range = t.ranges[i]
+		}
+	}
+
+	t.rangeLabel = t.pickedRange()
+	if t.onRange != nil {
+		t.onRange(append([]backend.Query(nil), t.queries...))
+	}
+	t.updateInstructions()
+	t.refreshInspect(t.focusIndex)
+
+	// Redraw the current data on the grid of the new range until it is
+	// fetched again
+	go t.queueUpdateDraw(t.redrawPanels)
+}
+
+// pickedRange describes the ranges picked in the TUI: the relative range of
+// every panel, "mixed" if the panels differ, or empty if every panel is on
+// its configured range
+func (t *TUI) pickedRange() string {
+	picked := false
+	for i, q := range t.queries {
+		if q.Window != nil || q.Range != t.ranges[i] {
+			picked = true
+		}
+	}
+	if !picked {
+		return ""
+	}
+	for _, q := range t.queries {
+		if q.Window != nil || q.Range != t.queries[0].Range {
+			return t.lang.T("mixed")
+		}
+	}
+	return t.lang.Sprintf("last %s", t.queries[0].Range)
+}
+
+// commonWindow returns the fixed window shared by every query, or nil
+func commonWindow(queries []backend.Query) *backend.TimeRange {
+	if len(queries) == 0 || queries[0].Window == nil {
+		return nil
+	}
+	for _, q := range queries[1:] {
+		if q.Window == nil || *q.Window != *queries[0].Window {
+			return nil
+		}
+	}
+	return queries[0].Window
+}
+
+// formatWindow renders a fixed range, leaving out the date of the end if
+// it is the same as the start's
+func formatWindow(window backend.TimeRange) string {
+	start, end := window.Start.Local(), window.End.Local()
+	if start.Format("2006-01-02") == end.Format("2006-01-02") {
+		return start.Format(backend.WindowLayout) + " to " + end.Format("15:04")
+	}
+	return start.Format(backend.WindowLayout) + " to " + end.Format(backend.WindowLayout)
+}
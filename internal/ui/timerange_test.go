@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"promviz/internal/backend"
+)
+
+func rangeQueries() []backend.Query {
+	return []backend.Query{
+		{ID: "cpu", Name: "CPU", Expr: "cpu", Range: "6h"},
+		{ID: "mem", Name: "Memory", Expr: "mem", Offset: "1h"},
+	}
+}
+
+func TestApplyRange(t *testing.T) {
+	queries := rangeQueries()
+	tui := NewTUI(queries, nil)
+	var received [][]backend.Query
+	tui.SetRangeHandler(func(q []backend.Query) { received = append(received, q) })
+
+	tui.applyRange([]int{0, 1}, "15m", nil)
+	if tui.queries[0].Range != "15m" || tui.queries[1].Range != "15m" || tui.rangeLabel != "last 15m" {
+		t.Errorf("Expected every panel on the last 15m, got %+v (%q)", tui.queries, tui.rangeLabel)
+	}
+	if queries[0].Range != "6h" {
+		t.Error("The range should not change the caller's queries")
+	}
+
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.Local)
+	window := backend.FixedRange(start, start.Add(2*time.Hour))
+	tui.applyRange([]int{1}, "", &window)
+	if tui.queries[1].Window == nil || tui.queries[0].Window != nil {
+		t.Errorf("Expected only the second panel on the fixed window, got %+v", tui.queries)
+	}
+	if tui.rangeLabel != "mixed" {
+		t.Errorf("Panels on different ranges should be labeled mixed, got %q", tui.rangeLabel)
+	}
+	if got := tui.instructions.GetText(true); !strings.Contains(got, "Range: mixed (t)") {
+		t.Errorf("Expected the mixed ranges in the help line, got %q", got)
+	}
+
+	tui.applyRange([]int{0}, "", &window)
+	if got := tui.instructions.GetText(true); !strings.Contains(got, "Range: 2024-05-02 13:00 to 15:00, refresh paused") {
+		t.Errorf("Expected the fixed window in the help line, got %q", got)
+	}
+
+	// A single panel on the range the others are on is not mixed
+	tui.applyRange([]int{0}, "15m", nil)
+	tui.applyRange([]int{1}, "15m", nil)
+	if tui.rangeLabel != "last 15m" {
+		t.Errorf("Expected the range shared by every panel, got %q", tui.rangeLabel)
+	}
+
+	tui.applyRange([]int{0, 1}, "", nil)
+	if tui.queries[0].Range != "6h" || tui.queries[1].Range != "" || tui.queries[1].Window != nil || tui.rangeLabel != "" {
+		t.Errorf("Expected the configured ranges back, got %+v (%q)", tui.queries, tui.rangeLabel)
+	}
+
+	if len(received) != 6 || received[1][1].Window == nil || !reflect.DeepEqual(received[5], tui.queries) {
+		t.Errorf("Expected the queries after every change, got %+v", received)
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	start := time.Date(2024, 5, 2, 13, 0, 0, 0, time.Local)
+	if got := formatWindow(backend.FixedRange(start, start.Add(2*time.Hour))); got != "2024-05-02 13:00 to 15:00" {
+		t.Errorf("Expected the end date left out, got %q", got)
+	}
+	if got := formatWindow(backend.FixedRange(start, start.Add(24*time.Hour))); got != "2024-05-02 13:00 to 2024-05-03 13:00" {
+		t.Errorf("Expected both dates, got %q", got)
+	}
+}
+
+func TestRangesKey(t *testing.T) {
+	h := newHarness(t, rangeQueries(), 160, 30)
+	var received []backend.Query
+	h.tui.SetRangeHandler(func(q []backend.Query) { received = q })
+
+	h.typeRune('t')
+	h.assertContains("Last 24h")
+	h.assertContains("As configured")
+
+	// Presets run 5m, 15m, 1h
+	h.press(tcell.KeyDown, 0)
+	h.press(tcell.KeyDown, 0)
+	h.press(tcell.KeyEnter, 0)
+	h.assertContains("Range: last 1h")
+	if len(received) != 2 || received[0].Range != "1h" || received[1].Range != "1h" {
+		t.Errorf("Expected every panel on the last hour, got %+v", received)
+	}
+
+	h.press(tcell.KeyTab, 0)
+	h.typeRune('t')
+	h.typeRune('f')
+	if received[0].Range != "1h" || received[1].Range != "5m" {
+		t.Errorf("Expected only the focused panel on the last 5m, got %+v", received)
+	}
+
+	h.typeRune('t')
+	for i := 0; i < len(rangePresets); i++ {
+		h.press(tcell.KeyDown, 0)
+	}
+	h.press(tcell.KeyEnter, 0)
+	h.assertContains("Custom range")
+	h.press(tcell.KeyEscape, 0)
+	h.assertNotContains("Custom range")
+
+	h.typeRune('t')
+	h.assertContains("As configured")
+	h.typeRune('t')
+	h.assertNotContains("As configured")
+}
@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guptarohit/asciigraph"
+	"github.com/rivo/tview"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+	"promviz/internal/topn"
+)
+
+// seriesPalette holds the line colors of top_n panels, highest series
+// first, one for each of up to topn.MaxN series
+var seriesPalette = []plotColor{
+	{asciigraph.White, "white"},
+	{asciigraph.Blue, "blue"},
+	{asciigraph.Fuchsia, "fuchsia"},
+	{asciigraph.Aqua, "aqua"},
+	{asciigraph.Lime, "lime"},
+	{asciigraph.Olive, "olive"},
+	{asciigraph.Purple, "purple"},
+	{asciigraph.Teal, "teal"},
+}
+
+// renderTopN renders a panel that only plots the series with the highest
+// current value. Membership is decided again on every update.
+func (t *TUI) renderTopN(index int) {
+	history := t.histories[index]
+	panel := t.panels[index]
+	q := t.queries[index]
+
+	top, hidden := topn.Select(history.TimeSeries.Points, q.TopN)
+	if len(top) == 0 || math.IsNaN(top[0].Latest) {
+		panel.SetText(t.lang.T("No data available"))
+		return
+	}
+
+	// Align the series on one timestamp grid, marking steps none of them has
+	grid, gaps := fillGaps(unionTimestamps(top), q.TimeRangeAt(t.now()))
+	gridValues := make([]float64, len(grid))
+	for i, p := range grid {
+		gridValues[i] = p.Value
+	}
+	series := make([][]float64, len(top))
+	for i, s := range top {
+		series[i] = alignSeries(s.Points, grid)
+	}
+
+	// Leave space for the header, legend and notes
+	reserved := 6 + len(top)
+	if hidden > 0 {
+		reserved++
+	}
+	if len(gaps) > 0 {
+		reserved++
+	}
+	if history.TimeSeries.Truncation != nil {
+		reserved++
+	}
+	graphWidth, graphHeight := graphSize(panel, reserved, t.numbers, series...)
+
+	// Draw the highest series last so it stays on top
+	reversed := make([][]float64, len(series))
+	colors := make([]asciigraph.AnsiColor, len(series))
+	for i := range series {
+		reversed[len(series)-1-i] = series[i]
+		colors[len(series)-1-i] = seriesPalette[i%len(seriesPalette)].ansi
+	}
+	graph := asciigraph.PlotMany(reversed,
+		asciigraph.Height(graphHeight),
+		asciigraph.Width(graphWidth),
+		asciigraph.SeriesColors(colors...),
+		asciigraph.Caption(fmt.Sprintf("%s Time Series", history.Name)))
+	if len(gaps) > 0 {
+		graph = hatchGaps(graph, gapColumns(gridValues, graphWidth), true)
+	}
+	rows := axisValues(graph)
+	graph = gridLines(graph, rows, graphWidth)
+	graph = shadeZones(graph, rows, q.Thresholds, graphWidth)
+	graph = localizeAxis(graph, t.numbers)
+
+	// Dim the whole panel when the newest point is older than max_age
+	var newest time.Time
+	for i := len(grid) - 1; i >= 0; i-- {
+		if !math.IsNaN(grid[i].Value) {
+			newest = grid[i].Timestamp
+			break
+		}
+	}
+	textColor := "white"
+	history.Sparkline = sparkline(series[0])
+	age, stale := staleAge(q, newest, t.now())
+	t.setStale(index, age, stale)
+	if stale {
+		textColor = "gray"
+	}
+
+	_, _, width, _ := panel.GetInnerRect()
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow]Top %d of %d series[%s]\n", len(top), len(top)+hidden, textColor)
+	fmt.Fprintf(&b, "[gray]Time Range: %s to %s[%s]\n",
+		grid[0].Timestamp.Format("15:04:05"), newest.Format("15:04:05"), textColor)
+	for i, s := range top {
+		valueColor := textColor
+		if q.Thresholds != nil && !stale {
+			valueColor = alert.Evaluate(q.Thresholds, s.Latest).Color()
+		}
+		value, mappedColor := t.currentValue(index, s.Latest, valueColor)
+		if !stale {
+			valueColor = mappedColor
+		}
+		fmt.Fprintf(&b, "[%s]━━[%s] [%s]%s[%s] %s\n",
+			seriesPalette[i%len(seriesPalette)].tag, textColor,
+			valueColor, value, textColor,
+			tview.Escape(truncate(seriesLabel(s.Name), width-12)))
+	}
+	b.WriteString("\n")
+	b.WriteString(colorizeGraph(graph, textColor, seriesPalette))
+	if hidden > 0 {
+		fmt.Fprintf(&b, "\n[gray]%d more series hidden[%s]", hidden, textColor)
+	}
+	if len(gaps) > 0 {
+		fmt.Fprintf(&b, "\n[red]%s[%s]", formatGaps(gaps), textColor)
+	}
+	b.WriteString(truncationNote(textColor, history.TimeSeries))
+
+	panel.SetText(b.String())
+}
+
+// unionTimestamps returns one placeholder point per distinct timestamp of
+// the series, sorted
+func unionTimestamps(series []topn.Series) []backend.DataPoint {
+	seen := make(map[int64]bool)
+	var points []backend.DataPoint
+	for _, s := range series {
+		for _, p := range s.Points {
+			if key := p.Timestamp.UnixNano(); !seen[key] {
+				seen[key] = true
+				points = append(points, backend.DataPoint{Timestamp: p.Timestamp})
+			}
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
+
+// alignSeries returns the values of points at the uniformly spaced grid
+// timestamps, NaN where the series has no point. Points are placed on the
+// nearest grid timestamp, the latest one winning.
+func alignSeries(points []backend.DataPoint, grid []backend.DataPoint) []float64 {
+	values := make([]float64, len(grid))
+	latest := make([]time.Time, len(grid))
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	if len(grid) == 0 {
+		return values
+	}
+
+	for _, p := range points {
+		// First grid timestamp after p, then whichever neighbour is nearer
+		i := sort.Search(len(grid), func(i int) bool {
+			return grid[i].Timestamp.After(p.Timestamp)
+		})
+		if i == len(grid) || (i > 0 && p.Timestamp.Sub(grid[i-1].Timestamp) <= grid[i].Timestamp.Sub(p.Timestamp)) {
+			i--
+		}
+
+		if latest[i].IsZero() || !p.Timestamp.Before(latest[i]) {
+			values[i] = p.Value
+			latest[i] = p.Timestamp
+		}
+	}
+	return values
+}
+
+// seriesLabel names a series in the legend
+func seriesLabel(name string) string {
+	if name == "" {
+		return "(unnamed series)"
+	}
+	return name
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if n < 1 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
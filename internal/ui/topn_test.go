@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestTopNMembership(t *testing.T) {
+	query := backend.Query{Name: "Load", Expr: "load", TopN: 2}
+	h := newHarness(t, []backend.Query{query}, 80, 24)
+
+	now := time.Now()
+	update := func(values map[string]float64) {
+		var points []backend.DataPoint
+		for name, v := range values {
+			for i := 0; i < 10; i++ {
+				points = append(points, backend.DataPoint{Timestamp: now.Add(time.Duration(i-9) * 15 * time.Second), Value: v, Series: name})
+			}
+		}
+		h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: points}, nil)
+		h.sync()
+	}
+
+	update(map[string]float64{"a": 3, "b": 2, "c": 1})
+	h.assertContains("Top 2 of 3 series")
+	h.assertContains("3.00 a")
+	h.assertContains("2.00 b")
+	h.assertNotContains("1.00 c")
+	h.assertContains("1 more series hidden")
+
+	// Membership follows the current values on every refresh
+	update(map[string]float64{"a": 1, "b": 2, "c": 3})
+	h.assertContains("3.00 c")
+	h.assertNotContains("1.00 a")
+
+	update(map[string]float64{"a": 1, "b": 2})
+	h.assertNotContains("hidden")
+}
+
+func TestAlignSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minute int, value float64) backend.DataPoint {
+		return backend.DataPoint{Timestamp: start.Add(time.Duration(minute) * time.Minute), Value: value}
+	}
+
+	grid := []backend.DataPoint{at(0, 0), at(1, 0), at(2, 0)}
+	values := alignSeries([]backend.DataPoint{at(2, 5), at(0, 3)}, grid)
+	if values[0] != 3 || !math.IsNaN(values[1]) || values[2] != 5 {
+		t.Errorf("Expected [3 NaN 5], got %v", values)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate(`up{instance="host-1"}`, 10); got != "up{instan…" {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("Short names should be kept, got %q", got)
+	}
+}
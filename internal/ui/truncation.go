@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"fmt"
+
+	"promviz/internal/backend"
+)
+
+// truncationNote returns a line warning about the results that were cut
+// down to the configured limits, empty if none was
+func truncationNote(textColor string, results ...*backend.TimeSeriesResult) string {
+	var note string
+	for _, r := range results {
+		if r != nil && r.Truncation != nil {
+			note += fmt.Sprintf("\n[yellow]%s[%s]", r.Truncation, textColor)
+		}
+	}
+	return note
+}
+
+// panelWarnings returns the warnings of the results a panel shows, which
+// the backend returned only part of
+func panelWarnings(h *QueryHistory) []string {
+	var warnings []string
+	for _, r := range []*backend.TimeSeriesResult{h.TimeSeries, h.Good, h.Total} {
+		if r != nil {
+			warnings = append(warnings, r.Warnings...)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+func TestTruncationNote(t *testing.T) {
+	if got := truncationNote("white", &backend.TimeSeriesResult{}, nil); got != "" {
+		t.Errorf("Expected no note without truncation, got %q", got)
+	}
+	truncated := &backend.TimeSeriesResult{Truncation: &backend.Truncation{Series: 1, KeptSeries: 1, Points: 12000, KeptPoints: 1000}}
+	if got := truncationNote("gray", truncated); got != "\n[yellow]truncated: 12k→1k points[gray]" {
+		t.Errorf("Unexpected note %q", got)
+	}
+}
+
+func TestTruncatedPanel(t *testing.T) {
+	query := backend.Query{Name: "CPU", Expr: "cpu", Range: "1h"}
+	h := newHarness(t, []backend.Query{query}, 80, 24)
+	h.tui.now = func() time.Time { return goldenNow }
+	result := &backend.TimeSeriesResult{Points: series(func(i int) (float64, bool) { return float64(i), true })}
+	h.tui.UpdateTimeSeries(0, backend.Limit(result, backend.Limits{MaxPoints: 20}), nil)
+	h.sync()
+
+	h.assertContains("truncated: 61→20 points")
+}
+
+func TestPartialPanel(t *testing.T) {
+	query := backend.Query{Name: "CPU", Expr: "cpu", Range: "1h"}
+	h := newHarness(t, []backend.Query{query}, 80, 24)
+	h.tui.now = func() time.Time { return goldenNow }
+	result := &backend.TimeSeriesResult{
+		Points:   series(func(i int) (float64, bool) { return float64(i), true }),
+		Warnings: []string{"receive-2: context deadline exceeded"},
+	}
+	h.tui.UpdateTimeSeries(0, result, nil)
+	h.sync()
+
+	// The series that came back are drawn, with a badge in the title
+	h.assertContains("partial (1)")
+	h.assertContains("Current: 60.00")
+	if details := h.tui.panelDetails(0); !strings.Contains(details, "Partial result:[white]\n  • receive-2: context deadline exceeded") {
+		t.Errorf("Expected the warnings in the details, got:\n%s", details)
+	}
+}
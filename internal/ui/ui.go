@@ -2,35 +2,107 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/guptarohit/asciigraph"
 	"github.com/rivo/tview"
 
+	"promviz/internal/alert"
 	"promviz/internal/backend"
+	"promviz/internal/i18n"
+	"promviz/internal/numfmt"
+	"promviz/internal/state"
 )
 
 // QueryHistory maintains time series data for a single query
 type QueryHistory struct {
 	Name       string
 	TimeSeries *backend.TimeSeriesResult
+	Good       *backend.TimeSeriesResult // SLO panels only
+	Total      *backend.TimeSeriesResult // SLO panels only
+	Health     *backend.HealthReport     // health panels only
 	LastError  error
+	Sparkline  string // braille trend of the latest values, shown in the title
+
+	Failures       int       // consecutive failures once throttled
+	ThrottledUntil time.Time // zero unless refreshes are backed off
+	IdleUntil      time.Time // zero unless outside the panel's active hours
 }
 
 // TUI represents the terminal user interface
 type TUI struct {
 	app           *tview.Application
+	pages         *tview.Pages // main layout plus modal overlays
 	flex          *tview.Flex
 	scrollView    *tview.Flex
 	panels        []*tview.TextView
+	inspect       *tview.TextView // side column of a maximized panel
+	header        *tview.TextView // nil unless enabled
+	headerTitle   string
+	started       time.Time
 	timeRange     *tview.TextView
+	instructions  *tview.TextView
 	focusIndex    int
 	scrollOffset  int // Track horizontal scroll position
 	visiblePanels int // Number of panels visible at once
 	histories     []*QueryHistory
+	metadata      []backend.Metadata // per panel, detected from the backend
+	queries       []backend.Query
 	onQuit        func()
+	onRetry       func(index int)
+	now           func() time.Time // clock for time-dependent rendering
+	numbers       numfmt.Format    // how values are written
+	lang          i18n.Catalog     // language of the help line, status bar and panel messages
+	exportDir     string           // where panel exports are written, the working directory if empty
+
+	layout  []int                // every panel by index, in display order
+	hidden  map[int]bool         // panels taken off the screen
+	shown   []int                // layout without hidden panels; scrolling and focus follow it
+	refresh time.Duration        // refresh interval + and - step from
+	acked   map[string]time.Time // newest acknowledged transition per query ID
+	muted   map[string]bool      // tags whose panels raise no alerts
+	onState func(state.State)
+	onMute  func(tags []string)
+
+	ranges     []string // configured range of each panel, restored from the time range menu
+	rangeLabel string   // ranges picked for the panels, see pickedRange
+	onRange    func(queries []backend.Query)
+
+	profiles  []string // backend profiles to switch between, none if not configured
+	profile   string   // active profile
+	onProfile func(name string)
+
+	credentialQueue []credentialRequest // prompts waiting for the one shown
+	onCredential    func(backendName, secret string) error
+
+	maximized bool // show only the focused panel
+	wide      bool // terminal fits the inspect column
+
+	playlistEnabled bool // rotate through panel pages on AdvancePlaylist
+	playlistPaused  bool
+
+	breaches []alert.Transition // recent threshold transitions, oldest first
+	warnings []string           // configuration problems shown in diagnostics
+
+	backendStatus []string // startup connection result per backend
+	backendsDown  int
+
+	sinks     []string // names of the sinks fetched series are written to
+	sinkError error    // of the last write to the sinks
+
+	resourceLimits string // memory and CPU limits applied, empty without limits
+	memoryUsed     uint64 // memory held by the process at the last check
+	memoryBudget   uint64 // zero without a memory limit
+	downsampled    bool   // panel data is thinned out to save memory
+
+	lifecycle sync.Mutex
+	running   bool
+	stopped   chan struct{} // closed by Stop
+	stopOnce  sync.Once
 }
 
 // NewTUI creates a new terminal user interface
@@ -38,10 +110,17 @@ func NewTUI(queries []backend.Query, onQuit func()) *TUI {
 	tui := &TUI{
 		app:           tview.NewApplication(),
 		histories:     make([]*QueryHistory, len(queries)),
+		metadata:      make([]backend.Metadata, len(queries)),
+		queries:       append([]backend.Query(nil), queries...), // ranges change at runtime
 		onQuit:        onQuit,
 		focusIndex:    0,
 		scrollOffset:  0,
 		visiblePanels: 3, // Default to showing 3 panels at once
+		stopped:       make(chan struct{}),
+		now:           time.Now,
+		hidden:        make(map[int]bool),
+		acked:         make(map[string]time.Time),
+		muted:         make(map[string]bool),
 	}
 
 	// Initialize query histories
@@ -53,6 +132,12 @@ func NewTUI(queries []backend.Query, onQuit func()) *TUI {
 		}
 	}
 
+	for i, query := range queries {
+		tui.layout = append(tui.layout, i)
+		tui.ranges = append(tui.ranges, query.Range)
+	}
+	tui.rebuildShown()
+
 	tui.setupUI(queries)
 	return tui
 }
@@ -88,6 +173,7 @@ func (t *TUI) setupUI(queries []backend.Query) {
 	}
 
 	// Initialize the scroll view with visible panels
+	t.setupInspect()
 	t.updateScrollView()
 
 	// Add time range display at the bottom
@@ -97,18 +183,23 @@ func (t *TUI) setupUI(queries []backend.Query) {
 	t.timeRange.SetDynamicColors(true)
 
 	// Add instructions at the very bottom
-	instructions := tview.NewTextView()
-	instructions.SetText("Navigation: ← → Arrow keys or Tab/Shift+Tab to switch panels | q/Q to quit")
-	instructions.SetTextAlign(tview.AlignCenter)
-	instructions.SetDynamicColors(true)
+	t.instructions = tview.NewTextView()
+	t.instructions.SetTextAlign(tview.AlignCenter)
+	t.instructions.SetDynamicColors(true)
+	t.updateInstructions()
 
 	// Add scrollable view, time range, and instructions to main container
 	t.flex.AddItem(t.scrollView, 0, 1, true)
 	t.flex.AddItem(t.timeRange, 1, 0, false)
-	t.flex.AddItem(instructions, 1, 0, false)
+	t.flex.AddItem(t.instructions, 1, 0, false)
 
 	// Set up key bindings
 	t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Modals handle their own keys
+		if t.modalOpen() {
+			return event
+		}
+
 		switch event.Key() {
 		case tcell.KeyRune:
 			switch event.Rune() {
@@ -117,6 +208,66 @@ func (t *TUI) setupUI(queries []backend.Query) {
 					t.onQuit()
 				}
 				return nil
+			case 'p', 'P':
+				if t.playlistEnabled {
+					t.togglePlaylistPause()
+					return nil
+				}
+			case 'b', 'B':
+				t.showBreaches()
+				return nil
+			case 'i', 'I':
+				t.showDetails()
+				return nil
+			case 'd', 'D':
+				t.showDiagnostics()
+				return nil
+			case 'r', 'R':
+				t.retry()
+				return nil
+			case 'o', 'O':
+				if len(t.panels) > 0 {
+					t.openRunbook(t.focusIndex)
+				}
+				return nil
+			case 'z', 'Z':
+				t.toggleMaximized()
+				return nil
+			case 'e', 'E':
+				t.exportFocused()
+				return nil
+			case 'h':
+				t.hideFocused()
+				return nil
+			case 'H':
+				t.showHidden()
+				return nil
+			case '<':
+				t.moveFocused(-1)
+				return nil
+			case '>':
+				t.moveFocused(1)
+				return nil
+			case 's', 'S':
+				t.showProfiles()
+				return nil
+			case 'g', 'G':
+				t.showTags()
+				return nil
+			case 't', 'T':
+				t.showRanges()
+				return nil
+			case '+', '=':
+				t.stepRefresh(-1)
+				return nil
+			case '-':
+				t.stepRefresh(1)
+				return nil
+			}
+		case tcell.KeyEscape:
+			if t.maximized {
+				t.toggleMaximized()
+				return nil
 			}
 		case tcell.KeyTab, tcell.KeyRight:
 			t.focusNext()
@@ -128,7 +279,89 @@ func (t *TUI) setupUI(queries []backend.Query) {
 		return event
 	})
 
-	t.app.SetRoot(t.flex, true)
+	t.pages = tview.NewPages()
+	t.pages.AddPage(mainPage, t.flex, true, true)
+
+	t.app.SetRoot(t.pages, true)
+	t.updateFocus()
+}
+
+// updateInstructions refreshes the key binding help line
+func (t *TUI) updateInstructions() {
+	text := t.lang.T("Navigation: ← → Arrow keys or Tab/Shift+Tab to switch panels | i details | o runbook | b breaches | q/Q to quit")
+	if t.maximized {
+		text += t.lang.T(" | [yellow]Maximized[white] (z or Esc to restore)")
+	}
+	if window := commonWindow(t.queries); window != nil {
+		text += t.lang.Sprintf(" | Range: [yellow]%s, refresh paused[white] (t)", formatWindow(*window))
+	} else if t.rangeLabel != "" {
+		text += t.lang.Sprintf(" | Range: [yellow]%s[white] (t)", t.rangeLabel)
+	}
+	if len(t.profiles) > 0 {
+		text += t.lang.Sprintf(" | Source: [yellow]%s[white] (s)", tview.Escape(t.profile))
+	}
+	if n := len(t.hidden); n > 0 {
+		text += t.lang.Sprintf(" | [yellow]%d panels hidden[white] (H to show)", n)
+	}
+	if n := len(t.muted); n > 0 {
+		text += t.lang.Sprintf(" | [yellow]%d tags muted[white] (g)", n)
+	}
+	if t.playlistEnabled {
+		if t.playlistPaused {
+			text += t.lang.T(" | [yellow]Rotation paused[white] (p to resume)")
+		} else {
+			text += t.lang.T(" | p to pause rotation")
+		}
+	}
+	if t.backendsDown > 0 {
+		text += t.lang.Sprintf(" | [red]%d backends unreachable (d)[white]", t.backendsDown)
+	}
+	if len(t.warnings) > 0 {
+		text += t.lang.Sprintf(" | [yellow]%d config warnings (d)[white]", len(t.warnings))
+	}
+	if n := t.throttledPanels(); n > 0 {
+		text += t.lang.Sprintf(" | [yellow]%d panels throttled (r to retry)[white]", n)
+	}
+	text += t.memoryNote()
+	t.instructions.SetText(text)
+}
+
+// EnablePlaylist turns on page rotation driven by AdvancePlaylist
+func (t *TUI) EnablePlaylist() {
+	t.playlistEnabled = true
+	t.updateInstructions()
+}
+
+// AdvancePlaylist shows the next page of panels unless rotation is paused
+func (t *TUI) AdvancePlaylist() {
+	t.queueUpdateDraw(func() {
+		if t.playlistPaused {
+			return
+		}
+		t.nextPage()
+	})
+}
+
+// togglePlaylistPause pauses or resumes page rotation
+func (t *TUI) togglePlaylistPause() {
+	t.playlistPaused = !t.playlistPaused
+	t.updateInstructions()
+}
+
+// nextPage scrolls to the next page of panels, wrapping to the first page
+func (t *TUI) nextPage() {
+	if len(t.shown) <= t.visiblePanels {
+		return
+	}
+
+	next := t.scrollOffset + t.visiblePanels
+	if next >= len(t.shown) || t.scrollOffset == len(t.shown)-t.visiblePanels {
+		next = 0
+	}
+
+	t.focusIndex = t.shown[next]
+	t.scrollOffset = next
+	t.updateScrollView()
 	t.updateFocus()
 }
 
@@ -141,8 +374,18 @@ func (t *TUI) updateScrollView() {
 		return
 	}
 
+	// A maximized panel fills the view, with the inspect column if it fits
+	if t.maximized {
+		t.scrollView.AddItem(t.panels[t.focusIndex], 0, 1, true)
+		if t.wide {
+			t.refreshInspect(t.focusIndex)
+			t.scrollView.AddItem(t.inspect, inspectWidth, 0, false)
+		}
+		return
+	}
+
 	// Calculate which panels should be visible
-	maxOffset := len(t.panels) - t.visiblePanels
+	maxOffset := len(t.shown) - t.visiblePanels
 	if maxOffset < 0 {
 		maxOffset = 0
 	}
@@ -157,13 +400,12 @@ func (t *TUI) updateScrollView() {
 
 	// Add visible panels to the scroll view
 	endIndex := t.scrollOffset + t.visiblePanels
-	if endIndex > len(t.panels) {
-		endIndex = len(t.panels)
+	if endIndex > len(t.shown) {
+		endIndex = len(t.shown)
 	}
 
-	for i := t.scrollOffset; i < endIndex; i++ {
-		panel := t.panels[i]
-		t.scrollView.AddItem(panel, 0, 1, i == t.focusIndex)
+	for _, i := range t.shown[t.scrollOffset:endIndex] {
+		t.scrollView.AddItem(t.panels[i], 0, 1, i == t.focusIndex)
 	}
 }
 
@@ -176,24 +418,26 @@ func (t *TUI) scrollToShowFocus() {
 	// Check if focused panel is visible in current scroll view
 	visibleStart := t.scrollOffset
 	visibleEnd := t.scrollOffset + t.visiblePanels - 1
+	pos := t.position(t.focusIndex)
 
 	// If focus is to the right of visible area, scroll right
-	if t.focusIndex > visibleEnd {
-		t.scrollOffset = t.focusIndex - t.visiblePanels + 1
+	if pos > visibleEnd {
+		t.scrollOffset = pos - t.visiblePanels + 1
 	}
 	// If focus is to the left of visible area, scroll left
-	if t.focusIndex < visibleStart {
-		t.scrollOffset = t.focusIndex
+	if pos < visibleStart {
+		t.scrollOffset = pos
 	}
 
-	// Update the scroll view with new offset
+	// Update the scroll view with new offset, or show the newly focused
+	// panel when maximized
 	t.updateScrollView()
 }
 
 // focusNext moves focus to the next panel
 func (t *TUI) focusNext() {
-	if len(t.panels) > 0 {
-		t.focusIndex = (t.focusIndex + 1) % len(t.panels)
+	if len(t.shown) > 0 {
+		t.focusIndex = t.shown[(t.position(t.focusIndex)+1)%len(t.shown)]
 		t.scrollToShowFocus()
 		t.updateFocus()
 	}
@@ -201,8 +445,8 @@ func (t *TUI) focusNext() {
 
 // focusPrev moves focus to the previous panel
 func (t *TUI) focusPrev() {
-	if len(t.panels) > 0 {
-		t.focusIndex = (t.focusIndex - 1 + len(t.panels)) % len(t.panels)
+	if len(t.shown) > 0 {
+		t.focusIndex = t.shown[(t.position(t.focusIndex)-1+len(t.shown))%len(t.shown)]
 		t.scrollToShowFocus()
 		t.updateFocus()
 	}
@@ -228,7 +472,7 @@ func (t *TUI) updateFocus() {
 	// Set app focus to the focused panel (if it's currently visible)
 	visibleStart := t.scrollOffset
 	visibleEnd := t.scrollOffset + t.visiblePanels - 1
-	if t.focusIndex >= visibleStart && t.focusIndex <= visibleEnd {
+	if pos := t.position(t.focusIndex); t.maximized || (pos >= visibleStart && pos <= visibleEnd) {
 		t.app.SetFocus(t.panels[t.focusIndex])
 	}
 }
@@ -264,11 +508,11 @@ func (t *TUI) updateTimeRange() {
 
 	var timeRangeText string
 	if hasData && earliestTime != nil && latestTime != nil {
-		timeRangeText = fmt.Sprintf("[yellow]Time Range:[white] %s [gray]to[white] %s",
+		timeRangeText = t.lang.Sprintf("[yellow]Time Range:[white] %s [gray]to[white] %s",
 			earliestTime.Format("15:04:05"),
 			latestTime.Format("15:04:05"))
 	} else {
-		timeRangeText = "[gray]Time Range: Waiting for data...[white]"
+		timeRangeText = t.lang.T("[gray]Time Range: Waiting for data...[white]")
 	}
 
 	t.timeRange.SetText(timeRangeText)
@@ -286,13 +530,14 @@ func (t *TUI) UpdateTimeSeries(index int, timeSeries *backend.TimeSeriesResult,
 	} else {
 		t.histories[index].TimeSeries = timeSeries
 		t.histories[index].LastError = nil
+		t.clearThrottled(index)
 	}
 
 	// Only queue UI updates if the app is properly initialized
 	if t.app != nil && len(t.panels) > index {
-		t.app.QueueUpdateDraw(func() {
+		t.queueUpdateDraw(func() {
 			if err != nil {
-				t.panels[index].SetText(fmt.Sprintf("[red]Error: %v[white]", err))
+				t.panels[index].SetText(t.errorText(index, err))
 			} else {
 				// Render the time series graph
 				t.renderTimeSeriesGraph(index)
@@ -300,6 +545,8 @@ func (t *TUI) UpdateTimeSeries(index int, timeSeries *backend.TimeSeriesResult,
 
 			// Update the time range display
 			t.updateTimeRange()
+			t.updateInstructions()
+			t.refreshInspect(index)
 		})
 	}
 }
@@ -310,83 +557,191 @@ func (t *TUI) renderTimeSeriesGraph(index int) {
 	panel := t.panels[index]
 
 	if len(history.TimeSeries.Points) == 0 {
-		panel.SetText("No data available")
+		panel.SetText(t.lang.T("No data available"))
+		return
+	}
+
+	if t.queries[index].PanelType() == backend.PanelBool {
+		t.renderBool(index)
+		return
+	}
+	if t.queries[index].PanelType() == backend.PanelCalendar {
+		t.renderCalendar(index)
+		return
+	}
+	if t.queries[index].PanelType() == backend.PanelBurnRate {
+		t.renderBurnRate(index)
+		return
+	}
+	if t.queries[index].PanelType() == backend.PanelStatus {
+		t.renderStatus(index)
+		return
+	}
+	if t.queries[index].TopN > 0 {
+		t.renderTopN(index)
 		return
 	}
 
-	// Sort points by timestamp to ensure correct order
+	// Sort points by timestamp to ensure correct order, keeping the backend's
+	// order for points of different series with the same timestamp
 	points := make([]backend.DataPoint, len(history.TimeSeries.Points))
 	copy(points, history.TimeSeries.Points)
-	sort.Slice(points, func(i, j int) bool {
+	sort.SliceStable(points, func(i, j int) bool {
 		return points[i].Timestamp.Before(points[j].Timestamp)
 	})
 
+	// Place points on a uniform time grid, marking steps the backend returned
+	// nothing for so outages aren't drawn as data
+	raw := points
+	points, gaps := fillGaps(points, t.queries[index].TimeRangeAt(t.now()))
+
 	// Extract values for graphing
 	values := make([]float64, len(points))
 	for i, point := range points {
 		values[i] = point.Value
 	}
 
-	// Get panel dimensions dynamically
-	_, _, width, height := panel.GetInnerRect()
-
-	// Calculate graph dimensions (leave space for text)
-	// Calculate margin based on max y value digits + 4 for outline space
-	maxY := values[0]
-	minY := values[0]
-	for _, v := range values {
-		if v > maxY {
-			maxY = v
-		}
-		if v < minY {
-			minY = v
-		}
+	// Get latest value and timestamp
+	latest, ok := alert.Latest(&backend.TimeSeriesResult{Points: points})
+	if !ok {
+		panel.SetText(t.lang.T("No data available"))
+		return
 	}
-	// Find the largest absolute value for y-axis
-	absMaxY := maxY
-	if -minY > maxY {
-		absMaxY = -minY
+
+	// Leave space for title and current value, and the missing data and
+	// truncation notes
+	reserved := 6
+	if len(gaps) > 0 {
+		reserved++
 	}
-	yDigits := len(fmt.Sprintf("%.0f", absMaxY))
-	if absMaxY < 0 {
-		yDigits++ // for negative sign
+	if history.TimeSeries.Truncation != nil {
+		reserved++
 	}
-	margin := yDigits + 7
-	graphWidth := width - margin // Leave margin based on y-axis label width
-	graphHeight := height - 6    // Leave space for title and current value
 
-	// Ensure minimum dimensions
-	if graphWidth < 20 {
-		graphWidth = 20
+	// Client-side percentiles, shown as a stats line and optionally as lines
+	// behind the series
+	series := [][]float64{values}
+	var current []float64
+	if p := t.queries[index].Percentiles; p != nil {
+		var lines [][]float64
+		current, lines = percentileLines(p, raw, points)
+		series = append(lines, values)
+		reserved++
 	}
-	if graphHeight < 3 {
-		graphHeight = 3
+	if t.queries[index].Stats != nil {
+		reserved++
 	}
 
+	graphWidth, graphHeight := graphSize(panel, reserved, t.numbers, values)
+
 	// Generate ASCII graph with dynamic sizing
-	graph := asciigraph.Plot(values,
+	options := []asciigraph.Option{
 		asciigraph.Height(graphHeight),
 		asciigraph.Width(graphWidth),
-		asciigraph.Caption(fmt.Sprintf("%s Time Series", history.Name)))
-
-	// Get latest value and timestamp
-	latest := points[len(points)-1]
+		asciigraph.Caption(t.lang.Sprintf("%s Time Series", history.Name)),
+	}
+	if len(series) > 1 {
+		options = append(options, asciigraph.SeriesColors(seriesColors(len(series)-1)...))
+	}
+	graph := asciigraph.PlotMany(series, options...)
+	if len(gaps) > 0 {
+		graph = hatchGaps(graph, gapColumns(values, graphWidth), true)
+	}
+	rows := axisValues(graph)
+	graph = gridLines(graph, rows, graphWidth)
+	graph = shadeZones(graph, rows, t.queries[index].Thresholds, graphWidth)
+	graph = localizeAxis(graph, t.numbers)
 
 	// Create time range info
 	oldest := points[0]
-	timeRange := fmt.Sprintf("%s to %s",
+	if raw[0].Timestamp.Before(oldest.Timestamp) {
+		oldest = raw[0]
+	}
+	timeRange := t.lang.Sprintf("%s to %s",
 		oldest.Timestamp.Format("15:04:05"),
 		latest.Timestamp.Format("15:04:05"))
 
-	// Build content with current value, time range, and graph
-	content := fmt.Sprintf("[yellow]Current: %.2f[white]\n[gray]Time Range: %s[white]\n\n%s",
-		latest.Value,
+	// Color the current value by alert level when thresholds are configured
+	valueColor := "yellow"
+	if th := t.queries[index].Thresholds; th != nil {
+		valueColor = alert.Evaluate(th, latest.Value).Color()
+	}
+	value, valueColor := t.currentValue(index, latest.Value, valueColor)
+
+	// Dim the whole panel when the newest point is older than max_age
+	textColor := "white"
+	history.Sparkline = sparkline(values)
+	age, stale := staleAge(t.queries[index], latest.Timestamp, t.now())
+	t.setStale(index, age, stale)
+	if stale {
+		valueColor, textColor = "gray", "gray"
+	}
+
+	graph = colorizeGraph(graph, textColor, overlayColors)
+
+	// Build content with current value, time range, percentiles and graph
+	content := t.lang.Sprintf("[%s]Current: %s[%s]\n[gray]Time Range: %s[%s]\n",
+		valueColor,
+		value,
+		textColor,
 		timeRange,
-		graph)
+		textColor)
+	if p := t.queries[index].Percentiles; p != nil {
+		content += formatPercentiles(p, current, textColor, t.numbers) + "\n"
+	}
+	if st := t.queries[index].Stats; st != nil {
+		content += formatStats(st, panelStats(st, raw), t.unit(index), textColor, t.numbers) + "\n"
+	}
+	content += "\n" + graph
+	if len(gaps) > 0 {
+		content += fmt.Sprintf("\n[red]%s[%s]", formatGaps(gaps), textColor)
+	}
+	content += truncationNote(textColor, history.TimeSeries)
 
 	panel.SetText(content)
 }
 
+// graphSize returns the plot size that fits a panel with reserved lines of
+// text around the graph. The y-axis labels take a margin based on the
+// width of the largest absolute value in series, written in format f.
+func graphSize(panel *tview.TextView, reserved int, f numfmt.Format, series ...[]float64) (width, height int) {
+	_, _, innerWidth, innerHeight := panel.GetInnerRect()
+
+	maxY, minY := math.Inf(-1), math.Inf(1)
+	for _, values := range series {
+		for _, v := range values {
+			if math.IsNaN(v) {
+				continue
+			}
+			if v > maxY {
+				maxY = v
+			}
+			if v < minY {
+				minY = v
+			}
+		}
+	}
+	// Find the largest absolute value for y-axis
+	absMaxY := maxY
+	if -minY > maxY {
+		absMaxY = -minY
+	}
+	yDigits := len([]rune(f.Float(absMaxY, 0)))
+	margin := yDigits + 7
+
+	width = innerWidth - margin
+	height = innerHeight - reserved
+
+	// Ensure minimum dimensions
+	if width < 20 {
+		width = 20
+	}
+	if height < 3 {
+		height = 3
+	}
+	return width, height
+}
+
 // UpdateMetric maintains compatibility with old interface (deprecated)
 func (t *TUI) UpdateMetric(index int, result backend.DataPoint, err error) {
 	// Convert single result to time series for backward compatibility
@@ -401,12 +756,53 @@ func (t *TUI) UpdateMetric(index int, result backend.DataPoint, err error) {
 	t.UpdateTimeSeries(index, timeSeries, nil)
 }
 
+// queueUpdateDraw runs f on the UI event loop and redraws the screen. The
+// event loop only exists between Run and Stop; updates outside that window
+// are dropped rather than blocking the caller forever.
+func (t *TUI) queueUpdateDraw(f func()) {
+	t.lifecycle.Lock()
+	running := t.running
+	t.lifecycle.Unlock()
+	if !running {
+		return
+	}
+
+	// Stop may end the event loop before it gets to this update
+	done := make(chan struct{})
+	go func() {
+		t.app.QueueUpdateDraw(f)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-t.stopped:
+	}
+}
+
 // Run starts the TUI application
 func (t *TUI) Run() error {
+	t.lifecycle.Lock()
+	select {
+	case <-t.stopped:
+		t.lifecycle.Unlock()
+		return nil
+	default:
+	}
+	t.running = true
+	t.lifecycle.Unlock()
+
 	return t.app.Run()
 }
 
-// Stop stops the TUI application
+// Stop stops the TUI application. It is safe to call more than once, from
+// key handlers and before Run.
 func (t *TUI) Stop() {
+	t.lifecycle.Lock()
+	t.running = false
+	t.lifecycle.Unlock()
+
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+	})
 	t.app.Stop()
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -291,3 +292,65 @@ func TestUpdateTimeSeriesWithEmptyData(t *testing.T) {
 		t.Errorf("Expected 0 points, got %d", len(tui.histories[0].TimeSeries.Points))
 	}
 }
+
+func TestPlaylistNextPage(t *testing.T) {
+	queries := []backend.Query{
+		{Name: "Query 1", Expr: "metric1"},
+		{Name: "Query 2", Expr: "metric2"},
+		{Name: "Query 3", Expr: "metric3"},
+		{Name: "Query 4", Expr: "metric4"},
+		{Name: "Query 5", Expr: "metric5"},
+	}
+
+	tui := NewTUI(queries, nil)
+	tui.EnablePlaylist()
+
+	// First rotation moves to the second page
+	tui.nextPage()
+	if tui.focusIndex != 3 {
+		t.Errorf("Expected focus index 3 after first rotation, got %d", tui.focusIndex)
+	}
+	if tui.scrollOffset != 2 {
+		t.Errorf("Expected scroll offset clamped to 2, got %d", tui.scrollOffset)
+	}
+
+	// Second rotation wraps back to the first page
+	tui.nextPage()
+	if tui.focusIndex != 0 || tui.scrollOffset != 0 {
+		t.Errorf("Expected rotation to wrap to first page, got focus %d offset %d", tui.focusIndex, tui.scrollOffset)
+	}
+}
+
+func TestPlaylistSinglePage(t *testing.T) {
+	queries := []backend.Query{
+		{Name: "Query 1", Expr: "metric1"},
+		{Name: "Query 2", Expr: "metric2"},
+	}
+
+	tui := NewTUI(queries, nil)
+	tui.focusNext()
+	tui.nextPage()
+
+	// Nothing to rotate when all panels fit on one page
+	if tui.focusIndex != 1 {
+		t.Errorf("Expected focus to stay at 1, got %d", tui.focusIndex)
+	}
+}
+
+func TestPlaylistPause(t *testing.T) {
+	tui := NewTUI([]backend.Query{{Name: "Query 1", Expr: "metric1"}}, nil)
+	tui.EnablePlaylist()
+
+	tui.togglePlaylistPause()
+	if !tui.playlistPaused {
+		t.Error("Playlist should be paused after toggle")
+	}
+	if !strings.Contains(tui.instructions.GetText(false), "Rotation paused") {
+		t.Errorf("Instructions should show paused rotation, got %q", tui.instructions.GetText(false))
+	}
+
+	tui.togglePlaylistPause()
+	if tui.playlistPaused {
+		t.Error("Playlist should resume after second toggle")
+	}
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+)
+
+// SetMetadata sets what the backend knows about the metric of a panel,
+// used for the unit and description its query leaves out
+func (t *TUI) SetMetadata(index int, md backend.Metadata) {
+	t.queueUpdateDraw(func() { t.setMetadata(index, md) })
+}
+
+// setMetadata stores the metadata of a panel and renders it again with the
+// detected unit. It runs on the UI event loop.
+func (t *TUI) setMetadata(index int, md backend.Metadata) {
+	if index < 0 || index >= len(t.metadata) {
+		return
+	}
+	t.metadata[index] = md
+	h := t.histories[index]
+	if h.LastError == nil && h.TimeSeries != nil && len(h.TimeSeries.Points) > 0 {
+		t.renderTimeSeriesGraph(index)
+	}
+	t.refreshInspect(index)
+}
+
+// unit returns the unit of a panel's values: the configured one, else the
+// one converted to, else the one detected from metadata
+func (t *TUI) unit(index int) string {
+	q := t.queries[index]
+	if q.Unit != "" {
+		return q.Unit
+	}
+	if q.Convert != nil {
+		return q.Convert.Target()
+	}
+	return t.metadata[index].Unit
+}
+
+// currentValue writes the latest value of a panel: the text of its value
+// mapping, else the value in the panel's unit. The mapping's color, if it
+// has one, replaces color.
+func (t *TUI) currentValue(index int, v float64, color string) (string, string) {
+	if m, ok := backend.MapValue(t.queries[index].ValueMappings, v); ok {
+		if m.Color != "" {
+			color = m.Color
+		}
+		return tview.Escape(m.Text), color
+	}
+	return formatValue(v, t.unit(index), t.numbers), color
+}
+
+// formatValue writes v in unit: bytes scaled to KiB, MiB and up, seconds
+// below one as milliseconds, ratios as percentages, and anything else
+// followed by the unit. A "/s" suffix is kept after the scaled value.
+func formatValue(v float64, unit string, f numfmt.Format) string {
+	base, perSecond := strings.CutSuffix(unit, backend.PerSecond)
+	var s string
+	switch base {
+	case "":
+		s = f.Float(v, 2)
+	case backend.UnitBytes:
+		if v < 0 {
+			s = "-" + f.Bytes(-v)
+		} else {
+			s = f.Bytes(v)
+		}
+	case backend.UnitSeconds:
+		switch abs := math.Abs(v); {
+		case abs == 0 || abs >= 1 || math.IsNaN(v):
+			s = f.Float(v, 2) + " s"
+		case abs >= 0.001:
+			s = f.Float(v*1e3, 1) + " ms"
+		default:
+			s = f.Float(v*1e6, 1) + " µs"
+		}
+	case backend.UnitPercent:
+		s = f.Float(v, 2) + "%"
+	case backend.UnitRatio:
+		s = f.Float(v*100, 2) + "%"
+	default:
+		s = f.Float(v, 2) + " " + base
+	}
+	if perSecond {
+		s += backend.PerSecond
+	}
+	return s
+}
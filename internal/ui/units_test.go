@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"promviz/internal/backend"
+	"promviz/internal/numfmt"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  string
+		want  string
+	}{
+		{12.345, "", "12.35"},
+		{3 << 20, "bytes", "3.0 MiB"},
+		{-2048, "bytes", "-2.0 KiB"},
+		{1536, "bytes/s", "1.5 KiB/s"},
+		{0.25, "seconds", "250.0 ms"},
+		{0.0000125, "seconds", "12.5 µs"},
+		{90, "seconds", "90.00 s"},
+		{0, "seconds", "0.00 s"},
+		{42, "percent", "42.00%"},
+		{0.995, "ratio", "99.50%"},
+		{12, "req/s", "12.00 req/s"},
+		{3, "req", "3.00 req"},
+		{math.NaN(), "seconds", "NaN s"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.value, tt.unit, numfmt.Format{}); got != tt.want {
+			t.Errorf("formatValue(%v, %q) = %q, want %q", tt.value, tt.unit, got, tt.want)
+		}
+	}
+
+	de := numfmt.Format{Decimal: ",", Thousands: "."}
+	if got := formatValue(0.5, "ratio", de); got != "50,00%" {
+		t.Errorf("Expected the locale's decimal separator, got %q", got)
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	tui := NewTUI([]backend.Query{
+		{Name: "Received", Expr: "rate(node_network_receive_bytes_total[5m])"},
+		{Name: "Latency", Expr: "latency", Unit: "ms", Description: "Checkout latency"},
+	}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	for i := range tui.histories {
+		tui.histories[i].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: goldenNow, Value: 2048}}}
+	}
+
+	md := backend.Metadata{Type: "counter", Help: "Bytes received", Unit: "bytes/s"}
+	tui.setMetadata(0, md)
+	tui.setMetadata(1, md)
+
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "Current: 2.0 KiB/s") {
+		t.Errorf("Expected the detected unit in the panel, got %q", text)
+	}
+	details := tui.panelDetails(0)
+	for _, want := range []string{"Bytes received [gray](from metadata)", "Unit:[white]  bytes/s (detected)", "Metric type:[white] counter"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("Details should contain %q, got:\n%s", want, details)
+		}
+	}
+
+	// Configured units and descriptions win over metadata
+	if text := tui.panels[1].GetText(false); !strings.Contains(text, "Current: 2048.00 ms") {
+		t.Errorf("Expected the configured unit in the panel, got %q", text)
+	}
+	details = tui.panelDetails(1)
+	if !strings.Contains(details, "Checkout latency") || strings.Contains(details, "Bytes received") || !strings.Contains(details, "Unit:[white]  ms\n") {
+		t.Errorf("Expected the configured unit and description, got:\n%s", details)
+	}
+}
+
+func TestValueMappings(t *testing.T) {
+	crit := 1.0
+	mappings := []backend.ValueMapping{
+		{Value: 0, Text: "DOWN", Color: "red"},
+		{Value: 1, Text: "UP", Color: "green"},
+		{Value: 2, Text: "DEGRADED"},
+	}
+	tui := NewTUI([]backend.Query{
+		{Name: "State", Expr: "service_state", ValueMappings: mappings},
+		{Name: "Other", Expr: "service_state", ValueMappings: mappings, Unit: "bytes"},
+		{Name: "Backend", Expr: "service_state", ValueMappings: mappings, Thresholds: &backend.Thresholds{Crit: &crit}},
+	}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	for i, v := range []float64{0, 3, 2} {
+		tui.histories[i].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: goldenNow, Value: v}}}
+		tui.renderTimeSeriesGraph(i)
+	}
+
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "[red]Current: DOWN") {
+		t.Errorf("Expected the mapped text in its color, got %q", text)
+	}
+	if text := tui.panels[1].GetText(false); !strings.Contains(text, "Current: 3 B") {
+		t.Errorf("Expected unmapped values in the panel's unit, got %q", text)
+	}
+	// Without a color of its own, a mapping keeps the threshold color
+	if text := tui.panels[2].GetText(false); !strings.Contains(text, "[red]Current: DEGRADED") {
+		t.Errorf("Expected the threshold color for a mapping without one, got %q", text)
+	}
+	if details := tui.panelDetails(0); !strings.Contains(details, "Mappings:[white] 0 → DOWN, 1 → UP, 2 → DEGRADED") {
+		t.Errorf("Expected the mappings in the details, got:\n%s", details)
+	}
+}
+
+func TestConvertedUnit(t *testing.T) {
+	tui := NewTUI([]backend.Query{
+		{Name: "Disk", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes", To: "GiB"}},
+		{Name: "Cost", Expr: "disk_bytes", Convert: &backend.ConvertConfig{From: "bytes", To: "GB", Multiply: 0.02, Unit: "USD"}},
+	}, nil)
+	tui.now = func() time.Time { return goldenNow }
+	for i := range tui.histories {
+		tui.histories[i].TimeSeries = &backend.TimeSeriesResult{Points: []backend.DataPoint{{Timestamp: goldenNow, Value: 12.5}}}
+		tui.setMetadata(i, backend.Metadata{Unit: "bytes"})
+	}
+
+	// The converted unit wins over the detected one
+	if text := tui.panels[0].GetText(false); !strings.Contains(text, "Current: 12.50 GiB") {
+		t.Errorf("Expected the converted unit in the panel, got %q", text)
+	}
+	if text := tui.panels[1].GetText(false); !strings.Contains(text, "Current: 12.50 USD") {
+		t.Errorf("Expected the unit after multiply in the panel, got %q", text)
+	}
+	if details := tui.panelDetails(1); !strings.Contains(details, "Convert:[white] bytes → GB, × 0.02, in USD") {
+		t.Errorf("Expected the conversion in the details, got:\n%s", details)
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+)
+
+// zoneColors are the background colors of plot rows beyond a threshold,
+// dark enough for the line to stay readable on top
+var zoneColors = map[alert.Level]string{
+	alert.LevelWarning:  "#3a3000",
+	alert.LevelCritical: "#4a0e0e",
+}
+
+// shadeZones gives the plot rows of an asciigraph plot whose axis value is
+// beyond the warn or crit threshold a background color. rows holds the axis
+// value of each line as returned by axisValues; lines without one, such as
+// the caption, are left untouched. Shaded rows are padded to width plot
+// columns.
+func shadeZones(graph string, rows []float64, th *backend.Thresholds, width int) string {
+	if th == nil || (th.Warn == nil && th.Crit == nil) {
+		return graph
+	}
+
+	lines := strings.Split(graph, "\n")
+	for i, line := range lines {
+		axis := strings.IndexAny(line, "┤┼│")
+		if axis < 0 || math.IsNaN(rows[i]) {
+			continue
+		}
+		color, ok := zoneColors[alert.Evaluate(th, rows[i])]
+		if !ok {
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(line[axis:])
+		plot := line[axis+size:]
+		if n := len(cells(plot)); n < width {
+			plot += strings.Repeat(" ", width-n)
+		}
+		lines[i] = line[:axis+size] + "[:" + color + "]" + plot + "[:-]"
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/guptarohit/asciigraph"
+
+	"promviz/internal/alert"
+	"promviz/internal/backend"
+)
+
+func TestShadeZones(t *testing.T) {
+	warn, crit := 5.0, 8.0
+	graph := asciigraph.Plot([]float64{0, 2, 4, 6, 8, 10}, asciigraph.Height(10), asciigraph.Width(6), asciigraph.Caption("load"))
+
+	if got := shadeZones(graph, axisValues(graph), nil, 6); got != graph {
+		t.Errorf("Graph without thresholds should be untouched, got:\n%s", got)
+	}
+
+	lines := strings.Split(shadeZones(graph, axisValues(graph), &backend.Thresholds{Warn: &warn, Crit: &crit}, 6), "\n")
+	tests := []struct {
+		row   int
+		color string
+	}{
+		{0, zoneColors[alert.LevelCritical]}, // 10
+		{1, zoneColors[alert.LevelCritical]}, // 9
+		{2, zoneColors[alert.LevelWarning]},  // 8, not beyond crit
+		{4, zoneColors[alert.LevelWarning]},  // 6
+		{5, ""},                              // 5, not beyond warn
+		{10, ""},                             // 0
+	}
+	for _, tt := range tests {
+		line := lines[tt.row]
+		if tt.color == "" {
+			if strings.Contains(line, "[:") {
+				t.Errorf("Row %d should not be shaded, got %q", tt.row, line)
+			}
+			continue
+		}
+		if !strings.Contains(line, "┤[:"+tt.color+"]") && !strings.Contains(line, "┼[:"+tt.color+"]") {
+			t.Errorf("Row %d should be shaded %s, got %q", tt.row, tt.color, line)
+		}
+		if !strings.HasSuffix(line, "[:-]") {
+			t.Errorf("Row %d should reset the background, got %q", tt.row, line)
+		}
+	}
+	if strings.Contains(lines[len(lines)-1], "[:") {
+		t.Errorf("Caption should not be shaded, got %q", lines[len(lines)-1])
+	}
+
+	// Thresholds below the limit shade the lower rows
+	below := shadeZones(graph, axisValues(graph), &backend.Thresholds{Warn: &warn, Below: true}, 6)
+	lines = strings.Split(below, "\n")
+	if strings.Contains(lines[0], "[:") || !strings.Contains(lines[10], "[:"+zoneColors[alert.LevelWarning]+"]") {
+		t.Errorf("Expected only low rows shaded, got:\n%s", below)
+	}
+}
+
+func TestZoneShadingOnScreen(t *testing.T) {
+	warn := 50.0
+	query := backend.Query{Name: "CPU", Expr: "cpu", Thresholds: &backend.Thresholds{Warn: &warn}}
+	h := newHarness(t, []backend.Query{query}, 80, 20)
+	h.tui.now = func() time.Time { return goldenNow }
+	h.tui.UpdateTimeSeries(0, &backend.TimeSeriesResult{Points: series(func(i int) (float64, bool) {
+		return float64(i), true
+	})}, nil)
+	h.sync()
+
+	x, topY, ok := h.find("60 ┤")
+	if !ok {
+		t.Fatalf("Graph not rendered:\n%s", h.text())
+	}
+	_, bottomY, _ := h.find(" 0 ┼")
+	x += len([]rune("60 ┤")) + 2
+
+	if _, bg, _ := h.style(x, topY).Decompose(); bg != tcell.GetColor(zoneColors[alert.LevelWarning]) {
+		t.Errorf("Expected the top row shaded, got background %v", bg)
+	}
+	// Rows between grid lines are shaded as well
+	if _, bg, _ := h.style(x, topY+1).Decompose(); bg != tcell.GetColor(zoneColors[alert.LevelWarning]) {
+		t.Errorf("Expected the unlabeled row below the top shaded, got background %v", bg)
+	}
+	if _, bg, _ := h.style(x, bottomY).Decompose(); bg == tcell.GetColor(zoneColors[alert.LevelWarning]) {
+		t.Error("Expected the bottom row unshaded")
+	}
+	h.assertNotContains("[:")
+}
@@ -0,0 +1,221 @@
+package urlimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"promviz/internal/backend"
+)
+
+// Query is an expression and range extracted from a browser URL
+type Query struct {
+	Expr  string
+	Range string // empty when the URL does not carry a usable range
+}
+
+// grafanaPane is the JSON state Grafana Explore encodes into its URL
+type grafanaPane struct {
+	Queries []struct {
+		Expr  string `json:"expr"`
+		Query string `json:"query"`
+	} `json:"queries"`
+	Range struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"range"`
+}
+
+// promGraphParam matches the per-panel parameters of the Prometheus graph page
+var promGraphParam = regexp.MustCompile(`^g(\d+)\.expr$`)
+
+// Parse extracts queries from a Grafana Explore URL or a Prometheus graph URL
+func Parse(rawURL string) ([]Query, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	params := u.Query()
+
+	var queries []Query
+	switch {
+	case params.Get("panes") != "":
+		queries, err = parseGrafanaPanes(params.Get("panes"))
+	case params.Get("left") != "":
+		queries, err = parseGrafanaLeft(params.Get("left"))
+	default:
+		queries = parsePrometheusGraph(params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no query found in URL (expected a Grafana Explore or Prometheus graph URL)")
+	}
+	return queries, nil
+}
+
+// parseGrafanaPanes handles the Grafana 10+ format: panes={"id": {...}}
+func parseGrafanaPanes(raw string) ([]Query, error) {
+	var panes map[string]grafanaPane
+	if err := json.Unmarshal([]byte(raw), &panes); err != nil {
+		return nil, fmt.Errorf("invalid Grafana panes state: %w", err)
+	}
+
+	// Map iteration order is random; keep the output stable
+	ids := make([]string, 0, len(panes))
+	for id := range panes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var queries []Query
+	for _, id := range ids {
+		queries = append(queries, panes[id].queries()...)
+	}
+	return queries, nil
+}
+
+// parseGrafanaLeft handles the older left= state, either an object or the
+// compact ["from","to","datasource",{"expr":...}] array form
+func parseGrafanaLeft(raw string) ([]Query, error) {
+	var pane grafanaPane
+	if err := json.Unmarshal([]byte(raw), &pane); err == nil {
+		return pane.queries(), nil
+	}
+
+	var compact []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &compact); err != nil {
+		return nil, fmt.Errorf("invalid Grafana Explore state: %w", err)
+	}
+	if len(compact) < 4 {
+		return nil, fmt.Errorf("invalid Grafana Explore state: expected at least 4 elements, got %d", len(compact))
+	}
+
+	var from, to string
+	json.Unmarshal(compact[0], &from)
+	json.Unmarshal(compact[1], &to)
+	rng := grafanaRange(from, to)
+
+	var queries []Query
+	for _, item := range compact[3:] {
+		var q struct {
+			Expr string `json:"expr"`
+		}
+		if err := json.Unmarshal(item, &q); err == nil && q.Expr != "" {
+			queries = append(queries, Query{Expr: q.Expr, Range: rng})
+		}
+	}
+	return queries, nil
+}
+
+// queries returns the non-empty queries of a pane with the pane range
+func (p grafanaPane) queries() []Query {
+	rng := grafanaRange(p.Range.From, p.Range.To)
+
+	var queries []Query
+	for _, q := range p.Queries {
+		expr := q.Expr
+		if expr == "" {
+			expr = q.Query
+		}
+		if expr != "" {
+			queries = append(queries, Query{Expr: expr, Range: rng})
+		}
+	}
+	return queries
+}
+
+// grafanaRange converts a Grafana from/to pair into a range duration. Both
+// relative ("now-6h") and absolute millisecond timestamps are understood; an
+// absolute window is kept as its length since dashboards always end at now.
+func grafanaRange(from, to string) string {
+	if to != "" && to != "now" {
+		fromMs, err1 := strconv.ParseInt(from, 10, 64)
+		toMs, err2 := strconv.ParseInt(to, 10, 64)
+		if err1 != nil || err2 != nil || toMs <= fromMs {
+			return ""
+		}
+		return formatDuration(time.Duration(toMs-fromMs) * time.Millisecond)
+	}
+
+	if !strings.HasPrefix(from, "now-") {
+		return ""
+	}
+	rel := strings.TrimPrefix(from, "now-")
+	// Drop rounding suffixes such as "now-7d/d"
+	if i := strings.Index(rel, "/"); i >= 0 {
+		rel = rel[:i]
+	}
+	if _, err := backend.ParseDuration(rel); err != nil {
+		return ""
+	}
+	return rel
+}
+
+// parsePrometheusGraph handles g0.expr=...&g0.range_input=1h style URLs
+func parsePrometheusGraph(params url.Values) []Query {
+	var indices []int
+	for key := range params {
+		if m := promGraphParam.FindStringSubmatch(key); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			indices = append(indices, n)
+		}
+	}
+	sort.Ints(indices)
+
+	var queries []Query
+	for _, n := range indices {
+		expr := params.Get(fmt.Sprintf("g%d.expr", n))
+		if expr == "" {
+			continue
+		}
+		rng := params.Get(fmt.Sprintf("g%d.range_input", n))
+		if _, err := backend.ParseDuration(rng); err != nil {
+			rng = ""
+		}
+		queries = append(queries, Query{Expr: expr, Range: rng})
+	}
+	return queries
+}
+
+// formatDuration renders a duration in the largest whole Prometheus unit
+func formatDuration(d time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	for _, u := range units {
+		if d >= u.size && d%u.size == 0 {
+			return fmt.Sprintf("%d%s", d/u.size, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
+
+// ToQueries converts extracted queries into named config queries. The name
+// defaults to the expression; multiple queries get a numeric suffix.
+func ToQueries(extracted []Query, name string) []backend.Query {
+	queries := make([]backend.Query, len(extracted))
+	for i, q := range extracted {
+		queryName := name
+		if queryName == "" {
+			queryName = q.Expr
+		} else if len(extracted) > 1 {
+			queryName = fmt.Sprintf("%s (%d)", name, i+1)
+		}
+		queries[i] = backend.Query{Name: queryName, Expr: q.Expr, Range: q.Range}
+	}
+	return queries
+}
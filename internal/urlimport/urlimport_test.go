@@ -0,0 +1,99 @@
+package urlimport
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseGrafanaPanes(t *testing.T) {
+	state := `{"a1b":{"datasource":"prom","queries":[{"refId":"A","expr":"rate(http_requests_total[5m])"},{"refId":"B","expr":"up"}],"range":{"from":"now-6h","to":"now"}}}`
+	rawURL := "https://grafana.example.com/explore?schemaVersion=1&orgId=1&panes=" + url.QueryEscape(state)
+
+	queries, err := Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Parse should not return error, got %v", err)
+	}
+
+	expected := []Query{
+		{Expr: "rate(http_requests_total[5m])", Range: "6h"},
+		{Expr: "up", Range: "6h"},
+	}
+	if !reflect.DeepEqual(queries, expected) {
+		t.Errorf("Expected %v, got %v", expected, queries)
+	}
+}
+
+func TestParseGrafanaLeftObject(t *testing.T) {
+	state := `{"datasource":"prom","queries":[{"refId":"A","expr":"node_load1"}],"range":{"from":"now-7d/d","to":"now"}}`
+	rawURL := "https://grafana.example.com/explore?orgId=1&left=" + url.QueryEscape(state)
+
+	queries, err := Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Parse should not return error, got %v", err)
+	}
+
+	if len(queries) != 1 || queries[0].Expr != "node_load1" || queries[0].Range != "7d" {
+		t.Errorf("Unexpected queries: %v", queries)
+	}
+}
+
+func TestParseGrafanaLeftCompact(t *testing.T) {
+	state := `["1714651200000","1714658400000","Prometheus",{"expr":"sum(up)"}]`
+	rawURL := "https://grafana.example.com/explore?left=" + url.QueryEscape(state)
+
+	queries, err := Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Parse should not return error, got %v", err)
+	}
+
+	// Absolute two-hour window keeps its length
+	if len(queries) != 1 || queries[0].Expr != "sum(up)" || queries[0].Range != "2h" {
+		t.Errorf("Unexpected queries: %v", queries)
+	}
+}
+
+func TestParsePrometheusGraph(t *testing.T) {
+	rawURL := "http://prometheus:9090/graph?g1.expr=up&g1.range_input=bogus&g0.expr=" +
+		url.QueryEscape(`rate(node_cpu_seconds_total{mode="user"}[5m])`) + "&g0.tab=0&g0.range_input=1h"
+
+	queries, err := Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Parse should not return error, got %v", err)
+	}
+
+	expected := []Query{
+		{Expr: `rate(node_cpu_seconds_total{mode="user"}[5m])`, Range: "1h"},
+		{Expr: "up", Range: ""},
+	}
+	if !reflect.DeepEqual(queries, expected) {
+		t.Errorf("Expected %v, got %v", expected, queries)
+	}
+}
+
+func TestParseNoQuery(t *testing.T) {
+	if _, err := Parse("http://prometheus:9090/graph"); err == nil {
+		t.Error("Parse should return error when the URL has no query")
+	}
+
+	if _, err := Parse("https://grafana/explore?left=" + url.QueryEscape("{not json")); err == nil {
+		t.Error("Parse should return error for malformed Explore state")
+	}
+}
+
+func TestToQueries(t *testing.T) {
+	extracted := []Query{{Expr: "up", Range: "1h"}, {Expr: "sum(up)"}}
+
+	named := ToQueries(extracted, "Targets")
+	if named[0].Name != "Targets (1)" || named[1].Name != "Targets (2)" {
+		t.Errorf("Expected numbered names, got %q and %q", named[0].Name, named[1].Name)
+	}
+	if named[0].Range != "1h" {
+		t.Errorf("Expected range to carry over, got %q", named[0].Range)
+	}
+
+	unnamed := ToQueries(extracted[:1], "")
+	if unnamed[0].Name != "up" {
+		t.Errorf("Expected name to default to the expression, got %q", unnamed[0].Name)
+	}
+}
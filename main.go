@@ -8,18 +8,88 @@ import (
 	"promviz/internal/app"
 )
 
+// Set at build time with -ldflags "-X main.version=... -X main.releaseKey=..."
+var (
+	version    = "dev"
+	releaseKey = "" // base64 Ed25519 key release checksums are signed with
+)
+
 func main() {
+	// Dispatch subcommands before parsing the dashboard flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tmux":
+			runTmux(os.Args[2:])
+			return
+		case "add-url":
+			runAddURL(os.Args[2:])
+			return
+		case "compare":
+			runCompare(os.Args[2:])
+			return
+		case "cost":
+			runCost(os.Args[2:])
+			return
+		case "bench-render":
+			runBenchRender(os.Args[2:])
+			return
+		case "export-rules":
+			runExportRules(os.Args[2:])
+			return
+		case "init":
+			runInit(os.Args[2:])
+			return
+		case "login":
+			runLogin(os.Args[2:])
+			return
+		case "suggest":
+			runSuggest(os.Args[2:])
+			return
+		case "self-update":
+			runSelfUpdate(os.Args[2:])
+			return
+		case "version":
+			fmt.Println(version)
+			return
+		}
+	}
+
 	// Parse command line flags
 	configPath := flag.String("config", "queries.yaml", "Path to configuration file")
+	panel := flag.String("panel", "", "Only display the query with this name")
+	tag := flag.String("tag", "", "Only display the queries with this tag")
+	window := flag.String("window", "", `Show a fixed time window such as "2024-05-02 13:00 to 15:00" instead of refreshing`)
+	strict := flag.Bool("strict", false, "Fail on unknown config keys and duplicate query names instead of warning")
 	flag.Parse()
 
 	// Check if config file exists
 	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: Configuration file '%s' does not exist.\n", *configPath)
-		fmt.Fprintf(os.Stderr, "Please create a configuration file or specify a different path with --config.\n\n")
-		fmt.Fprintf(os.Stderr, "Example configurations:\n\n")
-		fmt.Fprintf(os.Stderr, "Prometheus:\n")
-		fmt.Fprintf(os.Stderr, `prometheus:
+		printConfigHelp(*configPath)
+		os.Exit(1)
+	}
+
+	// Create and start the application
+	application, err := app.New(*configPath, app.WithPanel(*panel), app.WithTag(*tag), app.WithWindow(*window), app.WithStrict(*strict))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Handle graceful shutdown
+	if err := application.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Application error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printConfigHelp explains how to create a configuration file
+func printConfigHelp(configPath string) {
+	fmt.Fprintf(os.Stderr, "Error: Configuration file '%s' does not exist.\n", configPath)
+	fmt.Fprintf(os.Stderr, "Please create a configuration file or specify a different path with --config.\n")
+	fmt.Fprintf(os.Stderr, "To start from a built-in dashboard, run: promviz init --template node --url http://localhost:9090\n\n")
+	fmt.Fprintf(os.Stderr, "Example configurations:\n\n")
+	fmt.Fprintf(os.Stderr, "Prometheus:\n")
+	fmt.Fprintf(os.Stderr, `prometheus:
   url: "http://localhost:9090"
 
 queries:
@@ -29,8 +99,8 @@ queries:
     expr: node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes
 
 `)
-		fmt.Fprintf(os.Stderr, "InfluxDB v2 (Flux):\n")
-		fmt.Fprintf(os.Stderr, `backend: influxdb
+	fmt.Fprintf(os.Stderr, "InfluxDB v2 (Flux):\n")
+	fmt.Fprintf(os.Stderr, `backend: influxdb
 influxdb:
   url: "http://localhost:8086"
   token: "your-token"
@@ -42,8 +112,8 @@ queries:
     expr: 'r._measurement == "cpu" and r._field == "usage_percent"'
 
 `)
-		fmt.Fprintf(os.Stderr, "InfluxDB v1 (InfluxQL):\n")
-		fmt.Fprintf(os.Stderr, `backend: influxdb1
+	fmt.Fprintf(os.Stderr, "InfluxDB v1 (InfluxQL):\n")
+	fmt.Fprintf(os.Stderr, `backend: influxdb1
 influxdb1:
   url: "http://localhost:8086"
   username: "admin"
@@ -54,19 +124,4 @@ queries:
   - name: CPU Usage
     expr: 'SELECT mean("usage_idle") FROM "cpu" WHERE time >= now() - 5m'
 `)
-		os.Exit(1)
-	}
-
-	// Create and start the application
-	application, err := app.New(*configPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Handle graceful shutdown
-	if err := application.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "Application error: %v\n", err)
-		os.Exit(1)
-	}
 }